@@ -17,6 +17,7 @@ import (
 
 	"github.com/joho/godotenv"
 	configaccess "github.com/router-for-me/CLIProxyAPI/v6/internal/access/config_access"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/cmd"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
@@ -64,6 +65,9 @@ func main() {
 	var antigravityLogin bool
 	var projectID string
 	var vertexImport string
+	var encryptAuth bool
+	var replayPath string
+	var replayOptions cmd.ReplayOptions
 	var configPath string
 	var password string
 
@@ -79,6 +83,11 @@ func main() {
 	flag.StringVar(&projectID, "project_id", "", "Project ID (Gemini only, not required)")
 	flag.StringVar(&configPath, "config", DefaultConfigPath, "Configure File Path")
 	flag.StringVar(&vertexImport, "vertex-import", "", "Import Vertex service account key JSON file")
+	flag.BoolVar(&encryptAuth, "encrypt-auth", false, "Encrypt existing auth files in place with the primary auth encryption key")
+	flag.StringVar(&replayPath, "replay", "", "Re-send a request recorded in a JSONL request log through the current pipeline")
+	flag.StringVar(&replayOptions.RequestID, "replay-id", "", "Request ID of the record to replay (default: last record)")
+	flag.StringVar(&replayOptions.Model, "replay-model", "", "Model to replay the recorded request against")
+	flag.StringVar(&replayOptions.Auth, "replay-auth", "", "Auth index or ID of the credential to replay the recorded request with")
 	flag.StringVar(&password, "password", "", "")
 
 	flag.CommandLine.Usage = func() {
@@ -211,6 +220,19 @@ func main() {
 		objectStoreLocalPath = value
 	}
 
+	// Auth files are encrypted at rest when keys are configured; the first key seals new data.
+	authKeys, _ := lookupEnv("AUTH_ENCRYPTION_KEYS", "auth_encryption_keys")
+	authKeyFile, _ := lookupEnv("AUTH_ENCRYPTION_KEY_FILE", "auth_encryption_key_file")
+	authKeyring, errKeyring := authcrypt.LoadKeyring(authKeys, authKeyFile)
+	if errKeyring != nil {
+		log.Errorf("failed to load auth encryption keys: %v", errKeyring)
+		return
+	}
+	authcrypt.SetKeyring(authKeyring)
+	if authKeyring != nil {
+		log.Infof("auth file encryption enabled, primary key: %s", authKeyring.PrimaryKeyID())
+	}
+
 	// Check for cloud deploy mode only on first execution
 	// Read env var name in uppercase: DEPLOY
 	deployEnv := os.Getenv("DEPLOY")
@@ -251,6 +273,7 @@ func main() {
 			cfg.AuthDir = pgStoreInst.AuthDir()
 			log.Infof("postgres-backed token store enabled, workspace path: %s", pgStoreInst.WorkDir())
 		}
+		usage.UsePostgresDatabase(pgStoreInst.DB(), pgStoreInst.Schema())
 	} else if useObjectStore {
 		if objectStoreLocalPath == "" {
 			if writableBase != "" {
@@ -444,7 +467,13 @@ func main() {
 
 	// Handle different command modes based on the provided flags.
 
-	if vertexImport != "" {
+	if encryptAuth {
+		// Encrypt existing auth files with the primary key
+		cmd.DoEncryptAuthFiles(cfg)
+	} else if replayPath != "" {
+		// Re-send a recorded request through a private proxy instance
+		cmd.DoReplay(cfg, configFilePath, replayPath, replayOptions)
+	} else if vertexImport != "" {
 		// Handle Vertex service account import
 		cmd.DoVertexImport(cfg, vertexImport)
 	} else if login {
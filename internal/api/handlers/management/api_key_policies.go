@@ -0,0 +1,132 @@
+package management
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/ratelimit"
+)
+
+// GetAPIKeyPolicies returns the per-client-API-key policies.
+func (h *Handler) GetAPIKeyPolicies(c *gin.Context) {
+	if h == nil || h.cfg == nil {
+		c.JSON(200, gin.H{"api-key-policies": []config.APIKeyPolicy{}})
+		return
+	}
+	c.JSON(200, gin.H{"api-key-policies": h.cfg.APIKeyPolicies})
+}
+
+// PutAPIKeyPolicies replaces all per-client-API-key policies.
+func (h *Handler) PutAPIKeyPolicies(c *gin.Context) {
+	var body struct {
+		Value []config.APIKeyPolicy `json:"value"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+	h.cfg.APIKeyPolicies = normalizeAPIKeyPolicies(body.Value)
+	h.persist(c)
+}
+
+// PatchAPIKeyPolicies adds or replaces policies, matched by api-key.
+func (h *Handler) PatchAPIKeyPolicies(c *gin.Context) {
+	var body struct {
+		Value []config.APIKeyPolicy `json:"value"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+
+	existing := make(map[string]int)
+	for i, entry := range h.cfg.APIKeyPolicies {
+		existing[entry.APIKey] = i
+	}
+	for _, entry := range normalizeAPIKeyPolicies(body.Value) {
+		if idx, ok := existing[entry.APIKey]; ok {
+			h.cfg.APIKeyPolicies[idx] = entry
+			continue
+		}
+		h.cfg.APIKeyPolicies = append(h.cfg.APIKeyPolicies, entry)
+		existing[entry.APIKey] = len(h.cfg.APIKeyPolicies) - 1
+	}
+	h.persist(c)
+}
+
+// DeleteAPIKeyPolicies removes policies for the given keys.
+// Body must be JSON: {"value": ["<api-key>", ...]}; an empty array clears all policies.
+func (h *Handler) DeleteAPIKeyPolicies(c *gin.Context) {
+	var body struct {
+		Value []string `json:"value"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+	if body.Value == nil {
+		c.JSON(400, gin.H{"error": "missing value"})
+		return
+	}
+	if len(body.Value) == 0 {
+		h.cfg.APIKeyPolicies = nil
+		h.persist(c)
+		return
+	}
+
+	toRemove := make(map[string]bool)
+	for _, key := range body.Value {
+		if trimmed := strings.TrimSpace(key); trimmed != "" {
+			toRemove[trimmed] = true
+		}
+	}
+	if len(toRemove) == 0 {
+		c.JSON(400, gin.H{"error": "empty value"})
+		return
+	}
+	kept := make([]config.APIKeyPolicy, 0, len(h.cfg.APIKeyPolicies))
+	for _, entry := range h.cfg.APIKeyPolicies {
+		if !toRemove[entry.APIKey] {
+			kept = append(kept, entry)
+		}
+	}
+	h.cfg.APIKeyPolicies = kept
+	h.persist(c)
+}
+
+// GetAPIKeyLimitUsage returns the live counters used to enforce api-key-policies.
+func (h *Handler) GetAPIKeyLimitUsage(c *gin.Context) {
+	c.JSON(200, gin.H{"api-key-usage": ratelimit.Default().Snapshot()})
+}
+
+// DeleteAPIKeyLimitUsage resets the live counters of one key (?api-key=) or of every key.
+func (h *Handler) DeleteAPIKeyLimitUsage(c *gin.Context) {
+	ratelimit.Default().Reset(strings.TrimSpace(c.Query("api-key")))
+	c.JSON(200, gin.H{"status": "ok"})
+}
+
+// normalizeAPIKeyPolicies trims keys, drops empty entries and keeps the last entry per key.
+func normalizeAPIKeyPolicies(entries []config.APIKeyPolicy) []config.APIKeyPolicy {
+	if len(entries) == 0 {
+		return nil
+	}
+	out := make([]config.APIKeyPolicy, 0, len(entries))
+	index := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		if entry.APIKey == "" {
+			continue
+		}
+		if idx, ok := index[entry.APIKey]; ok {
+			out[idx] = entry
+			continue
+		}
+		index[entry.APIKey] = len(out)
+		out = append(out, entry)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
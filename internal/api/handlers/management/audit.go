@@ -0,0 +1,83 @@
+package management
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	auditLogFileName = "management-audit.log"
+	auditLogCapacity = 500
+)
+
+// AuditEntry records one operator action taken through the management API.
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	Action   string    `json:"action"`
+	AuthID   string    `json:"auth_id,omitempty"`
+	Model    string    `json:"model,omitempty"`
+	Models   []string  `json:"models,omitempty"`
+	Duration int64     `json:"duration_seconds,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	ClientIP string    `json:"client_ip,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// auditLog keeps the most recent entries in memory and appends every entry as a JSON line to
+// management-audit.log in the log directory.
+type auditLog struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+}
+
+func (a *auditLog) record(dir string, entry AuditEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append(a.entries, entry)
+	if over := len(a.entries) - auditLogCapacity; over > 0 {
+		a.entries = append(a.entries[:0:0], a.entries[over:]...)
+	}
+	if dir == "" {
+		return
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		log.WithError(err).Warn("management audit: failed to create log directory")
+		return
+	}
+	file, err := os.OpenFile(filepath.Join(dir, auditLogFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		log.WithError(err).Warn("management audit: failed to open audit log")
+		return
+	}
+	defer func() {
+		if errClose := file.Close(); errClose != nil {
+			log.WithError(errClose).Warn("management audit: failed to close audit log")
+		}
+	}()
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		log.WithError(err).Warn("management audit: failed to write audit log")
+	}
+}
+
+// recent returns up to limit entries, newest first.
+func (a *auditLog) recent(limit int) []AuditEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if limit <= 0 || limit > len(a.entries) {
+		limit = len(a.entries)
+	}
+	out := make([]AuditEntry, 0, limit)
+	for i := len(a.entries) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, a.entries[i])
+	}
+	return out
+}
@@ -0,0 +1,151 @@
+package management
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// authControlRequest identifies the auth (and optionally the model) an operator control acts on.
+// Controls only change runtime state and never touch the persisted auth file.
+type authControlRequest struct {
+	AuthID    string `json:"auth_id"`
+	AuthIndex string `json:"auth_index"`
+	Model     string `json:"model"`
+	Seconds   int64  `json:"seconds"`
+	Reason    string `json:"reason"`
+}
+
+// bindAuthControl parses the request body and resolves the target auth ID. It writes the error
+// response itself and returns false when the request cannot proceed.
+func (h *Handler) bindAuthControl(c *gin.Context) (authControlRequest, bool) {
+	var body authControlRequest
+	if h == nil || h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "auth manager unavailable"})
+		return body, false
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return body, false
+	}
+	body.AuthID = strings.TrimSpace(body.AuthID)
+	body.Model = strings.TrimSpace(body.Model)
+	if body.AuthID == "" && strings.TrimSpace(body.AuthIndex) != "" {
+		if auth := h.authByIndex(body.AuthIndex); auth != nil {
+			body.AuthID = auth.ID
+		}
+	}
+	if body.AuthID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "auth_id or auth_index is required"})
+		return body, false
+	}
+	return body, true
+}
+
+// respondAuthControl records the action in the audit log and writes the response.
+func (h *Handler) respondAuthControl(c *gin.Context, action string, body authControlRequest, models []string, payload gin.H, err error) {
+	entry := AuditEntry{
+		Time:     time.Now().UTC(),
+		Action:   action,
+		AuthID:   body.AuthID,
+		Model:    body.Model,
+		Models:   models,
+		Duration: body.Seconds,
+		Reason:   strings.TrimSpace(body.Reason),
+		ClientIP: c.ClientIP(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	h.audit.record(h.logDirectory(), entry)
+	if err != nil {
+		status := http.StatusInternalServerError
+		var authErr *coreauth.Error
+		if errors.As(err, &authErr) && authErr.HTTPStatus != 0 {
+			status = authErr.HTTPStatus
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if payload == nil {
+		payload = gin.H{}
+	}
+	payload["status"] = "ok"
+	payload["auth_id"] = body.AuthID
+	if models != nil {
+		payload["models"] = models
+	}
+	c.JSON(http.StatusOK, payload)
+}
+
+// PostAuthCooldown puts an auth into a timed cooldown for one model, or all models when model
+// is omitted.
+func (h *Handler) PostAuthCooldown(c *gin.Context) {
+	body, ok := h.bindAuthControl(c)
+	if !ok {
+		return
+	}
+	if body.Seconds <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "seconds must be positive"})
+		return
+	}
+	duration := time.Duration(body.Seconds) * time.Second
+	models, err := h.authManager.CooldownAuth(c.Request.Context(), body.AuthID, body.Model, duration)
+	h.respondAuthControl(c, "cooldown", body, models, gin.H{"until": time.Now().Add(duration)}, err)
+}
+
+// PostAuthClearCooldown ends the cooldown of an auth early.
+func (h *Handler) PostAuthClearCooldown(c *gin.Context) {
+	body, ok := h.bindAuthControl(c)
+	if !ok {
+		return
+	}
+	models, err := h.authManager.ClearCooldown(c.Request.Context(), body.AuthID, body.Model)
+	h.respondAuthControl(c, "clear-cooldown", body, models, nil, err)
+}
+
+// PostAuthResetBackoff resets the progressive quota backoff level of an auth.
+func (h *Handler) PostAuthResetBackoff(c *gin.Context) {
+	body, ok := h.bindAuthControl(c)
+	if !ok {
+		return
+	}
+	models, err := h.authManager.ResetBackoff(c.Request.Context(), body.AuthID, body.Model)
+	h.respondAuthControl(c, "reset-backoff", body, models, nil, err)
+}
+
+// PostAuthDrain stops routing new requests to an auth while in-flight requests finish.
+func (h *Handler) PostAuthDrain(c *gin.Context) {
+	body, ok := h.bindAuthControl(c)
+	if !ok {
+		return
+	}
+	state, err := h.authManager.DrainAuth(body.AuthID)
+	h.respondAuthControl(c, "drain", body, nil, gin.H{"drain": state}, err)
+}
+
+// PostAuthResume lets a drained auth receive new requests again.
+func (h *Handler) PostAuthResume(c *gin.Context) {
+	body, ok := h.bindAuthControl(c)
+	if !ok {
+		return
+	}
+	state, err := h.authManager.ResumeAuth(body.AuthID)
+	h.respondAuthControl(c, "resume", body, nil, gin.H{"drain": state}, err)
+}
+
+// GetAuthControlAudit returns the most recent operator actions, newest first.
+func (h *Handler) GetAuthControlAudit(c *gin.Context) {
+	limit := 100
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": h.audit.recent(limit)})
+}
@@ -25,6 +25,7 @@ import (
 	iflowauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/iflow"
 	kiroauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/kiro"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/qwen"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
@@ -346,7 +347,7 @@ func (h *Handler) listAuthFilesFromDisk(c *gin.Context) {
 
 			// Read file to get type field
 			full := filepath.Join(h.cfg.AuthDir, name)
-			if data, errRead := os.ReadFile(full); errRead == nil {
+			if data, errRead := authcrypt.ReadFile(full); errRead == nil {
 				typeValue := gjson.GetBytes(data, "type").String()
 				emailValue := gjson.GetBytes(data, "email").String()
 				fileData["type"] = typeValue
@@ -516,7 +517,7 @@ func (h *Handler) DownloadAuthFile(c *gin.Context) {
 		return
 	}
 	full := filepath.Join(h.cfg.AuthDir, name)
-	data, err := os.ReadFile(full)
+	data, err := authcrypt.ReadFile(full)
 	if err != nil {
 		if os.IsNotExist(err) {
 			c.JSON(404, gin.H{"error": "file not found"})
@@ -548,13 +549,23 @@ func (h *Handler) UploadAuthFile(c *gin.Context) {
 				dst = abs
 			}
 		}
-		if errSave := c.SaveUploadedFile(file, dst); errSave != nil {
-			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to save file: %v", errSave)})
+		src, errOpen := file.Open()
+		if errOpen != nil {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("failed to open uploaded file: %v", errOpen)})
 			return
 		}
-		data, errRead := os.ReadFile(dst)
+		data, errRead := io.ReadAll(src)
+		_ = src.Close()
 		if errRead != nil {
-			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to read saved file: %v", errRead)})
+			c.JSON(400, gin.H{"error": fmt.Sprintf("failed to read uploaded file: %v", errRead)})
+			return
+		}
+		if data, errRead = authcrypt.Open(data); errRead != nil {
+			c.JSON(400, gin.H{"error": errRead.Error()})
+			return
+		}
+		if errWrite := authcrypt.WriteFile(dst, data); errWrite != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to save file: %v", errWrite)})
 			return
 		}
 		if errReg := h.registerAuthFromFile(ctx, dst, data); errReg != nil {
@@ -584,7 +595,11 @@ func (h *Handler) UploadAuthFile(c *gin.Context) {
 			dst = abs
 		}
 	}
-	if errWrite := os.WriteFile(dst, data, 0o600); errWrite != nil {
+	if data, err = authcrypt.Open(data); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if errWrite := authcrypt.WriteFile(dst, data); errWrite != nil {
 		c.JSON(500, gin.H{"error": fmt.Sprintf("failed to write file: %v", errWrite)})
 		return
 	}
@@ -783,7 +798,7 @@ func (h *Handler) registerAuthFromFile(ctx context.Context, path string, data []
 	}
 	if data == nil {
 		var err error
-		data, err = os.ReadFile(path)
+		data, err = authcrypt.ReadFile(path)
 		if err != nil {
 			return fmt.Errorf("failed to read auth file: %w", err)
 		}
@@ -2469,7 +2484,7 @@ func (h *Handler) GetKiroUsageLimits(c *gin.Context) {
 
 	// Find the auth file
 	authFilePath := filepath.Join(h.cfg.AuthDir, authID)
-	data, err := os.ReadFile(authFilePath)
+	data, err := authcrypt.ReadFile(authFilePath)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"status": "error", "error": "auth file not found"})
 		return
@@ -2637,7 +2652,7 @@ func (h *Handler) UploadOAuthCredentials(c *gin.Context) {
 	filePath := filepath.Join(h.cfg.AuthDir, fileName)
 
 	// Save the modified file
-	if err := os.WriteFile(filePath, modifiedData, 0644); err != nil {
+	if err := authcrypt.WriteFile(filePath, modifiedData); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "error": "failed to save file"})
 		return
 	}
@@ -2767,7 +2782,7 @@ func (h *Handler) ImportKiroAccount(c *gin.Context) {
 func updateKiroAuthFile(filePath string, tokenData *kiroauth.KiroTokenData) error {
 	// Read existing file
 	existingData := make(map[string]interface{})
-	if data, err := os.ReadFile(filePath); err == nil {
+	if data, err := authcrypt.ReadFile(filePath); err == nil {
 		if err := json.Unmarshal(data, &existingData); err != nil {
 			return fmt.Errorf("failed to parse existing auth file: %w", err)
 		}
@@ -2789,7 +2804,7 @@ func updateKiroAuthFile(filePath string, tokenData *kiroauth.KiroTokenData) erro
 		return fmt.Errorf("failed to marshal token data: %w", err)
 	}
 
-	if err := os.WriteFile(filePath, jsonData, 0600); err != nil {
+	if err := authcrypt.WriteFile(filePath, jsonData); err != nil {
 		return fmt.Errorf("failed to write auth file: %w", err)
 	}
 
@@ -0,0 +1,233 @@
+package management
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+const authRuntimeKeepAlive = 15 * time.Second
+
+// authRuntimeFilter narrows runtime listings by provider, status and served model.
+type authRuntimeFilter struct {
+	provider string
+	status   string
+	model    string
+}
+
+func authRuntimeFilterFromQuery(c *gin.Context) authRuntimeFilter {
+	return authRuntimeFilter{
+		provider: strings.ToLower(strings.TrimSpace(c.Query("provider"))),
+		status:   strings.ToLower(strings.TrimSpace(c.Query("status"))),
+		model:    strings.TrimSpace(c.Query("model")),
+	}
+}
+
+// matches reports whether auth passes the filter. The status filter also accepts
+// "disabled", "unavailable" and "draining", which are flags rather than lifecycle states.
+func (f authRuntimeFilter) matches(auth *coreauth.Auth, models []string, draining bool) bool {
+	if auth == nil {
+		return false
+	}
+	if f.provider != "" && !strings.EqualFold(strings.TrimSpace(auth.Provider), f.provider) {
+		return false
+	}
+	switch f.status {
+	case "":
+	case "disabled":
+		if !auth.Disabled {
+			return false
+		}
+	case "unavailable":
+		if !auth.Unavailable {
+			return false
+		}
+	case "draining":
+		if !draining {
+			return false
+		}
+	default:
+		if !strings.EqualFold(string(auth.Status), f.status) {
+			return false
+		}
+	}
+	if f.model != "" {
+		for _, model := range models {
+			if strings.EqualFold(model, f.model) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// servedModels lists the model IDs the registry currently routes to auth.
+func servedModels(authID string) []string {
+	infos := registry.GetGlobalRegistry().GetModelsForClient(authID)
+	models := make([]string, 0, len(infos))
+	for _, info := range infos {
+		if info != nil && info.ID != "" {
+			models = append(models, info.ID)
+		}
+	}
+	sort.Strings(models)
+	return models
+}
+
+func (h *Handler) buildAuthRuntimeEntry(auth *coreauth.Auth, models []string) gin.H {
+	name := strings.TrimSpace(auth.FileName)
+	if name == "" {
+		name = auth.ID
+	}
+	entry := gin.H{
+		"id":             auth.ID,
+		"auth_index":     auth.EnsureIndex(),
+		"name":           name,
+		"provider":       strings.TrimSpace(auth.Provider),
+		"label":          auth.Label,
+		"status":         auth.Status,
+		"status_message": auth.StatusMessage,
+		"disabled":       auth.Disabled,
+		"unavailable":    auth.Unavailable,
+		"quota":          auth.Quota,
+		"models":         models,
+		"drain":          h.authManager.DrainState(auth.ID),
+	}
+	if auth.LastError != nil {
+		entry["last_error"] = auth.LastError
+	}
+	if !auth.LastSuccessAt.IsZero() {
+		entry["last_success_at"] = auth.LastSuccessAt
+	}
+	if !auth.NextRetryAfter.IsZero() {
+		entry["next_retry_after"] = auth.NextRetryAfter
+	}
+	if !auth.UpdatedAt.IsZero() {
+		entry["updated_at"] = auth.UpdatedAt
+	}
+	if len(auth.ModelStates) > 0 {
+		entry["model_states"] = auth.ModelStates
+	}
+	return entry
+}
+
+// GetAuthRuntime returns the live runtime state of every credential held by the auth manager.
+// Optional provider, status and model query parameters filter the result.
+func (h *Handler) GetAuthRuntime(c *gin.Context) {
+	if h == nil || h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "auth manager unavailable"})
+		return
+	}
+	filter := authRuntimeFilterFromQuery(c)
+	auths := h.authManager.List()
+	sort.Slice(auths, func(i, j int) bool { return auths[i].ID < auths[j].ID })
+	entries := make([]gin.H, 0, len(auths))
+	for _, auth := range auths {
+		models := servedModels(auth.ID)
+		if !filter.matches(auth, models, h.authManager.DrainState(auth.ID).Draining) {
+			continue
+		}
+		entries = append(entries, h.buildAuthRuntimeEntry(auth, models))
+	}
+	c.JSON(http.StatusOK, gin.H{"auths": entries})
+}
+
+// StreamAuthRuntime pushes runtime changes as server-sent events. The stream opens with a
+// "snapshot" event carrying the filtered listing, followed by one event per auth update or
+// attempt result with the auth's state after the change.
+func (h *Handler) StreamAuthRuntime(c *gin.Context) {
+	if h == nil || h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "auth manager unavailable"})
+		return
+	}
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "streaming not supported"})
+		return
+	}
+	filter := authRuntimeFilterFromQuery(c)
+	events, cancel := h.authManager.SubscribeRuntimeEvents(256)
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	write := func(event string, payload any) bool {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return true
+		}
+		if _, err = fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event, data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	auths := h.authManager.List()
+	sort.Slice(auths, func(i, j int) bool { return auths[i].ID < auths[j].ID })
+	snapshot := make([]gin.H, 0, len(auths))
+	for _, auth := range auths {
+		models := servedModels(auth.ID)
+		if filter.matches(auth, models, h.authManager.DrainState(auth.ID).Draining) {
+			snapshot = append(snapshot, h.buildAuthRuntimeEntry(auth, models))
+		}
+	}
+	if !write("snapshot", gin.H{"auths": snapshot}) {
+		return
+	}
+
+	keepAlive := time.NewTicker(authRuntimeKeepAlive)
+	defer keepAlive.Stop()
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			auth, found := h.authManager.GetByID(event.AuthID)
+			if !found {
+				continue
+			}
+			models := servedModels(auth.ID)
+			if !filter.matches(auth, models, h.authManager.DrainState(auth.ID).Draining) {
+				continue
+			}
+			payload := gin.H{"time": event.Time, "auth": h.buildAuthRuntimeEntry(auth, models)}
+			if result := event.Result; result != nil {
+				resultEntry := gin.H{
+					"provider":   result.Provider,
+					"model":      result.Model,
+					"success":    result.Success,
+					"latency_ms": result.Latency.Milliseconds(),
+				}
+				if result.Error != nil {
+					resultEntry["error"] = result.Error
+				}
+				payload["result"] = resultEntry
+			}
+			if !write(string(event.Type), payload) {
+				return
+			}
+		}
+	}
+}
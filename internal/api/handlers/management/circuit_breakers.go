@@ -0,0 +1,28 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCircuitBreakers lists the upstream circuits the breaker is tracking.
+func (h *Handler) GetCircuitBreakers(c *gin.Context) {
+	if h == nil || h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "auth manager unavailable"})
+		return
+	}
+	enabled := h.cfg != nil && h.cfg.CircuitBreaker.Enable
+	c.JSON(http.StatusOK, gin.H{"enabled": enabled, "circuit-breakers": h.authManager.CircuitBreakers()})
+}
+
+// DeleteCircuitBreakers closes circuits. The optional provider and base-url query parameters
+// narrow the reset; without them every circuit is closed.
+func (h *Handler) DeleteCircuitBreakers(c *gin.Context) {
+	if h == nil || h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "auth manager unavailable"})
+		return
+	}
+	reset := h.authManager.ResetCircuitBreakers(c.Query("provider"), c.Query("base-url"))
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "reset": reset})
+}
@@ -6,12 +6,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/codex"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	log "github.com/sirupsen/logrus"
@@ -77,7 +77,7 @@ func (h *Handler) GetCodexUsage(c *gin.Context) {
 
 	authFilePath := filepath.Join(h.cfg.AuthDir, name)
 
-	data, err := os.ReadFile(authFilePath)
+	data, err := authcrypt.ReadFile(authFilePath)
 	if err != nil {
 		log.WithError(err).Errorf("Failed to read auth file: %s", authFilePath)
 		c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
@@ -135,7 +135,7 @@ func (h *Handler) GetCodexUsage(c *gin.Context) {
 				
 				updatedData, err := json.MarshalIndent(tokenStorage, "", "  ")
 				if err == nil {
-					if err := os.WriteFile(authFilePath, updatedData, 0600); err != nil {
+					if err := authcrypt.WriteFile(authFilePath, updatedData); err != nil {
 						log.WithError(err).Warn("Failed to save refreshed token")
 					} else {
 						log.Info("Token refreshed and saved successfully")
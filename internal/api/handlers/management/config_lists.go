@@ -398,6 +398,7 @@ func (h *Handler) PatchOpenAICompat(c *gin.Context) {
 		Name          *string                             `json:"name"`
 		Prefix        *string                             `json:"prefix"`
 		BaseURL       *string                             `json:"base-url"`
+		WireAPI       *string                             `json:"wire-api"`
 		APIKeyEntries *[]config.OpenAICompatibilityAPIKey `json:"api-key-entries"`
 		Models        *[]config.OpenAICompatibilityModel  `json:"models"`
 		Headers       *map[string]string                  `json:"headers"`
@@ -446,6 +447,9 @@ func (h *Handler) PatchOpenAICompat(c *gin.Context) {
 		}
 		entry.BaseURL = trimmed
 	}
+	if body.Value.WireAPI != nil {
+		entry.WireAPI = *body.Value.WireAPI
+	}
 	if body.Value.APIKeyEntries != nil {
 		entry.APIKeyEntries = append([]config.OpenAICompatibilityAPIKey(nil), (*body.Value.APIKeyEntries)...)
 	}
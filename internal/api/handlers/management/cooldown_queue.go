@@ -0,0 +1,17 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCooldownQueue reports the depth and wait times of the per-model cooldown queues.
+func (h *Handler) GetCooldownQueue(c *gin.Context) {
+	if h == nil || h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "auth manager unavailable"})
+		return
+	}
+	enabled := h.cfg != nil && h.cfg.CooldownQueue.Enable
+	c.JSON(http.StatusOK, gin.H{"enabled": enabled, "cooldown-queue": h.authManager.CooldownQueueStats()})
+}
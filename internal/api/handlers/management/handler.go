@@ -40,6 +40,7 @@ type Handler struct {
 	allowRemoteOverride bool
 	envSecret           string
 	logDir              string
+	audit               auditLog
 }
 
 // NewHandler creates a new management handler instance.
@@ -0,0 +1,23 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/responsecache"
+)
+
+// GetResponseCache returns the response cache configuration and counters.
+func (h *Handler) GetResponseCache(c *gin.Context) {
+	cache := responsecache.Default()
+	if h.cfg != nil {
+		cache.Apply(h.cfg.ResponseCache)
+	}
+	c.JSON(http.StatusOK, gin.H{"response-cache": cache.Stats()})
+}
+
+// DeleteResponseCache drops every cached response.
+func (h *Handler) DeleteResponseCache(c *gin.Context) {
+	removed := responsecache.Default().Purge()
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "removed": removed})
+}
@@ -0,0 +1,40 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// GetRoutingScores returns the latency and error-rate scores tracked by the active selector.
+// Optional query parameters "model" and "provider" filter the result.
+func (h *Handler) GetRoutingScores(c *gin.Context) {
+	strategy := ""
+	if h.cfg != nil {
+		strategy = h.cfg.Routing.Strategy
+	}
+	var scores []coreauth.AuthScore
+	supported := false
+	if h.authManager != nil {
+		scores, supported = h.authManager.SelectorScores()
+	}
+	model := strings.TrimSpace(c.Query("model"))
+	provider := strings.TrimSpace(c.Query("provider"))
+	filtered := make([]coreauth.AuthScore, 0, len(scores))
+	for _, score := range scores {
+		if model != "" && !strings.EqualFold(score.Model, model) {
+			continue
+		}
+		if provider != "" && !strings.EqualFold(score.Provider, provider) {
+			continue
+		}
+		filtered = append(filtered, score)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"strategy":  strategy,
+		"supported": supported,
+		"scores":    filtered,
+	})
+}
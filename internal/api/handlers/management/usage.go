@@ -3,6 +3,8 @@ package management
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -77,3 +79,122 @@ func (h *Handler) ImportUsageStatistics(c *gin.Context) {
 		"failed_requests": snapshot.FailureCount,
 	})
 }
+
+const (
+	defaultUsageRecordsLimit = 100
+	maxUsageRecordsLimit     = 1000
+)
+
+// GetUsageHistory aggregates persisted usage into hourly or daily buckets.
+// Query parameters: from, to (RFC3339 or unix seconds), api_key, model, provider, auth_index,
+// granularity (hour|day, default hour).
+func (h *Handler) GetUsageHistory(c *gin.Context) {
+	store := usage.PersistentStore()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "usage persistence is not enabled"})
+		return
+	}
+	q, ok := parseUsageQuery(c)
+	if !ok {
+		return
+	}
+	granularity := time.Hour
+	switch strings.ToLower(strings.TrimSpace(c.Query("granularity"))) {
+	case "", "hour":
+	case "day":
+		granularity = 24 * time.Hour
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "granularity must be hour or day"})
+		return
+	}
+
+	buckets, err := usage.History(c.Request.Context(), store, q, granularity)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var totals usage.HistoryBucket
+	for _, b := range buckets {
+		totals.Requests += b.Requests
+		totals.Failures += b.Failures
+		totals.Tokens.InputTokens += b.Tokens.InputTokens
+		totals.Tokens.OutputTokens += b.Tokens.OutputTokens
+		totals.Tokens.ReasoningTokens += b.Tokens.ReasoningTokens
+		totals.Tokens.CachedTokens += b.Tokens.CachedTokens
+		totals.Tokens.TotalTokens += b.Tokens.TotalTokens
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"buckets": buckets,
+		"totals": gin.H{
+			"requests": totals.Requests,
+			"failures": totals.Failures,
+			"tokens":   totals.Tokens,
+		},
+	})
+}
+
+// GetUsageRecords returns persisted raw records, most recent last.
+// Accepts the same filters as GetUsageHistory plus limit (default 100, max 1000).
+func (h *Handler) GetUsageRecords(c *gin.Context) {
+	store := usage.PersistentStore()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "usage persistence is not enabled"})
+		return
+	}
+	q, ok := parseUsageQuery(c)
+	if !ok {
+		return
+	}
+	q.Limit = defaultUsageRecordsLimit
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		q.Limit = min(limit, maxUsageRecordsLimit)
+	}
+
+	records, err := store.Records(c.Request.Context(), q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if records == nil {
+		records = []usage.PersistedRecord{}
+	}
+	c.JSON(http.StatusOK, gin.H{"records": records})
+}
+
+// parseUsageQuery reads the shared persisted-usage filters, writing a 400 response on failure.
+func parseUsageQuery(c *gin.Context) (usage.Query, bool) {
+	q := usage.Query{
+		APIKey:    strings.TrimSpace(c.Query("api_key")),
+		Model:     strings.TrimSpace(c.Query("model")),
+		Provider:  strings.TrimSpace(c.Query("provider")),
+		AuthIndex: strings.TrimSpace(c.Query("auth_index")),
+	}
+	for _, field := range []struct {
+		name   string
+		target *time.Time
+	}{{"from", &q.From}, {"to", &q.To}} {
+		raw := strings.TrimSpace(c.Query(field.name))
+		if raw == "" {
+			continue
+		}
+		ts, err := parseUsageTime(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + field.name})
+			return q, false
+		}
+		*field.target = ts
+	}
+	return q, true
+}
+
+func parseUsageTime(raw string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
@@ -50,6 +51,7 @@ func RequestLoggingMiddleware(logger logging.RequestLogger) gin.HandlerFunc {
 		if !logger.IsEnabled() {
 			wrapper.logOnErrorOnly = true
 		}
+		wrapper.ginContext = c
 		c.Writer = wrapper
 
 		// Process the request
@@ -82,6 +84,7 @@ func captureRequestInfo(c *gin.Context) (*RequestInfo, error) {
 	for key, values := range c.Request.Header {
 		headers[key] = values
 	}
+	headers = logging.RedactHeaders(headers)
 
 	// Capture request body
 	var body []byte
@@ -94,7 +97,7 @@ func captureRequestInfo(c *gin.Context) (*RequestInfo, error) {
 
 		// Restore the body for the actual request processing
 		c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-		body = bodyBytes
+		body = logging.RedactBody(bodyBytes)
 	}
 
 	return &RequestInfo{
@@ -103,6 +106,7 @@ func captureRequestInfo(c *gin.Context) (*RequestInfo, error) {
 		Headers:   headers,
 		Body:      body,
 		RequestID: logging.GetGinRequestID(c),
+		StartedAt: time.Now(),
 	}, nil
 }
 
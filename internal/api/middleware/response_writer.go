@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
@@ -20,6 +21,7 @@ type RequestInfo struct {
 	Headers   map[string][]string // Headers contains the request headers.
 	Body      []byte              // Body is the raw request body.
 	RequestID string              // RequestID is the unique identifier for the request.
+	StartedAt time.Time           // StartedAt is when the request arrived.
 }
 
 // ResponseWriterWrapper wraps the standard gin.ResponseWriter to intercept and log response data.
@@ -36,6 +38,8 @@ type ResponseWriterWrapper struct {
 	statusCode     int                        // statusCode stores the HTTP status code of the response.
 	headers        map[string][]string        // headers stores the response headers.
 	logOnErrorOnly bool                       // logOnErrorOnly enables logging only when an error response is detected.
+	ginContext     *gin.Context               // ginContext identifies the client API key once authentication has run.
+	bodiesOmitted  bool                       // bodiesOmitted keeps bodies out of the log for never-log-bodies API keys.
 }
 
 // NewResponseWriterWrapper creates and initializes a new ResponseWriterWrapper.
@@ -89,6 +93,9 @@ func (w *ResponseWriterWrapper) Write(data []byte) (int, error) {
 }
 
 func (w *ResponseWriterWrapper) shouldBufferResponseBody() bool {
+	if w.bodiesOmitted {
+		return false
+	}
 	if w.logger != nil && w.logger.IsEnabled() {
 		return true
 	}
@@ -143,6 +150,9 @@ func (w *ResponseWriterWrapper) WriteHeader(statusCode int) {
 	contentType := w.ResponseWriter.Header().Get("Content-Type")
 	w.isStreaming = w.detectStreaming(contentType)
 
+	// Authentication has run by now, so the client API key is known
+	w.checkBodiesOmitted()
+
 	// If streaming, initialize streaming log writer
 	if w.isStreaming && w.logger.IsEnabled() {
 		streamWriter, err := w.logger.LogStreamingRequest(
@@ -154,15 +164,17 @@ func (w *ResponseWriterWrapper) WriteHeader(statusCode int) {
 		)
 		if err == nil {
 			w.streamWriter = streamWriter
-			w.chunkChannel = make(chan []byte, 100) // Buffered channel for async writes
-			doneChan := make(chan struct{})
-			w.streamDone = doneChan
+			if !w.bodiesOmitted {
+				w.chunkChannel = make(chan []byte, 100) // Buffered channel for async writes
+				doneChan := make(chan struct{})
+				w.streamDone = doneChan
 
-			// Start async chunk processor
-			go w.processStreamingChunks(doneChan)
+				// Start async chunk processor
+				go w.processStreamingChunks(doneChan)
+			}
 
 			// Write status immediately
-			_ = streamWriter.WriteStatus(statusCode, w.headers)
+			_ = streamWriter.WriteStatus(statusCode, logging.RedactHeaders(w.headers))
 		}
 	}
 
@@ -170,6 +182,19 @@ func (w *ResponseWriterWrapper) WriteHeader(statusCode int) {
 	w.ResponseWriter.WriteHeader(statusCode)
 }
 
+// checkBodiesOmitted replaces the logged request body with a marker when the client API key
+// must never have its bodies logged.
+func (w *ResponseWriterWrapper) checkBodiesOmitted() {
+	if w.bodiesOmitted || w.ginContext == nil || w.requestInfo == nil {
+		return
+	}
+	if logging.OmitBodies(w.ginContext.GetString("apiKey")) {
+		w.bodiesOmitted = true
+		w.requestInfo.Body = []byte(logging.OmittedBodyMarker)
+		w.body.Reset()
+	}
+}
+
 // ensureHeadersCaptured is a helper function to make sure response headers are captured.
 // It is safe to call this method multiple times; it will always refresh the headers
 // with the latest state from the underlying ResponseWriter.
@@ -263,6 +288,8 @@ func (w *ResponseWriterWrapper) Finalize(c *gin.Context) error {
 		}
 	}
 
+	w.checkBodiesOmitted()
+
 	hasAPIError := len(slicesAPIResponseError) > 0 || finalStatusCode >= http.StatusBadRequest
 	forceLog := w.logOnErrorOnly && hasAPIError && !w.logger.IsEnabled()
 	if !w.logger.IsEnabled() && !forceLog {
@@ -281,12 +308,15 @@ func (w *ResponseWriterWrapper) Finalize(c *gin.Context) error {
 		}
 
 		// Write API Request and Response to the streaming log before closing
+		if structured, ok := w.streamWriter.(logging.StructuredStreamingLogWriter); ok {
+			structured.WriteUpstreamAttempts(w.requestInfo.StartedAt, w.extractUpstreamAttempts(c))
+		}
 		apiRequest := w.extractAPIRequest(c)
-		if len(apiRequest) > 0 {
+		if len(apiRequest) > 0 && !w.bodiesOmitted {
 			_ = w.streamWriter.WriteAPIRequest(apiRequest)
 		}
 		apiResponse := w.extractAPIResponse(c)
-		if len(apiResponse) > 0 {
+		if len(apiResponse) > 0 && !w.bodiesOmitted {
 			_ = w.streamWriter.WriteAPIResponse(apiResponse)
 		}
 		if err := w.streamWriter.Close(); err != nil {
@@ -297,7 +327,33 @@ func (w *ResponseWriterWrapper) Finalize(c *gin.Context) error {
 		return nil
 	}
 
-	return w.logRequest(finalStatusCode, w.cloneHeaders(), w.body.Bytes(), w.extractAPIRequest(c), w.extractAPIResponse(c), slicesAPIResponseError, forceLog)
+	if structured, ok := w.logger.(logging.StructuredRequestLogger); ok && w.requestInfo != nil {
+		responseBody := w.body.Bytes()
+		if w.bodiesOmitted {
+			responseBody = []byte(logging.OmittedBodyMarker)
+		}
+		return structured.LogStructuredRequest(
+			w.requestInfo.StartedAt,
+			w.requestInfo.URL,
+			w.requestInfo.Method,
+			w.requestInfo.Headers,
+			w.requestInfo.Body,
+			finalStatusCode,
+			w.cloneHeaders(),
+			responseBody,
+			w.extractUpstreamAttempts(c),
+			slicesAPIResponseError,
+			forceLog,
+			w.requestInfo.RequestID,
+		)
+	}
+
+	responseBody, apiRequest, apiResponse := w.body.Bytes(), w.extractAPIRequest(c), w.extractAPIResponse(c)
+	if w.bodiesOmitted {
+		// The pre-rendered upstream sections embed the translated bodies, so they are left out.
+		responseBody, apiRequest, apiResponse = []byte(logging.OmittedBodyMarker), nil, nil
+	}
+	return w.logRequest(finalStatusCode, w.cloneHeaders(), responseBody, apiRequest, apiResponse, slicesAPIResponseError, forceLog)
 }
 
 func (w *ResponseWriterWrapper) cloneHeaders() map[string][]string {
@@ -310,7 +366,7 @@ func (w *ResponseWriterWrapper) cloneHeaders() map[string][]string {
 		finalHeaders[key] = headerValues
 	}
 
-	return finalHeaders
+	return logging.RedactHeaders(finalHeaders)
 }
 
 func (w *ResponseWriterWrapper) extractAPIRequest(c *gin.Context) []byte {
@@ -337,6 +393,27 @@ func (w *ResponseWriterWrapper) extractAPIResponse(c *gin.Context) []byte {
 	return data
 }
 
+func (w *ResponseWriterWrapper) extractUpstreamAttempts(c *gin.Context) []*logging.UpstreamAttempt {
+	value, isExist := c.Get(logging.UpstreamAttemptsKey)
+	if !isExist {
+		return nil
+	}
+	attempts, _ := value.([]*logging.UpstreamAttempt)
+	if !w.bodiesOmitted {
+		return attempts
+	}
+	stripped := make([]*logging.UpstreamAttempt, 0, len(attempts))
+	for _, attempt := range attempts {
+		if attempt == nil {
+			continue
+		}
+		copied := *attempt
+		copied.Body, copied.Response = nil, nil
+		stripped = append(stripped, &copied)
+	}
+	return stripped
+}
+
 func (w *ResponseWriterWrapper) logRequest(statusCode int, headers map[string][]string, body []byte, apiRequestBody, apiResponseBody []byte, apiResponseErrors []*interfaces.ErrorMessage, forceLog bool) error {
 	if w.requestInfo == nil {
 		return nil
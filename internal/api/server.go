@@ -5,6 +5,7 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"crypto/subtle"
 	"errors"
@@ -26,6 +27,9 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/responsecache"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
@@ -57,10 +61,14 @@ type ServerOption func(*serverOptionConfig)
 
 func defaultRequestLoggerFactory(cfg *config.Config, configPath string) logging.RequestLogger {
 	configDir := filepath.Dir(configPath)
+	logsDir := "logs"
 	if base := util.WritablePath(); base != "" {
-		return logging.NewFileRequestLogger(cfg.RequestLog, filepath.Join(base, "logs"), configDir)
+		logsDir = filepath.Join(base, "logs")
 	}
-	return logging.NewFileRequestLogger(cfg.RequestLog, "logs", configDir)
+	if logging.ParseRequestLogFormat(cfg.RequestLogFormat) == logging.RequestLogFormatJSONL {
+		return logging.NewJSONLRequestLogger(cfg.RequestLog, logsDir, configDir)
+	}
+	return logging.NewFileRequestLogger(cfg.RequestLog, logsDir, configDir)
 }
 
 // WithMiddleware appends additional Gin middleware during server construction.
@@ -202,12 +210,14 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	// Add middleware
 	engine.Use(logging.GinLogrusLogger())
 	engine.Use(logging.GinLogrusRecovery())
+	engine.Use(tracing.Middleware())
 	for _, mw := range optionState.extraMiddleware {
 		engine.Use(mw)
 	}
 
 	// Add request logging middleware (positioned after recovery, before auth)
 	// Resolve logs directory relative to the configuration file directory.
+	logging.ConfigureRedaction(cfg)
 	var requestLogger logging.RequestLogger
 	var toggle func(bool)
 	if !cfg.CommercialMode {
@@ -268,6 +278,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 
 	// Setup routes
 	s.setupRoutes()
+	s.handlers.StartBatches()
 
 	// Register Amp module using V2 interface with Context
 	s.ampModule = ampmodule.NewLegacy(accessManager, AuthMiddleware(accessManager))
@@ -310,6 +321,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 // It defines the endpoints and associates them with their respective handlers.
 func (s *Server) setupRoutes() {
 	s.engine.GET("/management.html", s.serveManagementControlPanel)
+	s.engine.GET("/metrics", s.metricsMiddleware(), gin.WrapH(metrics.Default().Handler()))
 	openaiHandlers := openai.NewOpenAIAPIHandler(s.handlers)
 	geminiHandlers := gemini.NewGeminiAPIHandler(s.handlers)
 	geminiCLIHandlers := gemini.NewGeminiCLIAPIHandler(s.handlers)
@@ -323,9 +335,30 @@ func (s *Server) setupRoutes() {
 		v1.GET("/models", s.unifiedModelsHandler(openaiHandlers, claudeCodeHandlers))
 		v1.POST("/chat/completions", openaiHandlers.ChatCompletions)
 		v1.POST("/completions", openaiHandlers.Completions)
+		v1.POST("/embeddings", openaiHandlers.Embeddings)
+		v1.POST("/images/generations", openaiHandlers.ImageGenerations)
+		v1.POST("/images/edits", openaiHandlers.ImageEdits)
 		v1.POST("/messages", claudeCodeHandlers.ClaudeMessages)
 		v1.POST("/messages/count_tokens", claudeCodeHandlers.ClaudeCountTokens)
+		v1.POST("/messages/batches", claudeCodeHandlers.CreateMessageBatch)
+		v1.GET("/messages/batches", claudeCodeHandlers.ListMessageBatches)
+		v1.GET("/messages/batches/:id", claudeCodeHandlers.GetMessageBatch)
+		v1.DELETE("/messages/batches/:id", claudeCodeHandlers.DeleteMessageBatch)
+		v1.POST("/messages/batches/:id/cancel", claudeCodeHandlers.CancelMessageBatch)
+		v1.GET("/messages/batches/:id/results", claudeCodeHandlers.MessageBatchResults)
+		v1.POST("/files", openaiHandlers.UploadFile)
+		v1.GET("/files", openaiHandlers.ListFiles)
+		v1.GET("/files/:id", openaiHandlers.GetFile)
+		v1.DELETE("/files/:id", openaiHandlers.DeleteFile)
+		v1.GET("/files/:id/content", openaiHandlers.GetFileContent)
+		v1.POST("/batches", openaiHandlers.CreateBatch)
+		v1.GET("/batches", openaiHandlers.ListBatches)
+		v1.GET("/batches/:id", openaiHandlers.GetBatch)
+		v1.POST("/batches/:id/cancel", openaiHandlers.CancelBatch)
 		v1.POST("/responses", openaiResponsesHandlers.Responses)
+		v1.GET("/responses/:id", openaiResponsesHandlers.GetResponse)
+		v1.DELETE("/responses/:id", openaiResponsesHandlers.DeleteResponse)
+		v1.GET("/responses/:id/input_items", openaiResponsesHandlers.ResponseInputItems)
 	}
 
 	// Gemini compatible API routes
@@ -479,6 +512,9 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/usage", s.mgmt.GetUsageStatistics)
 		mgmt.GET("/usage/export", s.mgmt.ExportUsageStatistics)
 		mgmt.POST("/usage/import", s.mgmt.ImportUsageStatistics)
+		mgmt.GET("/usage/history", s.mgmt.GetUsageHistory)
+		mgmt.GET("/usage/records", s.mgmt.GetUsageRecords)
+		mgmt.GET("/routing/scores", s.mgmt.GetRoutingScores)
 		mgmt.GET("/config", s.mgmt.GetConfig)
 		mgmt.GET("/config.yaml", s.mgmt.GetConfigYAML)
 		mgmt.PUT("/config.yaml", s.mgmt.PutConfigYAML)
@@ -516,6 +552,18 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PATCH("/api-keys", s.mgmt.PatchAPIKeys)
 		mgmt.DELETE("/api-keys", s.mgmt.DeleteAPIKeys)
 
+		mgmt.GET("/api-key-policies", s.mgmt.GetAPIKeyPolicies)
+		mgmt.PUT("/api-key-policies", s.mgmt.PutAPIKeyPolicies)
+		mgmt.PATCH("/api-key-policies", s.mgmt.PatchAPIKeyPolicies)
+		mgmt.DELETE("/api-key-policies", s.mgmt.DeleteAPIKeyPolicies)
+		mgmt.GET("/api-key-usage", s.mgmt.GetAPIKeyLimitUsage)
+		mgmt.DELETE("/api-key-usage", s.mgmt.DeleteAPIKeyLimitUsage)
+		mgmt.GET("/response-cache", s.mgmt.GetResponseCache)
+		mgmt.DELETE("/response-cache", s.mgmt.DeleteResponseCache)
+		mgmt.GET("/circuit-breakers", s.mgmt.GetCircuitBreakers)
+		mgmt.DELETE("/circuit-breakers", s.mgmt.DeleteCircuitBreakers)
+		mgmt.GET("/cooldown-queue", s.mgmt.GetCooldownQueue)
+
 		mgmt.GET("/gemini-api-key", s.mgmt.GetGeminiKeys)
 		mgmt.PUT("/gemini-api-key", s.mgmt.PutGeminiKeys)
 		mgmt.PATCH("/gemini-api-key", s.mgmt.PatchGeminiKey)
@@ -584,6 +632,14 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PATCH("/oauth-excluded-models", s.mgmt.PatchOAuthExcludedModels)
 		mgmt.DELETE("/oauth-excluded-models", s.mgmt.DeleteOAuthExcludedModels)
 
+		mgmt.GET("/auth-runtime", s.mgmt.GetAuthRuntime)
+		mgmt.GET("/auth-runtime/stream", s.mgmt.StreamAuthRuntime)
+		mgmt.POST("/auth-controls/cooldown", s.mgmt.PostAuthCooldown)
+		mgmt.POST("/auth-controls/clear-cooldown", s.mgmt.PostAuthClearCooldown)
+		mgmt.POST("/auth-controls/reset-backoff", s.mgmt.PostAuthResetBackoff)
+		mgmt.POST("/auth-controls/drain", s.mgmt.PostAuthDrain)
+		mgmt.POST("/auth-controls/resume", s.mgmt.PostAuthResume)
+		mgmt.GET("/auth-controls/audit", s.mgmt.GetAuthControlAudit)
 		mgmt.GET("/auth-files", s.mgmt.ListAuthFiles)
 		mgmt.GET("/auth-files/models", s.mgmt.GetAuthFileModels)
 		mgmt.GET("/auth-files/download", s.mgmt.DownloadAuthFile)
@@ -620,6 +676,24 @@ func (s *Server) managementAvailabilityMiddleware() gin.HandlerFunc {
 	}
 }
 
+// metricsMiddleware hides /metrics unless enabled and applies management key
+// authentication when metrics.require-management-key is set.
+func (s *Server) metricsMiddleware() gin.HandlerFunc {
+	managementAuth := s.mgmt.Middleware()
+	return func(c *gin.Context) {
+		cfg := s.cfg
+		if cfg == nil || !cfg.Metrics.Enable {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		if cfg.Metrics.RequireManagementKey {
+			managementAuth(c)
+			return
+		}
+		c.Next()
+	}
+}
+
 func (s *Server) serveManagementControlPanel(c *gin.Context) {
 	cfg := s.cfg
 	if cfg == nil {
@@ -835,6 +909,16 @@ func (s *Server) applyAccessConfig(oldCfg, newCfg *config.Config) {
 	}
 }
 
+// configChangedBesidesResponseCache reports whether the two configurations differ in anything
+// other than the response-cache settings.
+func configChangedBesidesResponseCache(oldCfg, cfg *config.Config) bool {
+	oldCopy, newCopy := *oldCfg, *cfg
+	oldCopy.ResponseCache, newCopy.ResponseCache = config.ResponseCacheConfig{}, config.ResponseCacheConfig{}
+	oldYAML, errOld := yaml.Marshal(&oldCopy)
+	newYAML, errNew := yaml.Marshal(&newCopy)
+	return errOld != nil || errNew != nil || !bytes.Equal(oldYAML, newYAML)
+}
+
 // UpdateClients updates the server's client list and configuration.
 // This method is called when the configuration or authentication tokens change.
 //
@@ -848,6 +932,9 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		_ = yaml.Unmarshal(s.oldConfigYaml, &oldCfg)
 	}
 
+	// Redaction rules are cheap to compile, so they are rebuilt on every reload
+	logging.ConfigureRedaction(cfg)
+
 	// Update request logger enabled state if it has changed
 	previousRequestLog := false
 	if oldCfg != nil {
@@ -866,6 +953,13 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		}
 	}
 
+	// The request logger is built once at startup and the middleware holds on to it, so a new
+	// format only takes effect after a restart.
+	if s.requestLogger != nil && oldCfg != nil && logging.ParseRequestLogFormat(oldCfg.RequestLogFormat) != logging.ParseRequestLogFormat(cfg.RequestLogFormat) {
+		log.Warnf("request-log-format changed from %s to %s; restart the server to apply it",
+			logging.ParseRequestLogFormat(oldCfg.RequestLogFormat), logging.ParseRequestLogFormat(cfg.RequestLogFormat))
+	}
+
 	if oldCfg == nil || oldCfg.LoggingToFile != cfg.LoggingToFile || oldCfg.LogsMaxTotalSizeMB != cfg.LogsMaxTotalSizeMB {
 		if err := logging.ConfigureLogOutput(cfg); err != nil {
 			log.Errorf("failed to reconfigure log output: %v", err)
@@ -900,6 +994,14 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 			log.Debugf("disable_cooling toggled to %t", cfg.DisableCooling)
 		}
 	}
+	// Cached responses were produced under the previous model mappings, aliases and payload
+	// rules, so any other configuration change invalidates them.
+	if oldCfg != nil && configChangedBesidesResponseCache(oldCfg, cfg) {
+		if removed := responsecache.Default().Purge(); removed > 0 {
+			log.Infof("response cache purged after configuration reload (%d entries)", removed)
+		}
+	}
+
 	if s.handlers != nil && s.handlers.AuthManager != nil {
 		s.handlers.AuthManager.SetRetryConfig(cfg.RequestRetry, time.Duration(cfg.MaxRetryInterval)*time.Second)
 	}
@@ -957,6 +1059,7 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	s.oldConfigYaml, _ = yaml.Marshal(cfg)
 
 	s.handlers.UpdateClients(&cfg.SDKConfig)
+	s.handlers.StartBatches()
 
 	if !cfg.RemoteManagement.DisableControlPanel {
 		staticDir := managementasset.StaticDir(s.configFilePath)
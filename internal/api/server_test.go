@@ -109,3 +109,19 @@ func TestAmpProviderModelRoutes(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigChangedBesidesResponseCache(t *testing.T) {
+	base := &proxyconfig.Config{SDKConfig: sdkconfig.SDKConfig{APIKeys: []string{"k"}}}
+
+	cacheOnly := *base
+	cacheOnly.ResponseCache = proxyconfig.ResponseCacheConfig{Enable: true, MaxEntries: 10}
+	if configChangedBesidesResponseCache(base, &cacheOnly) {
+		t.Fatalf("response-cache changes alone should keep cached responses")
+	}
+
+	remapped := *base
+	remapped.OpenAICompatibility = []proxyconfig.OpenAICompatibility{{Name: "p", Models: []proxyconfig.OpenAICompatibilityModel{{Name: "up", Alias: "m"}}}}
+	if !configChangedBesidesResponseCache(base, &remapped) {
+		t.Fatalf("alias changes should invalidate cached responses")
+	}
+}
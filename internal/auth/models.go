@@ -7,6 +7,8 @@ package auth
 // authentication tokens to a file system location.
 type TokenStorage interface {
 	// SaveTokenToFile persists authentication tokens to the specified file path.
+	// The content is sealed with authcrypt before it is written, so plaintext
+	// credentials never reach the disk when encryption is enabled.
 	//
 	// Parameters:
 	//   - authFilePath: The file path where the authentication tokens should be saved
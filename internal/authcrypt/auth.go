@@ -0,0 +1,28 @@
+package authcrypt
+
+import (
+	"time"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// UnreadableAuth describes an auth file that could not be decrypted. The entry carries no
+// metadata, so it is never selected for requests nor written back over the sealed file, but it
+// stays visible with an error status instead of silently disappearing.
+func UnreadableAuth(id, path string, err error) *cliproxyauth.Auth {
+	now := time.Now()
+	message := err.Error()
+	return &cliproxyauth.Auth{
+		ID:            id,
+		Provider:      "unknown",
+		FileName:      id,
+		Label:         id,
+		Status:        cliproxyauth.StatusError,
+		StatusMessage: message,
+		Unavailable:   true,
+		Attributes:    map[string]string{"path": path, "source": path},
+		LastError:     &cliproxyauth.Error{Code: "decrypt_failed", Message: message},
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
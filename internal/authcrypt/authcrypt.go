@@ -0,0 +1,340 @@
+// Package authcrypt encrypts credential files at rest. Each file is sealed with its own random
+// data key using AES-256-GCM; the data key is in turn sealed with a master key from the
+// keyring. The result is a small JSON envelope, so sealed files can live anywhere a plaintext
+// auth file could, including git, object storage and PostgreSQL JSON columns.
+//
+// The first key of the keyring seals new data; the remaining keys are only used to open data
+// sealed before a rotation. Plaintext files are returned as-is so existing deployments keep
+// working until they are migrated.
+package authcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	envelopeVersion = "v1"
+	// envelopeField marks a sealed file; its value is the envelope version.
+	envelopeField = "cliproxy_encrypted"
+	keySize       = 32
+)
+
+var (
+	// ErrNoKey is returned when sealed data is opened without a configured keyring.
+	ErrNoKey = errors.New("auth file is encrypted but no encryption key is configured")
+	// ErrUnknownKey is returned when sealed data names a key missing from the keyring.
+	ErrUnknownKey = errors.New("auth file is encrypted with an unknown key")
+	// ErrDecrypt is returned when sealed data fails authentication.
+	ErrDecrypt = errors.New("auth file decryption failed")
+)
+
+// Key is one master key of a keyring.
+type Key struct {
+	ID     string
+	secret []byte
+}
+
+// Keyring holds the master keys. The first key is the primary key used for sealing.
+type Keyring struct {
+	keys []Key
+}
+
+type envelope struct {
+	Version    string `json:"cliproxy_encrypted"`
+	KeyID      string `json:"key_id"`
+	WrappedKey string `json:"wrapped_key"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+var current atomic.Pointer[Keyring]
+
+// SetKeyring installs the process-wide keyring. A nil keyring disables encryption.
+func SetKeyring(k *Keyring) {
+	current.Store(k)
+}
+
+// Enabled reports whether new data is sealed.
+func Enabled() bool {
+	return current.Load() != nil
+}
+
+// PrimaryKeyID returns the id of the key new data is sealed with, or "" when encryption is
+// disabled.
+func PrimaryKeyID() string {
+	return current.Load().PrimaryKeyID()
+}
+
+// ParseKeyring parses keys separated by commas or newlines. Each key is "id:secret" or a bare
+// secret, where the secret is 32 bytes encoded as base64 or hex; bare secrets are identified by
+// a fingerprint. Lines starting with '#' are ignored. The first key is the primary key.
+func ParseKeyring(spec string) (*Keyring, error) {
+	k := &Keyring{}
+	seen := make(map[string]struct{})
+	for _, line := range strings.Split(spec, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, entry := range strings.Split(line, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			id, encoded, hasID := strings.Cut(entry, ":")
+			if !hasID {
+				id, encoded = "", entry
+			}
+			secret, err := decodeSecret(strings.TrimSpace(encoded))
+			if err != nil {
+				return nil, err
+			}
+			id = strings.TrimSpace(id)
+			if id == "" {
+				sum := sha256.Sum256(secret)
+				id = hex.EncodeToString(sum[:4])
+			}
+			if _, dup := seen[id]; dup {
+				return nil, fmt.Errorf("authcrypt: duplicate key id %q", id)
+			}
+			seen[id] = struct{}{}
+			k.keys = append(k.keys, Key{ID: id, secret: secret})
+		}
+	}
+	if len(k.keys) == 0 {
+		return nil, fmt.Errorf("authcrypt: no keys found")
+	}
+	return k, nil
+}
+
+func decodeSecret(encoded string) ([]byte, error) {
+	if len(encoded) == hex.EncodedLen(keySize) {
+		if secret, err := hex.DecodeString(encoded); err == nil {
+			return secret, nil
+		}
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if secret, err := enc.DecodeString(encoded); err == nil && len(secret) == keySize {
+			return secret, nil
+		}
+	}
+	return nil, fmt.Errorf("authcrypt: key must be %d bytes encoded as base64 or hex", keySize)
+}
+
+// PrimaryKeyID returns the id of the key used for sealing.
+func (k *Keyring) PrimaryKeyID() string {
+	if k == nil || len(k.keys) == 0 {
+		return ""
+	}
+	return k.keys[0].ID
+}
+
+func (k *Keyring) key(id string) ([]byte, bool) {
+	for _, key := range k.keys {
+		if key.ID == id {
+			return key.secret, true
+		}
+	}
+	return nil, false
+}
+
+// IsSealed reports whether data is an encryption envelope.
+func IsSealed(data []byte) bool {
+	return gjson.GetBytes(data, envelopeField).Type == gjson.String
+}
+
+// Current reports whether data is stored the way Seal would store it now: plaintext when
+// encryption is disabled, sealed with the primary key otherwise.
+func Current(data []byte) bool {
+	k := current.Load()
+	if k == nil {
+		return !IsSealed(data)
+	}
+	return IsSealed(data) && gjson.GetBytes(data, "key_id").String() == k.PrimaryKeyID()
+}
+
+// Seal encrypts plaintext with the primary key. Without a keyring plaintext is returned as-is.
+func Seal(plaintext []byte) ([]byte, error) {
+	k := current.Load()
+	if k == nil {
+		return plaintext, nil
+	}
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("authcrypt: generate data key: %w", err)
+	}
+	nonce, ciphertext, err := seal(dataKey, plaintext, []byte(envelopeVersion))
+	if err != nil {
+		return nil, err
+	}
+	primary := k.keys[0]
+	keyNonce, wrapped, err := seal(primary.secret, dataKey, []byte(envelopeVersion+":"+primary.ID))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(envelope{
+		Version:    envelopeVersion,
+		KeyID:      primary.ID,
+		WrappedKey: base64.StdEncoding.EncodeToString(append(keyNonce, wrapped...)),
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+	})
+}
+
+// Open decrypts sealed data. Data that is not sealed is returned as-is.
+func Open(data []byte) ([]byte, error) {
+	if !IsSealed(data) {
+		return data, nil
+	}
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecrypt, err)
+	}
+	if env.Version != envelopeVersion {
+		return nil, fmt.Errorf("%w: unsupported envelope version %q", ErrDecrypt, env.Version)
+	}
+	k := current.Load()
+	if k == nil {
+		return nil, ErrNoKey
+	}
+	master, ok := k.key(env.KeyID)
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, env.KeyID)
+	}
+	wrapped, errWrapped := base64.StdEncoding.DecodeString(env.WrappedKey)
+	nonce, errNonce := base64.StdEncoding.DecodeString(env.Nonce)
+	ciphertext, errCiphertext := base64.StdEncoding.DecodeString(env.Ciphertext)
+	if err := errors.Join(errWrapped, errNonce, errCiphertext); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecrypt, err)
+	}
+	dataKey, err := open(master, wrapped, []byte(envelopeVersion+":"+env.KeyID))
+	if err != nil {
+		return nil, err
+	}
+	return open(dataKey, append(nonce, ciphertext...), []byte(envelopeVersion))
+}
+
+func seal(key, plaintext, additional []byte) (nonce, ciphertext []byte, err error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce = make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, nil, fmt.Errorf("authcrypt: generate nonce: %w", err)
+	}
+	return nonce, gcm.Seal(nil, nonce, plaintext, additional), nil
+}
+
+// open decrypts data laid out as nonce followed by ciphertext.
+func open(key, data, additional []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], additional)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// ReadFile reads and opens the file at path.
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Open(data)
+}
+
+// WriteFile seals plaintext and atomically replaces the file at path.
+func WriteFile(path string, plaintext []byte) error {
+	data, err := Seal(plaintext)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// SealFile rewrites the file at path unless it is already Current: plaintext is sealed and
+// data sealed with a rotated-out key is sealed again with the primary key. It reports whether
+// the file changed.
+func SealFile(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	if len(data) == 0 || Current(data) {
+		return false, nil
+	}
+	plaintext, err := Open(data)
+	if err != nil {
+		return false, err
+	}
+	if err = WriteFile(path, plaintext); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("authcrypt: create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	_, errWrite := tmp.Write(data)
+	errClose := tmp.Close()
+	if err = errors.Join(errWrite, errClose, os.Chmod(tmpName, 0o600)); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("authcrypt: write temp file: %w", err)
+	}
+	if err = os.Rename(tmpName, path); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("authcrypt: replace file: %w", err)
+	}
+	return nil
+}
+
+// LoadKeyring builds a keyring from an inline key list and a key file, inline keys first.
+// It returns nil when neither is set.
+func LoadKeyring(keys, keyFile string) (*Keyring, error) {
+	spec := strings.TrimSpace(keys)
+	if path := strings.TrimSpace(keyFile); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("authcrypt: read key file: %w", err)
+		}
+		spec += "\n" + string(data)
+	}
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+	return ParseKeyring(spec)
+}
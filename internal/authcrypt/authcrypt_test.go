@@ -0,0 +1,112 @@
+package authcrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, keySize))
+}
+
+func useKeyring(t *testing.T, spec string) {
+	t.Helper()
+	k, err := ParseKeyring(spec)
+	if err != nil {
+		t.Fatalf("ParseKeyring() error = %v", err)
+	}
+	SetKeyring(k)
+	t.Cleanup(func() { SetKeyring(nil) })
+}
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	plaintext := []byte(`{"type":"claude","refresh_token":"secret"}`)
+	if out, err := Seal(plaintext); err != nil || !bytes.Equal(out, plaintext) {
+		t.Fatalf("Seal() without keys = %s, %v", out, err)
+	}
+
+	useKeyring(t, "k1:"+testKey(1))
+	sealed, err := Seal(plaintext)
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if !IsSealed(sealed) || !Current(sealed) || bytes.Contains(sealed, []byte("secret")) {
+		t.Fatalf("sealed data = %s", sealed)
+	}
+	opened, err := Open(sealed)
+	if err != nil || !bytes.Equal(opened, plaintext) {
+		t.Fatalf("Open() = %s, %v", opened, err)
+	}
+	if opened, err = Open(plaintext); err != nil || !bytes.Equal(opened, plaintext) {
+		t.Fatalf("Open() of plaintext = %s, %v", opened, err)
+	}
+
+	tampered := bytes.Replace(sealed, []byte(`"ciphertext":"`), []byte(`"ciphertext":"AA`), 1)
+	if _, err = Open(tampered); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("Open() of tampered data error = %v", err)
+	}
+	SetKeyring(nil)
+	if _, err = Open(sealed); !errors.Is(err, ErrNoKey) {
+		t.Fatalf("Open() without keys error = %v", err)
+	}
+}
+
+func TestKeyRotationResealsFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth.json")
+	if err := os.WriteFile(path, []byte(`{"type":"codex"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	useKeyring(t, "old:"+testKey(1))
+	if changed, err := SealFile(path); err != nil || !changed {
+		t.Fatalf("SealFile() of plaintext = %v, %v", changed, err)
+	}
+	if changed, err := SealFile(path); err != nil || changed {
+		t.Fatalf("SealFile() of current file = %v, %v", changed, err)
+	}
+
+	useKeyring(t, "new:"+testKey(2)+"\nold:"+testKey(1))
+	data, _ := os.ReadFile(path)
+	if Current(data) {
+		t.Fatalf("file sealed with the old key reported current")
+	}
+	if opened, err := Open(data); err != nil || string(opened) != `{"type":"codex"}` {
+		t.Fatalf("Open() with rotated keyring = %s, %v", opened, err)
+	}
+	if changed, err := SealFile(path); err != nil || !changed {
+		t.Fatalf("SealFile() after rotation = %v, %v", changed, err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("resealed file mode = %v, %v", info, err)
+	}
+
+	useKeyring(t, "new:"+testKey(2))
+	if opened, err := ReadFile(path); err != nil || string(opened) != `{"type":"codex"}` {
+		t.Fatalf("ReadFile() without the old key = %s, %v", opened, err)
+	}
+	useKeyring(t, "other:"+testKey(3))
+	if _, err := ReadFile(path); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("ReadFile() with unknown key error = %v", err)
+	}
+}
+
+func TestParseKeyring(t *testing.T) {
+	hexKey := strings.Repeat("ab", keySize)
+	k, err := ParseKeyring("# rotated keys\n" + testKey(1) + ", legacy:" + hexKey)
+	if err != nil {
+		t.Fatalf("ParseKeyring() error = %v", err)
+	}
+	if len(k.keys) != 2 || len(k.PrimaryKeyID()) != 8 || k.keys[1].ID != "legacy" {
+		t.Fatalf("keys = %+v", k.keys)
+	}
+	for _, spec := range []string{"", "k:short", "a:" + testKey(1) + ",a:" + testKey(2)} {
+		if _, err = ParseKeyring(spec); err == nil {
+			t.Fatalf("ParseKeyring(%q) succeeded", spec)
+		}
+	}
+}
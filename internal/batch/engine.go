@@ -0,0 +1,855 @@
+// Package batch implements the local job queue behind the Claude Message Batches and OpenAI
+// Batch APIs. A job is a list of independent requests that are executed through the regular
+// request pipeline at a bounded concurrency. Requests, results and job state are written to
+// disk as they progress, so unfinished jobs resume where they stopped after a restart.
+// Requests rejected because every credential is cooling down are retried after the reported
+// reset time instead of being recorded as failures.
+package batch
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultConcurrency = 4
+	defaultMaxRequests = 100000
+	defaultRetention   = 30 * 24 * time.Hour
+
+	// jobWindow is how long a job may run before its remaining requests expire.
+	jobWindow = 24 * time.Hour
+
+	// Requests rejected with 429 are retried up to maxCooldownRetries times, waiting for the
+	// reported Retry-After or defaultCooldownWait, capped at maxCooldownWait.
+	maxCooldownRetries  = 8
+	defaultCooldownWait = 30 * time.Second
+	maxCooldownWait     = 10 * time.Minute
+
+	janitorInterval = time.Hour
+)
+
+// Job kinds select the API a job was created through.
+const (
+	KindClaude = "claude"
+	KindOpenAI = "openai"
+)
+
+// Job processing states.
+const (
+	StatusInProgress = "in_progress"
+	StatusCanceling  = "canceling"
+	StatusEnded      = "ended"
+)
+
+// Result types.
+const (
+	ResultSucceeded = "succeeded"
+	ResultErrored   = "errored"
+	ResultCanceled  = "canceled"
+	ResultExpired   = "expired"
+)
+
+var (
+	// ErrNotFound is returned for unknown jobs and files or those owned by another API key.
+	ErrNotFound = errors.New("not found")
+	// ErrNotEnded is returned when an operation needs a job that has finished processing.
+	ErrNotEnded = errors.New("batch is still processing")
+	// ErrDisabled is returned when the batch engine is disabled or not started.
+	ErrDisabled = errors.New("batch processing is disabled")
+	// ErrInvalid wraps rejected job definitions.
+	ErrInvalid = errors.New("invalid batch")
+)
+
+// Request is one request of a job.
+type Request struct {
+	// CustomID is the caller supplied identifier echoed in the result.
+	CustomID string `json:"custom_id"`
+	// HandlerType is the API format of Body.
+	HandlerType string `json:"handler_type"`
+	// Model is the requested model.
+	Model string `json:"model"`
+	// Body is the non-streaming request payload.
+	Body json.RawMessage `json:"body"`
+}
+
+// Result is the outcome of one request of a job.
+type Result struct {
+	ID         string          `json:"id"`
+	CustomID   string          `json:"custom_id"`
+	Type       string          `json:"type"`
+	StatusCode int             `json:"status_code,omitempty"`
+	Body       json.RawMessage `json:"body,omitempty"`
+}
+
+// Counts tallies the requests of a job by state.
+type Counts struct {
+	Processing int `json:"processing"`
+	Succeeded  int `json:"succeeded"`
+	Errored    int `json:"errored"`
+	Canceled   int `json:"canceled"`
+	Expired    int `json:"expired"`
+}
+
+func (c *Counts) add(resultType string) {
+	c.Processing--
+	switch resultType {
+	case ResultSucceeded:
+		c.Succeeded++
+	case ResultErrored:
+		c.Errored++
+	case ResultCanceled:
+		c.Canceled++
+	case ResultExpired:
+		c.Expired++
+	}
+}
+
+// Job is the persisted state of a batch. Owner is the hash of the client API key that
+// created it; the key itself is never persisted.
+type Job struct {
+	ID     string `json:"id"`
+	Kind   string `json:"kind"`
+	Owner  string `json:"owner,omitempty"`
+	Status string `json:"status"`
+	Total  int    `json:"total"`
+	Counts Counts `json:"counts"`
+
+	CreatedAt         time.Time  `json:"created_at"`
+	ExpiresAt         time.Time  `json:"expires_at"`
+	StartedAt         *time.Time `json:"started_at,omitempty"`
+	EndedAt           *time.Time `json:"ended_at,omitempty"`
+	CancelRequestedAt *time.Time `json:"cancel_requested_at,omitempty"`
+
+	// Endpoint, InputFileID, OutputFileID, ErrorFileID, CompletionWindow and Metadata carry
+	// the OpenAI batch fields.
+	Endpoint         string          `json:"endpoint,omitempty"`
+	InputFileID      string          `json:"input_file_id,omitempty"`
+	OutputFileID     string          `json:"output_file_id,omitempty"`
+	ErrorFileID      string          `json:"error_file_id,omitempty"`
+	CompletionWindow string          `json:"completion_window,omitempty"`
+	Metadata         json.RawMessage `json:"metadata,omitempty"`
+}
+
+// Expired reports whether the job ran out of time before finishing every request.
+func (j *Job) Expired() bool { return j.Counts.Expired > 0 }
+
+// Outcome is the response to one executed request.
+type Outcome struct {
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+	// Body is the response body, or the error body in the caller's format.
+	Body []byte
+	// RetryAfter is the wait reported with a 429 response.
+	RetryAfter time.Duration
+}
+
+// ExecuteFunc executes one request on behalf of the client API key whose hash is owner.
+type ExecuteFunc func(ctx context.Context, owner string, req Request) Outcome
+
+// Options carries the optional fields of a new job.
+type Options struct {
+	Endpoint         string
+	InputFileID      string
+	CompletionWindow string
+	Metadata         json.RawMessage
+}
+
+type jobState struct {
+	mu       sync.Mutex
+	job      *Job
+	canceled chan struct{}
+}
+
+var defaultEngine = New()
+
+// Default returns the process-wide engine used by the API handlers.
+func Default() *Engine { return defaultEngine }
+
+// Engine runs batch jobs.
+type Engine struct {
+	mu      sync.RWMutex
+	cfg     config.BatchConfig
+	dir     string
+	exec    ExecuteFunc
+	started bool
+	ctx     context.Context
+	stop    context.CancelFunc
+	jobs    map[string]*jobState
+	files   map[string]*File
+	limiter *limiter
+
+	now func() time.Time
+}
+
+// New constructs an engine; call Apply and Start to use it.
+func New() *Engine {
+	return &Engine{
+		jobs:    make(map[string]*jobState),
+		files:   make(map[string]*File),
+		limiter: newLimiter(defaultConcurrency),
+		now:     time.Now,
+	}
+}
+
+// Apply updates the configuration. Concurrency changes take effect immediately; the storage
+// directory is fixed once the engine has started.
+func (e *Engine) Apply(cfg config.BatchConfig) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cfg = cfg
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	e.limiter.setLimit(concurrency)
+	if !e.started {
+		e.dir = strings.TrimSpace(cfg.Dir)
+		if e.dir == "" {
+			e.dir = defaultDir()
+		}
+	}
+}
+
+// Start binds exec and, on the first call, loads stored jobs and files and resumes every
+// unfinished job. Later calls only replace exec.
+func (e *Engine) Start(exec ExecuteFunc) {
+	e.mu.Lock()
+	e.exec = exec
+	if e.started || e.cfg.Disable {
+		e.mu.Unlock()
+		return
+	}
+	if e.dir == "" {
+		e.dir = defaultDir()
+	}
+	e.started = true
+	e.ctx, e.stop = context.WithCancel(context.Background())
+	e.loadLocked()
+	var resume []*jobState
+	for _, state := range e.jobs {
+		if state.job.Status != StatusEnded {
+			resume = append(resume, state)
+		}
+	}
+	e.mu.Unlock()
+
+	for _, state := range resume {
+		log.Infof("batch: resuming %s (%d/%d done)", state.job.ID, state.job.Total-state.job.Counts.Processing, state.job.Total)
+		go e.run(state)
+	}
+	go e.janitor()
+}
+
+// Stop halts processing. In-flight requests are abandoned and run again on the next start.
+func (e *Engine) Stop() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.stop != nil {
+		e.stop()
+	}
+}
+
+// Enabled reports whether the engine accepts jobs.
+func (e *Engine) Enabled() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.started && !e.cfg.Disable && e.ctx.Err() == nil
+}
+
+// MaxRequests returns the configured per-job request limit.
+func (e *Engine) MaxRequests() int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.cfg.MaxRequests > 0 {
+		return e.cfg.MaxRequests
+	}
+	return defaultMaxRequests
+}
+
+// Create stores a new job for owner and starts processing it.
+func (e *Engine) Create(kind, owner string, requests []Request, opts Options) (*Job, error) {
+	if !e.Enabled() {
+		return nil, ErrDisabled
+	}
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("%w: at least one request is required", ErrInvalid)
+	}
+	if limit := e.MaxRequests(); len(requests) > limit {
+		return nil, fmt.Errorf("%w: at most %d requests are allowed", ErrInvalid, limit)
+	}
+	seen := make(map[string]struct{}, len(requests))
+	for _, req := range requests {
+		if _, dup := seen[req.CustomID]; dup {
+			return nil, fmt.Errorf("%w: duplicate custom_id %q", ErrInvalid, req.CustomID)
+		}
+		seen[req.CustomID] = struct{}{}
+	}
+
+	prefix := "batch_"
+	if kind == KindClaude {
+		prefix = "msgbatch_"
+	}
+	now := e.now().UTC()
+	job := &Job{
+		ID:               prefix + randomID(),
+		Kind:             kind,
+		Owner:            owner,
+		Status:           StatusInProgress,
+		Total:            len(requests),
+		Counts:           Counts{Processing: len(requests)},
+		CreatedAt:        now,
+		ExpiresAt:        now.Add(jobWindow),
+		StartedAt:        &now,
+		Endpoint:         opts.Endpoint,
+		InputFileID:      opts.InputFileID,
+		CompletionWindow: opts.CompletionWindow,
+		Metadata:         opts.Metadata,
+	}
+	dir := e.jobDir(job.ID)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create batch directory: %w", err)
+	}
+	var lines []byte
+	for _, req := range requests {
+		line, err := json.Marshal(req)
+		if err != nil {
+			return nil, fmt.Errorf("encode request %s: %w", req.CustomID, err)
+		}
+		lines = append(append(lines, line...), '\n')
+	}
+	if err := writeFileAtomic(filepath.Join(dir, "requests.jsonl"), lines); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	if err := e.saveJob(job); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+
+	state := &jobState{job: job, canceled: make(chan struct{})}
+	e.mu.Lock()
+	e.jobs[job.ID] = state
+	e.mu.Unlock()
+	go e.run(state)
+	return cloneJob(job), nil
+}
+
+// Get returns the job id of kind owned by owner.
+func (e *Engine) Get(kind, id, owner string) (*Job, error) {
+	state, err := e.state(kind, id, owner)
+	if err != nil {
+		return nil, err
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return cloneJob(state.job), nil
+}
+
+// List returns the jobs of kind owned by owner, newest first.
+func (e *Engine) List(kind, owner string) []*Job {
+	e.mu.RLock()
+	states := make([]*jobState, 0, len(e.jobs))
+	for _, state := range e.jobs {
+		states = append(states, state)
+	}
+	e.mu.RUnlock()
+	jobs := make([]*Job, 0, len(states))
+	for _, state := range states {
+		state.mu.Lock()
+		if state.job.Kind == kind && state.job.Owner == owner {
+			jobs = append(jobs, cloneJob(state.job))
+		}
+		state.mu.Unlock()
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].CreatedAt.Equal(jobs[j].CreatedAt) {
+			return jobs[i].ID > jobs[j].ID
+		}
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+	return jobs
+}
+
+// Cancel stops a job: requests that have not started are recorded as canceled while
+// in-flight requests finish normally. Canceling an ended job is a no-op.
+func (e *Engine) Cancel(kind, id, owner string) (*Job, error) {
+	state, err := e.state(kind, id, owner)
+	if err != nil {
+		return nil, err
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.job.Status == StatusInProgress {
+		now := e.now().UTC()
+		state.job.Status = StatusCanceling
+		state.job.CancelRequestedAt = &now
+		close(state.canceled)
+		if errSave := e.saveJob(state.job); errSave != nil {
+			log.Warnf("batch: %v", errSave)
+		}
+	}
+	return cloneJob(state.job), nil
+}
+
+// Delete removes an ended job and its results.
+func (e *Engine) Delete(kind, id, owner string) error {
+	state, err := e.state(kind, id, owner)
+	if err != nil {
+		return err
+	}
+	state.mu.Lock()
+	ended := state.job.Status == StatusEnded
+	state.mu.Unlock()
+	if !ended {
+		return ErrNotEnded
+	}
+	e.mu.Lock()
+	delete(e.jobs, id)
+	e.mu.Unlock()
+	return os.RemoveAll(e.jobDir(id))
+}
+
+// Results calls fn for every result of an ended job in completion order.
+func (e *Engine) Results(kind, id, owner string, fn func(Result) error) error {
+	state, err := e.state(kind, id, owner)
+	if err != nil {
+		return err
+	}
+	state.mu.Lock()
+	ended := state.job.Status == StatusEnded
+	state.mu.Unlock()
+	if !ended {
+		return ErrNotEnded
+	}
+	return readResults(filepath.Join(e.jobDir(id), "results.jsonl"), fn)
+}
+
+func (e *Engine) state(kind, id, owner string) (*jobState, error) {
+	e.mu.RLock()
+	state, ok := e.jobs[id]
+	e.mu.RUnlock()
+	if !ok || state.job.Kind != kind || state.job.Owner != owner {
+		return nil, ErrNotFound
+	}
+	return state, nil
+}
+
+// run executes the pending requests of a job and finalizes it.
+func (e *Engine) run(state *jobState) {
+	e.mu.RLock()
+	ctx := e.ctx
+	e.mu.RUnlock()
+
+	dir := e.jobDir(state.job.ID)
+	requests, err := readRequests(filepath.Join(dir, "requests.jsonl"))
+	if err != nil {
+		log.Errorf("batch %s: %v", state.job.ID, err)
+	}
+	done := make(map[string]struct{})
+	_ = readResults(filepath.Join(dir, "results.jsonl"), func(r Result) error {
+		done[r.CustomID] = struct{}{}
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for _, req := range requests {
+		if _, ok := done[req.CustomID]; ok {
+			continue
+		}
+		if reason := e.stopReason(state); reason != "" {
+			e.record(state, Result{CustomID: req.CustomID, Type: reason})
+			continue
+		}
+		if !e.limiter.acquire(ctx) {
+			break
+		}
+		wg.Add(1)
+		go func(req Request) {
+			defer wg.Done()
+			defer e.limiter.release()
+			if result, ok := e.execute(ctx, state, req); ok {
+				e.record(state, result)
+			}
+		}(req)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return
+	}
+	e.finish(state)
+}
+
+// execute runs one request, retrying while every credential is cooling down. ok is false
+// when the engine stopped before the request completed.
+func (e *Engine) execute(ctx context.Context, state *jobState, req Request) (Result, bool) {
+	e.mu.RLock()
+	exec := e.exec
+	e.mu.RUnlock()
+	state.mu.Lock()
+	owner := state.job.Owner
+	state.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		out := exec(ctx, owner, req)
+		if ctx.Err() != nil {
+			return Result{}, false
+		}
+		if out.StatusCode == http.StatusTooManyRequests && attempt < maxCooldownRetries {
+			wait := out.RetryAfter
+			if wait <= 0 {
+				wait = defaultCooldownWait
+			}
+			wait = min(wait, maxCooldownWait)
+			log.Debugf("batch %s: %s rate limited, retrying in %s", state.job.ID, req.CustomID, wait)
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return Result{}, false
+			case <-state.canceled:
+				timer.Stop()
+				return Result{CustomID: req.CustomID, Type: ResultCanceled}, true
+			case <-timer.C:
+			}
+			if reason := e.stopReason(state); reason != "" {
+				return Result{CustomID: req.CustomID, Type: reason}, true
+			}
+			continue
+		}
+		result := Result{CustomID: req.CustomID, Type: ResultErrored, StatusCode: out.StatusCode, Body: json.RawMessage(out.Body)}
+		if out.StatusCode >= 200 && out.StatusCode < 300 {
+			result.Type = ResultSucceeded
+		}
+		if !json.Valid(result.Body) {
+			result.Body = nil
+		}
+		return result, true
+	}
+}
+
+// stopReason returns the result type for requests that should no longer start.
+func (e *Engine) stopReason(state *jobState) string {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	switch {
+	case state.job.CancelRequestedAt != nil:
+		return ResultCanceled
+	case !e.now().Before(state.job.ExpiresAt):
+		return ResultExpired
+	default:
+		return ""
+	}
+}
+
+// record appends a result and updates the job counters.
+func (e *Engine) record(state *jobState, result Result) {
+	result.ID = "batch_req_" + randomID()
+	line, err := json.Marshal(result)
+	if err != nil {
+		log.Errorf("batch %s: encode result: %v", state.job.ID, err)
+		return
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if err = appendLine(filepath.Join(e.jobDir(state.job.ID), "results.jsonl"), line); err != nil {
+		log.Errorf("batch %s: %v", state.job.ID, err)
+		return
+	}
+	state.job.Counts.add(result.Type)
+	if err = e.saveJob(state.job); err != nil {
+		log.Warnf("batch %s: %v", state.job.ID, err)
+	}
+}
+
+// finish marks a job as ended and, for OpenAI jobs, writes the output and error files.
+func (e *Engine) finish(state *jobState) {
+	state.mu.Lock()
+	job := state.job
+	if job.Status == StatusEnded {
+		state.mu.Unlock()
+		return
+	}
+	owner, kind := job.Owner, job.Kind
+	state.mu.Unlock()
+
+	var outputID, errorID string
+	if kind == KindOpenAI {
+		outputID, errorID = e.writeOpenAIOutput(job.ID, owner)
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	now := e.now().UTC()
+	job.Status = StatusEnded
+	job.EndedAt = &now
+	job.OutputFileID = outputID
+	job.ErrorFileID = errorID
+	if err := e.saveJob(job); err != nil {
+		log.Warnf("batch %s: %v", job.ID, err)
+	}
+	log.Infof("batch %s ended: %d succeeded, %d errored, %d canceled, %d expired",
+		job.ID, job.Counts.Succeeded, job.Counts.Errored, job.Counts.Canceled, job.Counts.Expired)
+}
+
+// janitor removes ended jobs and files older than the retention period.
+func (e *Engine) janitor() {
+	ticker := time.NewTicker(janitorInterval)
+	defer ticker.Stop()
+	for {
+		e.cleanup()
+		e.mu.RLock()
+		ctx := e.ctx
+		e.mu.RUnlock()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Engine) cleanup() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	retention := time.Duration(e.cfg.RetentionHours) * time.Hour
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+	cutoff := e.now().Add(-retention)
+	for id, state := range e.jobs {
+		state.mu.Lock()
+		expired := state.job.EndedAt != nil && state.job.EndedAt.Before(cutoff)
+		state.mu.Unlock()
+		if expired {
+			delete(e.jobs, id)
+			_ = os.RemoveAll(e.jobDir(id))
+		}
+	}
+	for id, file := range e.files {
+		if time.Unix(file.CreatedAt, 0).Before(cutoff) {
+			delete(e.files, id)
+			e.removeFile(id)
+		}
+	}
+}
+
+// loadLocked reads stored jobs and files. Missing directories are not an error.
+func (e *Engine) loadLocked() {
+	entries, err := os.ReadDir(filepath.Join(e.dir, "jobs"))
+	if err != nil && !os.IsNotExist(err) {
+		log.Warnf("batch: read jobs: %v", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		data, errRead := os.ReadFile(filepath.Join(e.jobDir(entry.Name()), "job.json"))
+		if errRead != nil {
+			continue
+		}
+		var job Job
+		if errRead = json.Unmarshal(data, &job); errRead != nil || job.ID != entry.Name() {
+			log.Warnf("batch: skipping unreadable job %s", entry.Name())
+			continue
+		}
+		if job.Status != StatusEnded {
+			// Results are appended before the job file is rewritten; recount so a crash
+			// between the two writes does not skew the counters.
+			job.Counts = Counts{Processing: job.Total}
+			_ = readResults(filepath.Join(e.jobDir(job.ID), "results.jsonl"), func(r Result) error {
+				job.Counts.add(r.Type)
+				return nil
+			})
+		}
+		state := &jobState{job: &job, canceled: make(chan struct{})}
+		if job.CancelRequestedAt != nil {
+			close(state.canceled)
+		}
+		e.jobs[job.ID] = state
+	}
+	e.loadFilesLocked()
+}
+
+func (e *Engine) jobDir(id string) string {
+	return filepath.Join(e.dir, "jobs", id)
+}
+
+func (e *Engine) saveJob(job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("encode job: %w", err)
+	}
+	return writeFileAtomic(filepath.Join(e.jobDir(job.ID), "job.json"), data)
+}
+
+func readRequests(path string) ([]Request, error) {
+	var requests []Request
+	err := scanLines(path, func(line []byte) error {
+		var req Request
+		if err := json.Unmarshal(line, &req); err != nil {
+			return err
+		}
+		requests = append(requests, req)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read requests: %w", err)
+	}
+	return requests, nil
+}
+
+func readResults(path string, fn func(Result) error) error {
+	err := scanLines(path, func(line []byte) error {
+		var result Result
+		if errDecode := json.Unmarshal(line, &result); errDecode != nil {
+			// A torn final line from a crash is skipped; the request runs again.
+			return nil
+		}
+		return fn(result)
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func scanLines(path string, fn func([]byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 256<<20)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		if err = fn(line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func appendLine(path string, line []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("append result: %w", err)
+	}
+	_, errWrite := f.Write(append(line, '\n'))
+	errClose := f.Close()
+	if errWrite != nil {
+		return fmt.Errorf("append result: %w", errWrite)
+	}
+	if errClose != nil {
+		return fmt.Errorf("append result: %w", errClose)
+	}
+	return nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+	_, errWrite := tmp.Write(data)
+	errClose := tmp.Close()
+	if errWrite == nil {
+		errWrite = errClose
+	}
+	if errWrite == nil {
+		errWrite = os.Rename(tmp.Name(), path)
+	}
+	if errWrite != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write %s: %w", filepath.Base(path), errWrite)
+	}
+	return nil
+}
+
+func cloneJob(job *Job) *Job {
+	cloned := *job
+	return &cloned
+}
+
+func randomID() string {
+	buf := make([]byte, 12)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func defaultDir() string {
+	if base := util.WritablePath(); base != "" {
+		return filepath.Join(base, "batches")
+	}
+	return "batches"
+}
+
+// limiter bounds concurrent requests across jobs. The limit can change while requests wait.
+type limiter struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	active int
+	limit  int
+}
+
+func newLimiter(limit int) *limiter {
+	l := &limiter{limit: limit}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+func (l *limiter) setLimit(limit int) {
+	l.mu.Lock()
+	l.limit = limit
+	l.mu.Unlock()
+	l.cond.Broadcast()
+}
+
+// acquire waits for a free slot and reports false when ctx ends first.
+func (l *limiter) acquire(ctx context.Context) bool {
+	stop := context.AfterFunc(ctx, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.cond.Broadcast()
+	})
+	defer stop()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.active >= l.limit {
+		if ctx.Err() != nil {
+			return false
+		}
+		l.cond.Wait()
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+	l.active++
+	return true
+}
+
+func (l *limiter) release() {
+	l.mu.Lock()
+	l.active--
+	l.mu.Unlock()
+	l.cond.Signal()
+}
@@ -0,0 +1,182 @@
+package batch
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func startTestEngine(t *testing.T, dir string, exec ExecuteFunc) *Engine {
+	t.Helper()
+	engine := New()
+	engine.Apply(config.BatchConfig{Dir: dir, Concurrency: 2})
+	engine.Start(exec)
+	t.Cleanup(engine.Stop)
+	return engine
+}
+
+func waitEnded(t *testing.T, engine *Engine, kind, id, owner string) *Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := engine.Get(kind, id, owner)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if job.Status == StatusEnded {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("batch %s did not end", id)
+	return nil
+}
+
+func TestJobRetriesCooldownAndRecordsResults(t *testing.T) {
+	var cooled atomic.Bool
+	engine := startTestEngine(t, t.TempDir(), func(_ context.Context, owner string, req Request) Outcome {
+		if owner != "key-1" {
+			t.Errorf("request executed under %q", owner)
+		}
+		switch req.CustomID {
+		case "cooling":
+			if cooled.CompareAndSwap(false, true) {
+				return Outcome{StatusCode: http.StatusTooManyRequests, RetryAfter: 10 * time.Millisecond}
+			}
+		case "broken":
+			return Outcome{StatusCode: http.StatusBadRequest, Body: []byte(`{"type":"error","error":{"type":"invalid_request_error","message":"bad"}}`)}
+		}
+		return Outcome{StatusCode: http.StatusOK, Body: []byte(`{"id":"msg_` + req.CustomID + `"}`)}
+	})
+
+	job, err := engine.Create(KindClaude, "key-1", []Request{
+		{CustomID: "ok", Model: "m", Body: []byte(`{}`)},
+		{CustomID: "cooling", Model: "m", Body: []byte(`{}`)},
+		{CustomID: "broken", Model: "m", Body: []byte(`{}`)},
+	}, Options{})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if !strings.HasPrefix(job.ID, "msgbatch_") {
+		t.Fatalf("claude batch id = %s", job.ID)
+	}
+	if _, err = engine.Get(KindClaude, job.ID, "key-2"); err != ErrNotFound {
+		t.Fatalf("Get() by another key error = %v", err)
+	}
+
+	job = waitEnded(t, engine, KindClaude, job.ID, "key-1")
+	if job.Counts.Succeeded != 2 || job.Counts.Errored != 1 || job.Counts.Processing != 0 {
+		t.Fatalf("counts = %+v", job.Counts)
+	}
+	lines := map[string]string{}
+	err = engine.Results(KindClaude, job.ID, "key-1", func(r Result) error {
+		lines[r.CustomID] = string(ClaudeResultLine(r))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Results() error = %v", err)
+	}
+	if got := gjson.Get(lines["cooling"], "result.type").String(); got != ResultSucceeded {
+		t.Fatalf("cooling request result = %s", lines["cooling"])
+	}
+	if got := gjson.Get(lines["broken"], "result.error.error.message").String(); got != "bad" {
+		t.Fatalf("errored request result = %s", lines["broken"])
+	}
+}
+
+func TestUnfinishedJobResumesAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	release := make(chan struct{})
+	first := New()
+	first.Apply(config.BatchConfig{Dir: dir, Concurrency: 1})
+	first.Start(func(ctx context.Context, _ string, req Request) Outcome {
+		if req.CustomID == "a" {
+			return Outcome{StatusCode: http.StatusOK, Body: []byte(`{}`)}
+		}
+		<-ctx.Done()
+		close(release)
+		return Outcome{StatusCode: http.StatusInternalServerError}
+	})
+	job, err := first.Create(KindOpenAI, "", []Request{
+		{CustomID: "a", Body: []byte(`{}`)},
+		{CustomID: "b", Body: []byte(`{}`)},
+	}, Options{Endpoint: "/v1/chat/completions"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		current, _ := first.Get(KindOpenAI, job.ID, "")
+		if current.Counts.Succeeded == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("first request never completed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	first.Stop()
+	<-release
+
+	var executed atomic.Int32
+	second := startTestEngine(t, dir, func(_ context.Context, _ string, req Request) Outcome {
+		executed.Add(1)
+		if req.CustomID != "b" {
+			t.Errorf("completed request %s ran again", req.CustomID)
+		}
+		return Outcome{StatusCode: http.StatusBadRequest, Body: []byte(`{"error":{"message":"bad"}}`)}
+	})
+	resumed := waitEnded(t, second, KindOpenAI, job.ID, "")
+	if executed.Load() != 1 || resumed.Counts.Succeeded != 1 || resumed.Counts.Errored != 1 {
+		t.Fatalf("resumed job = %+v, executed %d", resumed.Counts, executed.Load())
+	}
+	if resumed.OutputFileID == "" || resumed.ErrorFileID == "" {
+		t.Fatalf("output files not written: %+v", resumed)
+	}
+	output, err := second.FileContent(resumed.OutputFileID, "")
+	if err != nil {
+		t.Fatalf("FileContent() error = %v", err)
+	}
+	if got := gjson.GetBytes(output, "custom_id").String(); got != "a" || gjson.GetBytes(output, "response.status_code").Int() != http.StatusOK {
+		t.Fatalf("output file = %s", output)
+	}
+}
+
+func TestCancelMarksPendingRequests(t *testing.T) {
+	block := make(chan struct{})
+	engine := startTestEngine(t, t.TempDir(), func(ctx context.Context, _ string, _ Request) Outcome {
+		select {
+		case <-block:
+		case <-ctx.Done():
+		}
+		return Outcome{StatusCode: http.StatusOK, Body: []byte(`{}`)}
+	})
+	requests := make([]Request, 5)
+	for i := range requests {
+		requests[i] = Request{CustomID: string(rune('a' + i)), Body: []byte(`{}`)}
+	}
+	job, err := engine.Create(KindClaude, "", requests, Options{})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if job, err = engine.Cancel(KindClaude, job.ID, ""); err != nil || job.Status != StatusCanceling {
+		t.Fatalf("Cancel() = %+v, %v", job, err)
+	}
+	if err = engine.Delete(KindClaude, job.ID, ""); err != ErrNotEnded {
+		t.Fatalf("Delete() of a running batch error = %v", err)
+	}
+	close(block)
+	job = waitEnded(t, engine, KindClaude, job.ID, "")
+	if job.Counts.Canceled == 0 || job.Counts.Succeeded+job.Counts.Canceled != len(requests) {
+		t.Fatalf("counts after cancel = %+v", job.Counts)
+	}
+	if err = engine.Delete(KindClaude, job.ID, ""); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+package batch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// File purposes used by the OpenAI Batch API.
+const (
+	PurposeBatch       = "batch"
+	PurposeBatchOutput = "batch_output"
+)
+
+// File is an uploaded or generated file. Content is stored next to the metadata. Like Job,
+// it records the hash of the owning client API key rather than the key.
+type File struct {
+	ID        string `json:"id"`
+	Owner     string `json:"owner,omitempty"`
+	Bytes     int64  `json:"bytes"`
+	CreatedAt int64  `json:"created_at"`
+	Filename  string `json:"filename"`
+	Purpose   string `json:"purpose"`
+}
+
+// CreateFile stores data as a new file owned by owner.
+func (e *Engine) CreateFile(owner, filename, purpose string, data []byte) (*File, error) {
+	if !e.Enabled() {
+		return nil, ErrDisabled
+	}
+	file := &File{
+		ID:        "file-" + randomID(),
+		Owner:     owner,
+		Bytes:     int64(len(data)),
+		CreatedAt: e.now().Unix(),
+		Filename:  filename,
+		Purpose:   purpose,
+	}
+	dir := filepath.Join(e.dir, "files")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create files directory: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(dir, file.ID+".data"), data); err != nil {
+		return nil, err
+	}
+	meta, err := json.Marshal(file)
+	if err != nil {
+		return nil, fmt.Errorf("encode file: %w", err)
+	}
+	if err = writeFileAtomic(filepath.Join(dir, file.ID+".json"), meta); err != nil {
+		e.removeFile(file.ID)
+		return nil, err
+	}
+	e.mu.Lock()
+	e.files[file.ID] = file
+	e.mu.Unlock()
+	cloned := *file
+	return &cloned, nil
+}
+
+// File returns the file id owned by owner.
+func (e *Engine) File(id, owner string) (*File, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	file, ok := e.files[id]
+	if !ok || file.Owner != owner {
+		return nil, ErrNotFound
+	}
+	cloned := *file
+	return &cloned, nil
+}
+
+// Files returns the files owned by owner, newest first. An empty purpose matches every file.
+func (e *Engine) Files(owner, purpose string) []*File {
+	e.mu.RLock()
+	files := make([]*File, 0, len(e.files))
+	for _, file := range e.files {
+		if file.Owner == owner && (purpose == "" || file.Purpose == purpose) {
+			cloned := *file
+			files = append(files, &cloned)
+		}
+	}
+	e.mu.RUnlock()
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].CreatedAt == files[j].CreatedAt {
+			return files[i].ID > files[j].ID
+		}
+		return files[i].CreatedAt > files[j].CreatedAt
+	})
+	return files
+}
+
+// FileContent returns the content of the file id owned by owner.
+func (e *Engine) FileContent(id, owner string) ([]byte, error) {
+	if _, err := e.File(id, owner); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(e.dir, "files", id+".data"))
+	if err != nil {
+		return nil, fmt.Errorf("read file %s: %w", id, err)
+	}
+	return data, nil
+}
+
+// DeleteFile removes the file id owned by owner.
+func (e *Engine) DeleteFile(id, owner string) error {
+	if _, err := e.File(id, owner); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	delete(e.files, id)
+	e.mu.Unlock()
+	e.removeFile(id)
+	return nil
+}
+
+func (e *Engine) removeFile(id string) {
+	for _, suffix := range []string{".json", ".data"} {
+		if err := os.Remove(filepath.Join(e.dir, "files", id+suffix)); err != nil && !os.IsNotExist(err) {
+			log.Debugf("batch: remove file %s: %v", id, err)
+		}
+	}
+}
+
+func (e *Engine) loadFilesLocked() {
+	dir := filepath.Join(e.dir, "files")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("batch: read files: %v", err)
+		}
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		data, errRead := os.ReadFile(filepath.Join(dir, name))
+		if errRead != nil {
+			continue
+		}
+		var file File
+		if errRead = json.Unmarshal(data, &file); errRead != nil || file.ID+".json" != name {
+			continue
+		}
+		e.files[file.ID] = &file
+	}
+}
+
+// writeOpenAIOutput renders the results of an OpenAI job into an output file with the
+// successful responses and an error file with everything else. Empty files are not created.
+func (e *Engine) writeOpenAIOutput(jobID, owner string) (outputID, errorID string) {
+	var output, errorsOut bytes.Buffer
+	err := readResults(filepath.Join(e.jobDir(jobID), "results.jsonl"), func(r Result) error {
+		line := OpenAIResultLine(r)
+		if r.Type == ResultSucceeded {
+			output.Write(line)
+			output.WriteByte('\n')
+		} else {
+			errorsOut.Write(line)
+			errorsOut.WriteByte('\n')
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("batch %s: read results: %v", jobID, err)
+	}
+	if output.Len() > 0 {
+		if file, errCreate := e.CreateFile(owner, jobID+"_output.jsonl", PurposeBatchOutput, output.Bytes()); errCreate == nil {
+			outputID = file.ID
+		} else {
+			log.Errorf("batch %s: write output file: %v", jobID, errCreate)
+		}
+	}
+	if errorsOut.Len() > 0 {
+		if file, errCreate := e.CreateFile(owner, jobID+"_error.jsonl", PurposeBatchOutput, errorsOut.Bytes()); errCreate == nil {
+			errorID = file.ID
+		} else {
+			log.Errorf("batch %s: write error file: %v", jobID, errCreate)
+		}
+	}
+	return outputID, errorID
+}
+
+// OpenAIResultLine renders a result as a line of an OpenAI batch output or error file.
+func OpenAIResultLine(r Result) []byte {
+	line := map[string]any{"id": r.ID, "custom_id": r.CustomID, "response": nil, "error": nil}
+	switch r.Type {
+	case ResultSucceeded, ResultErrored:
+		line["response"] = map[string]any{"status_code": r.StatusCode, "request_id": r.ID, "body": r.Body}
+	case ResultCanceled:
+		line["error"] = map[string]any{"code": "batch_cancelled", "message": "This request was cancelled before it was processed."}
+	case ResultExpired:
+		line["error"] = map[string]any{"code": "batch_expired", "message": "This request could not be executed before the completion window expired."}
+	}
+	data, _ := json.Marshal(line)
+	return data
+}
+
+// ClaudeResultLine renders a result as a line of a Claude Message Batches results file.
+func ClaudeResultLine(r Result) []byte {
+	result := map[string]any{"type": r.Type}
+	switch r.Type {
+	case ResultSucceeded:
+		result["message"] = r.Body
+	case ResultErrored:
+		body := r.Body
+		if len(body) == 0 {
+			body = json.RawMessage(`{"type":"error","error":{"type":"api_error","message":"request failed"}}`)
+		}
+		result["error"] = body
+	}
+	data, _ := json.Marshal(map[string]any{"custom_id": r.CustomID, "result": result})
+	return data
+}
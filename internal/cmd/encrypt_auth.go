@@ -0,0 +1,71 @@
+// Package cmd contains CLI helpers. This file implements the migration that encrypts existing
+// auth files in place.
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// DoEncryptAuthFiles seals every auth file under the auth directory with the primary
+// encryption key. Plaintext files are encrypted and files sealed with a rotated-out key are
+// re-encrypted; files already sealed with the primary key are left alone. Changed files are
+// pushed to the backing git, object or PostgreSQL store when one is in use.
+func DoEncryptAuthFiles(cfg *config.Config) {
+	if !authcrypt.Enabled() {
+		log.Error("encrypt-auth: no encryption key configured; set AUTH_ENCRYPTION_KEYS or AUTH_ENCRYPTION_KEY_FILE")
+		return
+	}
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	authDir, errResolve := util.ResolveAuthDir(cfg.AuthDir)
+	if errResolve != nil || authDir == "" {
+		log.Errorf("encrypt-auth: resolve auth directory: %v", errResolve)
+		return
+	}
+
+	var changed []string
+	failed := 0
+	errWalk := filepath.WalkDir(authDir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() || !strings.HasSuffix(strings.ToLower(d.Name()), ".json") {
+			return nil
+		}
+		sealed, errSeal := authcrypt.SealFile(path)
+		if errSeal != nil {
+			failed++
+			log.Errorf("encrypt-auth: %s: %v", path, errSeal)
+			return nil
+		}
+		if sealed {
+			changed = append(changed, path)
+		}
+		return nil
+	})
+	if errWalk != nil {
+		log.Errorf("encrypt-auth: walk auth directory: %v", errWalk)
+		return
+	}
+
+	if persister, ok := sdkAuth.GetTokenStore().(interface {
+		PersistAuthFiles(ctx context.Context, message string, paths ...string) error
+	}); ok && len(changed) > 0 {
+		if errPersist := persister.PersistAuthFiles(context.Background(), "Encrypt auth files", changed...); errPersist != nil {
+			log.Errorf("encrypt-auth: persist encrypted files: %v", errPersist)
+			return
+		}
+	}
+	fmt.Printf("Encrypted %d auth file(s) with key %s; %d failed\n", len(changed), authcrypt.PrimaryKeyID(), failed)
+}
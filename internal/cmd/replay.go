@@ -0,0 +1,263 @@
+// Package cmd contains CLI helpers. This file implements -replay, which re-sends a request
+// recorded in a JSON Lines request log through a private instance of the proxy.
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const replayStartTimeout = 30 * time.Second
+
+// replayDroppedHeaders are recorded headers that are masked in the log, describe the original
+// connection, or are recomputed by the HTTP client.
+var replayDroppedHeaders = []string{
+	"Authorization", "X-Api-Key", "X-Goog-Api-Key", "Cookie",
+	"Host", "Connection", "Content-Length", "Accept-Encoding", "Transfer-Encoding",
+}
+
+// ReplayOptions selects the recorded request to replay and what to change before sending it.
+type ReplayOptions struct {
+	// RequestID selects the record by request ID; the last record of the file is used when empty.
+	RequestID string
+	// Model replaces the model of the recorded request.
+	Model string
+	// Auth restricts credential selection to the auth with this auth index or ID.
+	Auth string
+}
+
+// DoReplay re-sends a request recorded by the JSON Lines request log. It starts the proxy with
+// the current configuration and credentials on a free loopback port, sends the recorded
+// inbound request to it and prints the response to stdout, so translator and executor changes
+// can be checked against the exact payload that failed.
+func DoReplay(cfg *config.Config, configPath, recordPath string, opts ReplayOptions) {
+	record, err := loadReplayRecord(recordPath, opts.RequestID)
+	if err != nil {
+		log.Errorf("replay: %v", err)
+		return
+	}
+	path, body, err := replayRequest(record, opts.Model)
+	if err != nil {
+		log.Errorf("replay: %v", err)
+		return
+	}
+
+	port, err := freeLoopbackPort()
+	if err != nil {
+		log.Errorf("replay: %v", err)
+		return
+	}
+	replayCfg := *cfg
+	replayCfg.Host = "127.0.0.1"
+	replayCfg.Port = port
+	replayCfg.TLS.Enable = false
+	// The replay instance must not pick up batch jobs or usage data owned by a running server.
+	replayCfg.Batch.Disable = true
+	replayCfg.UsagePersistence.Enable = false
+
+	builder := cliproxy.NewBuilder().WithConfig(&replayCfg).WithConfigPath(configPath)
+	if target := strings.TrimSpace(opts.Auth); target != "" {
+		tokenStore := sdkAuth.GetTokenStore()
+		if dirSetter, ok := tokenStore.(interface{ SetBaseDir(string) }); ok {
+			dirSetter.SetBaseDir(replayCfg.AuthDir)
+		}
+		selector := &pinnedSelector{target: target, next: &coreauth.RoundRobinSelector{}}
+		builder = builder.WithCoreAuthManager(coreauth.NewManager(tokenStore, selector, metrics.Default()))
+	}
+	service, err := builder.Build()
+	if err != nil {
+		log.Errorf("replay: failed to build proxy service: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- service.Run(ctx) }()
+	defer func() {
+		cancel()
+		if errRun := <-runErr; errRun != nil && !errors.Is(errRun, context.Canceled) {
+			log.Errorf("replay: proxy service exited with error: %v", errRun)
+		}
+	}()
+
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	if err = waitForListener(addr, runErr); err != nil {
+		log.Errorf("replay: %v", err)
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, record.Request.Method, "http://"+addr+path, bytes.NewReader(body))
+	if err != nil {
+		log.Errorf("replay: build request: %v", err)
+		return
+	}
+	for key, values := range record.Request.Headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	for _, key := range replayDroppedHeaders {
+		req.Header.Del(key)
+	}
+	if len(replayCfg.APIKeys) > 0 {
+		req.Header.Set("Authorization", "Bearer "+replayCfg.APIKeys[0])
+	}
+
+	fmt.Fprintf(os.Stderr, "Replaying %s %s (recorded %s, request %s)\n", req.Method, path, record.Timestamp.Format(time.RFC3339), record.RequestID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Errorf("replay: send request: %v", err)
+		return
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("replay: close response body: %v", errClose)
+		}
+	}()
+	fmt.Fprintf(os.Stderr, "HTTP %d (recorded %d)\n", resp.StatusCode, record.Response.Status)
+	if _, err = io.Copy(os.Stdout, resp.Body); err != nil {
+		log.Errorf("replay: read response: %v", err)
+	}
+	fmt.Println()
+}
+
+// loadReplayRecord reads the record with requestID from the log at path, or the last record
+// when requestID is empty.
+func loadReplayRecord(path, requestID string) (*logging.RequestRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	records, err := logging.ReadRequestRecords(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s contains no request records", path)
+	}
+	requestID = strings.TrimSpace(requestID)
+	if requestID == "" {
+		return records[len(records)-1], nil
+	}
+	for _, record := range records {
+		if record.RequestID == requestID {
+			return record, nil
+		}
+	}
+	return nil, fmt.Errorf("%s has no record for request %s", path, requestID)
+}
+
+// replayRequest returns the path and body to send for record, with the model replaced when
+// model is set. Gemini routes carry the model in the path, the other APIs in the body.
+func replayRequest(record *logging.RequestRecord, model string) (string, []byte, error) {
+	path := record.Request.URL
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		// The query was masked when recorded and only carries credentials.
+		path = path[:i]
+	}
+	if path == "" || record.Request.Method == "" {
+		return "", nil, fmt.Errorf("record %s has no request URL or method", record.RequestID)
+	}
+	var body []byte
+	if raw := bytes.TrimSpace(record.Request.Body); len(raw) > 0 && raw[0] == '"' {
+		var text string
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return "", nil, fmt.Errorf("decode recorded body: %w", err)
+		}
+		body = []byte(text)
+	} else {
+		body = raw
+	}
+
+	model = strings.TrimSpace(model)
+	if model == "" {
+		return path, body, nil
+	}
+	if start := strings.Index(path, "/models/"); start >= 0 {
+		rest := path[start+len("/models/"):]
+		end := strings.IndexByte(rest, ':')
+		if end < 0 {
+			end = len(rest)
+		}
+		path = path[:start+len("/models/")] + model + rest[end:]
+	}
+	if gjson.GetBytes(body, "model").Exists() {
+		updated, err := sjson.SetBytes(body, "model", model)
+		if err != nil {
+			return "", nil, fmt.Errorf("replace model: %w", err)
+		}
+		body = updated
+	}
+	return path, body, nil
+}
+
+func freeLoopbackPort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("find free port: %w", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	if err = listener.Close(); err != nil {
+		return 0, fmt.Errorf("find free port: %w", err)
+	}
+	return port, nil
+}
+
+// waitForListener blocks until addr accepts connections or the service stops.
+func waitForListener(addr string, runErr chan error) error {
+	deadline := time.Now().Add(replayStartTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case err := <-runErr:
+			runErr <- err
+			return fmt.Errorf("proxy service stopped before accepting requests: %v", err)
+		default:
+		}
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err == nil {
+			_ = conn.Close()
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("proxy service did not start listening on %s within %s", addr, replayStartTimeout)
+}
+
+// pinnedSelector restricts credential selection to one auth, matched by auth index or ID, so
+// a replay runs against a specific credential.
+type pinnedSelector struct {
+	target string
+	next   coreauth.Selector
+}
+
+// Pick delegates to the wrapped selector with only the pinned auth as candidate.
+func (s *pinnedSelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*coreauth.Auth) (*coreauth.Auth, error) {
+	var pinned []*coreauth.Auth
+	for _, auth := range auths {
+		if auth != nil && (auth.ID == s.target || auth.EnsureIndex() == s.target) {
+			pinned = append(pinned, auth)
+		}
+	}
+	return s.next.Pick(ctx, provider, model, opts, pinned)
+}
@@ -52,6 +52,15 @@ type Config struct {
 	// UsageStatisticsEnabled toggles in-memory usage aggregation; when false, usage data is discarded.
 	UsageStatisticsEnabled bool `yaml:"usage-statistics-enabled" json:"usage-statistics-enabled"`
 
+	// UsagePersistence configures durable storage of usage records across restarts.
+	UsagePersistence UsagePersistenceConfig `yaml:"usage-persistence" json:"usage-persistence"`
+
+	// Metrics configures the Prometheus /metrics endpoint.
+	Metrics MetricsConfig `yaml:"metrics" json:"metrics"`
+
+	// Tracing configures OpenTelemetry trace export.
+	Tracing TracingConfig `yaml:"tracing" json:"tracing"`
+
 	// DisableCooling disables quota cooldown scheduling when true.
 	DisableCooling bool `yaml:"disable-cooling" json:"disable-cooling"`
 
@@ -63,6 +72,12 @@ type Config struct {
 	// QuotaExceeded defines the behavior when a quota is exceeded.
 	QuotaExceeded QuotaExceeded `yaml:"quota-exceeded" json:"quota-exceeded"`
 
+	// Hedging configures speculative duplicate requests for latency-sensitive models.
+	Hedging HedgingConfig `yaml:"hedging,omitempty" json:"hedging,omitempty"`
+
+	// CircuitBreaker stops routing to an upstream (provider + base URL) that keeps failing.
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit-breaker,omitempty" json:"circuit-breaker,omitempty"`
+
 	// Routing controls credential selection behavior.
 	Routing RoutingConfig `yaml:"routing" json:"routing"`
 
@@ -81,6 +96,12 @@ type Config struct {
 	// OpenAICompatibility defines OpenAI API compatibility configurations for external providers.
 	OpenAICompatibility []OpenAICompatibility `yaml:"openai-compatibility" json:"openai-compatibility"`
 
+	// ClaudeCompatibility defines third-party providers that speak the Claude Messages API.
+	ClaudeCompatibility []ProtocolCompatibility `yaml:"claude-compatibility,omitempty" json:"claude-compatibility,omitempty"`
+
+	// GeminiCompatibility defines third-party providers that speak the Gemini generateContent API.
+	GeminiCompatibility []ProtocolCompatibility `yaml:"gemini-compatibility,omitempty" json:"gemini-compatibility,omitempty"`
+
 	// VertexCompatAPIKey defines Vertex AI-compatible API key configurations for third-party providers.
 	// Used for services that use Vertex AI-style paths but with simple API key authentication.
 	VertexCompatAPIKey []VertexCompatKey `yaml:"vertex-api-key" json:"vertex-api-key"`
@@ -135,14 +156,155 @@ type QuotaExceeded struct {
 	SwitchProject bool `yaml:"switch-project" json:"switch-project"`
 
 	// SwitchPreviewModel indicates whether to automatically switch to a preview model when a quota is exceeded.
+	// It is no longer consulted by request routing; configure ModelFallbacks instead.
 	SwitchPreviewModel bool `yaml:"switch-preview-model" json:"switch-preview-model"`
+
+	// FallbackAfterSeconds is how far away the nearest credential cooldown for a model must be
+	// before ModelFallbacks are tried instead of waiting. Defaults to max-retry-interval, so
+	// cooldowns the request would wait out anyway do not trigger a fallback.
+	FallbackAfterSeconds int `yaml:"fallback-after-seconds,omitempty" json:"fallback-after-seconds,omitempty"`
+
+	// ModelFallbacks lists ordered fallback chains tried when every credential for a model is
+	// cooling down. Fallback models may be served by other providers; the original request is
+	// translated again for them.
+	ModelFallbacks []ModelFallback `yaml:"model-fallbacks,omitempty" json:"model-fallbacks,omitempty"`
+}
+
+// HedgingConfig configures hedged requests: when an upstream attempt produces no first byte
+// within Delay, the same request is sent with another credential or provider and the first
+// to respond wins while the other is cancelled.
+type HedgingConfig struct {
+	// Enable turns on hedging.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// DelayMS is how long to wait for the first byte before sending the hedge. Defaults to 2000.
+	DelayMS int `yaml:"delay-ms,omitempty" json:"delay-ms,omitempty"`
+
+	// Models restricts hedging to models matching these patterns ('*' matches any substring).
+	// An empty list hedges every model.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+}
+
+// AppliesTo reports whether requests for model are hedged.
+func (h HedgingConfig) AppliesTo(model string) bool {
+	if !h.Enable {
+		return false
+	}
+	if len(h.Models) == 0 {
+		return true
+	}
+	for _, pattern := range h.Models {
+		if matchPolicyPattern(pattern, model) {
+			return true
+		}
+	}
+	return false
+}
+
+// CircuitBreakerConfig configures the per-upstream circuit breaker. An upstream is one provider
+// at one base URL; after FailureThreshold consecutive upstream failures its circuit opens and no
+// credential behind it is selected until OpenSeconds elapse. The circuit then turns half-open and
+// lets HalfOpenProbes requests through: a success closes it, a failure opens it again.
+type CircuitBreakerConfig struct {
+	// Enable turns on the circuit breaker.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// FailureThreshold is the number of consecutive failures that opens a circuit. Defaults to 5.
+	FailureThreshold int `yaml:"failure-threshold,omitempty" json:"failure-threshold,omitempty"`
+
+	// OpenSeconds is how long a circuit stays open before probes are allowed. Defaults to 30.
+	OpenSeconds int `yaml:"open-seconds,omitempty" json:"open-seconds,omitempty"`
+
+	// HalfOpenProbes is the number of concurrent probe requests allowed while half-open. Defaults to 1.
+	HalfOpenProbes int `yaml:"half-open-probes,omitempty" json:"half-open-probes,omitempty"`
+}
+
+// ModelFallback is an ordered fallback chain for one model.
+type ModelFallback struct {
+	// Model is the requested model name the chain applies to.
+	Model string `yaml:"model" json:"model"`
+
+	// Fallbacks are tried in order until one succeeds.
+	Fallbacks []string `yaml:"fallbacks" json:"fallbacks"`
+}
+
+// UsagePersistenceConfig configures the durable usage statistics backend.
+// The backend reuses the Postgres store connection when PGSTORE_DSN is set and otherwise
+// writes to a local bbolt file. Changing Enable or Path requires a restart.
+type UsagePersistenceConfig struct {
+	// Enable turns on durable usage storage.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Path is the local database file. Defaults to "usage.db" inside the auth directory.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+
+	// RawRetentionDays keeps individual request records for this many days before they are
+	// downsampled into hourly aggregates. Defaults to 7.
+	RawRetentionDays int `yaml:"raw-retention-days,omitempty" json:"raw-retention-days,omitempty"`
+
+	// RetentionDays drops hourly aggregates older than this many days. Defaults to 365.
+	RetentionDays int `yaml:"retention-days,omitempty" json:"retention-days,omitempty"`
+
+	// RestoreOnStart reloads retained raw records into the in-memory statistics at startup.
+	RestoreOnStart bool `yaml:"restore-on-start,omitempty" json:"restore-on-start,omitempty"`
+}
+
+// MetricsConfig configures the Prometheus/OpenMetrics endpoint served at /metrics.
+type MetricsConfig struct {
+	// Enable exposes the /metrics endpoint.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// RequireManagementKey protects /metrics with the management key, using the same
+	// rules (remote access, bans) as the management API.
+	RequireManagementKey bool `yaml:"require-management-key,omitempty" json:"require-management-key,omitempty"`
+}
+
+// TracingConfig configures OpenTelemetry trace export. Changes require a restart.
+type TracingConfig struct {
+	// Enable turns on span recording and export.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Exporter selects the destination: "otlp-http" (default) or "file".
+	Exporter string `yaml:"exporter,omitempty" json:"exporter,omitempty"`
+
+	// Endpoint is the OTLP/HTTP collector address (host:port or URL). Defaults to the
+	// OTEL_EXPORTER_OTLP_* environment variables, then "localhost:4318".
+	Endpoint string `yaml:"endpoint,omitempty" json:"endpoint,omitempty"`
+
+	// Insecure disables TLS for the OTLP/HTTP exporter.
+	Insecure bool `yaml:"insecure,omitempty" json:"insecure,omitempty"`
+
+	// FilePath is where the "file" exporter appends JSON encoded spans.
+	// Defaults to "traces.jsonl" inside the logs directory.
+	FilePath string `yaml:"file-path,omitempty" json:"file-path,omitempty"`
+
+	// SampleRatio is the fraction of new traces recorded (0-1]. Defaults to 1.
+	// Incoming sampled trace contexts are always honoured.
+	SampleRatio float64 `yaml:"sample-ratio,omitempty" json:"sample-ratio,omitempty"`
+
+	// ServiceName overrides the service.name resource attribute. Defaults to "cli-proxy-api".
+	ServiceName string `yaml:"service-name,omitempty" json:"service-name,omitempty"`
 }
 
 // RoutingConfig configures how credentials are selected for requests.
 type RoutingConfig struct {
 	// Strategy selects the credential selection strategy.
-	// Supported values: "round-robin" (default), "fill-first".
+	// Supported values: "round-robin" (default), "fill-first", "least-latency", "weighted", "sticky".
 	Strategy string `yaml:"strategy,omitempty" json:"strategy,omitempty"`
+
+	// StickyFallback selects the strategy used by "sticky" for new or displaced sessions.
+	// Accepts any non-sticky strategy; defaults to "round-robin".
+	StickyFallback string `yaml:"sticky-fallback,omitempty" json:"sticky-fallback,omitempty"`
+
+	// SessionTTL is the idle time in seconds after which a sticky session pin expires. Defaults to 3600.
+	SessionTTL int `yaml:"session-ttl,omitempty" json:"session-ttl,omitempty"`
+
+	// ExplorationRatio is the probability that the latency-aware strategies pick a random
+	// available credential so stale measurements get refreshed. Defaults to 0.05; negative disables.
+	ExplorationRatio float64 `yaml:"exploration-ratio,omitempty" json:"exploration-ratio,omitempty"`
+
+	// EWMAAlpha is the smoothing factor applied to latency and error-rate samples. Defaults to 0.2.
+	EWMAAlpha float64 `yaml:"ewma-alpha,omitempty" json:"ewma-alpha,omitempty"`
 }
 
 // ModelNameMapping defines a model ID rename mapping for a specific channel.
@@ -358,6 +520,11 @@ type OpenAICompatibility struct {
 	// BaseURL is the base URL for the external OpenAI-compatible API endpoint.
 	BaseURL string `yaml:"base-url" json:"base-url"`
 
+	// WireAPI selects the upstream API spoken natively by the provider: "chat" (default),
+	// "responses" or "completions". Requests arriving in the matching format are forwarded
+	// to that endpoint without translation; all others use chat completions.
+	WireAPI string `yaml:"wire-api,omitempty" json:"wire-api,omitempty"`
+
 	// APIKeyEntries defines API keys with optional per-key proxy configuration.
 	APIKeyEntries []OpenAICompatibilityAPIKey `yaml:"api-key-entries,omitempty" json:"api-key-entries,omitempty"`
 
@@ -368,6 +535,13 @@ type OpenAICompatibility struct {
 	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
 }
 
+// Wire API identifiers accepted by OpenAICompatibility.WireAPI.
+const (
+	WireAPIChat        = "chat"
+	WireAPIResponses   = "responses"
+	WireAPICompletions = "completions"
+)
+
 // OpenAICompatibilityAPIKey represents an API key configuration with optional proxy setting.
 type OpenAICompatibilityAPIKey struct {
 	// APIKey is the authentication key for accessing the external API services.
@@ -385,6 +559,9 @@ type OpenAICompatibilityModel struct {
 
 	// Alias is the model name alias that clients will use to reference this model.
 	Alias string `yaml:"alias" json:"alias"`
+
+	// Embedding marks the model as an embedding model served through the /embeddings endpoint.
+	Embedding bool `yaml:"embedding,omitempty" json:"embedding,omitempty"`
 }
 
 // LoadConfig reads a YAML configuration file from the given path,
@@ -494,6 +671,9 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Sanitize OpenAI compatibility providers: drop entries without base-url
 	cfg.SanitizeOpenAICompatibility()
 
+	// Sanitize Claude- and Gemini-compatible providers: drop entries without name or base-url
+	cfg.SanitizeProtocolCompatibility()
+
 	// Normalize OAuth provider model exclusion map.
 	cfg.OAuthExcludedModels = NormalizeOAuthExcludedModels(cfg.OAuthExcludedModels)
 
@@ -562,7 +742,7 @@ func (cfg *Config) SanitizeOAuthModelMappings() {
 
 // SanitizeOpenAICompatibility removes OpenAI-compatibility provider entries that are
 // not actionable, specifically those missing a BaseURL. It trims whitespace before
-// evaluation and preserves the relative order of remaining entries.
+// evaluation, normalizes wire-api values and preserves the relative order of remaining entries.
 func (cfg *Config) SanitizeOpenAICompatibility() {
 	if cfg == nil || len(cfg.OpenAICompatibility) == 0 {
 		return
@@ -574,6 +754,13 @@ func (cfg *Config) SanitizeOpenAICompatibility() {
 		e.Prefix = normalizeModelPrefix(e.Prefix)
 		e.BaseURL = strings.TrimSpace(e.BaseURL)
 		e.Headers = NormalizeHeaders(e.Headers)
+		e.WireAPI = strings.ToLower(strings.TrimSpace(e.WireAPI))
+		switch e.WireAPI {
+		case "", WireAPIChat, WireAPIResponses, WireAPICompletions:
+		default:
+			// Unknown wire APIs fall back to chat completions.
+			e.WireAPI = ""
+		}
 		if e.BaseURL == "" {
 			// Skip providers with no base-url; treated as removed
 			continue
@@ -0,0 +1,125 @@
+package config
+
+import "strings"
+
+// ProtocolCompatibility represents a third-party provider that speaks a vendor API natively,
+// such as a self-hosted gateway exposing the Claude Messages API (claude-compatibility) or
+// the Gemini generateContent API (gemini-compatibility). Unlike claude-api-key and
+// gemini-api-key entries, requests are sent without vendor client headers and only the
+// configured models are registered.
+type ProtocolCompatibility struct {
+	// Name is the identifier for this provider; it also becomes the provider key.
+	Name string `yaml:"name" json:"name"`
+
+	// Prefix optionally namespaces model aliases for this provider (e.g., "teamA/sonnet").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// BaseURL is the API root of the provider. The executor appends the vendor path, e.g.
+	// "/v1/messages" for Claude or "/v1beta/models/{model}:generateContent" for Gemini.
+	BaseURL string `yaml:"base-url" json:"base-url"`
+
+	// APIKeyEntries defines API keys with optional per-key proxy configuration.
+	APIKeyEntries []OpenAICompatibilityAPIKey `yaml:"api-key-entries,omitempty" json:"api-key-entries,omitempty"`
+
+	// Models defines the model configurations including aliases for routing.
+	Models []ProtocolCompatibilityModel `yaml:"models" json:"models"`
+
+	// Headers optionally adds extra HTTP headers for requests sent to this provider.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+}
+
+// ProtocolCompatibilityModel maps a client-facing alias to the model name used upstream.
+type ProtocolCompatibilityModel struct {
+	// Name is the actual model name used by the external provider.
+	Name string `yaml:"name" json:"name"`
+
+	// Alias is the model name alias that clients will use to reference this model.
+	Alias string `yaml:"alias" json:"alias"`
+}
+
+func (m ProtocolCompatibilityModel) GetName() string  { return m.Name }
+func (m ProtocolCompatibilityModel) GetAlias() string { return m.Alias }
+
+// reservedProviderNames lists the provider keys owned by built-in executors. A compatibility
+// provider registered under one of them would replace that executor.
+var reservedProviderNames = map[string]struct{}{
+	"gemini":               {},
+	"vertex":               {},
+	"gemini-cli":           {},
+	"aistudio":             {},
+	"antigravity":          {},
+	"claude":               {},
+	"codex":                {},
+	"qwen":                 {},
+	"iflow":                {},
+	"kiro":                 {},
+	"openai-compatibility": {},
+}
+
+// IsReservedProviderName reports whether name is the provider key of a built-in executor.
+func IsReservedProviderName(name string) bool {
+	_, ok := reservedProviderNames[strings.ToLower(strings.TrimSpace(name))]
+	return ok
+}
+
+// SanitizeProtocolCompatibility normalizes the claude-compatibility and gemini-compatibility
+// providers and removes entries without a name or base-url. Because the name becomes the
+// provider key, entries reusing a built-in provider name or a name already taken by an
+// openai-compatibility or earlier compatibility entry are removed as well.
+func (cfg *Config) SanitizeProtocolCompatibility() {
+	if cfg == nil {
+		return
+	}
+	taken := make(map[string]struct{}, len(cfg.OpenAICompatibility))
+	for i := range cfg.OpenAICompatibility {
+		if name := strings.ToLower(strings.TrimSpace(cfg.OpenAICompatibility[i].Name)); name != "" {
+			taken[name] = struct{}{}
+		}
+	}
+	cfg.ClaudeCompatibility = sanitizeProtocolCompatibility(cfg.ClaudeCompatibility, taken)
+	cfg.GeminiCompatibility = sanitizeProtocolCompatibility(cfg.GeminiCompatibility, taken)
+}
+
+// sanitizeProtocolCompatibility normalizes entries and records the accepted names in taken.
+func sanitizeProtocolCompatibility(entries []ProtocolCompatibility, taken map[string]struct{}) []ProtocolCompatibility {
+	if len(entries) == 0 {
+		return entries
+	}
+	out := make([]ProtocolCompatibility, 0, len(entries))
+	for i := range entries {
+		e := entries[i]
+		e.Name = strings.TrimSpace(e.Name)
+		e.Prefix = normalizeModelPrefix(e.Prefix)
+		e.BaseURL = strings.TrimRight(strings.TrimSpace(e.BaseURL), "/")
+		e.Headers = NormalizeHeaders(e.Headers)
+		if e.Name == "" || e.BaseURL == "" {
+			// The name keys the executor and model registration, so both are required.
+			continue
+		}
+		providerKey := strings.ToLower(e.Name)
+		if _, dup := taken[providerKey]; dup || IsReservedProviderName(providerKey) {
+			continue
+		}
+		taken[providerKey] = struct{}{}
+		keys := make([]OpenAICompatibilityAPIKey, 0, len(e.APIKeyEntries))
+		for _, key := range e.APIKeyEntries {
+			key.APIKey = strings.TrimSpace(key.APIKey)
+			key.ProxyURL = strings.TrimSpace(key.ProxyURL)
+			if key.APIKey != "" {
+				keys = append(keys, key)
+			}
+		}
+		e.APIKeyEntries = keys
+		models := make([]ProtocolCompatibilityModel, 0, len(e.Models))
+		for _, model := range e.Models {
+			model.Name = strings.TrimSpace(model.Name)
+			model.Alias = strings.TrimSpace(model.Alias)
+			if model.Name != "" || model.Alias != "" {
+				models = append(models, model)
+			}
+		}
+		e.Models = models
+		out = append(out, e)
+	}
+	return out
+}
@@ -0,0 +1,52 @@
+package config
+
+import "testing"
+
+func TestSanitizeProtocolCompatibility(t *testing.T) {
+	cfg := &Config{
+		OpenAICompatibility: []OpenAICompatibility{{Name: "Shared", BaseURL: "https://openai.example.com"}},
+		ClaudeCompatibility: []ProtocolCompatibility{
+			{Name: " gateway ", BaseURL: "https://claude.example.com/", APIKeyEntries: []OpenAICompatibilityAPIKey{{APIKey: " k "}, {APIKey: " "}}},
+			{Name: "no-base-url"},
+			{Name: "Claude", BaseURL: "https://claude.example.com"},
+			{Name: "shared", BaseURL: "https://claude.example.com"},
+		},
+		GeminiCompatibility: []ProtocolCompatibility{
+			{Name: "Gateway", BaseURL: "https://gemini.example.com"},
+			{Name: "gemini-cli", BaseURL: "https://gemini.example.com"},
+			{Name: "gemini-gateway", BaseURL: "https://gemini.example.com", Models: []ProtocolCompatibilityModel{{Name: " m "}, {}}},
+		},
+	}
+	cfg.SanitizeProtocolCompatibility()
+
+	if len(cfg.ClaudeCompatibility) != 1 {
+		t.Fatalf("claude-compatibility = %+v, want only the gateway entry", cfg.ClaudeCompatibility)
+	}
+	claude := cfg.ClaudeCompatibility[0]
+	if claude.Name != "gateway" || claude.BaseURL != "https://claude.example.com" {
+		t.Fatalf("claude entry not normalized: %+v", claude)
+	}
+	if len(claude.APIKeyEntries) != 1 || claude.APIKeyEntries[0].APIKey != "k" {
+		t.Fatalf("claude api keys = %+v", claude.APIKeyEntries)
+	}
+
+	// "Gateway" repeats a claude-compatibility name and "gemini-cli" is a built-in provider.
+	if len(cfg.GeminiCompatibility) != 1 {
+		t.Fatalf("gemini-compatibility = %+v, want only gemini-gateway", cfg.GeminiCompatibility)
+	}
+	gemini := cfg.GeminiCompatibility[0]
+	if gemini.Name != "gemini-gateway" || len(gemini.Models) != 1 || gemini.Models[0].Name != "m" {
+		t.Fatalf("gemini entry not normalized: %+v", gemini)
+	}
+}
+
+func TestIsReservedProviderName(t *testing.T) {
+	for _, name := range []string{"claude", " Gemini ", "codex", "openai-compatibility"} {
+		if !IsReservedProviderName(name) {
+			t.Fatalf("IsReservedProviderName(%q) = false", name)
+		}
+	}
+	if IsReservedProviderName("my-gateway") {
+		t.Fatalf("IsReservedProviderName(my-gateway) = true")
+	}
+}
@@ -4,6 +4,8 @@
 // debug settings, proxy configuration, and API keys.
 package config
 
+import "strings"
+
 // SDKConfig represents the application's configuration, loaded from a YAML file.
 type SDKConfig struct {
 	// ProxyURL is the URL of an optional proxy server to use for outbound requests.
@@ -17,14 +19,39 @@ type SDKConfig struct {
 	// RequestLog enables or disables detailed request logging functionality.
 	RequestLog bool `yaml:"request-log" json:"request-log"`
 
+	// RequestLogFormat selects how request logs are written: "text" (default) writes one
+	// readable file per request, "jsonl" appends structured records to a daily JSON Lines file.
+	// The format is read at startup; changing it requires a restart.
+	RequestLogFormat string `yaml:"request-log-format,omitempty" json:"request-log-format,omitempty"`
+
+	// LogRedaction configures what is removed from request logs and error logs before they
+	// are written.
+	LogRedaction LogRedactionConfig `yaml:"log-redaction,omitempty" json:"log-redaction,omitempty"`
+
 	// APIKeys is a list of keys for authenticating clients to this proxy server.
 	APIKeys []string `yaml:"api-keys" json:"api-keys"`
 
+	// APIKeyPolicies configures per-client-API-key limits, matched by key value.
+	APIKeyPolicies []APIKeyPolicy `yaml:"api-key-policies,omitempty" json:"api-key-policies,omitempty"`
+
 	// Access holds request authentication provider configuration.
 	Access AccessConfig `yaml:"auth,omitempty" json:"auth,omitempty"`
 
 	// Streaming configures server-side streaming behavior (keep-alives and safe bootstrap retries).
 	Streaming StreamingConfig `yaml:"streaming" json:"streaming"`
+
+	// ResponseCache configures the optional cache of completed responses.
+	ResponseCache ResponseCacheConfig `yaml:"response-cache,omitempty" json:"response-cache,omitempty"`
+
+	// ResponseStore configures the store of Responses API results used to resolve
+	// previous_response_id.
+	ResponseStore ResponseStoreConfig `yaml:"response-store,omitempty" json:"response-store,omitempty"`
+
+	// Batch configures the local queue behind the Claude Message Batches and OpenAI Batch APIs.
+	Batch BatchConfig `yaml:"batch,omitempty" json:"batch,omitempty"`
+
+	// CooldownQueue parks opted-in requests while every credential for their model cools down.
+	CooldownQueue CooldownQueueConfig `yaml:"cooldown-queue,omitempty" json:"cooldown-queue,omitempty"`
 }
 
 // StreamingConfig holds server streaming behavior configuration.
@@ -39,6 +66,244 @@ type StreamingConfig struct {
 	BootstrapRetries int `yaml:"bootstrap-retries,omitempty" json:"bootstrap-retries,omitempty"`
 }
 
+// ResponseCacheConfig configures the response cache consulted before executing a request.
+// Entries are keyed by the normalized request payload, the resolved model and the source format.
+type ResponseCacheConfig struct {
+	// Enable turns on response caching.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Backend selects the storage: "memory" (default) or "disk".
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+
+	// Dir is the directory used by the disk backend. Defaults to "response-cache" inside
+	// the writable path.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// TTLSeconds is how long an entry stays valid. Defaults to 3600.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+
+	// MaxEntries caps the number of cached responses; the least recently used entry is evicted
+	// first. Defaults to 1000.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+
+	// MaxSizeMB caps the total size of cached responses. <= 0 disables the size cap.
+	MaxSizeMB int `yaml:"max-size-mb,omitempty" json:"max-size-mb,omitempty"`
+
+	// IncludeNonDeterministic also caches requests that do not pin temperature to 0.
+	IncludeNonDeterministic bool `yaml:"include-nondeterministic,omitempty" json:"include-nondeterministic,omitempty"`
+
+	// SharedAcrossKeys lets different client API keys share cache entries. By default the
+	// client API key is part of the cache key.
+	SharedAcrossKeys bool `yaml:"shared-across-keys,omitempty" json:"shared-across-keys,omitempty"`
+}
+
+// ResponseStoreConfig configures the store of OpenAI Responses API results. Stored responses
+// let clients continue a conversation with previous_response_id regardless of the upstream
+// provider, and are served by the GET/DELETE /v1/responses/{id} endpoints.
+type ResponseStoreConfig struct {
+	// Disable turns off storing responses. previous_response_id is then forwarded unresolved.
+	Disable bool `yaml:"disable,omitempty" json:"disable,omitempty"`
+
+	// Backend selects the storage: "memory" (default) or "disk".
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+
+	// Dir is the directory used by the disk backend. Defaults to "response-store" inside
+	// the writable path.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// TTLSeconds is how long a stored response can be referenced. Defaults to 86400.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+
+	// MaxEntries caps the number of stored responses; the least recently used response is
+	// evicted first. Defaults to 10000.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+}
+
+// LogRedactionConfig configures redaction of request logs and error logs. Rules apply to
+// client requests and responses as well as the requests sent upstream.
+type LogRedactionConfig struct {
+	// Action is applied to every match: "mask" (default) replaces it with "[REDACTED]", "hash"
+	// with a short SHA-256 digest so equal values can still be correlated, "drop" removes it.
+	Action string `yaml:"action,omitempty" json:"action,omitempty"`
+
+	// JSONPaths selects body fields to redact, e.g. "messages.#.content". "#" matches every
+	// array element and "*" every array element or object key.
+	JSONPaths []string `yaml:"json-paths,omitempty" json:"json-paths,omitempty"`
+
+	// BuiltinPatterns enables built-in detectors: "api-key", "jwt", "email" and "bearer".
+	BuiltinPatterns []string `yaml:"builtin-patterns,omitempty" json:"builtin-patterns,omitempty"`
+
+	// Patterns are regular expressions whose matches are redacted from bodies and errors.
+	Patterns []string `yaml:"patterns,omitempty" json:"patterns,omitempty"`
+
+	// HeaderAllowlist, when set, lists the only headers whose values are logged.
+	HeaderAllowlist []string `yaml:"header-allowlist,omitempty" json:"header-allowlist,omitempty"`
+}
+
+// BatchConfig configures the local batch queue. Batch requests are executed one by one
+// through the regular request pipeline; jobs, results and uploaded files are kept on disk so
+// unfinished batches resume after a restart.
+type BatchConfig struct {
+	// Disable turns off the batch and file endpoints.
+	Disable bool `yaml:"disable,omitempty" json:"disable,omitempty"`
+
+	// Dir stores jobs, results and uploaded files. Defaults to "batches" inside the writable
+	// path. Changes take effect after a restart.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// Concurrency is how many batch requests run at once across all jobs. Defaults to 4.
+	Concurrency int `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+
+	// MaxRequests caps the number of requests in one batch. Defaults to 100000.
+	MaxRequests int `yaml:"max-requests,omitempty" json:"max-requests,omitempty"`
+
+	// RetentionHours is how long finished batches and uploaded files are kept. Defaults to 720.
+	RetentionHours int `yaml:"retention-hours,omitempty" json:"retention-hours,omitempty"`
+}
+
+// CooldownQueueConfig configures the server-side wait queue used instead of an immediate 429
+// when every credential for a model is cooling down. Only requests sent with the
+// "X-CLIProxy-Queue: wait" header or from one of APIKeys are queued; they wait in FIFO order
+// per model until a credential frees up.
+type CooldownQueueConfig struct {
+	// Enable turns on the queue.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// APIKeys lists client API keys whose requests are always queued.
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+
+	// MaxQueueLength caps the requests waiting per model. Defaults to 100.
+	MaxQueueLength int `yaml:"max-queue-length,omitempty" json:"max-queue-length,omitempty"`
+
+	// MaxWaitSeconds is how long a request may wait before its 429 is returned. Defaults to 300.
+	MaxWaitSeconds int `yaml:"max-wait-seconds,omitempty" json:"max-wait-seconds,omitempty"`
+}
+
+// APIKeyPolicy holds the limits applied to a single client API key.
+// Zero values disable the corresponding limit.
+type APIKeyPolicy struct {
+	// APIKey is the client API key the policy applies to.
+	APIKey string `yaml:"api-key" json:"api-key"`
+
+	// RequestsPerMinute caps requests over a sliding one-minute window.
+	RequestsPerMinute int `yaml:"requests-per-minute,omitempty" json:"requests-per-minute,omitempty"`
+
+	// MaxConcurrentStreams caps streaming requests in flight at the same time.
+	MaxConcurrentStreams int `yaml:"max-concurrent-streams,omitempty" json:"max-concurrent-streams,omitempty"`
+
+	// InputTokensPerDay caps input tokens consumed per UTC day.
+	InputTokensPerDay int64 `yaml:"input-tokens-per-day,omitempty" json:"input-tokens-per-day,omitempty"`
+
+	// OutputTokensPerDay caps output tokens consumed per UTC day.
+	OutputTokensPerDay int64 `yaml:"output-tokens-per-day,omitempty" json:"output-tokens-per-day,omitempty"`
+
+	// InputTokensPerMonth caps input tokens consumed per UTC calendar month.
+	InputTokensPerMonth int64 `yaml:"input-tokens-per-month,omitempty" json:"input-tokens-per-month,omitempty"`
+
+	// OutputTokensPerMonth caps output tokens consumed per UTC calendar month.
+	OutputTokensPerMonth int64 `yaml:"output-tokens-per-month,omitempty" json:"output-tokens-per-month,omitempty"`
+
+	// AllowedModels restricts the key to models matching these patterns ('*' matches any substring).
+	AllowedModels []string `yaml:"allowed-models,omitempty" json:"allowed-models,omitempty"`
+
+	// AllowedProviders restricts the key to these providers (e.g. "gemini", "codex") or
+	// model prefixes (e.g. "teamA" for "teamA/<model>").
+	AllowedProviders []string `yaml:"allowed-providers,omitempty" json:"allowed-providers,omitempty"`
+
+	// MaxThinkingBudget caps the requested thinking budget in tokens. When set, dynamic
+	// ("auto") budgets are rejected because they are unbounded.
+	MaxThinkingBudget int `yaml:"max-thinking-budget,omitempty" json:"max-thinking-budget,omitempty"`
+
+	// DisableStreaming rejects streaming requests made with the key.
+	DisableStreaming bool `yaml:"disable-streaming,omitempty" json:"disable-streaming,omitempty"`
+
+	// DisableResponseCache prevents requests made with the key from reading or filling the
+	// response cache.
+	DisableResponseCache bool `yaml:"disable-response-cache,omitempty" json:"disable-response-cache,omitempty"`
+
+	// NeverLogBodies keeps request and response bodies of requests made with the key out of
+	// request logs and error logs; only metadata is logged.
+	NeverLogBodies bool `yaml:"never-log-bodies,omitempty" json:"never-log-bodies,omitempty"`
+}
+
+// AllowsModel reports whether any of the given model names matches AllowedModels.
+// An empty AllowedModels list allows every model.
+func (p *APIKeyPolicy) AllowsModel(models ...string) bool {
+	if p == nil || len(p.AllowedModels) == 0 {
+		return true
+	}
+	for _, pattern := range p.AllowedModels {
+		for _, model := range models {
+			if model != "" && matchPolicyPattern(pattern, model) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FilterProviders returns the providers the key may use for model. A provider is kept when
+// it, or the prefix of model, is listed in AllowedProviders. An empty list allows all providers.
+func (p *APIKeyPolicy) FilterProviders(model string, providers []string) []string {
+	if p == nil || len(p.AllowedProviders) == 0 {
+		return providers
+	}
+	prefix := ""
+	if idx := strings.Index(model, "/"); idx > 0 {
+		prefix = model[:idx]
+	}
+	out := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		for _, allowed := range p.AllowedProviders {
+			allowed = strings.TrimSpace(allowed)
+			if strings.EqualFold(allowed, provider) || (prefix != "" && strings.EqualFold(allowed, prefix)) {
+				out = append(out, provider)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// matchPolicyPattern performs case-insensitive matching where '*' matches any substring.
+func matchPolicyPattern(pattern, value string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	value = strings.ToLower(strings.TrimSpace(value))
+	if pattern == "" {
+		return false
+	}
+	if !strings.Contains(pattern, "*") {
+		return pattern == value
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, segment := range parts[1 : len(parts)-1] {
+		idx := strings.Index(value, segment)
+		if idx < 0 {
+			return false
+		}
+		value = value[idx+len(segment):]
+	}
+	return strings.HasSuffix(value, last)
+}
+
+// APIKeyPolicy returns the policy configured for the given client API key, or nil when none exists.
+func (c *SDKConfig) APIKeyPolicy(apiKey string) *APIKeyPolicy {
+	if c == nil || apiKey == "" {
+		return nil
+	}
+	for i := range c.APIKeyPolicies {
+		if c.APIKeyPolicies[i].APIKey == apiKey {
+			return &c.APIKeyPolicies[i]
+		}
+	}
+	return nil
+}
+
 // AccessConfig groups request authentication providers.
 type AccessConfig struct {
 	// Providers lists configured authentication providers.
@@ -24,4 +24,10 @@ const (
 
 	// Antigravity represents the Antigravity response format identifier.
 	Antigravity = "antigravity"
+
+	// OpenAIEmbeddings represents the OpenAI embeddings request format identifier.
+	OpenAIEmbeddings = "openai-embeddings"
+
+	// GeminiEmbeddings represents the Gemini batchEmbedContents request format identifier.
+	GeminiEmbeddings = "gemini-embeddings"
 )
@@ -0,0 +1,361 @@
+// Package logging provides request logging functionality for the CLI Proxy API server.
+// This file implements the structured request logger, which appends one JSON record per
+// request to a daily JSON Lines file so logs can be filtered with jq and replayed.
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+)
+
+const (
+	// RequestLogFormatText writes one human-readable file per request.
+	RequestLogFormatText = "text"
+	// RequestLogFormatJSONL appends one structured record per request to a JSON Lines file.
+	RequestLogFormatJSONL = "jsonl"
+
+	// UpstreamAttemptsKey is the Gin context key holding the []*UpstreamAttempt recorded by the
+	// executors for the current request.
+	UpstreamAttemptsKey = "API_UPSTREAM_RECORDS"
+)
+
+// RequestRecord is one line of a JSON Lines request log. Timestamp is when the client request
+// arrived and DurationMS how long it took until the record was written.
+type RequestRecord struct {
+	RequestID  string             `json:"request_id,omitempty"`
+	Timestamp  time.Time          `json:"timestamp"`
+	Version    string             `json:"version,omitempty"`
+	DurationMS int64              `json:"duration_ms"`
+	Request    RecordedRequest    `json:"request"`
+	Upstream   []*UpstreamAttempt `json:"upstream,omitempty"`
+	Response   RecordedResponse   `json:"response"`
+}
+
+// RecordedRequest is the inbound client request. Sensitive headers are masked.
+type RecordedRequest struct {
+	URL     string              `json:"url"`
+	Method  string              `json:"method"`
+	Headers map[string][]string `json:"headers,omitempty"`
+	Body    json.RawMessage     `json:"body,omitempty"`
+}
+
+// RecordedResponse is the response returned to the client. Streaming responses keep every
+// chunk written to the client in Chunks; other responses keep the full body in Body.
+type RecordedResponse struct {
+	Status    int                 `json:"status"`
+	Headers   map[string][]string `json:"headers,omitempty"`
+	Streaming bool                `json:"streaming,omitempty"`
+	Body      json.RawMessage     `json:"body,omitempty"`
+	Chunks    []json.RawMessage   `json:"chunks,omitempty"`
+	Errors    []string            `json:"errors,omitempty"`
+}
+
+// UpstreamAttempt is one request sent upstream while serving a client request, together with
+// the translated payload and what came back.
+type UpstreamAttempt struct {
+	Index           int                 `json:"index"`
+	Timestamp       time.Time           `json:"timestamp"`
+	Provider        string              `json:"provider,omitempty"`
+	AuthID          string              `json:"auth_id,omitempty"`
+	AuthIndex       string              `json:"auth_index,omitempty"`
+	AuthLabel       string              `json:"auth_label,omitempty"`
+	URL             string              `json:"url,omitempty"`
+	Method          string              `json:"method,omitempty"`
+	Headers         map[string][]string `json:"headers,omitempty"`
+	Body            json.RawMessage     `json:"body,omitempty"`
+	Status          int                 `json:"status,omitempty"`
+	ResponseHeaders map[string][]string `json:"response_headers,omitempty"`
+	Response        []json.RawMessage   `json:"response,omitempty"`
+	Errors          []string            `json:"errors,omitempty"`
+	// LatencyMS is the time from sending the request to the last recorded response event.
+	LatencyMS int64 `json:"latency_ms"`
+}
+
+// RecordPayload returns data as a JSON value: valid JSON is embedded as-is and anything else,
+// such as server-sent event lines, becomes a JSON string.
+func RecordPayload(data []byte) json.RawMessage {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil
+	}
+	if json.Valid(trimmed) {
+		return json.RawMessage(bytes.Clone(trimmed))
+	}
+	encoded, _ := json.Marshal(string(data))
+	return encoded
+}
+
+// RecordHeaders copies headers with sensitive values masked.
+func RecordHeaders(headers map[string][]string) map[string][]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	out := make(map[string][]string, len(headers))
+	for key, values := range headers {
+		masked := make([]string, len(values))
+		for i, value := range values {
+			masked[i] = util.MaskSensitiveHeaderValue(key, value)
+		}
+		out[key] = masked
+	}
+	return out
+}
+
+// ReadRequestRecords decodes every record of a JSON Lines request log.
+func ReadRequestRecords(r io.Reader) ([]*RequestRecord, error) {
+	var records []*RequestRecord
+	reader := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, errRead := reader.ReadBytes('\n')
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			record := &RequestRecord{}
+			if err := json.Unmarshal(trimmed, record); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			records = append(records, record)
+		}
+		if errRead == io.EOF {
+			return records, nil
+		}
+		if errRead != nil {
+			return nil, errRead
+		}
+	}
+}
+
+// JSONLRequestLogger implements RequestLogger by appending one RequestRecord per request to
+// requests-YYYY-MM-DD.jsonl in the logs directory. Records forced by an upstream error while
+// logging is disabled go to error-requests-YYYY-MM-DD.jsonl instead.
+type JSONLRequestLogger struct {
+	// file supplies the enabled state, the logs directory and the shared file helpers.
+	file *FileRequestLogger
+
+	// mu serialises appends so records never interleave.
+	mu sync.Mutex
+}
+
+// NewJSONLRequestLogger creates a structured request logger. logsDir is resolved like in
+// NewFileRequestLogger.
+func NewJSONLRequestLogger(enabled bool, logsDir string, configDir string) *JSONLRequestLogger {
+	return &JSONLRequestLogger{file: NewFileRequestLogger(enabled, logsDir, configDir)}
+}
+
+// IsEnabled returns whether request logging is currently enabled.
+func (l *JSONLRequestLogger) IsEnabled() bool {
+	return l.file.IsEnabled()
+}
+
+// SetEnabled updates the request logging enabled state.
+func (l *JSONLRequestLogger) SetEnabled(enabled bool) {
+	l.file.SetEnabled(enabled)
+}
+
+// StructuredRequestLogger is implemented by request loggers that record the structured
+// upstream attempts instead of the pre-rendered API request and response sections.
+type StructuredRequestLogger interface {
+	LogStructuredRequest(startedAt time.Time, url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response []byte, attempts []*UpstreamAttempt, apiResponseErrors []*interfaces.ErrorMessage, force bool, requestID string) error
+}
+
+// StructuredStreamingLogWriter is implemented by streaming log writers that accept the
+// structured upstream attempts. startedAt is when the client request arrived.
+type StructuredStreamingLogWriter interface {
+	WriteUpstreamAttempts(startedAt time.Time, attempts []*UpstreamAttempt)
+}
+
+// LogRequest records a non-streaming request without upstream attempts.
+func (l *JSONLRequestLogger) LogRequest(url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, apiRequest, apiResponse []byte, apiResponseErrors []*interfaces.ErrorMessage, requestID string) error {
+	return l.LogStructuredRequest(time.Time{}, url, method, requestHeaders, body, statusCode, responseHeaders, response, nil, apiResponseErrors, false, requestID)
+}
+
+// LogRequestWithOptions records a non-streaming request without upstream attempts, forcing
+// the record when force is set even while logging is disabled.
+func (l *JSONLRequestLogger) LogRequestWithOptions(url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response, apiRequest, apiResponse []byte, apiResponseErrors []*interfaces.ErrorMessage, force bool, requestID string) error {
+	return l.LogStructuredRequest(time.Time{}, url, method, requestHeaders, body, statusCode, responseHeaders, response, nil, apiResponseErrors, force, requestID)
+}
+
+// LogStructuredRequest records a non-streaming request with its upstream attempts. A zero
+// startedAt means now. When logging is disabled the record is only written if force is set.
+func (l *JSONLRequestLogger) LogStructuredRequest(startedAt time.Time, url, method string, requestHeaders map[string][]string, body []byte, statusCode int, responseHeaders map[string][]string, response []byte, attempts []*UpstreamAttempt, apiResponseErrors []*interfaces.ErrorMessage, force bool, requestID string) error {
+	if !l.IsEnabled() && !force {
+		return nil
+	}
+	record := newRequestRecord(url, method, requestHeaders, body, requestID)
+	if !startedAt.IsZero() {
+		record.Timestamp = startedAt
+	}
+	record.Upstream = attempts
+	record.Response.Status = statusCode
+	record.Response.Headers = RecordHeaders(responseHeaders)
+	if decoded, err := l.file.decompressResponse(responseHeaders, response); err == nil {
+		response = RedactBody(decoded)
+	}
+	record.Response.Body = RecordPayload(response)
+	record.Response.Errors = errorMessages(apiResponseErrors)
+	return l.writeRecord(record, force)
+}
+
+func newRequestRecord(url, method string, headers map[string][]string, body []byte, requestID string) *RequestRecord {
+	return &RequestRecord{
+		RequestID: requestID,
+		Timestamp: time.Now(),
+		Version:   buildinfo.Version,
+		Request: RecordedRequest{
+			URL:     url,
+			Method:  method,
+			Headers: RecordHeaders(headers),
+			Body:    RecordPayload(body),
+		},
+	}
+}
+
+func errorMessages(apiResponseErrors []*interfaces.ErrorMessage) []string {
+	var out []string
+	for _, msg := range apiResponseErrors {
+		if msg == nil {
+			continue
+		}
+		text := http.StatusText(msg.StatusCode)
+		if msg.Error != nil {
+			text = RedactText(msg.Error.Error())
+		}
+		out = append(out, fmt.Sprintf("%d: %s", msg.StatusCode, text))
+	}
+	return out
+}
+
+// writeRecord appends record to the current log file.
+func (l *JSONLRequestLogger) writeRecord(record *RequestRecord, force bool) error {
+	enabled := l.IsEnabled()
+	if record == nil || (!enabled && !force) {
+		return nil
+	}
+	record.DurationMS = time.Since(record.Timestamp).Milliseconds()
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode request record: %w", err)
+	}
+	if errEnsure := l.file.ensureLogsDir(); errEnsure != nil {
+		return fmt.Errorf("failed to create logs directory: %w", errEnsure)
+	}
+
+	filename := fmt.Sprintf("requests-%s.jsonl", time.Now().Format("2006-01-02"))
+	if !enabled {
+		filename = "error-" + filename
+	}
+	l.mu.Lock()
+	errWrite := appendLine(filepath.Join(l.file.logsDir, filename), line)
+	l.mu.Unlock()
+	if errWrite != nil {
+		return fmt.Errorf("failed to write request record: %w", errWrite)
+	}
+
+	if !enabled {
+		if errCleanup := l.file.cleanupOldErrorLogs(); errCleanup != nil {
+			log.WithError(errCleanup).Warn("failed to clean up old error logs")
+		}
+	}
+	return nil
+}
+
+func appendLine(path string, line []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	_, errWrite := f.Write(append(line, '\n'))
+	errClose := f.Close()
+	if errWrite != nil {
+		return errWrite
+	}
+	return errClose
+}
+
+// LogStreamingRequest starts the record of a streaming request. The record is written when
+// the returned writer is closed.
+func (l *JSONLRequestLogger) LogStreamingRequest(url, method string, headers map[string][]string, body []byte, requestID string) (StreamingLogWriter, error) {
+	if !l.IsEnabled() {
+		return &NoOpStreamingLogWriter{}, nil
+	}
+	record := newRequestRecord(url, method, headers, body, requestID)
+	record.Response.Streaming = true
+	return &JSONLStreamingLogWriter{logger: l, record: record}, nil
+}
+
+// JSONLStreamingLogWriter collects the chunks of a streaming response into a RequestRecord.
+type JSONLStreamingLogWriter struct {
+	logger *JSONLRequestLogger
+	mu     sync.Mutex
+	record *RequestRecord
+	closed bool
+}
+
+// WriteChunkAsync appends a chunk written to the client.
+func (w *JSONLStreamingLogWriter) WriteChunkAsync(chunk []byte) {
+	if payload := RecordPayload(RedactBody(chunk)); payload != nil {
+		w.mu.Lock()
+		w.record.Response.Chunks = append(w.record.Response.Chunks, payload)
+		w.mu.Unlock()
+	}
+}
+
+// WriteStatus records the response status and headers.
+func (w *JSONLStreamingLogWriter) WriteStatus(status int, headers map[string][]string) error {
+	w.mu.Lock()
+	w.record.Response.Status = status
+	w.record.Response.Headers = RecordHeaders(headers)
+	w.mu.Unlock()
+	return nil
+}
+
+// WriteAPIRequest is a no-op; upstream requests are recorded by WriteUpstreamAttempts.
+func (w *JSONLStreamingLogWriter) WriteAPIRequest(_ []byte) error { return nil }
+
+// WriteAPIResponse is a no-op; upstream responses are recorded by WriteUpstreamAttempts.
+func (w *JSONLStreamingLogWriter) WriteAPIResponse(_ []byte) error { return nil }
+
+// WriteUpstreamAttempts attaches the structured upstream attempts to the record and dates it
+// from startedAt.
+func (w *JSONLStreamingLogWriter) WriteUpstreamAttempts(startedAt time.Time, attempts []*UpstreamAttempt) {
+	w.mu.Lock()
+	if !startedAt.IsZero() {
+		w.record.Timestamp = startedAt
+	}
+	w.record.Upstream = attempts
+	w.mu.Unlock()
+}
+
+// Close writes the record.
+func (w *JSONLStreamingLogWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	record := w.record
+	w.mu.Unlock()
+	return w.logger.writeRecord(record, false)
+}
+
+// ParseRequestLogFormat normalises the request-log-format setting, defaulting to text.
+func ParseRequestLogFormat(format string) string {
+	if strings.EqualFold(strings.TrimSpace(format), RequestLogFormatJSONL) {
+		return RequestLogFormatJSONL
+	}
+	return RequestLogFormatText
+}
@@ -162,5 +162,5 @@ func isLogFileName(name string) bool {
 		return false
 	}
 	lower := strings.ToLower(trimmed)
-	return strings.HasSuffix(lower, ".log") || strings.HasSuffix(lower, ".log.gz")
+	return strings.HasSuffix(lower, ".log") || strings.HasSuffix(lower, ".log.gz") || strings.HasSuffix(lower, ".jsonl")
 }
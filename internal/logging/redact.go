@@ -0,0 +1,324 @@
+// Package logging provides request logging functionality for the CLI Proxy API server.
+// This file implements the redaction rules applied to request logs before they are written.
+package logging
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+const (
+	redactActionMask = "mask"
+	redactActionHash = "hash"
+	redactActionDrop = "drop"
+
+	redactedMarker = "[REDACTED]"
+	// OmittedBodyMarker replaces bodies of requests made with a never-log-bodies API key.
+	OmittedBodyMarker = "[body omitted]"
+)
+
+// builtinRedactPatterns are the detectors selectable by name in builtin-patterns.
+var builtinRedactPatterns = map[string]string{
+	"api-key": `\b(?:sk-ant-[A-Za-z0-9_\-]{20,}|sk-[A-Za-z0-9_\-]{16,}|AIza[0-9A-Za-z_\-]{35}|gh[pousr]_[A-Za-z0-9]{36}|AKIA[0-9A-Z]{16}|xox[abprs]-[A-Za-z0-9\-]{10,})\b`,
+	"jwt":     `\beyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+`,
+	"email":   `\b[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}\b`,
+	"bearer":  `(?i)\bbearer\s+[A-Za-z0-9._~+/\-]+=*`,
+}
+
+// redactor holds the compiled redaction rules. A nil redactor leaves logs untouched.
+type redactor struct {
+	action     string
+	paths      [][]string
+	patterns   []*regexp.Regexp
+	allowlist  map[string]struct{}
+	noBodyKeys map[string]struct{}
+}
+
+var activeRedactor atomic.Pointer[redactor]
+
+// ConfigureRedaction compiles the log-redaction rules and the never-log-bodies API key
+// policies of cfg. It is called on startup and on every configuration reload; invalid
+// patterns are skipped with a warning.
+func ConfigureRedaction(cfg *config.Config) {
+	if cfg == nil {
+		activeRedactor.Store(nil)
+		return
+	}
+	rules := cfg.LogRedaction
+	r := &redactor{action: redactActionMask}
+	switch action := strings.ToLower(strings.TrimSpace(rules.Action)); action {
+	case "", redactActionMask:
+	case redactActionHash, redactActionDrop:
+		r.action = action
+	default:
+		log.Warnf("log-redaction: unknown action %q, masking instead", rules.Action)
+	}
+	for _, path := range rules.JSONPaths {
+		if segments := splitRedactPath(path); len(segments) > 0 {
+			r.paths = append(r.paths, segments)
+		}
+	}
+	for _, name := range rules.BuiltinPatterns {
+		expr, ok := builtinRedactPatterns[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			log.Warnf("log-redaction: unknown builtin pattern %q", name)
+			continue
+		}
+		r.patterns = append(r.patterns, regexp.MustCompile(expr))
+	}
+	for _, expr := range rules.Patterns {
+		if strings.TrimSpace(expr) == "" {
+			continue
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			log.Warnf("log-redaction: invalid pattern %q: %v", expr, err)
+			continue
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	if len(rules.HeaderAllowlist) > 0 {
+		r.allowlist = make(map[string]struct{}, len(rules.HeaderAllowlist))
+		for _, name := range rules.HeaderAllowlist {
+			r.allowlist[http.CanonicalHeaderKey(strings.TrimSpace(name))] = struct{}{}
+		}
+	}
+	for _, policy := range cfg.APIKeyPolicies {
+		if policy.NeverLogBodies && policy.APIKey != "" {
+			if r.noBodyKeys == nil {
+				r.noBodyKeys = make(map[string]struct{})
+			}
+			r.noBodyKeys[policy.APIKey] = struct{}{}
+		}
+	}
+	if len(r.paths) == 0 && len(r.patterns) == 0 && r.allowlist == nil && r.noBodyKeys == nil {
+		r = nil
+	}
+	activeRedactor.Store(r)
+}
+
+// OmitBodies reports whether requests made with apiKey must be logged without bodies.
+func OmitBodies(apiKey string) bool {
+	r := activeRedactor.Load()
+	if r == nil || apiKey == "" {
+		return false
+	}
+	_, ok := r.noBodyKeys[apiKey]
+	return ok
+}
+
+// RedactHeaders returns headers with the values of headers outside the allowlist redacted.
+// The input is returned unchanged when no allowlist is configured.
+func RedactHeaders(headers map[string][]string) map[string][]string {
+	r := activeRedactor.Load()
+	if r == nil || r.allowlist == nil || len(headers) == 0 {
+		return headers
+	}
+	out := make(map[string][]string, len(headers))
+	for key, values := range headers {
+		if _, ok := r.allowlist[http.CanonicalHeaderKey(key)]; ok {
+			out[key] = values
+			continue
+		}
+		if r.action == redactActionDrop {
+			continue
+		}
+		redacted := make([]string, len(values))
+		for i, value := range values {
+			redacted[i] = r.replacement(value)
+		}
+		out[key] = redacted
+	}
+	return out
+}
+
+// RedactBody applies the JSON path and pattern rules to a request or response body. JSON
+// paths are applied to JSON bodies and to the data lines of server-sent event streams.
+func RedactBody(body []byte) []byte {
+	r := activeRedactor.Load()
+	if r == nil || len(body) == 0 {
+		return body
+	}
+	if len(r.paths) > 0 {
+		if json.Valid(body) {
+			body = r.redactJSON(body)
+		} else if bytes.Contains(body, []byte("data:")) {
+			body = r.redactEventStream(body)
+		}
+	}
+	if len(r.patterns) > 0 {
+		body = []byte(r.redactText(string(body)))
+	}
+	return body
+}
+
+// RedactText applies the pattern rules to free text such as error messages.
+func RedactText(text string) string {
+	r := activeRedactor.Load()
+	if r == nil || len(r.patterns) == 0 || text == "" {
+		return text
+	}
+	return r.redactText(text)
+}
+
+func (r *redactor) redactText(text string) string {
+	for _, re := range r.patterns {
+		text = re.ReplaceAllStringFunc(text, r.replacement)
+	}
+	return text
+}
+
+func (r *redactor) replacement(value string) string {
+	switch r.action {
+	case redactActionDrop:
+		return ""
+	case redactActionHash:
+		sum := sha256.Sum256([]byte(value))
+		return "[sha256:" + hex.EncodeToString(sum[:6]) + "]"
+	default:
+		return redactedMarker
+	}
+}
+
+func (r *redactor) redactEventStream(body []byte) []byte {
+	lines := bytes.Split(body, []byte("\n"))
+	for i, line := range lines {
+		rest, ok := bytes.CutPrefix(line, []byte("data:"))
+		if !ok {
+			continue
+		}
+		payload := bytes.TrimSpace(rest)
+		if len(payload) == 0 || !json.Valid(payload) {
+			continue
+		}
+		lines[i] = append([]byte("data: "), r.redactJSON(payload)...)
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+func (r *redactor) redactJSON(body []byte) []byte {
+	for _, segments := range r.paths {
+		concrete := expandRedactPath(body, "", segments)
+		// Walk backwards so dropping an array element does not shift the ones still to visit.
+		for i := len(concrete) - 1; i >= 0; i-- {
+			value := gjson.GetBytes(body, concrete[i])
+			if !value.Exists() {
+				continue
+			}
+			var (
+				updated []byte
+				err     error
+			)
+			switch r.action {
+			case redactActionDrop:
+				updated, err = sjson.DeleteBytes(body, concrete[i])
+			case redactActionHash:
+				source := value.Raw
+				if value.Type == gjson.String {
+					source = value.Str
+				}
+				updated, err = sjson.SetBytes(body, concrete[i], r.replacement(source))
+			default:
+				updated, err = sjson.SetBytes(body, concrete[i], redactedMarker)
+			}
+			if err == nil {
+				body = updated
+			}
+		}
+	}
+	return body
+}
+
+// splitRedactPath splits a dotted selector such as "messages.#.content" into segments.
+// A backslash escapes a literal dot.
+func splitRedactPath(path string) []string {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil
+	}
+	var (
+		segments []string
+		current  strings.Builder
+	)
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path):
+			i++
+			current.WriteByte(path[i])
+		case path[i] == '.':
+			segments = append(segments, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(path[i])
+		}
+	}
+	return append(segments, current.String())
+}
+
+// expandRedactPath resolves the "#" and "*" wildcards of segments against body and returns
+// the concrete gjson/sjson paths of every selected value.
+func expandRedactPath(body []byte, prefix string, segments []string) []string {
+	if len(segments) == 0 {
+		if prefix == "" {
+			return nil
+		}
+		return []string{prefix}
+	}
+	segment, rest := segments[0], segments[1:]
+	if segment != "#" && segment != "*" {
+		next := joinRedactPath(prefix, escapeRedactKey(segment))
+		if !gjson.GetBytes(body, next).Exists() {
+			return nil
+		}
+		return expandRedactPath(body, next, rest)
+	}
+	parent := gjson.ParseBytes(body)
+	if prefix != "" {
+		parent = gjson.GetBytes(body, prefix)
+	}
+	var out []string
+	switch {
+	case parent.IsArray():
+		for i := range parent.Array() {
+			out = append(out, expandRedactPath(body, joinRedactPath(prefix, strconv.Itoa(i)), rest)...)
+		}
+	case parent.IsObject() && segment == "*":
+		var keys []string
+		parent.ForEach(func(key, _ gjson.Result) bool {
+			keys = append(keys, key.String())
+			return true
+		})
+		sort.Strings(keys)
+		for _, key := range keys {
+			out = append(out, expandRedactPath(body, joinRedactPath(prefix, escapeRedactKey(key)), rest)...)
+		}
+	}
+	return out
+}
+
+func joinRedactPath(prefix, segment string) string {
+	if prefix == "" {
+		return segment
+	}
+	return prefix + "." + segment
+}
+
+var redactKeyEscaper = strings.NewReplacer(".", `\.`, "*", `\*`, "?", `\?`, "#", `\#`, "|", `\|`, "@", `\@`)
+
+func escapeRedactKey(key string) string {
+	return redactKeyEscaper.Replace(key)
+}
@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func configureRedactionForTest(t *testing.T, rules config.LogRedactionConfig, policies ...config.APIKeyPolicy) {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.LogRedaction = rules
+	cfg.APIKeyPolicies = policies
+	ConfigureRedaction(cfg)
+	t.Cleanup(func() { ConfigureRedaction(nil) })
+}
+
+func TestRedactBodyAppliesJSONPathsAndPatterns(t *testing.T) {
+	configureRedactionForTest(t, config.LogRedactionConfig{
+		JSONPaths:       []string{"messages.#.content", "metadata.*"},
+		BuiltinPatterns: []string{"api-key", "email"},
+	})
+	body := []byte(`{"model":"gpt-5","messages":[{"role":"user","content":"secret prompt"},{"role":"assistant","content":"reply"}],` +
+		`"metadata":{"a":"x","b":{"c":1}},"note":"mail bob@example.com key sk-abcdefghijklmnopqrstuv"}`)
+	out := RedactBody(body)
+
+	if got := gjson.GetBytes(out, "messages.#.content").String(); got != `["[REDACTED]","[REDACTED]"]` {
+		t.Fatalf("message contents = %s", got)
+	}
+	if gjson.GetBytes(out, "metadata.b").String() != "[REDACTED]" || gjson.GetBytes(out, "model").String() != "gpt-5" {
+		t.Fatalf("redacted body = %s", out)
+	}
+	if note := gjson.GetBytes(out, "note").String(); note != "mail [REDACTED] key [REDACTED]" {
+		t.Fatalf("note = %q", note)
+	}
+	if !strings.Contains(string(body), "secret prompt") {
+		t.Fatalf("input body was modified")
+	}
+
+	stream := RedactBody([]byte("event: delta\ndata: {\"messages\":[{\"content\":\"hi\"}]}\n\n"))
+	if !strings.Contains(string(stream), `data: {"messages":[{"content":"[REDACTED]"}]}`) || !strings.HasPrefix(string(stream), "event: delta\n") {
+		t.Fatalf("event stream = %q", stream)
+	}
+}
+
+func TestRedactHashAndDropActions(t *testing.T) {
+	configureRedactionForTest(t, config.LogRedactionConfig{Action: "hash", JSONPaths: []string{"user"}, Patterns: []string{`token-\d+`}})
+	first := RedactBody([]byte(`{"user":"alice","text":"token-123"}`))
+	second := RedactBody([]byte(`{"user":"alice","text":"token-123"}`))
+	if string(first) != string(second) || !strings.HasPrefix(gjson.GetBytes(first, "user").String(), "[sha256:") {
+		t.Fatalf("hashed body = %s", first)
+	}
+	if strings.Contains(string(first), "alice") || strings.Contains(string(first), "token-123") {
+		t.Fatalf("hash leaked the value: %s", first)
+	}
+
+	configureRedactionForTest(t, config.LogRedactionConfig{Action: "drop", JSONPaths: []string{"tools.#"}, HeaderAllowlist: []string{"content-type"}})
+	if out := RedactBody([]byte(`{"tools":[1,2,3],"x":1}`)); string(out) != `{"tools":[],"x":1}` {
+		t.Fatalf("dropped body = %s", out)
+	}
+	headers := RedactHeaders(map[string][]string{"Content-Type": {"application/json"}, "X-Secret": {"v"}})
+	if len(headers) != 1 || headers["Content-Type"][0] != "application/json" {
+		t.Fatalf("headers = %v", headers)
+	}
+}
+
+func TestOmitBodiesFollowsAPIKeyPolicies(t *testing.T) {
+	configureRedactionForTest(t, config.LogRedactionConfig{}, config.APIKeyPolicy{APIKey: "private", NeverLogBodies: true}, config.APIKeyPolicy{APIKey: "public"})
+	if !OmitBodies("private") || OmitBodies("public") || OmitBodies("") {
+		t.Fatalf("unexpected never-log-bodies decision")
+	}
+	if out := RedactBody([]byte(`{"a":1}`)); string(out) != `{"a":1}` {
+		t.Fatalf("body changed without rules: %s", out)
+	}
+
+	// Reloading without the policy lifts the restriction.
+	configureRedactionForTest(t, config.LogRedactionConfig{})
+	if OmitBodies("private") {
+		t.Fatalf("never-log-bodies survived reload")
+	}
+}
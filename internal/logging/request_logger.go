@@ -226,6 +226,8 @@ func (l *FileRequestLogger) logRequest(url, method string, requestHeaders map[st
 	if decompressErr != nil {
 		// If decompression fails, continue with original response and annotate the log output.
 		responseToWrite = response
+	} else {
+		responseToWrite = RedactBody(responseToWrite)
 	}
 
 	logFile, errOpen := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
@@ -421,7 +423,8 @@ func (l *FileRequestLogger) sanitizeForFilename(path string) string {
 	return sanitized
 }
 
-// cleanupOldErrorLogs keeps only the newest 10 forced error log files.
+// cleanupOldErrorLogs keeps only the newest 10 forced error log files, counting text logs and
+// JSON Lines logs alike.
 func (l *FileRequestLogger) cleanupOldErrorLogs() error {
 	entries, errRead := os.ReadDir(l.logsDir)
 	if errRead != nil {
@@ -439,7 +442,7 @@ func (l *FileRequestLogger) cleanupOldErrorLogs() error {
 			continue
 		}
 		name := entry.Name()
-		if !strings.HasPrefix(name, "error-") || !strings.HasSuffix(name, ".log") {
+		if !strings.HasPrefix(name, "error-") || (!strings.HasSuffix(name, ".log") && !strings.HasSuffix(name, ".jsonl")) {
 			continue
 		}
 		info, errInfo := entry.Info()
@@ -627,7 +630,7 @@ func writeAPIErrorResponses(w io.Writer, apiResponseErrors []*interfaces.ErrorMe
 			return errWrite
 		}
 		if apiResponseErrors[i].Error != nil {
-			if _, errWrite := io.WriteString(w, apiResponseErrors[i].Error.Error()); errWrite != nil {
+			if _, errWrite := io.WriteString(w, RedactText(apiResponseErrors[i].Error.Error())); errWrite != nil {
 				return errWrite
 			}
 		}
@@ -988,6 +991,7 @@ func (w *FileStreamingLogWriter) WriteChunkAsync(chunk []byte) {
 	// Make a copy of the chunk to avoid data races
 	chunkCopy := make([]byte, len(chunk))
 	copy(chunkCopy, chunk)
+	chunkCopy = RedactBody(chunkCopy)
 
 	// Non-blocking send
 	select {
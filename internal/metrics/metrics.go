@@ -0,0 +1,288 @@
+// Package metrics exposes Prometheus/OpenMetrics instrumentation for the proxy.
+// Upstream attempt outcomes are observed through the core auth manager Hook, token and
+// client request counters through the usage plugin pipeline, and credential state,
+// retry counts, usage queue depth and websocket relay sessions are sampled at scrape time.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+const namespace = "cliproxy"
+
+var defaultCollector = NewCollector()
+
+func init() {
+	coreusage.RegisterPlugin(defaultCollector)
+}
+
+// Default returns the process-wide collector.
+func Default() *Collector { return defaultCollector }
+
+// AuthSource lists credentials and reports retry activity; *coreauth.Manager satisfies it.
+type AuthSource interface {
+	List() []*coreauth.Auth
+	RetryCount() uint64
+}
+
+// Collector owns the metrics registry. It implements coreauth.Hook and coreusage.Plugin.
+type Collector struct {
+	coreauth.NoopHook
+
+	registry *prometheus.Registry
+
+	upstreamRequests *prometheus.CounterVec
+	upstreamLatency  *prometheus.HistogramVec
+	upstreamTTFB     *prometheus.HistogramVec
+	clientRequests   *prometheus.CounterVec
+	tokens           *prometheus.CounterVec
+
+	mu            sync.RWMutex
+	auths         AuthSource
+	relaySessions func() int
+}
+
+// NewCollector constructs a collector with its own registry.
+func NewCollector() *Collector {
+	c := &Collector{
+		registry: prometheus.NewRegistry(),
+		upstreamRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upstream_requests_total",
+			Help:      "Upstream provider attempts by outcome.",
+		}, []string{"provider", "model", "result", "status_code"}),
+		upstreamLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "upstream_request_duration_seconds",
+			Help:      "Wall time of upstream provider attempts, including the full stream for streaming requests.",
+			Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300},
+		}, []string{"provider", "model", "result"}),
+		upstreamTTFB: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "upstream_time_to_first_byte_seconds",
+			Help:      "Time until the first stream chunk of streaming upstream attempts.",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 4, 8, 15, 30, 60},
+		}, []string{"provider", "model"}),
+		clientRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "Completed requests by client API key, provider and model.",
+		}, []string{"provider", "model", "api_key", "result"}),
+		tokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tokens_total",
+			Help:      "Tokens reported by upstream providers by client API key, provider, model and token type.",
+		}, []string{"provider", "model", "api_key", "type"}),
+	}
+	c.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		c.upstreamRequests,
+		c.upstreamLatency,
+		c.upstreamTTFB,
+		c.clientRequests,
+		c.tokens,
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "retries_total",
+			Help:      "Retry rounds scheduled after failed attempts while waiting for a credential cooldown.",
+		}, c.retryCount),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "usage_queue_depth",
+			Help:      "Usage records waiting to be delivered to usage plugins.",
+		}, func() float64 { return float64(coreusage.DefaultManager().QueueDepth()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "websocket_relay_sessions",
+			Help:      "Live websocket relay sessions.",
+		}, c.relaySessionCount),
+		newAuthCollector(c),
+	)
+	return c
+}
+
+// SetAuthSource attaches the credential manager sampled at scrape time.
+func (c *Collector) SetAuthSource(source AuthSource) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.auths = source
+	c.mu.Unlock()
+}
+
+// SetRelaySessions attaches the function reporting live websocket relay sessions.
+func (c *Collector) SetRelaySessions(fn func() int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.relaySessions = fn
+	c.mu.Unlock()
+}
+
+// Handler serves the registry in Prometheus text or OpenMetrics format.
+func (c *Collector) Handler() http.Handler {
+	return promhttp.HandlerFor(c.registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
+
+// Registry exposes the underlying registry so embedders can add their own collectors.
+func (c *Collector) Registry() *prometheus.Registry { return c.registry }
+
+// OnResult implements coreauth.Hook.
+func (c *Collector) OnResult(_ context.Context, result coreauth.Result) {
+	if c == nil {
+		return
+	}
+	outcome := "success"
+	statusCode := ""
+	if !result.Success {
+		outcome = "error"
+		if result.Error != nil && result.Error.HTTPStatus > 0 {
+			statusCode = strconv.Itoa(result.Error.HTTPStatus)
+		}
+	}
+	c.upstreamRequests.WithLabelValues(result.Provider, result.Model, outcome, statusCode).Inc()
+	if result.Latency > 0 {
+		c.upstreamLatency.WithLabelValues(result.Provider, result.Model, outcome).Observe(result.Latency.Seconds())
+	}
+	if result.FirstByteLatency > 0 {
+		c.upstreamTTFB.WithLabelValues(result.Provider, result.Model).Observe(result.FirstByteLatency.Seconds())
+	}
+}
+
+// HandleUsage implements coreusage.Plugin.
+func (c *Collector) HandleUsage(_ context.Context, record coreusage.Record) {
+	if c == nil {
+		return
+	}
+	apiKey := util.HideAPIKey(record.APIKey)
+	outcome := "success"
+	if record.Cancelled {
+		outcome = "cancelled"
+	} else if record.Failed {
+		outcome = "error"
+	}
+	c.clientRequests.WithLabelValues(record.Provider, record.Model, apiKey, outcome).Inc()
+	for _, entry := range []struct {
+		kind  string
+		value int64
+	}{
+		{"input", record.Detail.InputTokens},
+		{"output", record.Detail.OutputTokens},
+		{"reasoning", record.Detail.ReasoningTokens},
+		{"cached", record.Detail.CachedTokens},
+	} {
+		if entry.value > 0 {
+			c.tokens.WithLabelValues(record.Provider, record.Model, apiKey, entry.kind).Add(float64(entry.value))
+		}
+	}
+}
+
+func (c *Collector) authSource() AuthSource {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.auths
+}
+
+func (c *Collector) retryCount() float64 {
+	if source := c.authSource(); source != nil {
+		return float64(source.RetryCount())
+	}
+	return 0
+}
+
+func (c *Collector) relaySessionCount() float64 {
+	c.mu.RLock()
+	fn := c.relaySessions
+	c.mu.RUnlock()
+	if fn == nil {
+		return 0
+	}
+	return float64(fn())
+}
+
+// authCollector samples credential state from the attached AuthSource at scrape time.
+type authCollector struct {
+	parent   *Collector
+	status   *prometheus.Desc
+	cooldown *prometheus.Desc
+	backoff  *prometheus.Desc
+}
+
+func newAuthCollector(parent *Collector) *authCollector {
+	return &authCollector{
+		parent: parent,
+		status: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "auth", "status"),
+			"Credential lifecycle status; the series with value 1 carries the current status.",
+			[]string{"auth_index", "provider", "status"}, nil,
+		),
+		cooldown: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "auth", "cooldown_remaining_seconds"),
+			"Seconds until a cooled-down credential becomes eligible again; model is empty for credential-wide cooldowns.",
+			[]string{"auth_index", "provider", "model"}, nil,
+		),
+		backoff: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "auth", "quota_backoff_level"),
+			"Progressive quota cooldown exponent of a credential; model is empty for the credential-wide state.",
+			[]string{"auth_index", "provider", "model"}, nil,
+		),
+	}
+}
+
+func (a *authCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- a.status
+	ch <- a.cooldown
+	ch <- a.backoff
+}
+
+func (a *authCollector) Collect(ch chan<- prometheus.Metric) {
+	source := a.parent.authSource()
+	if source == nil {
+		return
+	}
+	now := time.Now()
+	for _, auth := range source.List() {
+		if auth == nil {
+			continue
+		}
+		index := auth.EnsureIndex()
+		status := string(auth.Status)
+		if auth.Disabled {
+			status = string(coreauth.StatusDisabled)
+		}
+		if status == "" {
+			status = string(coreauth.StatusUnknown)
+		}
+		ch <- prometheus.MustNewConstMetric(a.status, prometheus.GaugeValue, 1, index, auth.Provider, status)
+
+		emitState := func(model string, next time.Time, quota coreauth.QuotaState) {
+			remaining := 0.0
+			if next.After(now) {
+				remaining = next.Sub(now).Seconds()
+			}
+			ch <- prometheus.MustNewConstMetric(a.cooldown, prometheus.GaugeValue, remaining, index, auth.Provider, model)
+			ch <- prometheus.MustNewConstMetric(a.backoff, prometheus.GaugeValue, float64(quota.BackoffLevel), index, auth.Provider, model)
+		}
+		emitState("", auth.NextRetryAfter, auth.Quota)
+		for model, state := range auth.ModelStates {
+			if state == nil || model == "" {
+				continue
+			}
+			emitState(model, state.NextRetryAfter, state.Quota)
+		}
+	}
+}
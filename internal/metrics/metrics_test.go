@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+type fakeAuthSource struct {
+	auths   []*coreauth.Auth
+	retries uint64
+}
+
+func (f *fakeAuthSource) List() []*coreauth.Auth { return f.auths }
+func (f *fakeAuthSource) RetryCount() uint64     { return f.retries }
+
+func TestCollectorExposition(t *testing.T) {
+	c := NewCollector()
+	c.SetAuthSource(&fakeAuthSource{
+		retries: 3,
+		auths: []*coreauth.Auth{{
+			ID:       "a.json",
+			Provider: "claude",
+			Status:   coreauth.StatusError,
+			ModelStates: map[string]*coreauth.ModelState{
+				"claude-x": {NextRetryAfter: time.Now().Add(time.Minute), Quota: coreauth.QuotaState{BackoffLevel: 2}},
+			},
+		}},
+	})
+	c.SetRelaySessions(func() int { return 1 })
+
+	c.OnResult(context.Background(), coreauth.Result{Provider: "claude", Model: "claude-x", Success: false,
+		Error: &coreauth.Error{HTTPStatus: 429}, Latency: 300 * time.Millisecond})
+	c.OnResult(context.Background(), coreauth.Result{Provider: "claude", Model: "claude-x", Success: true,
+		Latency: time.Second, FirstByteLatency: 200 * time.Millisecond})
+	c.HandleUsage(context.Background(), coreusage.Record{Provider: "claude", Model: "claude-x", APIKey: "sk-client-0001",
+		Detail: coreusage.Detail{InputTokens: 10, OutputTokens: 4}})
+
+	rec := httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`cliproxy_upstream_requests_total{model="claude-x",provider="claude",result="error",status_code="429"} 1`,
+		`cliproxy_upstream_time_to_first_byte_seconds_count{model="claude-x",provider="claude"} 1`,
+		`cliproxy_tokens_total{api_key="sk-c...0001",model="claude-x",provider="claude",type="input"} 10`,
+		`cliproxy_requests_total{api_key="sk-c...0001",model="claude-x",provider="claude",result="success"} 1`,
+		`cliproxy_auth_status{auth_index=`,
+		`status="error"} 1`,
+		`cliproxy_auth_quota_backoff_level{auth_index=`,
+		`cliproxy_retries_total 3`,
+		`cliproxy_websocket_relay_sessions 1`,
+		`cliproxy_usage_queue_depth`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics output missing %q", want)
+		}
+	}
+}
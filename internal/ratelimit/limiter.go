@@ -0,0 +1,266 @@
+// Package ratelimit enforces per-client-API-key request rates, stream concurrency and
+// token budgets. Token consumption is fed from the usage records published through
+// sdk/cliproxy/usage, so budgets reflect what upstream providers actually reported.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+const (
+	dayLayout   = "2006-01-02"
+	monthLayout = "2006-01"
+)
+
+var defaultLimiter = NewLimiter()
+
+func init() {
+	coreusage.RegisterPlugin(defaultLimiter)
+}
+
+// Default returns the process-wide limiter that receives usage records.
+func Default() *Limiter { return defaultLimiter }
+
+// LimitError describes why a request was rejected.
+type LimitError struct {
+	// Limit names the exceeded limit using its configuration key.
+	Limit string
+	// Message is a human readable description suitable for clients.
+	Message string
+	// RetryAfter is the suggested wait before retrying; zero when unknown.
+	RetryAfter time.Duration
+}
+
+// Error implements error.
+func (e *LimitError) Error() string { return e.Message }
+
+// KeyUsage is a snapshot of the counters tracked for one API key.
+type KeyUsage struct {
+	APIKey            string `json:"api-key"`
+	RequestsLastMin   int    `json:"requests-last-minute"`
+	ActiveStreams     int    `json:"active-streams"`
+	Day               string `json:"day"`
+	InputTokensDay    int64  `json:"input-tokens-day"`
+	OutputTokensDay   int64  `json:"output-tokens-day"`
+	Month             string `json:"month"`
+	InputTokensMonth  int64  `json:"input-tokens-month"`
+	OutputTokensMonth int64  `json:"output-tokens-month"`
+}
+
+type keyState struct {
+	requests      []time.Time
+	activeStreams int
+	day           string
+	inputDay      int64
+	outputDay     int64
+	month         string
+	inputMonth    int64
+	outputMonth   int64
+}
+
+// Limiter tracks per-key counters. It is safe for concurrent use.
+type Limiter struct {
+	mu   sync.Mutex
+	keys map[string]*keyState
+	now  func() time.Time
+}
+
+// NewLimiter constructs an empty limiter.
+func NewLimiter() *Limiter {
+	return &Limiter{keys: make(map[string]*keyState), now: time.Now}
+}
+
+// Acquire admits a request for apiKey under policy, recording it against the request rate.
+// For streaming requests a concurrency slot is held until the returned release func is called.
+// The release func is always non-nil and safe to call more than once.
+func (l *Limiter) Acquire(apiKey string, policy *config.APIKeyPolicy, stream bool) (func(), *LimitError) {
+	noop := func() {}
+	if l == nil || policy == nil || apiKey == "" {
+		return noop, nil
+	}
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	state := l.stateLocked(apiKey, now)
+
+	if limitErr := checkBudgets(state, policy, now); limitErr != nil {
+		return noop, limitErr
+	}
+	if stream && policy.MaxConcurrentStreams > 0 && state.activeStreams >= policy.MaxConcurrentStreams {
+		return noop, &LimitError{
+			Limit:   "max-concurrent-streams",
+			Message: fmt.Sprintf("API key exceeded the limit of %d concurrent streams", policy.MaxConcurrentStreams),
+		}
+	}
+	if policy.RequestsPerMinute > 0 {
+		state.requests = pruneWindow(state.requests, now.Add(-time.Minute))
+		if len(state.requests) >= policy.RequestsPerMinute {
+			return noop, &LimitError{
+				Limit:      "requests-per-minute",
+				Message:    fmt.Sprintf("API key exceeded the limit of %d requests per minute", policy.RequestsPerMinute),
+				RetryAfter: state.requests[0].Add(time.Minute).Sub(now),
+			}
+		}
+		state.requests = append(state.requests, now)
+	}
+	if !stream || policy.MaxConcurrentStreams <= 0 {
+		return noop, nil
+	}
+
+	state.activeStreams++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			if st, ok := l.keys[apiKey]; ok && st.activeStreams > 0 {
+				st.activeStreams--
+			}
+			l.mu.Unlock()
+		})
+	}, nil
+}
+
+// HandleUsage implements coreusage.Plugin by accumulating token consumption per API key.
+func (l *Limiter) HandleUsage(_ context.Context, record coreusage.Record) {
+	if l == nil || record.APIKey == "" {
+		return
+	}
+	input := record.Detail.InputTokens
+	output := record.Detail.OutputTokens
+	// Some providers report reasoning tokens outside of output tokens; the total captures both.
+	if record.Detail.TotalTokens > input+output {
+		output = record.Detail.TotalTokens - input
+	}
+	if input == 0 && output == 0 {
+		return
+	}
+	ts := record.RequestedAt
+	if ts.IsZero() {
+		ts = l.now()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	state := l.stateLocked(record.APIKey, l.now())
+	if ts.UTC().Format(dayLayout) == state.day {
+		state.inputDay += input
+		state.outputDay += output
+	}
+	if ts.UTC().Format(monthLayout) == state.month {
+		state.inputMonth += input
+		state.outputMonth += output
+	}
+}
+
+// Snapshot returns the counters tracked for every API key, sorted by key.
+func (l *Limiter) Snapshot() []KeyUsage {
+	if l == nil {
+		return nil
+	}
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]KeyUsage, 0, len(l.keys))
+	for key := range l.keys {
+		state := l.stateLocked(key, now)
+		state.requests = pruneWindow(state.requests, now.Add(-time.Minute))
+		out = append(out, KeyUsage{
+			APIKey:            key,
+			RequestsLastMin:   len(state.requests),
+			ActiveStreams:     state.activeStreams,
+			Day:               state.day,
+			InputTokensDay:    state.inputDay,
+			OutputTokensDay:   state.outputDay,
+			Month:             state.month,
+			InputTokensMonth:  state.inputMonth,
+			OutputTokensMonth: state.outputMonth,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].APIKey < out[j].APIKey })
+	return out
+}
+
+// Reset clears the counters of apiKey, or of every key when apiKey is empty.
+// Active stream slots are preserved so in-flight streams still release correctly.
+func (l *Limiter) Reset(apiKey string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key, state := range l.keys {
+		if apiKey != "" && key != apiKey {
+			continue
+		}
+		l.keys[key] = &keyState{activeStreams: state.activeStreams}
+	}
+}
+
+// stateLocked returns the state for key, rolling daily and monthly counters over as needed.
+func (l *Limiter) stateLocked(key string, now time.Time) *keyState {
+	state, ok := l.keys[key]
+	if !ok {
+		state = &keyState{}
+		l.keys[key] = state
+	}
+	day := now.UTC().Format(dayLayout)
+	if state.day != day {
+		state.day = day
+		state.inputDay = 0
+		state.outputDay = 0
+	}
+	month := now.UTC().Format(monthLayout)
+	if state.month != month {
+		state.month = month
+		state.inputMonth = 0
+		state.outputMonth = 0
+	}
+	return state
+}
+
+func checkBudgets(state *keyState, policy *config.APIKeyPolicy, now time.Time) *LimitError {
+	utc := now.UTC()
+	nextDay := time.Date(utc.Year(), utc.Month(), utc.Day()+1, 0, 0, 0, 0, time.UTC)
+	nextMonth := time.Date(utc.Year(), utc.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	checks := []struct {
+		name  string
+		limit int64
+		used  int64
+		reset time.Time
+		label string
+	}{
+		{"input-tokens-per-day", policy.InputTokensPerDay, state.inputDay, nextDay, "daily input token budget"},
+		{"output-tokens-per-day", policy.OutputTokensPerDay, state.outputDay, nextDay, "daily output token budget"},
+		{"input-tokens-per-month", policy.InputTokensPerMonth, state.inputMonth, nextMonth, "monthly input token budget"},
+		{"output-tokens-per-month", policy.OutputTokensPerMonth, state.outputMonth, nextMonth, "monthly output token budget"},
+	}
+	for _, check := range checks {
+		if check.limit > 0 && check.used >= check.limit {
+			return &LimitError{
+				Limit:      check.name,
+				Message:    fmt.Sprintf("API key exhausted its %s of %d tokens", check.label, check.limit),
+				RetryAfter: check.reset.Sub(now),
+			}
+		}
+	}
+	return nil
+}
+
+func pruneWindow(times []time.Time, cutoff time.Time) []time.Time {
+	idx := 0
+	for idx < len(times) && !times[idx].After(cutoff) {
+		idx++
+	}
+	if idx == 0 {
+		return times
+	}
+	return append(times[:0], times[idx:]...)
+}
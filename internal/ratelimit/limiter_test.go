@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func newTestLimiter(now *time.Time) *Limiter {
+	l := NewLimiter()
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+func TestLimiterAcquire_RequestsPerMinute(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := newTestLimiter(&now)
+	policy := &config.APIKeyPolicy{APIKey: "k", RequestsPerMinute: 2}
+
+	for i := 0; i < 2; i++ {
+		if _, err := l.Acquire("k", policy, false); err != nil {
+			t.Fatalf("Acquire() #%d error = %v", i, err)
+		}
+	}
+	_, err := l.Acquire("k", policy, false)
+	if err == nil || err.Limit != "requests-per-minute" {
+		t.Fatalf("Acquire() error = %v, want requests-per-minute", err)
+	}
+	if err.RetryAfter != time.Minute {
+		t.Fatalf("RetryAfter = %v, want %v", err.RetryAfter, time.Minute)
+	}
+
+	now = now.Add(time.Minute + time.Second)
+	if _, err = l.Acquire("k", policy, false); err != nil {
+		t.Fatalf("Acquire() after window error = %v", err)
+	}
+}
+
+func TestLimiterAcquire_ConcurrentStreams(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := newTestLimiter(&now)
+	policy := &config.APIKeyPolicy{APIKey: "k", MaxConcurrentStreams: 1}
+
+	release, err := l.Acquire("k", policy, true)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if _, err = l.Acquire("k", policy, true); err == nil || err.Limit != "max-concurrent-streams" {
+		t.Fatalf("Acquire() second stream error = %v, want max-concurrent-streams", err)
+	}
+	if _, err = l.Acquire("k", policy, false); err != nil {
+		t.Fatalf("Acquire() non-stream error = %v", err)
+	}
+	release()
+	release()
+	if _, err = l.Acquire("k", policy, true); err != nil {
+		t.Fatalf("Acquire() after release error = %v", err)
+	}
+}
+
+func TestLimiterAcquire_TokenBudgetsRollOver(t *testing.T) {
+	now := time.Date(2025, 1, 31, 23, 0, 0, 0, time.UTC)
+	l := newTestLimiter(&now)
+	policy := &config.APIKeyPolicy{APIKey: "k", OutputTokensPerDay: 100, InputTokensPerMonth: 1000}
+
+	l.HandleUsage(context.Background(), coreusage.Record{APIKey: "k", RequestedAt: now, Detail: coreusage.Detail{InputTokens: 10, OutputTokens: 60, TotalTokens: 110}})
+	_, err := l.Acquire("k", policy, false)
+	if err == nil || err.Limit != "output-tokens-per-day" {
+		t.Fatalf("Acquire() error = %v, want output-tokens-per-day", err)
+	}
+	if err.RetryAfter != time.Hour {
+		t.Fatalf("RetryAfter = %v, want %v", err.RetryAfter, time.Hour)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err = l.Acquire("k", policy, false); err != nil {
+		t.Fatalf("Acquire() next day error = %v", err)
+	}
+}
@@ -53,6 +53,44 @@ type ModelInfo struct {
 	Thinking *ThinkingSupport `json:"thinking,omitempty"`
 }
 
+// EmbeddingGenerationMethods are the Gemini method names that mark a model as an embedding model.
+var EmbeddingGenerationMethods = []string{"embedContent", "batchEmbedContents"}
+
+// SupportsEmbeddings reports whether the model serves embedding requests.
+func (m *ModelInfo) SupportsEmbeddings() bool {
+	if m == nil {
+		return false
+	}
+	for _, method := range m.SupportedGenerationMethods {
+		if isEmbeddingMethod(method) {
+			return true
+		}
+	}
+	return false
+}
+
+// EmbeddingOnly reports whether the model serves embeddings and nothing else.
+func (m *ModelInfo) EmbeddingOnly() bool {
+	if !m.SupportsEmbeddings() {
+		return false
+	}
+	for _, method := range m.SupportedGenerationMethods {
+		if !isEmbeddingMethod(method) {
+			return false
+		}
+	}
+	return true
+}
+
+func isEmbeddingMethod(method string) bool {
+	for _, embedding := range EmbeddingGenerationMethods {
+		if method == embedding {
+			return true
+		}
+	}
+	return false
+}
+
 // ThinkingSupport describes a model family's supported internal reasoning budget range.
 // Values are interpreted in provider-native token units.
 type ThinkingSupport struct {
@@ -649,7 +687,9 @@ func (r *ModelRegistry) ClientSupportsModel(clientID, modelID string) bool {
 	return false
 }
 
-// GetAvailableModels returns all models that have at least one available client
+// GetAvailableModels returns all models that have at least one available client.
+// Embedding-only models are listed for the "gemini" handler only, where
+// supportedGenerationMethods tells clients they cannot be used for chat.
 // Parameters:
 //   - handlerType: The handler type to filter models for (e.g., "openai", "claude", "gemini")
 //
@@ -663,6 +703,10 @@ func (r *ModelRegistry) GetAvailableModels(handlerType string) []map[string]any
 	quotaExpiredDuration := 5 * time.Minute
 
 	for _, registration := range r.models {
+		if handlerType != "gemini" && registration.Info.EmbeddingOnly() {
+			continue
+		}
+
 		// Check if model has any non-quota-exceeded clients
 		availableClients := registration.Count
 		now := time.Now()
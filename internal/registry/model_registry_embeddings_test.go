@@ -0,0 +1,47 @@
+package registry
+
+import "testing"
+
+func TestGetAvailableModels_EmbeddingOnlyListedForGeminiOnly(t *testing.T) {
+	r := newTestModelRegistry()
+	r.RegisterClient("client-1", "gemini", []*ModelInfo{
+		{ID: "chat-model", SupportedGenerationMethods: []string{"generateContent"}},
+		{ID: "embed-model", SupportedGenerationMethods: EmbeddingGenerationMethods},
+	})
+
+	listed := func(handlerType string) map[string]bool {
+		ids := make(map[string]bool)
+		for _, model := range r.GetAvailableModels(handlerType) {
+			if id, ok := model["id"].(string); ok {
+				ids[id] = true
+			} else if name, ok := model["name"].(string); ok {
+				ids[name] = true
+			}
+		}
+		return ids
+	}
+
+	for _, handlerType := range []string{"openai", "claude"} {
+		ids := listed(handlerType)
+		if !ids["chat-model"] || ids["embed-model"] {
+			t.Fatalf("%s models = %v, want chat-model only", handlerType, ids)
+		}
+	}
+	if ids := listed("gemini"); !ids["chat-model"] || !ids["embed-model"] {
+		t.Fatalf("gemini models = %v, want both models", ids)
+	}
+}
+
+func TestStaticModels_EmbeddingOnlyForEmbeddingProviders(t *testing.T) {
+	// These providers have no embeddings executor, so they must not advertise embedding models.
+	for name, models := range map[string][]*ModelInfo{
+		"aistudio":   GetAIStudioModels(),
+		"gemini-cli": GetGeminiCLIModels(),
+	} {
+		for _, model := range models {
+			if model.SupportsEmbeddings() {
+				t.Fatalf("%s lists embedding model %s", name, model.ID)
+			}
+		}
+	}
+}
@@ -0,0 +1,354 @@
+// Package responsecache stores completed responses so repeated identical requests can be
+// answered without contacting an upstream provider. Entries are keyed by the normalized
+// request payload, the resolved model, the source format and optionally the client API key,
+// and are kept in memory or on disk with a TTL and LRU size bounds. Cached non-streaming
+// responses can be replayed to streaming callers as synthetic SSE chunks.
+package responsecache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	defaultTTL        = time.Hour
+	defaultMaxEntries = 1000
+
+	backendMemory = "memory"
+	backendDisk   = "disk"
+)
+
+// Header is the request header clients use to control caching and the response header
+// reporting the cache outcome.
+const Header = "X-CLIProxy-Cache"
+
+// Request header directives.
+const (
+	// DirectiveBypass skips the cache entirely.
+	DirectiveBypass = "bypass"
+	// DirectiveRefresh ignores a cached entry but stores the fresh response.
+	DirectiveRefresh = "refresh"
+	// DirectiveNoStore reads from the cache but never stores the response.
+	DirectiveNoStore = "no-store"
+)
+
+// Response header outcomes.
+const (
+	StatusHit    = "HIT"
+	StatusMiss   = "MISS"
+	StatusBypass = "BYPASS"
+)
+
+// Entry is a cached response in the caller's format.
+type Entry struct {
+	// Body is the complete non-streaming response.
+	Body []byte `json:"body,omitempty"`
+	// Chunks are the stream chunks recorded from a streaming response, as sent to the caller.
+	Chunks [][]byte `json:"chunks,omitempty"`
+	// HandlerType is the source format of the request that produced the entry.
+	HandlerType string `json:"handler_type"`
+	// Model is the resolved model name.
+	Model string `json:"model"`
+	// CreatedAt is when the entry was stored.
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt is when the entry stops being served.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (e *Entry) size() int64 {
+	if e == nil {
+		return 0
+	}
+	n := int64(len(e.Body))
+	for _, chunk := range e.Chunks {
+		n += int64(len(chunk))
+	}
+	return n
+}
+
+// Backend stores entries. Implementations enforce TTLs and capacity on their own and must
+// be safe for concurrent use.
+type Backend interface {
+	Get(key string, now time.Time) (*Entry, bool)
+	Put(key string, entry *Entry)
+	Purge() int
+	Len() int
+	Size() int64
+}
+
+// Stats summarizes cache activity since startup.
+type Stats struct {
+	Enabled bool   `json:"enabled"`
+	Backend string `json:"backend"`
+	Entries int    `json:"entries"`
+	Bytes   int64  `json:"bytes"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Stores  uint64 `json:"stores"`
+}
+
+var defaultCache = New()
+
+// Default returns the process-wide cache used by the API handlers.
+func Default() *Cache { return defaultCache }
+
+// Cache is a configurable response cache. The zero configuration is disabled.
+type Cache struct {
+	mu      sync.RWMutex
+	cfg     config.ResponseCacheConfig
+	backend Backend
+	name    string
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	stores atomic.Uint64
+
+	now func() time.Time
+}
+
+// New constructs a disabled cache; call Apply to configure it.
+func New() *Cache {
+	return &Cache{now: time.Now}
+}
+
+// Apply reconfigures the cache. The backend is rebuilt only when its kind, directory or
+// capacity changes; rebuilding a memory backend drops its entries.
+func (c *Cache) Apply(cfg config.ResponseCacheConfig) {
+	if c == nil {
+		return
+	}
+	c.mu.RLock()
+	unchanged := c.cfg == cfg && (c.backend != nil) == cfg.Enable
+	c.mu.RUnlock()
+	if unchanged {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	previous := c.cfg
+	c.cfg = cfg
+	if !cfg.Enable {
+		c.backend = nil
+		c.name = ""
+		return
+	}
+	if c.backend != nil && previous.Enable &&
+		previous.Backend == cfg.Backend && previous.Dir == cfg.Dir &&
+		previous.MaxEntries == cfg.MaxEntries && previous.MaxSizeMB == cfg.MaxSizeMB {
+		return
+	}
+
+	maxEntries := cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntries
+	}
+	var maxBytes int64
+	if cfg.MaxSizeMB > 0 {
+		maxBytes = int64(cfg.MaxSizeMB) << 20
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.Backend)) {
+	case backendDisk:
+		dir := strings.TrimSpace(cfg.Dir)
+		if dir == "" {
+			dir = defaultDir()
+		}
+		backend, err := NewDiskBackend(dir, maxEntries, maxBytes)
+		if err != nil {
+			log.Errorf("response cache: %v; falling back to memory", err)
+			c.backend = NewMemoryBackend(maxEntries, maxBytes)
+			c.name = backendMemory
+			return
+		}
+		c.backend = backend
+		c.name = backendDisk
+	default:
+		c.backend = NewMemoryBackend(maxEntries, maxBytes)
+		c.name = backendMemory
+	}
+	log.Infof("response cache enabled (backend=%s, max-entries=%d)", c.name, maxEntries)
+}
+
+// Enabled reports whether the cache is configured to serve entries.
+func (c *Cache) Enabled() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.backend != nil
+}
+
+// Config returns the active configuration.
+func (c *Cache) Config() config.ResponseCacheConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cfg
+}
+
+// Cacheable reports whether a request payload may be cached. Unless non-deterministic
+// requests are included, only requests pinning temperature to 0 qualify.
+func (c *Cache) Cacheable(payload []byte) bool {
+	if !c.Enabled() || !gjson.ValidBytes(payload) {
+		return false
+	}
+	if c.Config().IncludeNonDeterministic {
+		return true
+	}
+	for _, path := range []string{"temperature", "generationConfig.temperature", "request.generationConfig.temperature"} {
+		if value := gjson.GetBytes(payload, path); value.Exists() {
+			return value.Type == gjson.Number && value.Float() == 0
+		}
+	}
+	return false
+}
+
+// Key derives the cache key for a request. apiKey is ignored when entries are shared
+// across client keys.
+func (c *Cache) Key(handlerType, model, alt, apiKey string, payload []byte) (string, bool) {
+	if c.Config().SharedAcrossKeys {
+		apiKey = ""
+	}
+	normalized, ok := normalizePayload(payload)
+	if !ok {
+		return "", false
+	}
+	sum := sha256.New()
+	for _, part := range []string{handlerType, model, alt, apiKey} {
+		sum.Write([]byte(part))
+		sum.Write([]byte{0})
+	}
+	sum.Write(normalized)
+	return hex.EncodeToString(sum.Sum(nil)), true
+}
+
+// Lookup returns a live entry for key that can answer the request and records the hit or
+// miss. Non-streaming requests need a stored body; streaming requests can also be served
+// from a body through synthetic chunks.
+func (c *Cache) Lookup(key string, stream bool) (*Entry, bool) {
+	c.mu.RLock()
+	backend := c.backend
+	c.mu.RUnlock()
+	if backend == nil || key == "" {
+		return nil, false
+	}
+	entry, ok := backend.Get(key, c.now())
+	if !ok || (!stream && len(entry.Body) == 0) {
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.hits.Add(1)
+	return entry, true
+}
+
+// Put stores entry under key. A stored body and recorded chunks for the same key are
+// merged so streaming and non-streaming callers can share one entry.
+func (c *Cache) Put(key string, entry *Entry) {
+	c.mu.RLock()
+	backend := c.backend
+	ttl := time.Duration(c.cfg.TTLSeconds) * time.Second
+	c.mu.RUnlock()
+	if backend == nil || key == "" || entry == nil || (len(entry.Body) == 0 && len(entry.Chunks) == 0) {
+		return
+	}
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	now := c.now()
+	if existing, ok := backend.Get(key, now); ok {
+		if len(entry.Body) == 0 {
+			entry.Body = existing.Body
+		}
+		if len(entry.Chunks) == 0 {
+			entry.Chunks = existing.Chunks
+		}
+	}
+	entry.CreatedAt = now
+	entry.ExpiresAt = now.Add(ttl)
+	backend.Put(key, entry)
+	c.stores.Add(1)
+}
+
+// Purge removes every entry and returns how many were dropped.
+func (c *Cache) Purge() int {
+	c.mu.RLock()
+	backend := c.backend
+	c.mu.RUnlock()
+	if backend == nil {
+		return 0
+	}
+	return backend.Purge()
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *Cache) Stats() Stats {
+	c.mu.RLock()
+	backend := c.backend
+	name := c.name
+	c.mu.RUnlock()
+	stats := Stats{
+		Enabled: backend != nil,
+		Backend: name,
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Stores:  c.stores.Load(),
+	}
+	if backend != nil {
+		stats.Entries = backend.Len()
+		stats.Bytes = backend.Size()
+	}
+	return stats
+}
+
+// ParseDirective normalizes the request header value.
+func ParseDirective(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case DirectiveBypass:
+		return DirectiveBypass
+	case DirectiveRefresh, "no-cache":
+		return DirectiveRefresh
+	case DirectiveNoStore:
+		return DirectiveNoStore
+	default:
+		return ""
+	}
+}
+
+// normalizePayload re-encodes payload with sorted keys and without fields that only select
+// the delivery mode, so streaming and non-streaming requests share a key.
+func normalizePayload(payload []byte) ([]byte, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false
+	}
+	if obj, ok := value.(map[string]any); ok {
+		delete(obj, "stream")
+		delete(obj, "stream_options")
+	}
+	out, err := json.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+func defaultDir() string {
+	if base := util.WritablePath(); base != "" {
+		return filepath.Join(base, "response-cache")
+	}
+	return "response-cache"
+}
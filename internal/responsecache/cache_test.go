@@ -0,0 +1,141 @@
+package responsecache
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestKeyIgnoresStreamFlagAndFieldOrder(t *testing.T) {
+	c := New()
+	c.Apply(config.ResponseCacheConfig{Enable: true})
+
+	a, okA := c.Key("openai", "gpt-x", "", "k1", []byte(`{"model":"gpt-x","temperature":0,"stream":true,"messages":[{"role":"user","content":"hi"}]}`))
+	b, okB := c.Key("openai", "gpt-x", "", "k1", []byte(`{"messages":[{"content":"hi","role":"user"}],"temperature":0,"model":"gpt-x"}`))
+	if !okA || !okB || a != b {
+		t.Fatalf("expected equal keys, got %q and %q", a, b)
+	}
+	other, _ := c.Key("openai", "gpt-x", "", "k2", []byte(`{"messages":[{"content":"hi","role":"user"}],"temperature":0,"model":"gpt-x"}`))
+	if other == a {
+		t.Fatalf("keys must differ per client API key unless shared")
+	}
+	c.Apply(config.ResponseCacheConfig{Enable: true, SharedAcrossKeys: true})
+	shared1, _ := c.Key("openai", "gpt-x", "", "k1", []byte(`{"temperature":0}`))
+	shared2, _ := c.Key("openai", "gpt-x", "", "k2", []byte(`{"temperature":0}`))
+	if shared1 != shared2 {
+		t.Fatalf("shared keys differ")
+	}
+}
+
+func TestCacheableRequiresZeroTemperature(t *testing.T) {
+	c := New()
+	c.Apply(config.ResponseCacheConfig{Enable: true})
+	cases := map[string]bool{
+		`{"temperature":0}`:                      true,
+		`{"temperature":0.7}`:                    false,
+		`{}`:                                     false,
+		`{"generationConfig":{"temperature":0}}`: true,
+		`{"request":{"generationConfig":{"temperature":0.0}}}`: true,
+	}
+	for payload, want := range cases {
+		if got := c.Cacheable([]byte(payload)); got != want {
+			t.Errorf("Cacheable(%s) = %v, want %v", payload, got, want)
+		}
+	}
+	c.Apply(config.ResponseCacheConfig{Enable: true, IncludeNonDeterministic: true})
+	if !c.Cacheable([]byte(`{"temperature":1}`)) {
+		t.Fatalf("include-nondeterministic should cache any request")
+	}
+}
+
+func TestMemoryBackendTTLAndEviction(t *testing.T) {
+	c := New()
+	c.Apply(config.ResponseCacheConfig{Enable: true, TTLSeconds: 60, MaxEntries: 2})
+	now := time.Unix(1_700_000_000, 0)
+	c.now = func() time.Time { return now }
+
+	c.Put("a", &Entry{Body: []byte(`{"a":1}`)})
+	c.Put("b", &Entry{Body: []byte(`{"b":1}`)})
+	if _, ok := c.Lookup("a", false); !ok {
+		t.Fatalf("expected hit for a")
+	}
+	c.Put("c", &Entry{Body: []byte(`{"c":1}`)})
+	if _, ok := c.Lookup("b", false); ok {
+		t.Fatalf("least recently used entry b should have been evicted")
+	}
+
+	c.Put("s", &Entry{Chunks: [][]byte{[]byte(`{"x":1}`)}})
+	if _, ok := c.Lookup("s", false); ok {
+		t.Fatalf("stream-only entry must not answer non-streaming requests")
+	}
+	if _, ok := c.Lookup("s", true); !ok {
+		t.Fatalf("stream-only entry should answer streaming requests")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.Lookup("s", true); ok {
+		t.Fatalf("entry should have expired")
+	}
+}
+
+func TestDiskBackendSurvivesReopen(t *testing.T) {
+	dir := t.TempDir()
+	first, err := NewDiskBackend(dir, 10, 0)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	first.Put("k", &Entry{Body: []byte(`{"ok":true}`), ExpiresAt: time.Now().Add(time.Hour)})
+
+	second, err := NewDiskBackend(dir, 10, 0)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	entry, ok := second.Get("k", time.Now())
+	if !ok || string(entry.Body) != `{"ok":true}` {
+		t.Fatalf("entry not restored: %v %+v", ok, entry)
+	}
+	if n := second.Purge(); n != 1 || second.Len() != 0 {
+		t.Fatalf("purge removed %d entries, %d left", n, second.Len())
+	}
+}
+
+func TestSynthesizeClaude(t *testing.T) {
+	body := []byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-x","content":[{"type":"text","text":"hello"},{"type":"tool_use","id":"t1","name":"f","input":{"a":1}}],"stop_reason":"tool_use","stop_sequence":null,"usage":{"input_tokens":3,"output_tokens":5}}`)
+	chunks := Synthesize("claude", body)
+	var events []string
+	for _, chunk := range chunks {
+		frame := string(chunk)
+		if !strings.HasSuffix(frame, "\n\n") {
+			t.Fatalf("frame not terminated: %q", frame)
+		}
+		events = append(events, strings.TrimPrefix(strings.SplitN(frame, "\n", 2)[0], "event: "))
+	}
+	want := "message_start,content_block_start,content_block_delta,content_block_stop,content_block_start,content_block_delta,content_block_stop,message_delta,message_stop"
+	if got := strings.Join(events, ","); got != want {
+		t.Fatalf("events = %s", got)
+	}
+	toolDelta := strings.TrimPrefix(strings.SplitN(string(chunks[5]), "\n", 2)[1], "data: ")
+	if got := gjson.Get(toolDelta, "delta.partial_json").String(); got != `{"a":1}` {
+		t.Fatalf("partial_json = %s", got)
+	}
+}
+
+func TestSynthesizeOpenAIChat(t *testing.T) {
+	body := []byte(`{"id":"c1","object":"chat.completion","created":1,"model":"gpt-x","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],"usage":{"total_tokens":4}}`)
+	chunks := Synthesize("openai", body)
+	if len(chunks) != 2 {
+		t.Fatalf("chunks = %d, want 2", len(chunks))
+	}
+	if got := gjson.GetBytes(chunks[0], "choices.0.delta.content").String(); got != "hi" {
+		t.Fatalf("delta content = %q", got)
+	}
+	if got := gjson.GetBytes(chunks[1], "choices.0.finish_reason").String(); got != "stop" {
+		t.Fatalf("finish_reason = %q", got)
+	}
+	if gjson.GetBytes(chunks[1], "usage.total_tokens").Int() != 4 {
+		t.Fatalf("usage missing from final chunk")
+	}
+}
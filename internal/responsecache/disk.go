@@ -0,0 +1,165 @@
+package responsecache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const diskEntrySuffix = ".json"
+
+// DiskBackend stores one JSON file per entry in a directory. Entry metadata is indexed in
+// memory so capacity can be enforced without rescanning the directory; existing files are
+// loaded on startup so the cache survives restarts.
+type DiskBackend struct {
+	mu    sync.Mutex
+	dir   string
+	index *lruIndex
+}
+
+// NewDiskBackend opens dir, creating it when missing, and indexes the entries it contains.
+// Expired or unreadable files are removed.
+func NewDiskBackend(dir string, maxEntries int, maxBytes int64) (*DiskBackend, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create cache directory: %w", err)
+	}
+	b := &DiskBackend{dir: dir, index: newLRUIndex(maxEntries, maxBytes)}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read cache directory: %w", err)
+	}
+	now := time.Now()
+	loaded := make([]*lruItem, 0, len(files))
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, diskEntrySuffix) {
+			continue
+		}
+		key := strings.TrimSuffix(name, diskEntrySuffix)
+		entry, errRead := b.read(key)
+		if errRead != nil || !entry.ExpiresAt.After(now) {
+			_ = os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		loaded = append(loaded, &lruItem{key: key, size: entry.size(), expiresAt: entry.ExpiresAt, entry: &Entry{CreatedAt: entry.CreatedAt}})
+	}
+	sort.Slice(loaded, func(i, j int) bool { return loaded[i].entry.CreatedAt.Before(loaded[j].entry.CreatedAt) })
+	for _, item := range loaded {
+		item.entry = nil
+		b.removeFiles(b.index.add(item))
+	}
+	return b, nil
+}
+
+// Get implements Backend.
+func (b *DiskBackend) Get(key string, now time.Time) (*Entry, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	item, expired := b.index.get(key, now)
+	if expired {
+		b.removeFiles([]string{key})
+	}
+	if item == nil {
+		return nil, false
+	}
+	entry, err := b.read(key)
+	if err != nil {
+		log.Debugf("response cache: read entry %s: %v", key, err)
+		b.index.remove(key)
+		b.removeFiles([]string{key})
+		return nil, false
+	}
+	return entry, true
+}
+
+// Put implements Backend.
+func (b *DiskBackend) Put(key string, entry *Entry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Debugf("response cache: encode entry: %v", err)
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	tmp, err := os.CreateTemp(b.dir, key+".tmp-*")
+	if err != nil {
+		log.Warnf("response cache: write entry: %v", err)
+		return
+	}
+	_, errWrite := tmp.Write(data)
+	errClose := tmp.Close()
+	if errWrite != nil || errClose != nil {
+		_ = os.Remove(tmp.Name())
+		log.Warnf("response cache: write entry: %v", firstError(errWrite, errClose))
+		return
+	}
+	if err = os.Rename(tmp.Name(), b.path(key)); err != nil {
+		_ = os.Remove(tmp.Name())
+		log.Warnf("response cache: write entry: %v", err)
+		return
+	}
+	b.removeFiles(b.index.add(&lruItem{key: key, size: entry.size(), expiresAt: entry.ExpiresAt}))
+}
+
+// Purge implements Backend.
+func (b *DiskBackend) Purge() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.removeFiles(b.index.keys())
+	return b.index.reset()
+}
+
+// Len implements Backend.
+func (b *DiskBackend) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.index.order.Len()
+}
+
+// Size implements Backend.
+func (b *DiskBackend) Size() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.index.bytes
+}
+
+func (b *DiskBackend) path(key string) string {
+	return filepath.Join(b.dir, key+diskEntrySuffix)
+}
+
+func (b *DiskBackend) read(key string) (*Entry, error) {
+	data, err := os.ReadFile(b.path(key))
+	if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err = json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (b *DiskBackend) removeFiles(keys []string) {
+	for _, key := range keys {
+		if err := os.Remove(b.path(key)); err != nil && !os.IsNotExist(err) {
+			log.Debugf("response cache: remove entry %s: %v", key, err)
+		}
+	}
+}
+
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package responsecache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lruIndex tracks entry sizes and expiry in least-recently-used order. It is not safe for
+// concurrent use; backends guard it with their own lock.
+type lruIndex struct {
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
+	order      *list.List
+	items      map[string]*list.Element
+}
+
+type lruItem struct {
+	key       string
+	size      int64
+	expiresAt time.Time
+	entry     *Entry
+}
+
+func newLRUIndex(maxEntries int, maxBytes int64) *lruIndex {
+	return &lruIndex{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// get returns the live item for key, marking it as recently used. Expired items are removed
+// and reported through expired.
+func (l *lruIndex) get(key string, now time.Time) (item *lruItem, expired bool) {
+	elem, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	item = elem.Value.(*lruItem)
+	if !item.expiresAt.After(now) {
+		l.removeElement(elem)
+		return nil, true
+	}
+	l.order.MoveToFront(elem)
+	return item, false
+}
+
+// add inserts or replaces item and returns the keys evicted to stay within capacity.
+func (l *lruIndex) add(item *lruItem) []string {
+	if elem, ok := l.items[item.key]; ok {
+		l.removeElement(elem)
+	}
+	l.items[item.key] = l.order.PushFront(item)
+	l.bytes += item.size
+
+	var evicted []string
+	for l.order.Len() > 1 && (l.order.Len() > l.maxEntries || (l.maxBytes > 0 && l.bytes > l.maxBytes)) {
+		oldest := l.order.Back()
+		evicted = append(evicted, oldest.Value.(*lruItem).key)
+		l.removeElement(oldest)
+	}
+	return evicted
+}
+
+func (l *lruIndex) remove(key string) bool {
+	elem, ok := l.items[key]
+	if !ok {
+		return false
+	}
+	l.removeElement(elem)
+	return true
+}
+
+func (l *lruIndex) removeElement(elem *list.Element) {
+	item := elem.Value.(*lruItem)
+	l.order.Remove(elem)
+	delete(l.items, item.key)
+	l.bytes -= item.size
+}
+
+func (l *lruIndex) keys() []string {
+	out := make([]string, 0, len(l.items))
+	for key := range l.items {
+		out = append(out, key)
+	}
+	return out
+}
+
+func (l *lruIndex) reset() int {
+	n := l.order.Len()
+	l.order.Init()
+	l.items = make(map[string]*list.Element)
+	l.bytes = 0
+	return n
+}
+
+// MemoryBackend keeps entries in process memory.
+type MemoryBackend struct {
+	mu    sync.Mutex
+	index *lruIndex
+}
+
+// NewMemoryBackend creates an in-memory backend bounded by maxEntries and, when positive,
+// maxBytes.
+func NewMemoryBackend(maxEntries int, maxBytes int64) *MemoryBackend {
+	return &MemoryBackend{index: newLRUIndex(maxEntries, maxBytes)}
+}
+
+// Get implements Backend.
+func (b *MemoryBackend) Get(key string, now time.Time) (*Entry, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	item, _ := b.index.get(key, now)
+	if item == nil {
+		return nil, false
+	}
+	return item.entry, true
+}
+
+// Put implements Backend.
+func (b *MemoryBackend) Put(key string, entry *Entry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.index.add(&lruItem{key: key, size: entry.size(), expiresAt: entry.ExpiresAt, entry: entry})
+}
+
+// Purge implements Backend.
+func (b *MemoryBackend) Purge() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.index.reset()
+}
+
+// Len implements Backend.
+func (b *MemoryBackend) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.index.order.Len()
+}
+
+// Size implements Backend.
+func (b *MemoryBackend) Size() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.index.bytes
+}
@@ -0,0 +1,239 @@
+package responsecache
+
+import (
+	"fmt"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// StreamChunks returns the stream chunks that replay entry to a streaming caller of handlerType.
+// Recorded chunks are returned as-is; otherwise the stored body is converted into synthetic
+// chunks framed the way the handler's stream forwarder expects.
+func (e *Entry) StreamChunks(handlerType string) [][]byte {
+	if e == nil {
+		return nil
+	}
+	if len(e.Chunks) > 0 {
+		return e.Chunks
+	}
+	if len(e.Body) == 0 {
+		return nil
+	}
+	return Synthesize(handlerType, e.Body)
+}
+
+// Synthesize converts a complete non-streaming response body into stream chunks:
+//   - "openai": chat.completion.chunk JSON objects;
+//   - "claude": complete "event: ...\ndata: ...\n\n" SSE frames;
+//   - "openai-response": "event: ...\ndata: ..." frames without the trailing blank line;
+//   - "gemini" and "gemini-cli": the body itself, which is a valid single stream chunk.
+func Synthesize(handlerType string, body []byte) [][]byte {
+	if !gjson.ValidBytes(body) {
+		return nil
+	}
+	switch handlerType {
+	case "openai":
+		return synthesizeOpenAIChat(body)
+	case "claude":
+		return synthesizeClaude(body)
+	case "openai-response":
+		return synthesizeResponses(body)
+	default:
+		return [][]byte{append([]byte(nil), body...)}
+	}
+}
+
+func synthesizeOpenAIChat(body []byte) [][]byte {
+	root := gjson.ParseBytes(body)
+	base := []byte(`{"object":"chat.completion.chunk","choices":[]}`)
+	base, _ = sjson.SetBytes(base, "id", root.Get("id").String())
+	base, _ = sjson.SetBytes(base, "created", root.Get("created").Int())
+	base, _ = sjson.SetBytes(base, "model", root.Get("model").String())
+	if fp := root.Get("system_fingerprint"); fp.Exists() {
+		base, _ = sjson.SetRawBytes(base, "system_fingerprint", []byte(fp.Raw))
+	}
+
+	var chunks [][]byte
+	choices := root.Get("choices").Array()
+	for _, choice := range choices {
+		index := choice.Get("index").Int()
+		message := choice.Get("message")
+		delta := []byte(`{"role":"assistant"}`)
+		for _, field := range []string{"reasoning_content", "content", "refusal"} {
+			if value := message.Get(field); value.Exists() && value.Type != gjson.Null {
+				delta, _ = sjson.SetRawBytes(delta, field, []byte(value.Raw))
+			}
+		}
+		for i, call := range message.Get("tool_calls").Array() {
+			raw, _ := sjson.SetBytes([]byte(call.Raw), "index", i)
+			delta, _ = sjson.SetRawBytes(delta, "tool_calls.-1", raw)
+		}
+		chunk := []byte(`{"index":0,"delta":{}}`)
+		chunk, _ = sjson.SetBytes(chunk, "index", index)
+		chunk, _ = sjson.SetRawBytes(chunk, "delta", delta)
+		out, _ := sjson.SetRawBytes(base, "choices.-1", chunk)
+		chunks = append(chunks, out)
+	}
+
+	final := base
+	for _, choice := range choices {
+		done := []byte(`{"index":0,"delta":{}}`)
+		done, _ = sjson.SetBytes(done, "index", choice.Get("index").Int())
+		done, _ = sjson.SetBytes(done, "finish_reason", choice.Get("finish_reason").String())
+		final, _ = sjson.SetRawBytes(final, "choices.-1", done)
+	}
+	if usage := root.Get("usage"); usage.Exists() {
+		final, _ = sjson.SetRawBytes(final, "usage", []byte(usage.Raw))
+	}
+	return append(chunks, final)
+}
+
+func synthesizeClaude(body []byte) [][]byte {
+	root := gjson.ParseBytes(body)
+	var chunks [][]byte
+	emit := func(event string, data []byte) {
+		chunks = append(chunks, []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", event, data)))
+	}
+
+	message, _ := sjson.DeleteBytes(body, "content")
+	message, _ = sjson.SetRawBytes(message, "content", []byte(`[]`))
+	message, _ = sjson.SetRawBytes(message, "stop_reason", []byte(`null`))
+	message, _ = sjson.SetRawBytes(message, "stop_sequence", []byte(`null`))
+	message, _ = sjson.SetBytes(message, "usage.output_tokens", 0)
+	start, _ := sjson.SetRawBytes([]byte(`{"type":"message_start"}`), "message", message)
+	emit("message_start", start)
+
+	for index, block := range root.Get("content").Array() {
+		blockStart := []byte(`{"type":"content_block_start"}`)
+		blockStart, _ = sjson.SetBytes(blockStart, "index", index)
+		var deltas [][]byte
+		switch block.Get("type").String() {
+		case "text":
+			blockStart, _ = sjson.SetRawBytes(blockStart, "content_block", []byte(`{"type":"text","text":""}`))
+			delta, _ := sjson.SetBytes([]byte(`{"type":"text_delta"}`), "text", block.Get("text").String())
+			deltas = append(deltas, delta)
+		case "thinking":
+			blockStart, _ = sjson.SetRawBytes(blockStart, "content_block", []byte(`{"type":"thinking","thinking":""}`))
+			delta, _ := sjson.SetBytes([]byte(`{"type":"thinking_delta"}`), "thinking", block.Get("thinking").String())
+			deltas = append(deltas, delta)
+			if signature := block.Get("signature").String(); signature != "" {
+				sig, _ := sjson.SetBytes([]byte(`{"type":"signature_delta"}`), "signature", signature)
+				deltas = append(deltas, sig)
+			}
+		case "tool_use":
+			contentBlock, _ := sjson.SetRawBytes([]byte(block.Raw), "input", []byte(`{}`))
+			blockStart, _ = sjson.SetRawBytes(blockStart, "content_block", contentBlock)
+			input := block.Get("input").Raw
+			if input == "" {
+				input = "{}"
+			}
+			delta, _ := sjson.SetBytes([]byte(`{"type":"input_json_delta"}`), "partial_json", input)
+			deltas = append(deltas, delta)
+		default:
+			blockStart, _ = sjson.SetRawBytes(blockStart, "content_block", []byte(block.Raw))
+		}
+		emit("content_block_start", blockStart)
+		for _, delta := range deltas {
+			event, _ := sjson.SetBytes([]byte(`{"type":"content_block_delta"}`), "index", index)
+			event, _ = sjson.SetRawBytes(event, "delta", delta)
+			emit("content_block_delta", event)
+		}
+		stop, _ := sjson.SetBytes([]byte(`{"type":"content_block_stop"}`), "index", index)
+		emit("content_block_stop", stop)
+	}
+
+	messageDelta := []byte(`{"type":"message_delta","delta":{}}`)
+	messageDelta, _ = sjson.SetRawBytes(messageDelta, "delta.stop_reason", []byte(rawOrNull(root.Get("stop_reason"))))
+	messageDelta, _ = sjson.SetRawBytes(messageDelta, "delta.stop_sequence", []byte(rawOrNull(root.Get("stop_sequence"))))
+	if usage := root.Get("usage"); usage.Exists() {
+		messageDelta, _ = sjson.SetRawBytes(messageDelta, "usage", []byte(usage.Raw))
+	}
+	emit("message_delta", messageDelta)
+	emit("message_stop", []byte(`{"type":"message_stop"}`))
+	return chunks
+}
+
+func synthesizeResponses(body []byte) [][]byte {
+	root := gjson.ParseBytes(body)
+	var chunks [][]byte
+	sequence := 0
+	emit := func(event string, data []byte) {
+		data, _ = sjson.SetBytes(data, "type", event)
+		data, _ = sjson.SetBytes(data, "sequence_number", sequence)
+		sequence++
+		chunks = append(chunks, []byte(fmt.Sprintf("event: %s\ndata: %s", event, data)))
+	}
+
+	pending, _ := sjson.SetBytes(body, "status", "in_progress")
+	pending, _ = sjson.SetRawBytes(pending, "output", []byte(`[]`))
+	pending, _ = sjson.DeleteBytes(pending, "usage")
+	created, _ := sjson.SetRawBytes([]byte(`{}`), "response", pending)
+	emit("response.created", created)
+	emit("response.in_progress", created)
+
+	for outputIndex, item := range root.Get("output").Array() {
+		itemID := item.Get("id").String()
+		addedItem, _ := sjson.SetBytes([]byte(item.Raw), "status", "in_progress")
+		switch item.Get("type").String() {
+		case "message":
+			addedItem, _ = sjson.SetRawBytes(addedItem, "content", []byte(`[]`))
+		case "function_call":
+			addedItem, _ = sjson.SetBytes(addedItem, "arguments", "")
+		}
+		added, _ := sjson.SetBytes([]byte(`{}`), "output_index", outputIndex)
+		added, _ = sjson.SetRawBytes(added, "item", addedItem)
+		emit("response.output_item.added", added)
+
+		switch item.Get("type").String() {
+		case "message":
+			for contentIndex, part := range item.Get("content").Array() {
+				partEvent := func() []byte {
+					event, _ := sjson.SetBytes([]byte(`{}`), "item_id", itemID)
+					event, _ = sjson.SetBytes(event, "output_index", outputIndex)
+					event, _ = sjson.SetBytes(event, "content_index", contentIndex)
+					return event
+				}
+				if part.Get("type").String() != "output_text" {
+					event, _ := sjson.SetRawBytes(partEvent(), "part", []byte(part.Raw))
+					emit("response.content_part.added", event)
+					emit("response.content_part.done", event)
+					continue
+				}
+				text := part.Get("text").String()
+				emptyPart, _ := sjson.SetBytes([]byte(part.Raw), "text", "")
+				event, _ := sjson.SetRawBytes(partEvent(), "part", emptyPart)
+				emit("response.content_part.added", event)
+				event, _ = sjson.SetBytes(partEvent(), "delta", text)
+				emit("response.output_text.delta", event)
+				event, _ = sjson.SetBytes(partEvent(), "text", text)
+				emit("response.output_text.done", event)
+				event, _ = sjson.SetRawBytes(partEvent(), "part", []byte(part.Raw))
+				emit("response.content_part.done", event)
+			}
+		case "function_call":
+			arguments := item.Get("arguments").String()
+			event, _ := sjson.SetBytes([]byte(`{}`), "item_id", itemID)
+			event, _ = sjson.SetBytes(event, "output_index", outputIndex)
+			deltaEvent, _ := sjson.SetBytes(event, "delta", arguments)
+			emit("response.function_call_arguments.delta", deltaEvent)
+			doneEvent, _ := sjson.SetBytes(event, "arguments", arguments)
+			emit("response.function_call_arguments.done", doneEvent)
+		}
+
+		done, _ := sjson.SetBytes([]byte(`{}`), "output_index", outputIndex)
+		done, _ = sjson.SetRawBytes(done, "item", []byte(item.Raw))
+		emit("response.output_item.done", done)
+	}
+
+	completed, _ := sjson.SetRawBytes([]byte(`{}`), "response", body)
+	emit("response.completed", completed)
+	return chunks
+}
+
+func rawOrNull(value gjson.Result) string {
+	if !value.Exists() || value.Raw == "" {
+		return "null"
+	}
+	return value.Raw
+}
@@ -0,0 +1,153 @@
+package responsestore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const diskEntrySuffix = ".json"
+
+// DiskBackend stores one JSON file per response in a directory. Expiry and recency are
+// indexed in memory; existing files are loaded on startup so conversations survive restarts.
+type DiskBackend struct {
+	mu    sync.Mutex
+	dir   string
+	index *lruIndex
+}
+
+// NewDiskBackend opens dir, creating it when missing, and indexes the entries it contains.
+// Expired or unreadable files are removed.
+func NewDiskBackend(dir string, maxEntries int) (*DiskBackend, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create store directory: %w", err)
+	}
+	b := &DiskBackend{dir: dir, index: newLRUIndex(maxEntries)}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read store directory: %w", err)
+	}
+	now := time.Now()
+	loaded := make([]*Entry, 0, len(files))
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, diskEntrySuffix) {
+			continue
+		}
+		entry, errRead := b.read(strings.TrimSuffix(name, diskEntrySuffix))
+		if errRead != nil || !entry.ExpiresAt.After(now) {
+			_ = os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		loaded = append(loaded, entry)
+	}
+	sort.Slice(loaded, func(i, j int) bool { return loaded[i].CreatedAt.Before(loaded[j].CreatedAt) })
+	for _, entry := range loaded {
+		b.removeFiles(b.index.add(&lruItem{id: entry.ID, expiresAt: entry.ExpiresAt}))
+	}
+	return b, nil
+}
+
+// Get implements Backend.
+func (b *DiskBackend) Get(id string, now time.Time) (*Entry, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	item, expired := b.index.get(id, now)
+	if expired {
+		b.removeFiles([]string{id})
+	}
+	if item == nil {
+		return nil, false
+	}
+	entry, err := b.read(id)
+	if err != nil {
+		log.Debugf("response store: read entry %s: %v", id, err)
+		b.index.remove(id)
+		b.removeFiles([]string{id})
+		return nil, false
+	}
+	return entry, true
+}
+
+// Put implements Backend.
+func (b *DiskBackend) Put(entry *Entry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Debugf("response store: encode entry: %v", err)
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	tmp, err := os.CreateTemp(b.dir, entry.ID+".tmp-*")
+	if err != nil {
+		log.Warnf("response store: write entry: %v", err)
+		return
+	}
+	_, errWrite := tmp.Write(data)
+	errClose := tmp.Close()
+	if errWrite == nil {
+		errWrite = errClose
+	}
+	if errWrite == nil {
+		errWrite = os.Rename(tmp.Name(), b.path(entry.ID))
+	}
+	if errWrite != nil {
+		_ = os.Remove(tmp.Name())
+		log.Warnf("response store: write entry: %v", errWrite)
+		return
+	}
+	b.removeFiles(b.index.add(&lruItem{id: entry.ID, expiresAt: entry.ExpiresAt}))
+}
+
+// Delete implements Backend.
+func (b *DiskBackend) Delete(id string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.index.remove(id) {
+		return false
+	}
+	b.removeFiles([]string{id})
+	return true
+}
+
+// Len implements Backend.
+func (b *DiskBackend) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.index.order.Len()
+}
+
+func (b *DiskBackend) path(id string) string {
+	return filepath.Join(b.dir, id+diskEntrySuffix)
+}
+
+func (b *DiskBackend) read(id string) (*Entry, error) {
+	data, err := os.ReadFile(b.path(id))
+	if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err = json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	if entry.ID != id {
+		return nil, fmt.Errorf("entry id mismatch")
+	}
+	return &entry, nil
+}
+
+func (b *DiskBackend) removeFiles(ids []string) {
+	for _, id := range ids {
+		if err := os.Remove(b.path(id)); err != nil && !os.IsNotExist(err) {
+			log.Debugf("response store: remove entry %s: %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package responsestore
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lruIndex tracks entry expiry in least-recently-used order. It is not safe for concurrent
+// use; backends guard it with their own lock.
+type lruIndex struct {
+	maxEntries int
+	order      *list.List
+	items      map[string]*list.Element
+}
+
+type lruItem struct {
+	id        string
+	expiresAt time.Time
+	entry     *Entry
+}
+
+func newLRUIndex(maxEntries int) *lruIndex {
+	return &lruIndex{maxEntries: maxEntries, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// get returns the live item for id, marking it as recently used. Expired items are removed
+// and reported through expired.
+func (l *lruIndex) get(id string, now time.Time) (item *lruItem, expired bool) {
+	elem, ok := l.items[id]
+	if !ok {
+		return nil, false
+	}
+	item = elem.Value.(*lruItem)
+	if !item.expiresAt.After(now) {
+		l.order.Remove(elem)
+		delete(l.items, id)
+		return nil, true
+	}
+	l.order.MoveToFront(elem)
+	return item, false
+}
+
+// add inserts or replaces item and returns the IDs evicted to stay within capacity.
+func (l *lruIndex) add(item *lruItem) []string {
+	l.remove(item.id)
+	l.items[item.id] = l.order.PushFront(item)
+	var evicted []string
+	for l.order.Len() > l.maxEntries {
+		oldest := l.order.Back()
+		id := oldest.Value.(*lruItem).id
+		l.order.Remove(oldest)
+		delete(l.items, id)
+		evicted = append(evicted, id)
+	}
+	return evicted
+}
+
+func (l *lruIndex) remove(id string) bool {
+	elem, ok := l.items[id]
+	if !ok {
+		return false
+	}
+	l.order.Remove(elem)
+	delete(l.items, id)
+	return true
+}
+
+// MemoryBackend keeps entries in process memory.
+type MemoryBackend struct {
+	mu    sync.Mutex
+	index *lruIndex
+}
+
+// NewMemoryBackend returns an in-memory backend holding at most maxEntries responses.
+func NewMemoryBackend(maxEntries int) *MemoryBackend {
+	return &MemoryBackend{index: newLRUIndex(maxEntries)}
+}
+
+// Get implements Backend.
+func (b *MemoryBackend) Get(id string, now time.Time) (*Entry, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	item, _ := b.index.get(id, now)
+	if item == nil {
+		return nil, false
+	}
+	return item.entry, true
+}
+
+// Put implements Backend.
+func (b *MemoryBackend) Put(entry *Entry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.index.add(&lruItem{id: entry.ID, expiresAt: entry.ExpiresAt, entry: entry})
+}
+
+// Delete implements Backend.
+func (b *MemoryBackend) Delete(id string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.index.remove(id)
+}
+
+// Len implements Backend.
+func (b *MemoryBackend) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.index.order.Len()
+}
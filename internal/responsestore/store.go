@@ -0,0 +1,332 @@
+// Package responsestore keeps completed OpenAI Responses API results so conversations can be
+// continued with previous_response_id even when the upstream provider has no server-side
+// state. Each entry records the input items submitted with one response and the output items
+// it produced; resolving a previous_response_id walks the chain of entries and rebuilds the
+// full conversation as the request input. Entries are kept in memory or on disk with a TTL
+// and an LRU bound, and are scoped to the client API key that created them.
+package responsestore
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultTTL        = 24 * time.Hour
+	defaultMaxEntries = 10000
+
+	// maxChainLength bounds how many stored responses a single previous_response_id may expand.
+	maxChainLength = 10000
+
+	backendMemory = "memory"
+	backendDisk   = "disk"
+)
+
+// ErrNotFound is returned when a referenced response is missing, expired or owned by
+// another client API key.
+var ErrNotFound = errors.New("response not found")
+
+// validID matches the response IDs accepted as storage keys.
+var validID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+// Entry is one stored response.
+type Entry struct {
+	// ID is the response ID.
+	ID string `json:"id"`
+	// PreviousResponseID links the entry to the response it continued.
+	PreviousResponseID string `json:"previous_response_id,omitempty"`
+	// Owner is a digest of the client API key that created the response.
+	Owner string `json:"owner,omitempty"`
+	// Model is the model reported by the response.
+	Model string `json:"model,omitempty"`
+	// Input holds the input items submitted with this response, excluding those inherited
+	// from previous responses.
+	Input json.RawMessage `json:"input"`
+	// Output holds the output items of the response.
+	Output json.RawMessage `json:"output"`
+	// Response is the complete response object returned to the client.
+	Response json.RawMessage `json:"response"`
+	// CreatedAt is when the entry was stored.
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt is when the entry can no longer be referenced.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Backend stores entries. Implementations enforce TTLs and capacity on their own and must
+// be safe for concurrent use.
+type Backend interface {
+	Get(id string, now time.Time) (*Entry, bool)
+	Put(entry *Entry)
+	Delete(id string) bool
+	Len() int
+}
+
+var defaultStore = New()
+
+// Default returns the process-wide store used by the API handlers.
+func Default() *Store { return defaultStore }
+
+// Store is a configurable response store.
+type Store struct {
+	mu      sync.RWMutex
+	cfg     config.ResponseStoreConfig
+	backend Backend
+	applied bool
+
+	now func() time.Time
+}
+
+// New constructs a store that is configured on the first call to Apply.
+func New() *Store {
+	return &Store{now: time.Now}
+}
+
+// Apply reconfigures the store. The backend is rebuilt only when its kind, directory or
+// capacity changes; rebuilding a memory backend drops its entries.
+func (s *Store) Apply(cfg config.ResponseStoreConfig) {
+	if s == nil {
+		return
+	}
+	s.mu.RLock()
+	unchanged := s.applied && s.cfg == cfg
+	s.mu.RUnlock()
+	if unchanged {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous := s.cfg
+	wasApplied := s.applied
+	s.cfg = cfg
+	s.applied = true
+	if cfg.Disable {
+		s.backend = nil
+		return
+	}
+	if s.backend != nil && wasApplied && !previous.Disable &&
+		previous.Backend == cfg.Backend && previous.Dir == cfg.Dir && previous.MaxEntries == cfg.MaxEntries {
+		return
+	}
+
+	maxEntries := cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntries
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.Backend)) {
+	case backendDisk:
+		dir := strings.TrimSpace(cfg.Dir)
+		if dir == "" {
+			dir = defaultDir()
+		}
+		backend, err := NewDiskBackend(dir, maxEntries)
+		if err != nil {
+			log.Errorf("response store: %v; falling back to memory", err)
+			s.backend = NewMemoryBackend(maxEntries)
+			return
+		}
+		s.backend = backend
+	default:
+		s.backend = NewMemoryBackend(maxEntries)
+	}
+	log.Debugf("response store enabled (max-entries=%d)", maxEntries)
+}
+
+// Enabled reports whether responses are being stored.
+func (s *Store) Enabled() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.backend != nil
+}
+
+func (s *Store) currentBackend() Backend {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.backend
+}
+
+// Get returns the live entry id owned by owner.
+func (s *Store) Get(id, owner string) (*Entry, bool) {
+	backend := s.currentBackend()
+	if backend == nil || !validID.MatchString(id) {
+		return nil, false
+	}
+	entry, ok := backend.Get(id, s.now())
+	if !ok || entry.Owner != owner {
+		return nil, false
+	}
+	return entry, true
+}
+
+// Delete removes the entry id owned by owner and reports whether it existed.
+func (s *Store) Delete(id, owner string) bool {
+	if _, ok := s.Get(id, owner); !ok {
+		return false
+	}
+	return s.currentBackend().Delete(id)
+}
+
+// Save stores the response produced for request. Requests with store set to false and
+// responses without an ID are skipped. The returned entry is nil when nothing was stored.
+func (s *Store) Save(owner string, request, response []byte) *Entry {
+	backend := s.currentBackend()
+	if backend == nil || gjson.GetBytes(request, "store").Type == gjson.False {
+		return nil
+	}
+	resp := gjson.ParseBytes(response)
+	id := resp.Get("id").String()
+	if !validID.MatchString(id) {
+		return nil
+	}
+	output := resp.Get("output").Raw
+	if output == "" {
+		output = "[]"
+	}
+	s.mu.RLock()
+	ttl := time.Duration(s.cfg.TTLSeconds) * time.Second
+	s.mu.RUnlock()
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	now := s.now()
+	entry := &Entry{
+		ID:                 id,
+		PreviousResponseID: gjson.GetBytes(request, "previous_response_id").String(),
+		Owner:              owner,
+		Model:              resp.Get("model").String(),
+		Input:              json.RawMessage(assignItemIDs(normalizeInput(gjson.GetBytes(request, "input")))),
+		Output:             json.RawMessage(output),
+		Response:           json.RawMessage(resp.Raw),
+		CreatedAt:          now,
+		ExpiresAt:          now.Add(ttl),
+	}
+	backend.Put(entry)
+	return entry
+}
+
+// Expand resolves previous_response_id in request by prepending the input and output items
+// of every response in the chain to the request input. Stored item IDs and the
+// previous_response_id itself are dropped so upstreams that do not know these local IDs
+// accept the replayed conversation. The request is
+// returned unchanged when it does not reference a previous response or the store is
+// disabled; ErrNotFound is returned when any response in the chain is unavailable.
+func (s *Store) Expand(owner string, request []byte) ([]byte, error) {
+	previousID := gjson.GetBytes(request, "previous_response_id").String()
+	if previousID == "" || !s.Enabled() {
+		return request, nil
+	}
+
+	var chain []*Entry
+	seen := make(map[string]struct{})
+	for id := previousID; id != ""; {
+		if _, loop := seen[id]; loop || len(chain) >= maxChainLength {
+			return nil, fmt.Errorf("previous response %s: invalid conversation chain", previousID)
+		}
+		seen[id] = struct{}{}
+		entry, ok := s.Get(id, owner)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		chain = append(chain, entry)
+		id = entry.PreviousResponseID
+	}
+
+	items := "[]"
+	appendItems := func(raw string) {
+		gjson.Parse(raw).ForEach(func(_, item gjson.Result) bool {
+			replayed, _ := sjson.Delete(item.Raw, "id")
+			items, _ = sjson.SetRaw(items, "-1", replayed)
+			return true
+		})
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		appendItems(string(chain[i].Input))
+		appendItems(string(chain[i].Output))
+	}
+	appendItems(normalizeInput(gjson.GetBytes(request, "input")))
+
+	out, err := sjson.SetRawBytes(request, "input", []byte(items))
+	if err == nil {
+		out, err = sjson.DeleteBytes(out, "previous_response_id")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("expand previous response %s: %w", previousID, err)
+	}
+	return out, nil
+}
+
+// Owner derives the entry owner for a client API key. The key itself is never stored.
+func Owner(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeInput returns the request input as an array of items. A string input becomes a
+// single user message.
+func normalizeInput(input gjson.Result) string {
+	switch {
+	case input.IsArray():
+		return input.Raw
+	case input.Type == gjson.String:
+		item := `{"type":"message","role":"user","content":[{"type":"input_text","text":""}]}`
+		item, _ = sjson.Set(item, "content.0.text", input.String())
+		return "[" + item + "]"
+	default:
+		return "[]"
+	}
+}
+
+// assignItemIDs gives every input item without an ID a generated one so the items can be
+// listed and paginated.
+func assignItemIDs(items string) string {
+	out := items
+	gjson.Parse(items).ForEach(func(key, item gjson.Result) bool {
+		if item.Get("id").String() != "" {
+			return true
+		}
+		prefix := "item_"
+		if item.Get("type").String() == "message" || (!item.Get("type").Exists() && item.Get("role").Exists()) {
+			prefix = "msg_"
+		}
+		out, _ = sjson.Set(out, key.String()+".id", prefix+randomHex(12))
+		return true
+	})
+	return out
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func defaultDir() string {
+	if base := util.WritablePath(); base != "" {
+		return filepath.Join(base, "response-store")
+	}
+	return "response-store"
+}
@@ -0,0 +1,105 @@
+package responsestore
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestExpandRebuildsConversationChain(t *testing.T) {
+	store := New()
+	store.Apply(config.ResponseStoreConfig{})
+	owner := Owner("client-key")
+
+	first := store.Save(owner,
+		[]byte(`{"model":"m","input":"hello"}`),
+		[]byte(`{"id":"resp_1","model":"m","output":[{"id":"msg_a","type":"message","role":"assistant","content":[{"type":"output_text","text":"hi"}]}]}`))
+	if first == nil {
+		t.Fatalf("Save() stored nothing")
+	}
+	store.Save(owner,
+		[]byte(`{"model":"m","previous_response_id":"resp_1","input":[{"type":"message","role":"user","content":"again"}]}`),
+		[]byte(`{"id":"resp_2","model":"m","output":[{"id":"fc_1","type":"function_call","call_id":"c1","name":"f","arguments":"{}"}]}`))
+
+	out, err := store.Expand(owner, []byte(`{"model":"m","previous_response_id":"resp_2","input":[{"type":"function_call_output","call_id":"c1","output":"ok"}]}`))
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+	if gjson.GetBytes(out, "previous_response_id").Exists() {
+		t.Fatalf("expanded request still references the local response id: %s", out)
+	}
+	items := gjson.GetBytes(out, "input").Array()
+	wantTypes := []string{"message", "message", "message", "function_call", "function_call_output"}
+	if len(items) != len(wantTypes) {
+		t.Fatalf("expanded input = %s", gjson.GetBytes(out, "input").Raw)
+	}
+	for i, item := range items {
+		if got := item.Get("type").String(); got != wantTypes[i] {
+			t.Fatalf("item %d type = %q, want %q", i, got, wantTypes[i])
+		}
+		if item.Get("id").Exists() {
+			t.Fatalf("replayed item %d kept its id: %s", i, item.Raw)
+		}
+	}
+	if got := items[0].Get("content.0.text").String(); got != "hello" {
+		t.Fatalf("string input not normalized: %s", items[0].Raw)
+	}
+
+	if _, err = store.Expand(Owner("other-key"), []byte(`{"previous_response_id":"resp_2"}`)); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expand() for another key error = %v, want ErrNotFound", err)
+	}
+	if !store.Delete("resp_1", owner) {
+		t.Fatalf("Delete() = false")
+	}
+	if _, err = store.Expand(owner, []byte(`{"previous_response_id":"resp_2"}`)); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expand() with a broken chain error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSaveHonorsStoreFalseAndTTL(t *testing.T) {
+	store := New()
+	store.Apply(config.ResponseStoreConfig{TTLSeconds: 60})
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	if store.Save("", []byte(`{"store":false,"input":"x"}`), []byte(`{"id":"resp_skip","output":[]}`)) != nil {
+		t.Fatalf("store=false response was saved")
+	}
+	store.Save("", []byte(`{"input":"x"}`), []byte(`{"id":"resp_ttl","output":[]}`))
+	if _, ok := store.Get("resp_ttl", ""); !ok {
+		t.Fatalf("Get() missed a live entry")
+	}
+	now = now.Add(2 * time.Minute)
+	if _, ok := store.Get("resp_ttl", ""); ok {
+		t.Fatalf("Get() returned an expired entry")
+	}
+
+	store.Apply(config.ResponseStoreConfig{Disable: true})
+	out, err := store.Expand("", []byte(`{"previous_response_id":"resp_ttl"}`))
+	if err != nil || gjson.GetBytes(out, "input").Exists() {
+		t.Fatalf("disabled store must forward the request unchanged, got %s, %v", out, err)
+	}
+}
+
+func TestDiskBackendReloadsEntries(t *testing.T) {
+	dir := t.TempDir()
+	store := New()
+	store.Apply(config.ResponseStoreConfig{Backend: "disk", Dir: dir})
+	store.Save("", []byte(`{"input":"persist me"}`), []byte(`{"id":"resp_disk","output":[]}`))
+
+	reopened := New()
+	reopened.Apply(config.ResponseStoreConfig{Backend: "disk", Dir: dir})
+	entry, ok := reopened.Get("resp_disk", "")
+	if !ok {
+		t.Fatalf("entry not reloaded from disk")
+	}
+	if got := gjson.GetBytes(entry.Input, "0.content.0.text").String(); got != "persist me" {
+		t.Fatalf("reloaded input = %s", entry.Input)
+	}
+	if !gjson.GetBytes(entry.Input, "0.id").Exists() {
+		t.Fatalf("stored input items need ids for listing")
+	}
+}
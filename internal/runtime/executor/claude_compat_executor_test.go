@@ -0,0 +1,170 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func newClaudeCompatTestExecutor(baseURL string) (*ClaudeCompatExecutor, *cliproxyauth.Auth) {
+	cfg := &config.Config{ClaudeCompatibility: []config.ProtocolCompatibility{{
+		Name:    "gw",
+		BaseURL: baseURL,
+		Models:  []config.ProtocolCompatibilityModel{{Name: "upstream-sonnet", Alias: "sonnet"}},
+	}}}
+	auth := &cliproxyauth.Auth{ID: "gw-auth", Provider: "gw", Attributes: map[string]string{
+		"base_url":       baseURL,
+		"api_key":        "gw-key",
+		"compat_name":    "gw",
+		"compat_kind":    "claude",
+		"provider_key":   "gw",
+		"header:X-Extra": "1",
+	}}
+	return NewClaudeCompatExecutor("gw", cfg), auth
+}
+
+func TestClaudeCompatExecutor_Execute(t *testing.T) {
+	upstream := &recordingUpstream{}
+	srv := httptest.NewServer(upstream.handler(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"id":"msg_up","type":"message","role":"assistant","model":"upstream-sonnet","content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn","usage":{"input_tokens":3,"output_tokens":1}}`)
+	}))
+	defer srv.Close()
+	exec, auth := newClaudeCompatTestExecutor(srv.URL)
+
+	payload := []byte(`{"model":"sonnet","max_tokens":16,"betas":["beta-a"],"messages":[{"role":"user","content":"hi"}]}`)
+	resp, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "sonnet", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatClaude, OriginalRequest: payload})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	path, body := upstream.last(t)
+	if path != "/v1/messages" {
+		t.Fatalf("upstream path = %q, want /v1/messages", path)
+	}
+	if gjson.GetBytes(body, "model").String() != "upstream-sonnet" || gjson.GetBytes(body, "betas").Exists() {
+		t.Fatalf("upstream body = %s", body)
+	}
+	header := upstream.lastHeader(t)
+	if header.Get("x-api-key") != "gw-key" || header.Get("Authorization") != "" {
+		t.Fatalf("auth headers = %v", header)
+	}
+	if header.Get("Anthropic-Version") != "2023-06-01" || header.Get("Anthropic-Beta") != "beta-a" || header.Get("X-Extra") != "1" {
+		t.Fatalf("upstream headers = %v", header)
+	}
+	if gjson.GetBytes(resp.Payload, "id").String() != "msg_up" {
+		t.Fatalf("response = %s", resp.Payload)
+	}
+}
+
+func TestClaudeCompatExecutor_RequestMiddlewareRunsOnce(t *testing.T) {
+	upstream := &recordingUpstream{}
+	srv := httptest.NewServer(upstream.handler(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"id":"msg_up","type":"message","role":"assistant","model":"upstream-sonnet","content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn","usage":{"input_tokens":3,"output_tokens":1}}`)
+	}))
+	defer srv.Close()
+	exec, auth := newClaudeCompatTestExecutor(srv.URL)
+
+	var calls atomic.Int32
+	pipeline := sdktranslator.NewPipeline(nil)
+	pipeline.UseRequest(func(ctx context.Context, req sdktranslator.RequestEnvelope, next sdktranslator.RequestHandler) (sdktranslator.RequestEnvelope, error) {
+		calls.Add(1)
+		return next(ctx, req)
+	})
+	ctx := sdktranslator.WithPipeline(context.Background(), pipeline)
+
+	payload := []byte(`{"model":"sonnet","messages":[{"role":"user","content":"hi"}]}`)
+	if _, err := exec.Execute(ctx, auth, cliproxyexecutor.Request{Model: "sonnet", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAI, OriginalRequest: payload}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("request middleware ran %d times, want 1", got)
+	}
+}
+
+func TestClaudeCompatExecutor_ExecuteStreamTranslates(t *testing.T) {
+	upstream := &recordingUpstream{}
+	srv := httptest.NewServer(upstream.handler(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_up\",\"model\":\"upstream-sonnet\",\"usage\":{\"input_tokens\":1,\"output_tokens\":0}}}\n\n"+
+			"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n"+
+			"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"hello\"}}\n\n"+
+			"event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"},\"usage\":{\"output_tokens\":1}}\n\n"+
+			"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	}))
+	defer srv.Close()
+	exec, auth := newClaudeCompatTestExecutor(srv.URL)
+
+	payload := []byte(`{"model":"sonnet","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	stream, err := exec.ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{Model: "sonnet", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAI, OriginalRequest: payload, Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	var out strings.Builder
+	for chunk := range stream {
+		if chunk.Err != nil {
+			t.Fatalf("stream error = %v", chunk.Err)
+		}
+		out.Write(chunk.Payload)
+	}
+	path, body := upstream.last(t)
+	if path != "/v1/messages" || gjson.GetBytes(body, "messages.0.content.0.text").String() != "hi" {
+		t.Fatalf("upstream request = %s %s", path, body)
+	}
+	if upstream.lastHeader(t).Get("Accept") != "text/event-stream" {
+		t.Fatalf("stream request Accept = %q", upstream.lastHeader(t).Get("Accept"))
+	}
+	if !strings.Contains(out.String(), "chat.completion.chunk") || !strings.Contains(out.String(), "hello") {
+		t.Fatalf("stream was not translated to OpenAI chunks: %q", out.String())
+	}
+}
+
+func TestClaudeCompatExecutor_CountTokens(t *testing.T) {
+	upstream := &recordingUpstream{}
+	srv := httptest.NewServer(upstream.handler(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"input_tokens":7}`)
+	}))
+	defer srv.Close()
+	exec, auth := newClaudeCompatTestExecutor(srv.URL)
+
+	payload := []byte(`{"model":"sonnet","messages":[{"role":"user","content":"hi"}]}`)
+	resp, err := exec.CountTokens(context.Background(), auth, cliproxyexecutor.Request{Model: "sonnet", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatClaude, OriginalRequest: payload})
+	if err != nil {
+		t.Fatalf("CountTokens() error = %v", err)
+	}
+	if path, body := upstream.last(t); path != "/v1/messages/count_tokens" || gjson.GetBytes(body, "model").String() != "upstream-sonnet" {
+		t.Fatalf("upstream request = %s %s", path, body)
+	}
+	if gjson.GetBytes(resp.Payload, "input_tokens").Int() != 7 {
+		t.Fatalf("response = %s", resp.Payload)
+	}
+}
+
+func TestClaudeCompatExecutor_UpstreamError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = io.WriteString(w, `{"type":"error","error":{"type":"rate_limit_error"}}`)
+	}))
+	defer srv.Close()
+	exec, auth := newClaudeCompatTestExecutor(srv.URL)
+
+	payload := []byte(`{"model":"sonnet","messages":[{"role":"user","content":"hi"}]}`)
+	_, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "sonnet", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatClaude})
+	status, ok := err.(statusErr)
+	if !ok || status.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("Execute() error = %v, want 429 statusErr", err)
+	}
+}
@@ -61,8 +61,11 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, r
 	if len(opts.OriginalRequest) > 0 {
 		originalPayload = bytes.Clone(opts.OriginalRequest)
 	}
-	originalTranslated := sdktranslator.TranslateRequest(from, to, model, originalPayload, stream)
-	body := sdktranslator.TranslateRequest(from, to, model, bytes.Clone(req.Payload), stream)
+	originalTranslated := translateOriginalRequest(ctx, from, to, model, originalPayload, stream)
+	body, errTranslate := translateRequest(ctx, from, to, model, bytes.Clone(req.Payload), stream)
+	if errTranslate != nil {
+		return resp, errTranslate
+	}
 	body, _ = sjson.SetBytes(body, "model", model)
 	// Inject thinking config based on model metadata for thinking variants
 	body = e.injectThinkingConfig(model, req.Metadata, body)
@@ -88,9 +91,10 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, r
 		return resp, err
 	}
 	applyClaudeHeaders(httpReq, auth, apiKey, false, extraBetas)
-	var authID, authLabel, authType, authValue string
+	var authID, authIndex, authLabel, authType, authValue string
 	if auth != nil {
 		authID = auth.ID
+		authIndex = auth.EnsureIndex()
 		authLabel = auth.Label
 		authType, authValue = auth.AccountInfo()
 	}
@@ -101,6 +105,7 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, r
 		Body:      body,
 		Provider:  e.Identifier(),
 		AuthID:    authID,
+		AuthIndex: authIndex,
 		AuthLabel: authLabel,
 		AuthType:  authType,
 		AuthValue: authValue,
@@ -153,7 +158,10 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, r
 		reporter.publish(ctx, parseClaudeUsage(data))
 	}
 	var param any
-	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, data, &param)
+	out, errTranslate := translateNonStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, data, &param)
+	if errTranslate != nil {
+		return resp, errTranslate
+	}
 	resp = cliproxyexecutor.Response{Payload: []byte(out)}
 	return resp, nil
 }
@@ -176,8 +184,11 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 	if len(opts.OriginalRequest) > 0 {
 		originalPayload = bytes.Clone(opts.OriginalRequest)
 	}
-	originalTranslated := sdktranslator.TranslateRequest(from, to, model, originalPayload, true)
-	body := sdktranslator.TranslateRequest(from, to, model, bytes.Clone(req.Payload), true)
+	originalTranslated := translateOriginalRequest(ctx, from, to, model, originalPayload, true)
+	body, errTranslate := translateRequest(ctx, from, to, model, bytes.Clone(req.Payload), true)
+	if errTranslate != nil {
+		return nil, errTranslate
+	}
 	body, _ = sjson.SetBytes(body, "model", model)
 	// Inject thinking config based on model metadata for thinking variants
 	body = e.injectThinkingConfig(model, req.Metadata, body)
@@ -200,9 +211,10 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 		return nil, err
 	}
 	applyClaudeHeaders(httpReq, auth, apiKey, true, extraBetas)
-	var authID, authLabel, authType, authValue string
+	var authID, authIndex, authLabel, authType, authValue string
 	if auth != nil {
 		authID = auth.ID
+		authIndex = auth.EnsureIndex()
 		authLabel = auth.Label
 		authType, authValue = auth.AccountInfo()
 	}
@@ -213,6 +225,7 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 		Body:      body,
 		Provider:  e.Identifier(),
 		AuthID:    authID,
+		AuthIndex: authIndex,
 		AuthLabel: authLabel,
 		AuthType:  authType,
 		AuthValue: authValue,
@@ -287,7 +300,12 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 			if detail, ok := parseClaudeStreamUsage(line); ok {
 				reporter.publish(ctx, detail)
 			}
-			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, bytes.Clone(line), &param)
+			chunks, errTranslate := translateStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, bytes.Clone(line), &param)
+			if errTranslate != nil {
+				reporter.publishFailure(ctx)
+				out <- cliproxyexecutor.StreamChunk{Err: errTranslate}
+				return
+			}
 			for i := range chunks {
 				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
 			}
@@ -316,7 +334,10 @@ func (e *ClaudeExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Aut
 	if override := e.resolveUpstreamModel(req.Model, auth); override != "" {
 		model = override
 	}
-	body := sdktranslator.TranslateRequest(from, to, model, bytes.Clone(req.Payload), stream)
+	body, errTranslate := translateRequest(ctx, from, to, model, bytes.Clone(req.Payload), stream)
+	if errTranslate != nil {
+		return cliproxyexecutor.Response{}, errTranslate
+	}
 	body, _ = sjson.SetBytes(body, "model", model)
 
 	if !strings.HasPrefix(model, "claude-3-5-haiku") {
@@ -333,9 +354,10 @@ func (e *ClaudeExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Aut
 		return cliproxyexecutor.Response{}, err
 	}
 	applyClaudeHeaders(httpReq, auth, apiKey, false, extraBetas)
-	var authID, authLabel, authType, authValue string
+	var authID, authIndex, authLabel, authType, authValue string
 	if auth != nil {
 		authID = auth.ID
+		authIndex = auth.EnsureIndex()
 		authLabel = auth.Label
 		authType, authValue = auth.AccountInfo()
 	}
@@ -346,6 +368,7 @@ func (e *ClaudeExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Aut
 		Body:      body,
 		Provider:  e.Identifier(),
 		AuthID:    authID,
+		AuthIndex: authIndex,
 		AuthLabel: authLabel,
 		AuthType:  authType,
 		AuthValue: authValue,
@@ -386,7 +409,7 @@ func (e *ClaudeExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Aut
 	}
 	appendAPIResponseChunk(ctx, e.cfg, data)
 	count := gjson.GetBytes(data, "input_tokens").Int()
-	out := sdktranslator.TranslateTokenCount(ctx, to, from, count, data)
+	out := translateTokenCount(ctx, to, from, count, data)
 	return cliproxyexecutor.Response{Payload: []byte(out)}, nil
 }
 
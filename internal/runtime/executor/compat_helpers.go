@@ -0,0 +1,81 @@
+package executor
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// resolveProtocolCompatConfig returns the claude-compatibility or gemini-compatibility entry
+// auth was synthesized from, matched by compat name, provider key or provider.
+func resolveProtocolCompatConfig(entries []config.ProtocolCompatibility, auth *cliproxyauth.Auth) *config.ProtocolCompatibility {
+	if auth == nil || len(entries) == 0 {
+		return nil
+	}
+	candidates := make([]string, 0, 3)
+	if auth.Attributes != nil {
+		if v := strings.TrimSpace(auth.Attributes["compat_name"]); v != "" {
+			candidates = append(candidates, v)
+		}
+		if v := strings.TrimSpace(auth.Attributes["provider_key"]); v != "" {
+			candidates = append(candidates, v)
+		}
+	}
+	if v := strings.TrimSpace(auth.Provider); v != "" {
+		candidates = append(candidates, v)
+	}
+	for _, candidate := range candidates {
+		for i := range entries {
+			if strings.EqualFold(candidate, entries[i].Name) {
+				return &entries[i]
+			}
+		}
+	}
+	return nil
+}
+
+// resolveProtocolCompatModel maps a client-facing model alias to the upstream model name
+// configured for entry. Thinking suffixes are stripped before matching.
+func resolveProtocolCompatModel(entry *config.ProtocolCompatibility, alias string) string {
+	trimmed := strings.TrimSpace(alias)
+	if entry == nil || trimmed == "" {
+		return ""
+	}
+	normalizedModel, metadata := util.NormalizeThinkingModel(trimmed)
+	candidates := []string{strings.TrimSpace(normalizedModel)}
+	if !strings.EqualFold(normalizedModel, trimmed) {
+		candidates = append(candidates, trimmed)
+	}
+	if original := util.ResolveOriginalModel(normalizedModel, metadata); original != "" && !strings.EqualFold(original, normalizedModel) {
+		candidates = append(candidates, original)
+	}
+	for i := range entry.Models {
+		name := strings.TrimSpace(entry.Models[i].Name)
+		modelAlias := strings.TrimSpace(entry.Models[i].Alias)
+		for _, candidate := range candidates {
+			if candidate == "" {
+				continue
+			}
+			if modelAlias != "" && strings.EqualFold(modelAlias, candidate) {
+				if name != "" {
+					return name
+				}
+				return candidate
+			}
+			if name != "" && strings.EqualFold(name, candidate) {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// protocolCompatCreds returns the API key and base URL synthesized for a compatibility auth.
+func protocolCompatCreds(auth *cliproxyauth.Auth) (apiKey, baseURL string) {
+	if auth == nil || auth.Attributes == nil {
+		return "", ""
+	}
+	return strings.TrimSpace(auth.Attributes["api_key"]), strings.TrimSpace(auth.Attributes["base_url"])
+}
@@ -0,0 +1,356 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Embed sends an embeddings request to the batchEmbedContents endpoint of the Gemini API.
+func (e *GeminiExecutor) Embed(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	apiKey, bearer := geminiCreds(auth)
+
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	model := req.Model
+	if override := e.resolveUpstreamModel(model, auth); override != "" {
+		model = override
+	}
+
+	from := opts.SourceFormat
+	to := sdktranslator.FormatGeminiEmbeddings
+	body, errTranslate := translateRequest(ctx, from, to, model, bytes.Clone(req.Payload), false)
+	if errTranslate != nil {
+		return resp, errTranslate
+	}
+	body = setGeminiEmbeddingModel(body, model)
+
+	url := fmt.Sprintf("%s/%s/models/%s:batchEmbedContents", resolveGeminiBaseURL(auth), glAPIVersion, model)
+	headers := http.Header{}
+	if apiKey != "" {
+		headers.Set("x-goog-api-key", apiKey)
+	} else if bearer != "" {
+		headers.Set("Authorization", "Bearer "+bearer)
+	}
+	data, err := postEmbeddings(ctx, e.cfg, auth, e.Identifier(), url, headers, body, applyGeminiHeaders)
+	if err != nil {
+		return resp, err
+	}
+	data = ensureGeminiEmbeddingUsage(data, body, model)
+	reporter.publish(ctx, parseGeminiUsage(data))
+
+	out, errTranslate := translateNonStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, data, nil)
+	if errTranslate != nil {
+		return resp, errTranslate
+	}
+	return cliproxyexecutor.Response{Payload: []byte(out)}, nil
+}
+
+// Embed sends an embeddings request to the Vertex AI predict endpoint, using either service
+// account or API key credentials.
+func (e *GeminiVertexExecutor) Embed(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	model := req.Model
+	headers := http.Header{}
+	var url string
+	apiKey, baseURL := vertexAPICreds(auth)
+	if apiKey == "" {
+		projectID, location, saJSON, errCreds := vertexCreds(auth)
+		if errCreds != nil {
+			return resp, errCreds
+		}
+		token, errTok := vertexAccessToken(ctx, e.cfg, auth, saJSON)
+		if errTok != nil {
+			log.Errorf("vertex executor: access token error: %v", errTok)
+			return resp, statusErr{code: http.StatusInternalServerError, msg: "internal server error"}
+		}
+		headers.Set("Authorization", "Bearer "+token)
+		url = fmt.Sprintf("%s/%s/projects/%s/locations/%s/publishers/google/models/%s:predict", vertexBaseURL(location), vertexAPIVersion, projectID, location, model)
+	} else {
+		if override := e.resolveUpstreamModel(model, auth); override != "" {
+			model = override
+		}
+		if baseURL == "" {
+			baseURL = "https://generativelanguage.googleapis.com"
+		}
+		headers.Set("x-goog-api-key", apiKey)
+		url = fmt.Sprintf("%s/%s/publishers/google/models/%s:predict", baseURL, vertexAPIVersion, model)
+	}
+
+	from := opts.SourceFormat
+	to := sdktranslator.FormatGeminiEmbeddings
+	batch, errTranslate := translateRequest(ctx, from, to, model, bytes.Clone(req.Payload), false)
+	if errTranslate != nil {
+		return resp, errTranslate
+	}
+	batch = setGeminiEmbeddingModel(batch, model)
+	body := geminiEmbeddingsToVertexPredict(batch)
+
+	data, err := postEmbeddings(ctx, e.cfg, auth, e.Identifier(), url, headers, body, applyGeminiHeaders)
+	if err != nil {
+		return resp, err
+	}
+	data = ensureGeminiEmbeddingUsage(vertexPredictToGeminiEmbeddings(data), batch, model)
+	reporter.publish(ctx, parseGeminiUsage(data))
+
+	out, errTranslate := translateNonStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), batch, data, nil)
+	if errTranslate != nil {
+		return resp, errTranslate
+	}
+	return cliproxyexecutor.Response{Payload: []byte(out)}, nil
+}
+
+// Embed sends an embeddings request to the /embeddings endpoint of the compatible provider.
+func (e *OpenAICompatExecutor) Embed(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	baseURL, apiKey := e.resolveCredentials(auth)
+	if baseURL == "" {
+		err = statusErr{code: http.StatusUnauthorized, msg: "missing provider baseURL"}
+		return
+	}
+
+	from := opts.SourceFormat
+	to := sdktranslator.FormatOpenAIEmbeddings
+	body, errTranslate := translateRequest(ctx, from, to, req.Model, bytes.Clone(req.Payload), false)
+	if errTranslate != nil {
+		return resp, errTranslate
+	}
+	if modelOverride := e.resolveUpstreamModel(req.Model, auth); modelOverride != "" {
+		body = e.overrideModel(body, modelOverride)
+	}
+
+	url := strings.TrimSuffix(baseURL, "/") + "/embeddings"
+	headers := http.Header{}
+	if apiKey != "" {
+		headers.Set("Authorization", "Bearer "+apiKey)
+	}
+	headers.Set("User-Agent", "cli-proxy-openai-compat")
+	applyHeaders := func(httpReq *http.Request, auth *cliproxyauth.Auth) {
+		var attrs map[string]string
+		if auth != nil {
+			attrs = auth.Attributes
+		}
+		util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+	}
+	data, err := postEmbeddings(ctx, e.cfg, auth, e.Identifier(), url, headers, body, applyHeaders)
+	if err != nil {
+		return resp, err
+	}
+	detail := parseOpenAIUsage(data)
+	if detail.InputTokens == 0 {
+		detail.InputTokens = estimateEmbeddingTokens(req.Model, openAIEmbeddingTexts(body))
+	}
+	if detail.TotalTokens == 0 {
+		detail.TotalTokens = detail.InputTokens
+	}
+	reporter.publish(ctx, detail)
+
+	out, errTranslate := translateNonStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, data, nil)
+	if errTranslate != nil {
+		return resp, errTranslate
+	}
+	return cliproxyexecutor.Response{Payload: []byte(out)}, nil
+}
+
+// postEmbeddings sends an embeddings request upstream with request logging and returns the
+// body of a successful response.
+func postEmbeddings(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, provider, url string, headers http.Header, body []byte, applyHeaders func(*http.Request, *cliproxyauth.Auth)) ([]byte, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range headers {
+		httpReq.Header[key] = values
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if applyHeaders != nil {
+		applyHeaders(httpReq, auth)
+	}
+	var authID, authIndex, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authIndex = auth.EnsureIndex()
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, cfg, upstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  provider,
+		AuthID:    authID,
+		AuthIndex: authIndex,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := newProxyAwareHTTPClient(ctx, cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		recordAPIResponseError(ctx, cfg, err)
+		return nil, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("%s executor: close embeddings response body error: %v", provider, errClose)
+		}
+	}()
+	recordAPIResponseMetadata(ctx, cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		recordAPIResponseError(ctx, cfg, err)
+		return nil, err
+	}
+	appendAPIResponseChunk(ctx, cfg, data)
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		log.Debugf("request error, error status: %d, error body: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), data))
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(data)}
+	}
+	return data, nil
+}
+
+// setGeminiEmbeddingModel points every entry of a batchEmbedContents request at the upstream
+// model, which the endpoint requires to match the model in the URL.
+func setGeminiEmbeddingModel(body []byte, model string) []byte {
+	count := len(gjson.GetBytes(body, "requests").Array())
+	for i := 0; i < count; i++ {
+		body, _ = sjson.SetBytes(body, fmt.Sprintf("requests.%d.model", i), "models/"+model)
+	}
+	return body
+}
+
+// geminiEmbeddingTexts returns the text of each entry of a batchEmbedContents request.
+func geminiEmbeddingTexts(body []byte) []string {
+	var texts []string
+	gjson.GetBytes(body, "requests").ForEach(func(_, request gjson.Result) bool {
+		texts = append(texts, geminiEmbeddingText(request))
+		return true
+	})
+	return texts
+}
+
+// geminiEmbeddingText joins the text parts of one batchEmbedContents entry.
+func geminiEmbeddingText(request gjson.Result) string {
+	var texts []string
+	request.Get("content.parts").ForEach(func(_, part gjson.Result) bool {
+		if text := part.Get("text"); text.Exists() {
+			texts = append(texts, text.String())
+		}
+		return true
+	})
+	return strings.Join(texts, "\n")
+}
+
+// openAIEmbeddingTexts returns the string inputs of an OpenAI embeddings request.
+func openAIEmbeddingTexts(body []byte) []string {
+	input := gjson.GetBytes(body, "input")
+	if !input.IsArray() {
+		return []string{input.String()}
+	}
+	var texts []string
+	input.ForEach(func(_, item gjson.Result) bool {
+		if item.Type == gjson.String {
+			texts = append(texts, item.String())
+		}
+		return true
+	})
+	return texts
+}
+
+// estimateEmbeddingTokens approximates the input tokens of texts for upstreams that do not
+// report usage on embedding calls.
+func estimateEmbeddingTokens(model string, texts []string) int64 {
+	enc, err := tokenizerForModel(model)
+	if err != nil {
+		return 0
+	}
+	var total int64
+	for _, text := range texts {
+		if count, errCount := enc.Count(text); errCount == nil {
+			total += int64(count)
+		}
+	}
+	return total
+}
+
+// ensureGeminiEmbeddingUsage adds an estimated usageMetadata block to a Gemini embeddings
+// response that lacks one. The Gemini API does not report tokens for embeddings.
+func ensureGeminiEmbeddingUsage(data, request []byte, model string) []byte {
+	if gjson.GetBytes(data, "usageMetadata.promptTokenCount").Exists() {
+		return data
+	}
+	tokens := estimateEmbeddingTokens(model, geminiEmbeddingTexts(request))
+	data, _ = sjson.SetBytes(data, "usageMetadata.promptTokenCount", tokens)
+	data, _ = sjson.SetBytes(data, "usageMetadata.totalTokenCount", tokens)
+	return data
+}
+
+// geminiEmbeddingsToVertexPredict converts a batchEmbedContents request into the instances
+// form of the Vertex AI predict endpoint.
+func geminiEmbeddingsToVertexPredict(batch []byte) []byte {
+	out := `{"instances":[]}`
+	requests := gjson.GetBytes(batch, "requests").Array()
+	for _, request := range requests {
+		instance := `{"content":""}`
+		instance, _ = sjson.Set(instance, "content", geminiEmbeddingText(request))
+		if taskType := request.Get("taskType"); taskType.Exists() {
+			instance, _ = sjson.Set(instance, "task_type", taskType.String())
+		}
+		if title := request.Get("title"); title.Exists() {
+			instance, _ = sjson.Set(instance, "title", title.String())
+		}
+		out, _ = sjson.SetRaw(out, "instances.-1", instance)
+	}
+	if len(requests) > 0 {
+		if dimensions := requests[0].Get("outputDimensionality"); dimensions.Exists() {
+			out, _ = sjson.Set(out, "parameters.outputDimensionality", dimensions.Int())
+		}
+	}
+	return []byte(out)
+}
+
+// vertexPredictToGeminiEmbeddings converts a Vertex AI predict response into the
+// batchEmbedContents response shape, summing the per-instance token counts.
+func vertexPredictToGeminiEmbeddings(data []byte) []byte {
+	out := `{"embeddings":[]}`
+	var tokens int64
+	predictions := gjson.GetBytes(data, "predictions").Array()
+	for _, prediction := range predictions {
+		values := prediction.Get("embeddings.values").Raw
+		if values == "" {
+			values = "[]"
+		}
+		out, _ = sjson.SetRaw(out, "embeddings.-1", `{"values":`+values+`}`)
+		tokens += prediction.Get("embeddings.statistics.token_count").Int()
+	}
+	if tokens > 0 {
+		out, _ = sjson.Set(out, "usageMetadata.promptTokenCount", tokens)
+		out, _ = sjson.Set(out, "usageMetadata.totalTokenCount", tokens)
+	}
+	return []byte(out)
+}
+
+var (
+	_ cliproxyauth.EmbeddingExecutor = (*GeminiExecutor)(nil)
+	_ cliproxyauth.EmbeddingExecutor = (*GeminiVertexExecutor)(nil)
+	_ cliproxyauth.EmbeddingExecutor = (*OpenAICompatExecutor)(nil)
+)
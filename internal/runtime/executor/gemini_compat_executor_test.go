@@ -0,0 +1,130 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func newGeminiCompatTestExecutor(baseURL string) (*GeminiCompatExecutor, *cliproxyauth.Auth) {
+	cfg := &config.Config{GeminiCompatibility: []config.ProtocolCompatibility{{
+		Name:    "gw",
+		BaseURL: baseURL,
+		Models:  []config.ProtocolCompatibilityModel{{Name: "upstream-flash", Alias: "flash"}},
+	}}}
+	auth := &cliproxyauth.Auth{ID: "gw-auth", Provider: "gw", Attributes: map[string]string{
+		"base_url":       baseURL,
+		"api_key":        "gw-key",
+		"compat_name":    "gw",
+		"compat_kind":    "gemini",
+		"provider_key":   "gw",
+		"header:X-Extra": "1",
+	}}
+	return NewGeminiCompatExecutor("gw", cfg), auth
+}
+
+func TestGeminiCompatExecutor_Execute(t *testing.T) {
+	upstream := &recordingUpstream{}
+	srv := httptest.NewServer(upstream.handler(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":2,"candidatesTokenCount":1,"totalTokenCount":3}}`)
+	}))
+	defer srv.Close()
+	exec, auth := newGeminiCompatTestExecutor(srv.URL)
+
+	payload := []byte(`{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}`)
+	resp, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "flash", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatGemini, OriginalRequest: payload})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	path, body := upstream.last(t)
+	if path != "/v1beta/models/upstream-flash:generateContent" {
+		t.Fatalf("upstream path = %q", path)
+	}
+	if gjson.GetBytes(body, "contents.0.parts.0.text").String() != "hi" {
+		t.Fatalf("upstream body = %s", body)
+	}
+	header := upstream.lastHeader(t)
+	if header.Get("x-goog-api-key") != "gw-key" || header.Get("Authorization") != "" || header.Get("X-Extra") != "1" {
+		t.Fatalf("upstream headers = %v", header)
+	}
+	if gjson.GetBytes(resp.Payload, "candidates.0.content.parts.0.text").String() != "ok" {
+		t.Fatalf("response = %s", resp.Payload)
+	}
+}
+
+func TestGeminiCompatExecutor_ExecuteStreamTranslates(t *testing.T) {
+	upstream := &recordingUpstream{}
+	srv := httptest.NewServer(upstream.handler(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\"hello\"}]}}]}\n\n"+
+			"data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\"\"}]},\"finishReason\":\"STOP\"}],\"usageMetadata\":{\"promptTokenCount\":1,\"candidatesTokenCount\":1,\"totalTokenCount\":2}}\n\n")
+	}))
+	defer srv.Close()
+	exec, auth := newGeminiCompatTestExecutor(srv.URL)
+
+	payload := []byte(`{"model":"flash","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	stream, err := exec.ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{Model: "flash", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAI, OriginalRequest: payload, Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	var out strings.Builder
+	for chunk := range stream {
+		if chunk.Err != nil {
+			t.Fatalf("stream error = %v", chunk.Err)
+		}
+		out.Write(chunk.Payload)
+	}
+	path, body := upstream.last(t)
+	if path != "/v1beta/models/upstream-flash:streamGenerateContent?alt=sse" {
+		t.Fatalf("upstream path = %q", path)
+	}
+	if gjson.GetBytes(body, "contents.0.parts.0.text").String() != "hi" {
+		t.Fatalf("upstream body = %s", body)
+	}
+	if !strings.Contains(out.String(), "chat.completion.chunk") || !strings.Contains(out.String(), "hello") {
+		t.Fatalf("stream was not translated to OpenAI chunks: %q", out.String())
+	}
+}
+
+func TestGeminiCompatExecutor_CountTokens(t *testing.T) {
+	upstream := &recordingUpstream{}
+	srv := httptest.NewServer(upstream.handler(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"totalTokens":5}`)
+	}))
+	defer srv.Close()
+	exec, auth := newGeminiCompatTestExecutor(srv.URL)
+
+	payload := []byte(`{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}`)
+	resp, err := exec.CountTokens(context.Background(), auth, cliproxyexecutor.Request{Model: "flash", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatGemini, OriginalRequest: payload})
+	if err != nil {
+		t.Fatalf("CountTokens() error = %v", err)
+	}
+	if path, _ := upstream.last(t); path != "/v1beta/models/upstream-flash:countTokens" {
+		t.Fatalf("upstream path = %q", path)
+	}
+	if gjson.GetBytes(resp.Payload, "totalTokens").Int() != 5 {
+		t.Fatalf("response = %s", resp.Payload)
+	}
+}
+
+func TestGeminiCompatExecutor_MissingBaseURL(t *testing.T) {
+	exec, auth := newGeminiCompatTestExecutor("")
+	_, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "flash", Payload: []byte(`{}`)},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatGemini})
+	status, ok := err.(statusErr)
+	if !ok || status.StatusCode() != http.StatusUnauthorized {
+		t.Fatalf("Execute() error = %v, want 401 statusErr", err)
+	}
+}
@@ -56,7 +56,10 @@ func (e *KiroExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req
 
 	from := opts.SourceFormat
 	to := sdktranslator.FromString("claude")
-	body := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), true)
+	body, errTranslate := translateRequest(ctx, from, to, req.Model, bytes.Clone(req.Payload), true)
+	if errTranslate != nil {
+		return resp, errTranslate
+	}
 
 	upstreamModel := util.ResolveOriginalModel(req.Model, req.Metadata)
 	if upstreamModel == "" {
@@ -76,9 +79,10 @@ func (e *KiroExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req
 
 	e.applyKiroHeaders(httpReq, tokenData)
 
-	var authID, authLabel, authType, authValue string
+	var authID, authIndex, authLabel, authType, authValue string
 	if auth != nil {
 		authID = auth.ID
+		authIndex = auth.EnsureIndex()
 		authLabel = auth.Label
 		authType, authValue = auth.AccountInfo()
 	}
@@ -89,6 +93,7 @@ func (e *KiroExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req
 		Body:      kiroReq,
 		Provider:  e.Identifier(),
 		AuthID:    authID,
+		AuthIndex: authIndex,
 		AuthLabel: authLabel,
 		AuthType:  authType,
 		AuthValue: authValue,
@@ -146,7 +151,10 @@ func (e *KiroExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req
 					inputTokens := estimateInputTokens(body)
 					claudeRespRetry := e.parseKiroResponse(dataRetry, req.Model, inputTokens)
 					var paramRetry any
-					outRetry := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, claudeRespRetry, &paramRetry)
+					outRetry, errTranslate := translateNonStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, claudeRespRetry, &paramRetry)
+					if errTranslate != nil {
+						return resp, errTranslate
+					}
 					return cliproxyexecutor.Response{Payload: []byte(outRetry)}, nil
 				}
 				bRetry, _ := io.ReadAll(httpRespRetry.Body)
@@ -206,7 +214,10 @@ func (e *KiroExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req
 	})
 
 	var param any
-	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, claudeResp, &param)
+	out, errTranslate := translateNonStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, claudeResp, &param)
+	if errTranslate != nil {
+		return resp, errTranslate
+	}
 	resp = cliproxyexecutor.Response{Payload: []byte(out)}
 	return resp, nil
 }
@@ -223,7 +234,10 @@ func (e *KiroExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Aut
 
 	from := opts.SourceFormat
 	to := sdktranslator.FromString("claude")
-	body := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), true)
+	body, errTranslate := translateRequest(ctx, from, to, req.Model, bytes.Clone(req.Payload), true)
+	if errTranslate != nil {
+		return nil, errTranslate
+	}
 
 	upstreamModel := util.ResolveOriginalModel(req.Model, req.Metadata)
 	if upstreamModel == "" {
@@ -243,9 +257,10 @@ func (e *KiroExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Aut
 
 	e.applyKiroHeaders(httpReq, tokenData)
 
-	var authID, authLabel, authType, authValue string
+	var authID, authIndex, authLabel, authType, authValue string
 	if auth != nil {
 		authID = auth.ID
+		authIndex = auth.EnsureIndex()
 		authLabel = auth.Label
 		authType, authValue = auth.AccountInfo()
 	}
@@ -256,6 +271,7 @@ func (e *KiroExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Aut
 		Body:      kiroReq,
 		Provider:  e.Identifier(),
 		AuthID:    authID,
+		AuthIndex: authIndex,
 		AuthLabel: authLabel,
 		AuthType:  authType,
 		AuthValue: authValue,
@@ -343,7 +359,12 @@ processStream:
 
 		// 辅助函数：发送 SSE 事件
 		// 当 from == to 时直接发送，否则通过 TranslateStream 转换
+		// 转换失败后停止发送后续事件
+		var translateErr error
 		sendEvent := func(event []byte) {
+			if translateErr != nil {
+				return
+			}
 			// 调试日志：记录发送的 SSE 事件
 			eventStr := string(event)
 			if len(eventStr) > 200 {
@@ -356,7 +377,13 @@ processStream:
 				// 直接发送 SSE 事件（参考 claude_executor 的做法）
 				out <- cliproxyexecutor.StreamChunk{Payload: event}
 			} else {
-				chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, event, &param)
+				chunks, errTranslate := translateStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, event, &param)
+				if errTranslate != nil {
+					translateErr = errTranslate
+					reporter.publishFailure(ctx)
+					out <- cliproxyexecutor.StreamChunk{Err: errTranslate}
+					return
+				}
 				for _, chunk := range chunks {
 					out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunk)}
 				}
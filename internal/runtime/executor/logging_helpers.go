@@ -12,6 +12,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 )
 
@@ -29,6 +30,7 @@ type upstreamRequestLog struct {
 	Body      []byte
 	Provider  string
 	AuthID    string
+	AuthIndex string
 	AuthLabel string
 	AuthType  string
 	AuthValue string
@@ -44,6 +46,8 @@ type upstreamAttempt struct {
 	bodyStarted          bool
 	bodyHasContent       bool
 	errorWritten         bool
+	// record is the structured form of the attempt used by the JSON Lines request log.
+	record *logging.UpstreamAttempt
 }
 
 // recordAPIRequest stores the upstream request metadata in Gin context for request logging.
@@ -58,6 +62,8 @@ func recordAPIRequest(ctx context.Context, cfg *config.Config, info upstreamRequ
 
 	attempts := getAttempts(ginCtx)
 	index := len(attempts) + 1
+	headers := http.Header(logging.RedactHeaders(info.Headers))
+	body := logging.RedactBody(info.Body)
 
 	builder := &strings.Builder{}
 	builder.WriteString(fmt.Sprintf("=== API REQUEST %d ===\n", index))
@@ -74,10 +80,10 @@ func recordAPIRequest(ctx context.Context, cfg *config.Config, info upstreamRequ
 		builder.WriteString(fmt.Sprintf("Auth: %s\n", auth))
 	}
 	builder.WriteString("\nHeaders:\n")
-	writeHeaders(builder, info.Headers)
+	writeHeaders(builder, headers)
 	builder.WriteString("\nBody:\n")
-	if len(info.Body) > 0 {
-		builder.WriteString(string(bytes.Clone(info.Body)))
+	if len(body) > 0 {
+		builder.WriteString(string(body))
 	} else {
 		builder.WriteString("<empty>")
 	}
@@ -87,6 +93,18 @@ func recordAPIRequest(ctx context.Context, cfg *config.Config, info upstreamRequ
 		index:    index,
 		request:  builder.String(),
 		response: &strings.Builder{},
+		record: &logging.UpstreamAttempt{
+			Index:     index,
+			Timestamp: time.Now(),
+			Provider:  strings.TrimSpace(info.Provider),
+			AuthID:    strings.TrimSpace(info.AuthID),
+			AuthIndex: info.AuthIndex,
+			AuthLabel: strings.TrimSpace(info.AuthLabel),
+			URL:       info.URL,
+			Method:    info.Method,
+			Headers:   logging.RecordHeaders(headers),
+			Body:      logging.RecordPayload(body),
+		},
 	}
 	attempts = append(attempts, attempt)
 	ginCtx.Set(apiAttemptsKey, attempts)
@@ -104,17 +122,21 @@ func recordAPIResponseMetadata(ctx context.Context, cfg *config.Config, status i
 	}
 	attempts, attempt := ensureAttempt(ginCtx)
 	ensureResponseIntro(attempt)
+	headers = logging.RedactHeaders(headers)
 
 	if status > 0 && !attempt.statusWritten {
 		attempt.response.WriteString(fmt.Sprintf("Status: %d\n", status))
 		attempt.statusWritten = true
+		attempt.record.Status = status
 	}
 	if !attempt.headersWritten {
 		attempt.response.WriteString("Headers:\n")
 		writeHeaders(attempt.response, headers)
 		attempt.headersWritten = true
 		attempt.response.WriteString("\n")
+		attempt.record.ResponseHeaders = logging.RecordHeaders(headers)
 	}
+	touchRecord(attempt.record)
 
 	updateAggregatedResponse(ginCtx, attempts)
 }
@@ -138,8 +160,11 @@ func recordAPIResponseError(ctx context.Context, cfg *config.Config, err error)
 	if attempt.errorWritten {
 		attempt.response.WriteString("\n")
 	}
-	attempt.response.WriteString(fmt.Sprintf("Error: %s\n", err.Error()))
+	message := logging.RedactText(err.Error())
+	attempt.response.WriteString(fmt.Sprintf("Error: %s\n", message))
 	attempt.errorWritten = true
+	attempt.record.Errors = append(attempt.record.Errors, message)
+	touchRecord(attempt.record)
 
 	updateAggregatedResponse(ginCtx, attempts)
 }
@@ -149,7 +174,7 @@ func appendAPIResponseChunk(ctx context.Context, cfg *config.Config, chunk []byt
 	if cfg == nil || !cfg.RequestLog {
 		return
 	}
-	data := bytes.TrimSpace(bytes.Clone(chunk))
+	data := bytes.TrimSpace(logging.RedactBody(bytes.Clone(chunk)))
 	if len(data) == 0 {
 		return
 	}
@@ -175,6 +200,8 @@ func appendAPIResponseChunk(ctx context.Context, cfg *config.Config, chunk []byt
 	}
 	attempt.response.WriteString(string(data))
 	attempt.bodyHasContent = true
+	attempt.record.Response = append(attempt.record.Response, logging.RecordPayload(data))
+	touchRecord(attempt.record)
 
 	updateAggregatedResponse(ginCtx, attempts)
 }
@@ -203,6 +230,7 @@ func ensureAttempt(ginCtx *gin.Context) ([]*upstreamAttempt, *upstreamAttempt) {
 			index:    1,
 			request:  "=== API REQUEST 1 ===\n<missing>\n\n",
 			response: &strings.Builder{},
+			record:   &logging.UpstreamAttempt{Index: 1, Timestamp: time.Now()},
 		}
 		attempts = []*upstreamAttempt{attempt}
 		ginCtx.Set(apiAttemptsKey, attempts)
@@ -226,10 +254,18 @@ func updateAggregatedRequest(ginCtx *gin.Context, attempts []*upstreamAttempt) {
 		return
 	}
 	var builder strings.Builder
+	records := make([]*logging.UpstreamAttempt, 0, len(attempts))
 	for _, attempt := range attempts {
 		builder.WriteString(attempt.request)
+		records = append(records, attempt.record)
 	}
 	ginCtx.Set(apiRequestKey, []byte(builder.String()))
+	ginCtx.Set(logging.UpstreamAttemptsKey, records)
+}
+
+// touchRecord extends the latency of an attempt to the latest response event.
+func touchRecord(record *logging.UpstreamAttempt) {
+	record.LatencyMS = time.Since(record.Timestamp).Milliseconds()
 }
 
 func updateAggregatedResponse(ginCtx *gin.Context, attempts []*upstreamAttempt) {
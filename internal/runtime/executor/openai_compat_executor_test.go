@@ -0,0 +1,233 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// recordingUpstream captures the requests an executor sends and answers with canned bodies.
+type recordingUpstream struct {
+	mu      sync.Mutex
+	paths   []string
+	headers []http.Header
+	bodies  [][]byte
+}
+
+func (u *recordingUpstream) handler(t *testing.T, respond func(w http.ResponseWriter, r *http.Request)) http.Handler {
+	t.Helper()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		u.mu.Lock()
+		u.paths = append(u.paths, r.URL.RequestURI())
+		u.headers = append(u.headers, r.Header.Clone())
+		u.bodies = append(u.bodies, body)
+		u.mu.Unlock()
+		respond(w, r)
+	})
+}
+
+func (u *recordingUpstream) last(t *testing.T) (string, []byte) {
+	t.Helper()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if len(u.paths) == 0 {
+		t.Fatalf("upstream received no request")
+	}
+	return u.paths[len(u.paths)-1], u.bodies[len(u.bodies)-1]
+}
+
+func (u *recordingUpstream) lastHeader(t *testing.T) http.Header {
+	t.Helper()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if len(u.headers) == 0 {
+		t.Fatalf("upstream received no request")
+	}
+	return u.headers[len(u.headers)-1]
+}
+
+// markingPipeline returns a context whose translator pipeline tags every request body, so
+// tests can tell whether a payload went through the request middleware.
+func markingPipeline() context.Context {
+	pipeline := sdktranslator.NewPipeline(nil)
+	pipeline.UseRequest(func(ctx context.Context, req sdktranslator.RequestEnvelope, next sdktranslator.RequestHandler) (sdktranslator.RequestEnvelope, error) {
+		req.Body, _ = sjson.SetBytes(req.Body, "x_middleware", true)
+		return next(ctx, req)
+	})
+	return sdktranslator.WithPipeline(context.Background(), pipeline)
+}
+
+func newWireAPITestExecutor(baseURL, wireAPI string) (*OpenAICompatExecutor, *cliproxyauth.Auth) {
+	cfg := &config.Config{OpenAICompatibility: []config.OpenAICompatibility{{
+		Name:    "wire",
+		BaseURL: baseURL,
+		WireAPI: wireAPI,
+		Models:  []config.OpenAICompatibilityModel{{Name: "upstream-model", Alias: "alias-model"}},
+	}}}
+	auth := &cliproxyauth.Auth{ID: "wire-auth", Provider: "wire", Attributes: map[string]string{
+		"base_url":     baseURL,
+		"api_key":      "key",
+		"compat_name":  "wire",
+		"provider_key": "wire",
+	}}
+	return NewOpenAICompatExecutor("wire", cfg), auth
+}
+
+func TestOpenAICompatExecutor_WireAPIResponsesPassthrough(t *testing.T) {
+	upstream := &recordingUpstream{}
+	srv := httptest.NewServer(upstream.handler(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"id":"resp_up","object":"response","output":[],"usage":{"input_tokens":3,"output_tokens":2,"total_tokens":5}}`)
+	}))
+	defer srv.Close()
+	exec, auth := newWireAPITestExecutor(srv.URL, config.WireAPIResponses)
+
+	payload := []byte(`{"model":"alias-model","input":"hi"}`)
+	resp, err := exec.Execute(markingPipeline(), auth, cliproxyexecutor.Request{Model: "alias-model", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAIResponse, OriginalRequest: payload})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	path, body := upstream.last(t)
+	if path != "/responses" {
+		t.Fatalf("upstream path = %q, want /responses", path)
+	}
+	if gjson.GetBytes(body, "input").String() != "hi" || gjson.GetBytes(body, "messages").Exists() {
+		t.Fatalf("responses request was translated: %s", body)
+	}
+	if gjson.GetBytes(body, "model").String() != "upstream-model" {
+		t.Fatalf("upstream model = %s", gjson.GetBytes(body, "model").Raw)
+	}
+	if !gjson.GetBytes(body, "x_middleware").Bool() {
+		t.Fatalf("passthrough body skipped the request middleware: %s", body)
+	}
+	if gjson.GetBytes(resp.Payload, "id").String() != "resp_up" {
+		t.Fatalf("response = %s", resp.Payload)
+	}
+}
+
+func TestOpenAICompatExecutor_WireAPIResponsesStream(t *testing.T) {
+	upstream := &recordingUpstream{}
+	srv := httptest.NewServer(upstream.handler(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "event: response.output_text.delta\ndata: {\"type\":\"response.output_text.delta\",\"delta\":\"hi\"}\n\n"+
+			"event: response.completed\ndata: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_up\",\"usage\":{\"input_tokens\":1,\"output_tokens\":1}}}\n\n")
+	}))
+	defer srv.Close()
+	exec, auth := newWireAPITestExecutor(srv.URL, config.WireAPIResponses)
+
+	payload := []byte(`{"model":"alias-model","input":"hi","stream":true}`)
+	stream, err := exec.ExecuteStream(markingPipeline(), auth, cliproxyexecutor.Request{Model: "alias-model", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAIResponse, OriginalRequest: payload, Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	var out strings.Builder
+	for chunk := range stream {
+		if chunk.Err != nil {
+			t.Fatalf("stream error = %v", chunk.Err)
+		}
+		out.Write(chunk.Payload)
+	}
+	if path, body := upstream.last(t); path != "/responses" || !gjson.GetBytes(body, "x_middleware").Bool() {
+		t.Fatalf("upstream request = %s %s", path, body)
+	}
+	if !strings.Contains(out.String(), "response.completed") {
+		t.Fatalf("stream was not passed through: %q", out.String())
+	}
+}
+
+func TestOpenAICompatExecutor_WireAPICompletionsPassthrough(t *testing.T) {
+	upstream := &recordingUpstream{}
+	srv := httptest.NewServer(upstream.handler(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"id":"cmpl_up","object":"text_completion","choices":[{"index":0,"text":"there","finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`)
+	}))
+	defer srv.Close()
+	exec, auth := newWireAPITestExecutor(srv.URL, config.WireAPICompletions)
+
+	completions := []byte(`{"model":"alias-model","prompt":"hi"}`)
+	chat := []byte(`{"model":"alias-model","messages":[{"role":"user","content":"hi"}]}`)
+	resp, err := exec.Execute(markingPipeline(), auth, cliproxyexecutor.Request{Model: "alias-model", Payload: chat}, cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FormatOpenAI,
+		Metadata:     map[string]any{cliproxyexecutor.CompletionsRequestMetadataKey: completions},
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	path, body := upstream.last(t)
+	if path != "/completions" {
+		t.Fatalf("upstream path = %q, want /completions", path)
+	}
+	if gjson.GetBytes(body, "prompt").String() != "hi" || gjson.GetBytes(body, "messages").Exists() {
+		t.Fatalf("completions request was not passed through: %s", body)
+	}
+	if gjson.GetBytes(body, "model").String() != "upstream-model" || !gjson.GetBytes(body, "x_middleware").Bool() {
+		t.Fatalf("completions body = %s", body)
+	}
+	if gjson.GetBytes(resp.Payload, "object").String() != "text_completion" {
+		t.Fatalf("response = %s", resp.Payload)
+	}
+}
+
+func TestOpenAICompatExecutor_WireAPIFallsBackToChatCompletions(t *testing.T) {
+	upstream := &recordingUpstream{}
+	srv := httptest.NewServer(upstream.handler(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"id":"chat_up","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+	}))
+	defer srv.Close()
+
+	chat := []byte(`{"model":"alias-model","messages":[{"role":"user","content":"hi"}]}`)
+	for _, wireAPI := range []string{config.WireAPIResponses, config.WireAPICompletions, ""} {
+		exec, auth := newWireAPITestExecutor(srv.URL, wireAPI)
+		// Chat requests and completions requests without the original body use chat completions.
+		if _, err := exec.Execute(markingPipeline(), auth, cliproxyexecutor.Request{Model: "alias-model", Payload: chat},
+			cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAI}); err != nil {
+			t.Fatalf("wire-api %q: Execute() error = %v", wireAPI, err)
+		}
+		if path, body := upstream.last(t); path != "/chat/completions" || gjson.GetBytes(body, "messages.0.content").String() != "hi" {
+			t.Fatalf("wire-api %q: upstream request = %s %s", wireAPI, path, body)
+		}
+	}
+
+	// Responses requests are translated to chat completions unless wire-api is responses.
+	exec, auth := newWireAPITestExecutor(srv.URL, config.WireAPICompletions)
+	payload := []byte(`{"model":"alias-model","input":"hi"}`)
+	if _, err := exec.Execute(markingPipeline(), auth, cliproxyexecutor.Request{Model: "alias-model", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAIResponse, OriginalRequest: payload}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if path, body := upstream.last(t); path != "/chat/completions" || !gjson.GetBytes(body, "messages").Exists() {
+		t.Fatalf("responses request was not translated: %s %s", path, body)
+	}
+}
+
+func TestParseOpenAIResponsesUsage(t *testing.T) {
+	detail := parseOpenAIResponsesUsage([]byte(`{"usage":{"input_tokens":10,"output_tokens":4,"total_tokens":14,` +
+		`"input_tokens_details":{"cached_tokens":6},"output_tokens_details":{"reasoning_tokens":2}}}`))
+	if detail.InputTokens != 10 || detail.OutputTokens != 4 || detail.TotalTokens != 14 || detail.CachedTokens != 6 || detail.ReasoningTokens != 2 {
+		t.Fatalf("usage = %+v", detail)
+	}
+	if detail = parseOpenAIResponsesUsage([]byte(`{"id":"resp"}`)); detail.TotalTokens != 0 {
+		t.Fatalf("usage without usage node = %+v", detail)
+	}
+
+	if _, ok := parseOpenAIResponsesStreamUsage([]byte(`data: {"type":"response.output_text.delta","delta":"x"}`)); ok {
+		t.Fatalf("delta event reported usage")
+	}
+	detail, ok := parseOpenAIResponsesStreamUsage([]byte(`data: {"type":"response.completed","response":{"usage":{"input_tokens":3,"output_tokens":5,"total_tokens":8}}}`))
+	if !ok || detail.InputTokens != 3 || detail.OutputTokens != 5 || detail.TotalTokens != 8 {
+		t.Fatalf("stream usage = %+v, %v", detail, ok)
+	}
+}
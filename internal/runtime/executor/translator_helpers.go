@@ -0,0 +1,69 @@
+package executor
+
+import (
+	"context"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// translateRequest converts a request payload through the translator pipeline carried by ctx,
+// so request middleware registered by the host applies to every provider.
+func translateRequest(ctx context.Context, from, to sdktranslator.Format, model string, payload []byte, stream bool) ([]byte, error) {
+	ctx, span := startTranslateSpan(ctx, "translate.request", from, to, model)
+	defer span.End()
+	envelope := sdktranslator.RequestEnvelope{Format: from, Model: model, Stream: stream, Body: payload}
+	translated, err := sdktranslator.PipelineFromContext(ctx).TranslateRequest(ctx, from, to, envelope)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	return translated.Body, nil
+}
+
+// translateOriginalRequest converts the client's original payload with the registry of the pipeline
+// carried by ctx. Request middleware is skipped: it already runs once on the payload sent upstream,
+// and the translated original only feeds the response translators.
+func translateOriginalRequest(ctx context.Context, from, to sdktranslator.Format, model string, payload []byte, stream bool) []byte {
+	return sdktranslator.PipelineFromContext(ctx).Registry().TranslateRequest(from, to, model, payload, stream)
+}
+
+// translateNonStream converts a complete provider response through the translator pipeline carried by ctx.
+func translateNonStream(ctx context.Context, from, to sdktranslator.Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) (string, error) {
+	ctx, span := startTranslateSpan(ctx, "translate.response", from, to, model)
+	defer span.End()
+	envelope := sdktranslator.ResponseEnvelope{Format: from, Model: model, Body: rawJSON}
+	translated, err := sdktranslator.PipelineFromContext(ctx).TranslateResponse(ctx, from, to, envelope, originalRequestRawJSON, requestRawJSON, param)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return "", err
+	}
+	return string(translated.Body), nil
+}
+
+// startTranslateSpan opens a span for a whole-payload translation. Stream chunks are not
+// traced individually to keep traces readable.
+func startTranslateSpan(ctx context.Context, name string, from, to sdktranslator.Format, model string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, name, trace.WithAttributes(
+		attribute.String("cliproxy.translate.from", from.String()),
+		attribute.String("cliproxy.translate.to", to.String()),
+		tracing.AttrModel.String(model),
+	))
+}
+
+// translateStream converts a single provider stream chunk through the translator pipeline carried by ctx.
+func translateStream(ctx context.Context, from, to sdktranslator.Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) ([]string, error) {
+	envelope := sdktranslator.ResponseEnvelope{Format: from, Model: model, Stream: true, Body: rawJSON}
+	translated, err := sdktranslator.PipelineFromContext(ctx).TranslateResponse(ctx, from, to, envelope, originalRequestRawJSON, requestRawJSON, param)
+	if err != nil {
+		return nil, err
+	}
+	return translated.Chunks, nil
+}
+
+// translateTokenCount converts a token count using the registry of the pipeline carried by ctx.
+func translateTokenCount(ctx context.Context, from, to sdktranslator.Format, count int64, rawJSON []byte) string {
+	return sdktranslator.PipelineFromContext(ctx).TranslateTokenCount(ctx, from, to, count, rawJSON)
+}
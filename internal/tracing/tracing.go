@@ -0,0 +1,192 @@
+// Package tracing wires OpenTelemetry trace export and provides the span helpers used along
+// the request path: inbound handler, translation, credential attempts, cooldown waits and
+// upstream HTTP calls. When tracing is disabled the global no-op provider is left in place,
+// so instrumented code paths cost next to nothing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName identifies spans produced by the proxy.
+const InstrumentationName = "github.com/router-for-me/CLIProxyAPI/v6"
+
+const (
+	defaultServiceName = "cli-proxy-api"
+	exporterOTLPHTTP   = "otlp-http"
+	exporterFile       = "file"
+)
+
+// Attribute keys shared by proxy spans.
+const (
+	AttrProvider        = attribute.Key("cliproxy.provider")
+	AttrModel           = attribute.Key("cliproxy.model")
+	AttrAuthIndex       = attribute.Key("cliproxy.auth_index")
+	AttrAttempt         = attribute.Key("cliproxy.attempt")
+	AttrRequestID       = attribute.Key("cliproxy.request_id")
+	AttrStream          = attribute.Key("cliproxy.stream")
+	AttrInputTokens     = attribute.Key("gen_ai.usage.input_tokens")
+	AttrOutputTokens    = attribute.Key("gen_ai.usage.output_tokens")
+	AttrReasoningTokens = attribute.Key("cliproxy.usage.reasoning_tokens")
+	AttrCachedTokens    = attribute.Key("cliproxy.usage.cached_tokens")
+	AttrTotalTokens     = attribute.Key("cliproxy.usage.total_tokens")
+)
+
+// Tracer returns the proxy tracer from the global provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// Setup installs a global tracer provider according to cfg.Tracing and returns a shutdown
+// function that flushes pending spans. It returns a no-op shutdown when tracing is disabled.
+func Setup(ctx context.Context, cfg *config.Config) (func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+	if cfg == nil || !cfg.Tracing.Enable {
+		return noop, nil
+	}
+	tc := cfg.Tracing
+
+	var (
+		exporter sdktrace.SpanExporter
+		closer   func() error
+		err      error
+	)
+	switch strings.ToLower(strings.TrimSpace(tc.Exporter)) {
+	case "", exporterOTLPHTTP:
+		opts := make([]otlptracehttp.Option, 0, 2)
+		if endpoint := strings.TrimSpace(tc.Endpoint); endpoint != "" {
+			if strings.Contains(endpoint, "://") {
+				opts = append(opts, otlptracehttp.WithEndpointURL(endpoint))
+			} else {
+				opts = append(opts, otlptracehttp.WithEndpoint(endpoint))
+			}
+		}
+		if tc.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case exporterFile:
+		path := strings.TrimSpace(tc.FilePath)
+		if path == "" {
+			path = defaultTraceFile()
+		}
+		if errMkdir := os.MkdirAll(filepath.Dir(path), 0o755); errMkdir != nil {
+			return noop, fmt.Errorf("tracing: create trace directory: %w", errMkdir)
+		}
+		file, errOpen := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if errOpen != nil {
+			return noop, fmt.Errorf("tracing: open trace file: %w", errOpen)
+		}
+		closer = file.Close
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+	default:
+		return noop, fmt.Errorf("tracing: unsupported exporter %q", tc.Exporter)
+	}
+	if err != nil {
+		if closer != nil {
+			_ = closer()
+		}
+		return noop, fmt.Errorf("tracing: create exporter: %w", err)
+	}
+
+	serviceName := strings.TrimSpace(tc.ServiceName)
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", serviceName),
+		attribute.String("service.version", buildinfo.Version),
+	))
+	if err != nil {
+		res = resource.Default()
+	}
+
+	ratio := tc.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	log.Infof("tracing enabled (exporter=%s, sample-ratio=%.2f)", strings.ToLower(strings.TrimSpace(tc.Exporter)), ratio)
+
+	return func(shutdownCtx context.Context) error {
+		errShutdown := provider.Shutdown(shutdownCtx)
+		if closer != nil {
+			if errClose := closer(); errClose != nil && errShutdown == nil {
+				errShutdown = errClose
+			}
+		}
+		return errShutdown
+	}, nil
+}
+
+func defaultTraceFile() string {
+	dir := "logs"
+	if base := util.WritablePath(); base != "" {
+		dir = filepath.Join(base, "logs")
+	}
+	return filepath.Join(dir, "traces.jsonl")
+}
+
+// InheritSpan copies the active span of src into dst when dst carries none. Handlers derive
+// execution contexts from context.Background(), so the inbound span has to be carried over.
+func InheritSpan(dst, src context.Context) context.Context {
+	if dst == nil || src == nil {
+		return dst
+	}
+	if trace.SpanContextFromContext(dst).IsValid() {
+		return dst
+	}
+	span := trace.SpanFromContext(src)
+	if !span.SpanContext().IsValid() {
+		return dst
+	}
+	return trace.ContextWithSpan(dst, span)
+}
+
+// RecordError marks span as failed with err.
+func RecordError(span trace.Span, err error) {
+	if span == nil || err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// RecordUsage attaches token counts to the active span of ctx.
+func RecordUsage(ctx context.Context, input, output, reasoning, cached, total int64) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	span.SetAttributes(
+		AttrInputTokens.Int64(input),
+		AttrOutputTokens.Int64(output),
+		AttrReasoningTokens.Int64(reasoning),
+		AttrCachedTokens.Int64(cached),
+		AttrTotalTokens.Int64(total),
+	)
+}
@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTransportSpanEndsOnBodyClose(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte("slow down"))
+	}))
+	defer upstream.Close()
+
+	ctx, parent := Tracer().Start(context.Background(), "attempt")
+	detached := InheritSpan(context.Background(), ctx)
+	req, _ := http.NewRequestWithContext(detached, http.MethodPost, upstream.URL+"/v1/messages", nil)
+	resp, err := (&http.Client{Transport: Transport(nil)}).Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if len(recorder.Ended()) != 0 {
+		t.Fatalf("upstream span ended before the body was consumed")
+	}
+	_, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	parent.End()
+
+	ended := recorder.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("ended spans = %d, want 2", len(ended))
+	}
+	upstreamSpan := ended[0]
+	if upstreamSpan.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("upstream span is not a child of the attempt span")
+	}
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range upstreamSpan.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if got := attrs["http.response.status_code"].AsInt64(); got != http.StatusTooManyRequests {
+		t.Fatalf("status attribute = %d, want 429", got)
+	}
+	if got := attrs["http.response.body.size"].AsInt64(); got != int64(len("slow down")) {
+		t.Fatalf("body size attribute = %d", got)
+	}
+}
@@ -0,0 +1,59 @@
+// Package embeddings translates OpenAI embeddings requests into the Gemini batchEmbedContents
+// shape and Gemini embedding results back into OpenAI embedding lists.
+package embeddings
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertOpenAIEmbeddingsRequestToGemini converts an OpenAI /v1/embeddings request into a
+// batchEmbedContents request with one entry per input string. Gemini has no equivalent for
+// token-array inputs; they are sent as empty text and rejected by the upstream.
+//
+// Parameters:
+//   - modelName: The upstream model name
+//   - inputRawJSON: The raw JSON request body in OpenAI embeddings format
+//   - stream: Unused, embeddings are never streamed
+//
+// Returns:
+//   - []byte: The request body in Gemini batchEmbedContents format
+func ConvertOpenAIEmbeddingsRequestToGemini(modelName string, inputRawJSON []byte, _ bool) []byte {
+	root := gjson.ParseBytes(inputRawJSON)
+
+	entry := `{"model":"","content":{"parts":[{"text":""}]}}`
+	entry, _ = sjson.Set(entry, "model", "models/"+strings.TrimPrefix(modelName, "models/"))
+	if dimensions := root.Get("dimensions"); dimensions.Exists() && dimensions.Int() > 0 {
+		entry, _ = sjson.Set(entry, "outputDimensionality", dimensions.Int())
+	}
+
+	out := `{"requests":[]}`
+	for _, text := range embeddingInputs(root.Get("input")) {
+		item, _ := sjson.Set(entry, "content.parts.0.text", text)
+		out, _ = sjson.SetRaw(out, "requests.-1", item)
+	}
+	return []byte(out)
+}
+
+// embeddingInputs flattens the OpenAI input field, which may be a string, an array of
+// strings, a token array or an array of token arrays.
+func embeddingInputs(input gjson.Result) []string {
+	if !input.IsArray() {
+		return []string{input.String()}
+	}
+	items := input.Array()
+	if len(items) > 0 && items[0].Type == gjson.Number {
+		return []string{""}
+	}
+	texts := make([]string, 0, len(items))
+	for _, item := range items {
+		if item.Type == gjson.String {
+			texts = append(texts, item.String())
+		} else {
+			texts = append(texts, "")
+		}
+	}
+	return texts
+}
@@ -0,0 +1,70 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertGeminiEmbeddingsResponseToOpenAI converts a batchEmbedContents (or embedContent)
+// response into an OpenAI embedding list. Vectors are base64 encoded as little-endian float32
+// when the original request asked for encoding_format "base64".
+//
+// Parameters:
+//   - ctx: The context for the request
+//   - modelName: The model name reported back to the client
+//   - originalRequestRawJSON: The original OpenAI embeddings request
+//   - requestRawJSON: The translated Gemini request
+//   - rawJSON: The raw JSON response from the Gemini API
+//   - param: Unused
+//
+// Returns:
+//   - string: The OpenAI embeddings response
+func ConvertGeminiEmbeddingsResponseToOpenAI(_ context.Context, modelName string, originalRequestRawJSON, _ []byte, rawJSON []byte, _ *any) string {
+	root := gjson.ParseBytes(rawJSON)
+	encodeBase64 := gjson.GetBytes(originalRequestRawJSON, "encoding_format").String() == "base64"
+
+	out := `{"object":"list","data":[],"model":"","usage":{"prompt_tokens":0,"total_tokens":0}}`
+	out, _ = sjson.Set(out, "model", modelName)
+
+	embeddings := root.Get("embeddings").Array()
+	if single := root.Get("embedding"); len(embeddings) == 0 && single.Exists() {
+		embeddings = []gjson.Result{single}
+	}
+	for index, embedding := range embeddings {
+		entry := `{"object":"embedding","index":0,"embedding":[]}`
+		entry, _ = sjson.Set(entry, "index", index)
+		values := embedding.Get("values")
+		if encodeBase64 {
+			entry, _ = sjson.Set(entry, "embedding", float32Base64(values))
+		} else if values.IsArray() {
+			entry, _ = sjson.SetRaw(entry, "embedding", values.Raw)
+		}
+		out, _ = sjson.SetRaw(out, "data.-1", entry)
+	}
+
+	if usage := root.Get("usageMetadata"); usage.Exists() {
+		prompt := usage.Get("promptTokenCount").Int()
+		total := usage.Get("totalTokenCount").Int()
+		if total == 0 {
+			total = prompt
+		}
+		out, _ = sjson.Set(out, "usage.prompt_tokens", prompt)
+		out, _ = sjson.Set(out, "usage.total_tokens", total)
+	}
+	return out
+}
+
+// float32Base64 packs a JSON number array the way OpenAI does for base64 embeddings.
+func float32Base64(values gjson.Result) string {
+	items := values.Array()
+	buf := make([]byte, 4*len(items))
+	for i, item := range items {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(float32(item.Float())))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,60 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIEmbeddingsRequestToGemini(t *testing.T) {
+	out := ConvertOpenAIEmbeddingsRequestToGemini("gemini-embedding-001", []byte(`{"model":"alias","input":["alpha","beta"],"dimensions":256}`), false)
+
+	requests := gjson.GetBytes(out, "requests").Array()
+	if len(requests) != 2 {
+		t.Fatalf("requests = %s, want two entries", out)
+	}
+	for i, want := range []string{"alpha", "beta"} {
+		if got := requests[i].Get("content.parts.0.text").String(); got != want {
+			t.Fatalf("requests[%d] text = %q, want %q", i, got, want)
+		}
+		if got := requests[i].Get("model").String(); got != "models/gemini-embedding-001" {
+			t.Fatalf("requests[%d] model = %q", i, got)
+		}
+		if got := requests[i].Get("outputDimensionality").Int(); got != 256 {
+			t.Fatalf("requests[%d] outputDimensionality = %d", i, got)
+		}
+	}
+
+	single := ConvertOpenAIEmbeddingsRequestToGemini("gemini-embedding-001", []byte(`{"input":"only"}`), false)
+	if got := gjson.GetBytes(single, "requests.#").Int(); got != 1 {
+		t.Fatalf("string input produced %d entries", got)
+	}
+}
+
+func TestConvertGeminiEmbeddingsResponseToOpenAI(t *testing.T) {
+	response := []byte(`{"embeddings":[{"values":[0.5,-1]},{"values":[2]}],"usageMetadata":{"promptTokenCount":7,"totalTokenCount":7}}`)
+
+	out := ConvertGeminiEmbeddingsResponseToOpenAI(context.Background(), "alias", []byte(`{"input":["a","b"]}`), nil, response, nil)
+	if got := gjson.Get(out, "data.1.index").Int(); got != 1 {
+		t.Fatalf("data[1].index = %d", got)
+	}
+	if got := gjson.Get(out, "data.0.embedding").Raw; got != "[0.5,-1]" {
+		t.Fatalf("data[0].embedding = %s", got)
+	}
+	if gjson.Get(out, "model").String() != "alias" || gjson.Get(out, "usage.prompt_tokens").Int() != 7 {
+		t.Fatalf("unexpected envelope: %s", out)
+	}
+
+	encoded := ConvertGeminiEmbeddingsResponseToOpenAI(context.Background(), "alias", []byte(`{"input":"a","encoding_format":"base64"}`), nil, response, nil)
+	raw, err := base64.StdEncoding.DecodeString(gjson.Get(encoded, "data.0.embedding").String())
+	if err != nil || len(raw) != 8 {
+		t.Fatalf("base64 embedding = %s (err %v)", gjson.Get(encoded, "data.0.embedding").Raw, err)
+	}
+	if got := math.Float32frombits(binary.LittleEndian.Uint32(raw[4:])); got != -1 {
+		t.Fatalf("decoded second value = %v, want -1", got)
+	}
+}
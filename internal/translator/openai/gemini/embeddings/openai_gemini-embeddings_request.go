@@ -0,0 +1,43 @@
+// Package embeddings translates Gemini batchEmbedContents requests into OpenAI embeddings
+// requests and OpenAI embedding lists back into Gemini embedding results.
+package embeddings
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertGeminiEmbeddingsRequestToOpenAI converts a batchEmbedContents request into an OpenAI
+// /v1/embeddings request. The text parts of each entry become one input string; taskType and
+// title have no OpenAI equivalent and are dropped.
+//
+// Parameters:
+//   - modelName: The upstream model name
+//   - inputRawJSON: The raw JSON request body in Gemini batchEmbedContents format
+//   - stream: Unused, embeddings are never streamed
+//
+// Returns:
+//   - []byte: The request body in OpenAI embeddings format
+func ConvertGeminiEmbeddingsRequestToOpenAI(modelName string, inputRawJSON []byte, _ bool) []byte {
+	root := gjson.ParseBytes(inputRawJSON)
+
+	out := `{"model":"","input":[],"encoding_format":"float"}`
+	out, _ = sjson.Set(out, "model", modelName)
+	root.Get("requests").ForEach(func(_, request gjson.Result) bool {
+		var texts []string
+		request.Get("content.parts").ForEach(func(_, part gjson.Result) bool {
+			if text := part.Get("text"); text.Exists() {
+				texts = append(texts, text.String())
+			}
+			return true
+		})
+		out, _ = sjson.Set(out, "input.-1", strings.Join(texts, "\n"))
+		if dimensions := request.Get("outputDimensionality"); dimensions.Exists() && !gjson.Get(out, "dimensions").Exists() {
+			out, _ = sjson.Set(out, "dimensions", dimensions.Int())
+		}
+		return true
+	})
+	return []byte(out)
+}
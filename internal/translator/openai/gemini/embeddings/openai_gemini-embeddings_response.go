@@ -0,0 +1,68 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertOpenAIEmbeddingsResponseToGemini converts an OpenAI embedding list into a
+// batchEmbedContents response, ordered by input index. Prompt token usage is carried over as
+// usageMetadata.
+//
+// Parameters:
+//   - ctx: The context for the request
+//   - modelName: Unused
+//   - originalRequestRawJSON: Unused
+//   - requestRawJSON: Unused
+//   - rawJSON: The raw JSON response from the OpenAI-compatible upstream
+//   - param: Unused
+//
+// Returns:
+//   - string: The Gemini batchEmbedContents response
+func ConvertOpenAIEmbeddingsResponseToGemini(_ context.Context, _ string, _, _ []byte, rawJSON []byte, _ *any) string {
+	root := gjson.ParseBytes(rawJSON)
+
+	data := root.Get("data").Array()
+	sort.SliceStable(data, func(i, j int) bool { return data[i].Get("index").Int() < data[j].Get("index").Int() })
+
+	out := `{"embeddings":[]}`
+	for _, item := range data {
+		embedding := item.Get("embedding")
+		values := embedding.Raw
+		if embedding.Type == gjson.String {
+			values = decodeFloat32Base64(embedding.String())
+		}
+		if values == "" {
+			values = "[]"
+		}
+		out, _ = sjson.SetRaw(out, "embeddings.-1", `{"values":`+values+`}`)
+	}
+
+	if usage := root.Get("usage"); usage.Exists() {
+		out, _ = sjson.Set(out, "usageMetadata.promptTokenCount", usage.Get("prompt_tokens").Int())
+		out, _ = sjson.Set(out, "usageMetadata.totalTokenCount", usage.Get("total_tokens").Int())
+	}
+	return out
+}
+
+// decodeFloat32Base64 unpacks a base64 little-endian float32 vector into a JSON array.
+func decodeFloat32Base64(encoded string) string {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "[]"
+	}
+	values := make([]string, 0, len(raw)/4)
+	for i := 0; i+4 <= len(raw); i += 4 {
+		value := math.Float32frombits(binary.LittleEndian.Uint32(raw[i:]))
+		values = append(values, strconv.FormatFloat(float64(value), 'g', -1, 32))
+	}
+	return "[" + strings.Join(values, ",") + "]"
+}
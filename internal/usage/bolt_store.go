@@ -0,0 +1,231 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	boltRecordsBucket = []byte("records")
+	boltRollupsBucket = []byte("rollups")
+)
+
+// BoltStore persists usage in a local bbolt database file.
+// Raw records are keyed by timestamp so time-range queries are cursor seeks.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens (or creates) the database file at path.
+func NewBoltStore(path string) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("usage bolt store: create directory: %w", err)
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("usage bolt store: open %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, errCreate := tx.CreateBucketIfNotExists(boltRecordsBucket); errCreate != nil {
+			return errCreate
+		}
+		_, errCreate := tx.CreateBucketIfNotExists(boltRollupsBucket)
+		return errCreate
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("usage bolt store: create buckets: %w", err)
+	}
+	return &BoltStore{db: db}, nil
+}
+
+// Append implements Store.
+func (s *BoltStore) Append(_ context.Context, records []PersistedRecord) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltRecordsBucket)
+		for _, record := range records {
+			seq, err := bucket.NextSequence()
+			if err != nil {
+				return err
+			}
+			data, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			key := make([]byte, 16)
+			binary.BigEndian.PutUint64(key[:8], uint64(record.RequestedAt.UnixNano()))
+			binary.BigEndian.PutUint64(key[8:], seq)
+			if err = bucket.Put(key, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Records implements Store.
+func (s *BoltStore) Records(_ context.Context, q Query) ([]PersistedRecord, error) {
+	var out []PersistedRecord
+	err := s.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(boltRecordsBucket).Cursor()
+		// Walk backwards from the end of the range so Limit keeps the most recent records.
+		var k, v []byte
+		if q.To.IsZero() {
+			k, v = cursor.Last()
+		} else {
+			k, v = cursor.Seek(timeKey(q.To.UnixNano()))
+			if k == nil {
+				k, v = cursor.Last()
+			} else {
+				k, v = cursor.Prev()
+			}
+		}
+		fromKey := timeKey(q.From.UnixNano())
+		for ; k != nil; k, v = cursor.Prev() {
+			if !q.From.IsZero() && bytes.Compare(k[:8], fromKey) < 0 {
+				break
+			}
+			var record PersistedRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				continue
+			}
+			if !q.matches(record.RequestedAt, record.APIKey, record.Model, record.Provider, record.AuthIndex) {
+				continue
+			}
+			out = append(out, record)
+			if q.Limit > 0 && len(out) >= q.Limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, nil
+}
+
+// Rollups implements Store.
+func (s *BoltStore) Rollups(_ context.Context, q Query) ([]Rollup, error) {
+	var out []Rollup
+	err := s.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(boltRollupsBucket).Cursor()
+		var k, v []byte
+		if q.From.IsZero() {
+			k, v = cursor.First()
+		} else {
+			k, v = cursor.Seek(timeKey(q.From.UTC().Truncate(time.Hour).Unix()))
+		}
+		for ; k != nil; k, v = cursor.Next() {
+			var rollup Rollup
+			if err := json.Unmarshal(v, &rollup); err != nil {
+				continue
+			}
+			if !q.To.IsZero() && !rollup.Bucket.Before(q.To) {
+				break
+			}
+			if !q.matches(rollup.Bucket, rollup.APIKey, rollup.Model, rollup.Provider, rollup.AuthIndex) {
+				continue
+			}
+			out = append(out, rollup)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// Downsample implements Store.
+func (s *BoltStore) Downsample(_ context.Context, before time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		records := tx.Bucket(boltRecordsBucket)
+		rollups := tx.Bucket(boltRollupsBucket)
+		limit := timeKey(before.UnixNano())
+
+		var batch []PersistedRecord
+		var keys [][]byte
+		cursor := records.Cursor()
+		for k, v := cursor.First(); k != nil && bytes.Compare(k[:8], limit) < 0; k, v = cursor.Next() {
+			var record PersistedRecord
+			if err := json.Unmarshal(v, &record); err == nil {
+				batch = append(batch, record)
+			}
+			keys = append(keys, append([]byte(nil), k...))
+		}
+		for _, rollup := range rollupRecords(batch) {
+			key := boltRollupKey(rollup)
+			if existing := rollups.Get(key); existing != nil {
+				var previous Rollup
+				if err := json.Unmarshal(existing, &previous); err == nil {
+					rollup.Requests += previous.Requests
+					rollup.Failures += previous.Failures
+					rollup.Tokens = addTokenStats(rollup.Tokens, previous.Tokens)
+				}
+			}
+			data, err := json.Marshal(rollup)
+			if err != nil {
+				return err
+			}
+			if err = rollups.Put(key, data); err != nil {
+				return err
+			}
+		}
+		for _, key := range keys {
+			if err := records.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Prune implements Store.
+func (s *BoltStore) Prune(_ context.Context, before time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := deleteBefore(tx.Bucket(boltRecordsBucket), timeKey(before.UnixNano())); err != nil {
+			return err
+		}
+		return deleteBefore(tx.Bucket(boltRollupsBucket), timeKey(before.Unix()))
+	})
+}
+
+// Close implements Store.
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+func deleteBefore(bucket *bolt.Bucket, limit []byte) error {
+	var keys [][]byte
+	cursor := bucket.Cursor()
+	for k, _ := cursor.First(); k != nil && bytes.Compare(k[:8], limit) < 0; k, _ = cursor.Next() {
+		keys = append(keys, append([]byte(nil), k...))
+	}
+	for _, key := range keys {
+		if err := bucket.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func timeKey(value int64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(value))
+	return key
+}
+
+// boltRollupKey orders aggregates by hour, then by their dimensions.
+func boltRollupKey(rollup Rollup) []byte {
+	key := timeKey(rollup.Bucket.Unix())
+	return append(key, []byte(rollupKey(rollup.Bucket, rollup.APIKey, rollup.Model, rollup.Provider, rollup.AuthIndex))...)
+}
@@ -0,0 +1,73 @@
+package usage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBoltStoreQueryDownsampleAndPrune(t *testing.T) {
+	store, err := NewBoltStore(filepath.Join(t.TempDir(), "usage.db"))
+	if err != nil {
+		t.Fatalf("NewBoltStore: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	ctx := context.Background()
+	base := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	records := []PersistedRecord{
+		{RequestedAt: base.Add(5 * time.Minute), APIKey: "a", Model: "m1", Provider: "p", Tokens: TokenStats{InputTokens: 10, TotalTokens: 10}},
+		{RequestedAt: base.Add(20 * time.Minute), APIKey: "a", Model: "m1", Provider: "p", Failed: true, Tokens: TokenStats{InputTokens: 5, TotalTokens: 5}},
+		{RequestedAt: base.Add(2 * time.Hour), APIKey: "b", Model: "m2", Provider: "p", Tokens: TokenStats{OutputTokens: 7, TotalTokens: 7}},
+		{RequestedAt: base.Add(30 * 24 * time.Hour), APIKey: "a", Model: "m1", Provider: "p", Tokens: TokenStats{InputTokens: 1, TotalTokens: 1}},
+	}
+	if err = store.Append(ctx, records); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	got, err := store.Records(ctx, Query{APIKey: "a", To: base.Add(24 * time.Hour)})
+	if err != nil {
+		t.Fatalf("Records: %v", err)
+	}
+	if len(got) != 2 || !got[0].RequestedAt.Before(got[1].RequestedAt) {
+		t.Fatalf("Records filtered = %+v, want two chronological records for key a", got)
+	}
+	latest, err := store.Records(ctx, Query{Limit: 1})
+	if err != nil || len(latest) != 1 || !latest[0].RequestedAt.Equal(records[3].RequestedAt) {
+		t.Fatalf("Records limit = %+v, %v; want the most recent record", latest, err)
+	}
+
+	if err = store.Downsample(ctx, base.Add(24*time.Hour)); err != nil {
+		t.Fatalf("Downsample: %v", err)
+	}
+	remaining, _ := store.Records(ctx, Query{})
+	if len(remaining) != 1 {
+		t.Fatalf("raw records after downsample = %d, want 1", len(remaining))
+	}
+	rollups, err := store.Rollups(ctx, Query{Model: "m1"})
+	if err != nil {
+		t.Fatalf("Rollups: %v", err)
+	}
+	if len(rollups) != 1 || rollups[0].Requests != 2 || rollups[0].Failures != 1 || rollups[0].Tokens.InputTokens != 15 {
+		t.Fatalf("rollups = %+v, want one m1 bucket with 2 requests, 1 failure, 15 input tokens", rollups)
+	}
+
+	history, err := History(ctx, store, Query{}, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(history) != 2 || history[0].Requests != 3 || history[1].Requests != 1 {
+		t.Fatalf("history = %+v, want daily buckets of 3 and 1 requests", history)
+	}
+
+	if err = store.Prune(ctx, base.Add(24*time.Hour)); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if rollups, _ = store.Rollups(ctx, Query{}); len(rollups) != 0 {
+		t.Fatalf("rollups after prune = %+v, want none", rollups)
+	}
+	if remaining, _ = store.Records(ctx, Query{}); len(remaining) != 1 {
+		t.Fatalf("raw records after prune = %d, want 1", len(remaining))
+	}
+}
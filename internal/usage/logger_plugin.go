@@ -94,6 +94,7 @@ type RequestDetail struct {
 	AuthIndex string     `json:"auth_index"`
 	Tokens    TokenStats `json:"tokens"`
 	Failed    bool       `json:"failed"`
+	Cancelled bool       `json:"cancelled,omitempty"`
 }
 
 // TokenStats captures the token usage breakdown for a request.
@@ -169,7 +170,7 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 		statsKey = resolveAPIIdentifier(ctx, record)
 	}
 	failed := record.Failed
-	if !failed {
+	if !failed && !record.Cancelled {
 		failed = !resolveSuccess(ctx)
 	}
 	success := !failed
@@ -202,6 +203,7 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 		AuthIndex: record.AuthIndex,
 		Tokens:    detail,
 		Failed:    failed,
+		Cancelled: record.Cancelled,
 	})
 
 	s.requestsByDay[dayKey]++
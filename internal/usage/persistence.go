@@ -0,0 +1,500 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultRawRetentionDays = 7
+	defaultRetentionDays    = 365
+	persistFlushInterval    = 2 * time.Second
+	persistFlushBatch       = 200
+	persistMaintenanceEvery = time.Hour
+)
+
+// PersistedRecord is a single request usage entry stored by a durable backend.
+type PersistedRecord struct {
+	RequestedAt time.Time  `json:"requested_at"`
+	APIKey      string     `json:"api_key"`
+	Model       string     `json:"model"`
+	Provider    string     `json:"provider"`
+	AuthID      string     `json:"auth_id"`
+	AuthIndex   string     `json:"auth_index"`
+	Source      string     `json:"source"`
+	Failed      bool       `json:"failed"`
+	Cancelled   bool       `json:"cancelled,omitempty"`
+	Tokens      TokenStats `json:"tokens"`
+}
+
+// Rollup aggregates downsampled records for one hour and one key/model/provider/auth combination.
+type Rollup struct {
+	Bucket    time.Time  `json:"bucket"`
+	APIKey    string     `json:"api_key"`
+	Model     string     `json:"model"`
+	Provider  string     `json:"provider"`
+	AuthIndex string     `json:"auth_index"`
+	Requests  int64      `json:"requests"`
+	Failures  int64      `json:"failures"`
+	Tokens    TokenStats `json:"tokens"`
+}
+
+// Query filters persisted usage. Zero values match everything.
+type Query struct {
+	From      time.Time
+	To        time.Time
+	APIKey    string
+	Model     string
+	Provider  string
+	AuthIndex string
+	// Limit caps the number of raw records returned, keeping the most recent ones. Zero means no limit.
+	Limit int
+}
+
+// matches reports whether the dimensions and timestamp satisfy the query.
+func (q Query) matches(ts time.Time, apiKey, model, provider, authIndex string) bool {
+	if !q.From.IsZero() && ts.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !ts.Before(q.To) {
+		return false
+	}
+	if q.APIKey != "" && q.APIKey != apiKey {
+		return false
+	}
+	if q.Model != "" && q.Model != model {
+		return false
+	}
+	if q.Provider != "" && q.Provider != provider {
+		return false
+	}
+	if q.AuthIndex != "" && q.AuthIndex != authIndex {
+		return false
+	}
+	return true
+}
+
+// Store is a durable usage backend.
+type Store interface {
+	// Append persists a batch of records.
+	Append(ctx context.Context, records []PersistedRecord) error
+	// Records returns raw records matching q in chronological order.
+	Records(ctx context.Context, q Query) ([]PersistedRecord, error)
+	// Rollups returns hourly aggregates matching q in chronological order.
+	Rollups(ctx context.Context, q Query) ([]Rollup, error)
+	// Downsample folds raw records older than before into hourly aggregates.
+	Downsample(ctx context.Context, before time.Time) error
+	// Prune deletes raw records and aggregates older than before.
+	Prune(ctx context.Context, before time.Time) error
+	// Close releases backend resources.
+	Close() error
+}
+
+// HistoryBucket is one time slice of a usage history query.
+type HistoryBucket struct {
+	Time     time.Time  `json:"time"`
+	Requests int64      `json:"requests"`
+	Failures int64      `json:"failures"`
+	Tokens   TokenStats `json:"tokens"`
+}
+
+// History aggregates raw records and downsampled rollups matching q into buckets of the given
+// granularity, so ranges spanning the raw retention boundary are answered seamlessly.
+func History(ctx context.Context, store Store, q Query, granularity time.Duration) ([]HistoryBucket, error) {
+	if store == nil {
+		return nil, fmt.Errorf("usage persistence is not enabled")
+	}
+	if granularity <= 0 {
+		granularity = time.Hour
+	}
+	q.Limit = 0
+	records, err := store.Records(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	rollups, err := store.Rollups(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make(map[int64]*HistoryBucket)
+	bucketFor := func(ts time.Time) *HistoryBucket {
+		start := ts.UTC().Truncate(granularity)
+		b, ok := buckets[start.Unix()]
+		if !ok {
+			b = &HistoryBucket{Time: start}
+			buckets[start.Unix()] = b
+		}
+		return b
+	}
+	for _, record := range records {
+		b := bucketFor(record.RequestedAt)
+		b.Requests++
+		if record.Failed {
+			b.Failures++
+		}
+		b.Tokens = addTokenStats(b.Tokens, record.Tokens)
+	}
+	for _, rollup := range rollups {
+		b := bucketFor(rollup.Bucket)
+		b.Requests += rollup.Requests
+		b.Failures += rollup.Failures
+		b.Tokens = addTokenStats(b.Tokens, rollup.Tokens)
+	}
+
+	out := make([]HistoryBucket, 0, len(buckets))
+	for _, b := range buckets {
+		out = append(out, *b)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out, nil
+}
+
+func addTokenStats(a, b TokenStats) TokenStats {
+	return TokenStats{
+		InputTokens:     a.InputTokens + b.InputTokens,
+		OutputTokens:    a.OutputTokens + b.OutputTokens,
+		ReasoningTokens: a.ReasoningTokens + b.ReasoningTokens,
+		CachedTokens:    a.CachedTokens + b.CachedTokens,
+		TotalTokens:     a.TotalTokens + b.TotalTokens,
+	}
+}
+
+// rollupRecords folds raw records into hourly aggregates keyed by their dimensions.
+func rollupRecords(records []PersistedRecord) []Rollup {
+	index := make(map[string]int)
+	out := make([]Rollup, 0)
+	for _, record := range records {
+		bucket := record.RequestedAt.UTC().Truncate(time.Hour)
+		key := rollupKey(bucket, record.APIKey, record.Model, record.Provider, record.AuthIndex)
+		idx, ok := index[key]
+		if !ok {
+			idx = len(out)
+			index[key] = idx
+			out = append(out, Rollup{
+				Bucket:    bucket,
+				APIKey:    record.APIKey,
+				Model:     record.Model,
+				Provider:  record.Provider,
+				AuthIndex: record.AuthIndex,
+			})
+		}
+		out[idx].Requests++
+		if record.Failed {
+			out[idx].Failures++
+		}
+		out[idx].Tokens = addTokenStats(out[idx].Tokens, record.Tokens)
+	}
+	return out
+}
+
+func rollupKey(bucket time.Time, apiKey, model, provider, authIndex string) string {
+	return strings.Join([]string{fmt.Sprint(bucket.Unix()), apiKey, model, provider, authIndex}, "\x00")
+}
+
+// PersistentPlugin batches usage records and writes them to a durable Store.
+// It implements coreusage.Plugin.
+type PersistentPlugin struct {
+	store Store
+
+	mu      sync.Mutex
+	pending []PersistedRecord
+	stopped bool
+
+	rawRetention time.Duration
+	retention    time.Duration
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewPersistentPlugin wraps store with batching and retention maintenance.
+func NewPersistentPlugin(store Store) *PersistentPlugin {
+	return &PersistentPlugin{
+		store:        store,
+		rawRetention: defaultRawRetentionDays * 24 * time.Hour,
+		retention:    defaultRetentionDays * 24 * time.Hour,
+		wake:         make(chan struct{}, 1),
+		done:         make(chan struct{}),
+	}
+}
+
+// Store returns the backing store.
+func (p *PersistentPlugin) Store() Store {
+	if p == nil {
+		return nil
+	}
+	return p.store
+}
+
+// SetRetention updates raw and aggregate retention. Non-positive values select the defaults.
+func (p *PersistentPlugin) SetRetention(rawDays, days int) {
+	if p == nil {
+		return
+	}
+	if rawDays <= 0 {
+		rawDays = defaultRawRetentionDays
+	}
+	if days <= 0 {
+		days = defaultRetentionDays
+	}
+	p.mu.Lock()
+	p.rawRetention = time.Duration(rawDays) * 24 * time.Hour
+	p.retention = time.Duration(days) * 24 * time.Hour
+	p.mu.Unlock()
+}
+
+// HandleUsage implements coreusage.Plugin.
+func (p *PersistentPlugin) HandleUsage(ctx context.Context, record coreusage.Record) {
+	if p == nil {
+		return
+	}
+	timestamp := record.RequestedAt
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	apiKey := record.APIKey
+	if apiKey == "" {
+		apiKey = resolveAPIIdentifier(ctx, record)
+	}
+	persisted := PersistedRecord{
+		RequestedAt: timestamp.UTC(),
+		APIKey:      apiKey,
+		Model:       record.Model,
+		Provider:    record.Provider,
+		AuthID:      record.AuthID,
+		AuthIndex:   record.AuthIndex,
+		Source:      record.Source,
+		Failed:      record.Failed || (!record.Cancelled && !resolveSuccess(ctx)),
+		Cancelled:   record.Cancelled,
+		Tokens:      normaliseDetail(record.Detail),
+	}
+
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return
+	}
+	p.pending = append(p.pending, persisted)
+	full := len(p.pending) >= persistFlushBatch
+	p.mu.Unlock()
+	if full {
+		select {
+		case p.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Start launches the background flush and maintenance loop.
+func (p *PersistentPlugin) Start(ctx context.Context) {
+	if p == nil {
+		return
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+	go p.run(runCtx)
+}
+
+// Stop flushes pending records, stops background work and closes the store.
+func (p *PersistentPlugin) Stop() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return
+	}
+	p.stopped = true
+	p.mu.Unlock()
+	if p.cancel != nil {
+		p.cancel()
+		<-p.done
+	}
+	p.flush(context.Background())
+	if err := p.store.Close(); err != nil {
+		log.Errorf("usage persistence: close store: %v", err)
+	}
+}
+
+func (p *PersistentPlugin) run(ctx context.Context) {
+	defer close(p.done)
+	flushTicker := time.NewTicker(persistFlushInterval)
+	defer flushTicker.Stop()
+	maintenanceTicker := time.NewTicker(persistMaintenanceEvery)
+	defer maintenanceTicker.Stop()
+
+	p.maintain(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-flushTicker.C:
+			p.flush(ctx)
+		case <-p.wake:
+			p.flush(ctx)
+		case <-maintenanceTicker.C:
+			p.maintain(ctx)
+		}
+	}
+}
+
+func (p *PersistentPlugin) flush(ctx context.Context) {
+	p.mu.Lock()
+	batch := p.pending
+	p.pending = nil
+	p.mu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+	if err := p.store.Append(context.WithoutCancel(ctx), batch); err != nil {
+		log.Errorf("usage persistence: write %d records: %v", len(batch), err)
+	}
+}
+
+func (p *PersistentPlugin) maintain(ctx context.Context) {
+	p.mu.Lock()
+	rawRetention, retention := p.rawRetention, p.retention
+	p.mu.Unlock()
+	now := time.Now().UTC()
+	if err := p.store.Downsample(ctx, now.Add(-rawRetention).Truncate(time.Hour)); err != nil {
+		log.Errorf("usage persistence: downsample: %v", err)
+	}
+	if err := p.store.Prune(ctx, now.Add(-retention)); err != nil {
+		log.Errorf("usage persistence: prune: %v", err)
+	}
+}
+
+// restore replays retained raw records into stats.
+func (p *PersistentPlugin) restore(ctx context.Context, stats *RequestStatistics) error {
+	records, err := p.store.Records(ctx, Query{})
+	if err != nil {
+		return err
+	}
+	snapshot := StatisticsSnapshot{APIs: make(map[string]APISnapshot)}
+	for _, record := range records {
+		apiKey := record.APIKey
+		if apiKey == "" {
+			apiKey = "unknown"
+		}
+		model := record.Model
+		if model == "" {
+			model = "unknown"
+		}
+		api, ok := snapshot.APIs[apiKey]
+		if !ok {
+			api = APISnapshot{Models: make(map[string]ModelSnapshot)}
+		}
+		modelSnapshot := api.Models[model]
+		modelSnapshot.Details = append(modelSnapshot.Details, RequestDetail{
+			Timestamp: record.RequestedAt.Local(),
+			Source:    record.Source,
+			AuthIndex: record.AuthIndex,
+			Tokens:    record.Tokens,
+			Failed:    record.Failed,
+			Cancelled: record.Cancelled,
+		})
+		api.Models[model] = modelSnapshot
+		snapshot.APIs[apiKey] = api
+	}
+	result := stats.MergeSnapshot(snapshot)
+	log.Infof("usage persistence: restored %d records (%d skipped)", result.Added, result.Skipped)
+	return nil
+}
+
+var persistence struct {
+	mu       sync.Mutex
+	plugin   *PersistentPlugin
+	pgDB     *sql.DB
+	pgSchema string
+}
+
+// UsePostgresDatabase makes the durable usage backend reuse an existing Postgres connection
+// (the PGSTORE_DSN store) instead of a local file.
+func UsePostgresDatabase(db *sql.DB, schema string) {
+	persistence.mu.Lock()
+	persistence.pgDB = db
+	persistence.pgSchema = schema
+	persistence.mu.Unlock()
+}
+
+// StartPersistence opens the configured durable backend and registers it as a usage plugin.
+// It is a no-op when persistence is disabled or already running.
+func StartPersistence(ctx context.Context, cfg *config.Config) error {
+	if cfg == nil || !cfg.UsagePersistence.Enable {
+		return nil
+	}
+	persistence.mu.Lock()
+	defer persistence.mu.Unlock()
+	if persistence.plugin != nil {
+		return nil
+	}
+
+	var (
+		store Store
+		err   error
+	)
+	if persistence.pgDB != nil {
+		store, err = NewPostgresStore(ctx, persistence.pgDB, persistence.pgSchema)
+	} else {
+		path := strings.TrimSpace(cfg.UsagePersistence.Path)
+		if path == "" {
+			path = filepath.Join(cfg.AuthDir, "usage.db")
+		}
+		store, err = NewBoltStore(path)
+	}
+	if err != nil {
+		return err
+	}
+
+	plugin := NewPersistentPlugin(store)
+	plugin.SetRetention(cfg.UsagePersistence.RawRetentionDays, cfg.UsagePersistence.RetentionDays)
+	if cfg.UsagePersistence.RestoreOnStart {
+		if errRestore := plugin.restore(ctx, defaultRequestStatistics); errRestore != nil {
+			log.Errorf("usage persistence: restore: %v", errRestore)
+		}
+	}
+	plugin.Start(context.WithoutCancel(ctx))
+	coreusage.RegisterPlugin(plugin)
+	persistence.plugin = plugin
+	return nil
+}
+
+// UpdatePersistenceConfig applies retention changes from a reloaded configuration.
+func UpdatePersistenceConfig(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	persistence.mu.Lock()
+	plugin := persistence.plugin
+	persistence.mu.Unlock()
+	plugin.SetRetention(cfg.UsagePersistence.RawRetentionDays, cfg.UsagePersistence.RetentionDays)
+}
+
+// StopPersistence flushes and closes the durable backend.
+func StopPersistence() {
+	persistence.mu.Lock()
+	plugin := persistence.plugin
+	persistence.plugin = nil
+	persistence.mu.Unlock()
+	plugin.Stop()
+}
+
+// PersistentStore returns the active durable backend, or nil when persistence is disabled.
+func PersistentStore() Store {
+	persistence.mu.Lock()
+	defer persistence.mu.Unlock()
+	return persistence.plugin.Store()
+}
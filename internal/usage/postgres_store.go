@@ -0,0 +1,252 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	postgresRecordsTable = "usage_records"
+	postgresRollupsTable = "usage_rollups"
+)
+
+// PostgresStore persists usage in PostgreSQL, sharing the connection of the Postgres token store.
+type PostgresStore struct {
+	db      *sql.DB
+	records string
+	rollups string
+}
+
+// NewPostgresStore prepares the usage tables inside schema (or the default search path).
+// The connection is owned by the caller and is not closed by Close.
+func NewPostgresStore(ctx context.Context, db *sql.DB, schema string) (*PostgresStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("usage postgres store: database is nil")
+	}
+	s := &PostgresStore{
+		db:      db,
+		records: postgresTableName(schema, postgresRecordsTable),
+		rollups: postgresTableName(schema, postgresRollupsTable),
+	}
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			requested_at TIMESTAMPTZ NOT NULL,
+			api_key TEXT NOT NULL DEFAULT '',
+			model TEXT NOT NULL DEFAULT '',
+			provider TEXT NOT NULL DEFAULT '',
+			auth_id TEXT NOT NULL DEFAULT '',
+			auth_index TEXT NOT NULL DEFAULT '',
+			source TEXT NOT NULL DEFAULT '',
+			failed BOOLEAN NOT NULL DEFAULT FALSE,
+			input_tokens BIGINT NOT NULL DEFAULT 0,
+			output_tokens BIGINT NOT NULL DEFAULT 0,
+			reasoning_tokens BIGINT NOT NULL DEFAULT 0,
+			cached_tokens BIGINT NOT NULL DEFAULT 0,
+			total_tokens BIGINT NOT NULL DEFAULT 0
+		)`, s.records),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS usage_records_requested_at_idx ON %s (requested_at)`, s.records),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			bucket TIMESTAMPTZ NOT NULL,
+			api_key TEXT NOT NULL DEFAULT '',
+			model TEXT NOT NULL DEFAULT '',
+			provider TEXT NOT NULL DEFAULT '',
+			auth_index TEXT NOT NULL DEFAULT '',
+			requests BIGINT NOT NULL DEFAULT 0,
+			failures BIGINT NOT NULL DEFAULT 0,
+			input_tokens BIGINT NOT NULL DEFAULT 0,
+			output_tokens BIGINT NOT NULL DEFAULT 0,
+			reasoning_tokens BIGINT NOT NULL DEFAULT 0,
+			cached_tokens BIGINT NOT NULL DEFAULT 0,
+			total_tokens BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (bucket, api_key, model, provider, auth_index)
+		)`, s.rollups),
+	}
+	for _, statement := range statements {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return nil, fmt.Errorf("usage postgres store: prepare schema: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// Append implements Store.
+func (s *PostgresStore) Append(ctx context.Context, records []PersistedRecord) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("usage postgres store: begin: %w", err)
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (requested_at, api_key, model, provider, auth_id, auth_index, source, failed,
+		input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`, s.records)
+	for _, r := range records {
+		if _, err = tx.ExecContext(ctx, query, r.RequestedAt, r.APIKey, r.Model, r.Provider, r.AuthID, r.AuthIndex, r.Source, r.Failed,
+			r.Tokens.InputTokens, r.Tokens.OutputTokens, r.Tokens.ReasoningTokens, r.Tokens.CachedTokens, r.Tokens.TotalTokens); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("usage postgres store: insert record: %w", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("usage postgres store: commit: %w", err)
+	}
+	return nil
+}
+
+// Records implements Store.
+func (s *PostgresStore) Records(ctx context.Context, q Query) ([]PersistedRecord, error) {
+	where, args := postgresFilter("requested_at", q)
+	query := fmt.Sprintf(`SELECT requested_at, api_key, model, provider, auth_id, auth_index, source, failed,
+		input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens
+		FROM %s%s ORDER BY requested_at DESC, id DESC`, s.records, where)
+	if q.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", q.Limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("usage postgres store: query records: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var out []PersistedRecord
+	for rows.Next() {
+		var r PersistedRecord
+		if err = rows.Scan(&r.RequestedAt, &r.APIKey, &r.Model, &r.Provider, &r.AuthID, &r.AuthIndex, &r.Source, &r.Failed,
+			&r.Tokens.InputTokens, &r.Tokens.OutputTokens, &r.Tokens.ReasoningTokens, &r.Tokens.CachedTokens, &r.Tokens.TotalTokens); err != nil {
+			return nil, fmt.Errorf("usage postgres store: scan record: %w", err)
+		}
+		r.RequestedAt = r.RequestedAt.UTC()
+		out = append(out, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("usage postgres store: iterate records: %w", err)
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, nil
+}
+
+// Rollups implements Store.
+func (s *PostgresStore) Rollups(ctx context.Context, q Query) ([]Rollup, error) {
+	if !q.From.IsZero() {
+		q.From = q.From.UTC().Truncate(time.Hour)
+	}
+	where, args := postgresFilter("bucket", q)
+	query := fmt.Sprintf(`SELECT bucket, api_key, model, provider, auth_index, requests, failures,
+		input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens
+		FROM %s%s ORDER BY bucket`, s.rollups, where)
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("usage postgres store: query rollups: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var out []Rollup
+	for rows.Next() {
+		var r Rollup
+		if err = rows.Scan(&r.Bucket, &r.APIKey, &r.Model, &r.Provider, &r.AuthIndex, &r.Requests, &r.Failures,
+			&r.Tokens.InputTokens, &r.Tokens.OutputTokens, &r.Tokens.ReasoningTokens, &r.Tokens.CachedTokens, &r.Tokens.TotalTokens); err != nil {
+			return nil, fmt.Errorf("usage postgres store: scan rollup: %w", err)
+		}
+		r.Bucket = r.Bucket.UTC()
+		out = append(out, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("usage postgres store: iterate rollups: %w", err)
+	}
+	return out, nil
+}
+
+// Downsample implements Store.
+func (s *PostgresStore) Downsample(ctx context.Context, before time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("usage postgres store: begin: %w", err)
+	}
+	upsert := fmt.Sprintf(`INSERT INTO %[1]s AS r (bucket, api_key, model, provider, auth_index, requests, failures,
+			input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens)
+		SELECT date_trunc('hour', requested_at), api_key, model, provider, auth_index, COUNT(*),
+			COUNT(*) FILTER (WHERE failed), SUM(input_tokens), SUM(output_tokens), SUM(reasoning_tokens),
+			SUM(cached_tokens), SUM(total_tokens)
+		FROM %[2]s WHERE requested_at < $1
+		GROUP BY 1, api_key, model, provider, auth_index
+		ON CONFLICT (bucket, api_key, model, provider, auth_index) DO UPDATE SET
+			requests = r.requests + EXCLUDED.requests,
+			failures = r.failures + EXCLUDED.failures,
+			input_tokens = r.input_tokens + EXCLUDED.input_tokens,
+			output_tokens = r.output_tokens + EXCLUDED.output_tokens,
+			reasoning_tokens = r.reasoning_tokens + EXCLUDED.reasoning_tokens,
+			cached_tokens = r.cached_tokens + EXCLUDED.cached_tokens,
+			total_tokens = r.total_tokens + EXCLUDED.total_tokens`, s.rollups, s.records)
+	if _, err = tx.ExecContext(ctx, upsert, before); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("usage postgres store: downsample: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE requested_at < $1`, s.records), before); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("usage postgres store: delete downsampled records: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("usage postgres store: commit: %w", err)
+	}
+	return nil
+}
+
+// Prune implements Store.
+func (s *PostgresStore) Prune(ctx context.Context, before time.Time) error {
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE requested_at < $1`, s.records), before); err != nil {
+		return fmt.Errorf("usage postgres store: prune records: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE bucket < $1`, s.rollups), before); err != nil {
+		return fmt.Errorf("usage postgres store: prune rollups: %w", err)
+	}
+	return nil
+}
+
+// Close implements Store. The shared connection stays open for the token store.
+func (s *PostgresStore) Close() error { return nil }
+
+// postgresFilter builds the WHERE clause for q against the given time column.
+func postgresFilter(timeColumn string, q Query) (string, []any) {
+	var clauses []string
+	var args []any
+	add := func(clause string, value any) {
+		args = append(args, value)
+		clauses = append(clauses, fmt.Sprintf(clause, len(args)))
+	}
+	if !q.From.IsZero() {
+		add(timeColumn+" >= $%d", q.From)
+	}
+	if !q.To.IsZero() {
+		add(timeColumn+" < $%d", q.To)
+	}
+	if q.APIKey != "" {
+		add("api_key = $%d", q.APIKey)
+	}
+	if q.Model != "" {
+		add("model = $%d", q.Model)
+	}
+	if q.Provider != "" {
+		add("provider = $%d", q.Provider)
+	}
+	if q.AuthIndex != "" {
+		add("auth_index = $%d", q.AuthIndex)
+	}
+	if len(clauses) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(clauses, " AND "), args
+}
+
+func postgresTableName(schema, table string) string {
+	quote := func(identifier string) string {
+		return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+	}
+	if schema = strings.TrimSpace(schema); schema == "" {
+		return quote(table)
+	}
+	return quote(schema) + "." + quote(table)
+}
@@ -62,6 +62,33 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.QuotaExceeded.SwitchPreviewModel != newCfg.QuotaExceeded.SwitchPreviewModel {
 		changes = append(changes, fmt.Sprintf("quota-exceeded.switch-preview-model: %t -> %t", oldCfg.QuotaExceeded.SwitchPreviewModel, newCfg.QuotaExceeded.SwitchPreviewModel))
 	}
+	if oldCfg.Hedging.Enable != newCfg.Hedging.Enable {
+		changes = append(changes, fmt.Sprintf("hedging.enable: %t -> %t", oldCfg.Hedging.Enable, newCfg.Hedging.Enable))
+	}
+	if oldCfg.Hedging.DelayMS != newCfg.Hedging.DelayMS {
+		changes = append(changes, fmt.Sprintf("hedging.delay-ms: %d -> %d", oldCfg.Hedging.DelayMS, newCfg.Hedging.DelayMS))
+	}
+	if !reflect.DeepEqual(oldCfg.Hedging.Models, newCfg.Hedging.Models) {
+		changes = append(changes, "hedging.models: updated")
+	}
+	if oldCfg.CircuitBreaker.Enable != newCfg.CircuitBreaker.Enable {
+		changes = append(changes, fmt.Sprintf("circuit-breaker.enable: %t -> %t", oldCfg.CircuitBreaker.Enable, newCfg.CircuitBreaker.Enable))
+	}
+	if oldCfg.CircuitBreaker.FailureThreshold != newCfg.CircuitBreaker.FailureThreshold {
+		changes = append(changes, fmt.Sprintf("circuit-breaker.failure-threshold: %d -> %d", oldCfg.CircuitBreaker.FailureThreshold, newCfg.CircuitBreaker.FailureThreshold))
+	}
+	if oldCfg.CircuitBreaker.OpenSeconds != newCfg.CircuitBreaker.OpenSeconds {
+		changes = append(changes, fmt.Sprintf("circuit-breaker.open-seconds: %d -> %d", oldCfg.CircuitBreaker.OpenSeconds, newCfg.CircuitBreaker.OpenSeconds))
+	}
+	if oldCfg.CircuitBreaker.HalfOpenProbes != newCfg.CircuitBreaker.HalfOpenProbes {
+		changes = append(changes, fmt.Sprintf("circuit-breaker.half-open-probes: %d -> %d", oldCfg.CircuitBreaker.HalfOpenProbes, newCfg.CircuitBreaker.HalfOpenProbes))
+	}
+	if oldCfg.QuotaExceeded.FallbackAfterSeconds != newCfg.QuotaExceeded.FallbackAfterSeconds {
+		changes = append(changes, fmt.Sprintf("quota-exceeded.fallback-after-seconds: %d -> %d", oldCfg.QuotaExceeded.FallbackAfterSeconds, newCfg.QuotaExceeded.FallbackAfterSeconds))
+	}
+	if !reflect.DeepEqual(oldCfg.QuotaExceeded.ModelFallbacks, newCfg.QuotaExceeded.ModelFallbacks) {
+		changes = append(changes, fmt.Sprintf("quota-exceeded.model-fallbacks: updated (%d -> %d chains)", len(oldCfg.QuotaExceeded.ModelFallbacks), len(newCfg.QuotaExceeded.ModelFallbacks)))
+	}
 
 	// API keys (redacted) and counts
 	if len(oldCfg.APIKeys) != len(newCfg.APIKeys) {
@@ -244,6 +271,20 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 		}
 	}
 
+	// Claude- and Gemini-compatible providers (summarized)
+	if compat := DiffProtocolCompatibility(oldCfg.ClaudeCompatibility, newCfg.ClaudeCompatibility); len(compat) > 0 {
+		changes = append(changes, "claude-compatibility:")
+		for _, c := range compat {
+			changes = append(changes, "  "+c)
+		}
+	}
+	if compat := DiffProtocolCompatibility(oldCfg.GeminiCompatibility, newCfg.GeminiCompatibility); len(compat) > 0 {
+		changes = append(changes, "gemini-compatibility:")
+		for _, c := range compat {
+			changes = append(changes, "  "+c)
+		}
+	}
+
 	// Vertex-compatible API keys
 	if len(oldCfg.VertexCompatAPIKey) != len(newCfg.VertexCompatAPIKey) {
 		changes = append(changes, fmt.Sprintf("vertex-api-key count: %d -> %d", len(oldCfg.VertexCompatAPIKey), len(newCfg.VertexCompatAPIKey)))
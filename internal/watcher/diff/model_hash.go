@@ -13,6 +13,25 @@ import (
 // ComputeOpenAICompatModelsHash returns a stable hash for OpenAI-compat models.
 // Used to detect model list changes during hot reload.
 func ComputeOpenAICompatModelsHash(models []config.OpenAICompatibilityModel) string {
+	keys := normalizeModelPairs(func(out func(key string)) {
+		for _, model := range models {
+			name := strings.TrimSpace(model.Name)
+			alias := strings.TrimSpace(model.Alias)
+			if name == "" && alias == "" {
+				continue
+			}
+			key := strings.ToLower(name) + "|" + strings.ToLower(alias)
+			if model.Embedding {
+				key += "|embedding"
+			}
+			out(key)
+		}
+	})
+	return hashJoined(keys)
+}
+
+// ComputeProtocolCompatModelsHash returns a stable hash for Claude- and Gemini-compatible models.
+func ComputeProtocolCompatModelsHash(models []config.ProtocolCompatibilityModel) string {
 	keys := normalizeModelPairs(func(out func(key string)) {
 		for _, model := range models {
 			name := strings.TrimSpace(model.Name)
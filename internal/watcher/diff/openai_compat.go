@@ -65,7 +65,7 @@ func describeOpenAICompatibilityUpdate(oldEntry, newEntry config.OpenAICompatibi
 	newKeyCount := countAPIKeys(newEntry)
 	oldModelCount := countOpenAIModels(oldEntry.Models)
 	newModelCount := countOpenAIModels(newEntry.Models)
-	details := make([]string, 0, 3)
+	details := make([]string, 0, 4)
 	if oldKeyCount != newKeyCount {
 		details = append(details, fmt.Sprintf("api-keys %d -> %d", oldKeyCount, newKeyCount))
 	}
@@ -75,12 +75,22 @@ func describeOpenAICompatibilityUpdate(oldEntry, newEntry config.OpenAICompatibi
 	if !equalStringMap(oldEntry.Headers, newEntry.Headers) {
 		details = append(details, "headers updated")
 	}
+	if oldEntry.WireAPI != newEntry.WireAPI {
+		details = append(details, fmt.Sprintf("wire-api %s -> %s", wireAPIOrDefault(oldEntry.WireAPI), wireAPIOrDefault(newEntry.WireAPI)))
+	}
 	if len(details) == 0 {
 		return ""
 	}
 	return "(" + strings.Join(details, ", ") + ")"
 }
 
+func wireAPIOrDefault(wireAPI string) string {
+	if wireAPI == "" {
+		return config.WireAPIChat
+	}
+	return wireAPI
+}
+
 func countAPIKeys(entry config.OpenAICompatibility) int {
 	count := 0
 	for _, keyEntry := range entry.APIKeyEntries {
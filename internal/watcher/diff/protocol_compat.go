@@ -0,0 +1,72 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// DiffProtocolCompatibility produces human-readable change descriptions for the
+// claude-compatibility and gemini-compatibility provider lists. Entries are keyed by name.
+func DiffProtocolCompatibility(oldList, newList []config.ProtocolCompatibility) []string {
+	oldMap := make(map[string]config.ProtocolCompatibility, len(oldList))
+	for _, entry := range oldList {
+		oldMap[strings.ToLower(strings.TrimSpace(entry.Name))] = entry
+	}
+	newMap := make(map[string]config.ProtocolCompatibility, len(newList))
+	for _, entry := range newList {
+		newMap[strings.ToLower(strings.TrimSpace(entry.Name))] = entry
+	}
+	keys := make([]string, 0, len(oldMap)+len(newMap))
+	for key := range oldMap {
+		keys = append(keys, key)
+	}
+	for key := range newMap {
+		if _, ok := oldMap[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	changes := make([]string, 0)
+	for _, key := range keys {
+		oldEntry, oldOk := oldMap[key]
+		newEntry, newOk := newMap[key]
+		switch {
+		case !oldOk:
+			changes = append(changes, fmt.Sprintf("provider added: %s (api-keys=%d, models=%d)", newEntry.Name, len(newEntry.APIKeyEntries), len(newEntry.Models)))
+		case !newOk:
+			changes = append(changes, fmt.Sprintf("provider removed: %s (api-keys=%d, models=%d)", oldEntry.Name, len(oldEntry.APIKeyEntries), len(oldEntry.Models)))
+		default:
+			if detail := describeProtocolCompatibilityUpdate(oldEntry, newEntry); detail != "" {
+				changes = append(changes, fmt.Sprintf("provider updated: %s %s", newEntry.Name, detail))
+			}
+		}
+	}
+	return changes
+}
+
+func describeProtocolCompatibilityUpdate(oldEntry, newEntry config.ProtocolCompatibility) string {
+	details := make([]string, 0, 5)
+	if oldEntry.BaseURL != newEntry.BaseURL {
+		details = append(details, fmt.Sprintf("base-url %s -> %s", oldEntry.BaseURL, newEntry.BaseURL))
+	}
+	if oldEntry.Prefix != newEntry.Prefix {
+		details = append(details, fmt.Sprintf("prefix %s -> %s", oldEntry.Prefix, newEntry.Prefix))
+	}
+	if len(oldEntry.APIKeyEntries) != len(newEntry.APIKeyEntries) {
+		details = append(details, fmt.Sprintf("api-keys %d -> %d", len(oldEntry.APIKeyEntries), len(newEntry.APIKeyEntries)))
+	}
+	if ComputeProtocolCompatModelsHash(oldEntry.Models) != ComputeProtocolCompatModelsHash(newEntry.Models) {
+		details = append(details, fmt.Sprintf("models %d -> %d", len(oldEntry.Models), len(newEntry.Models)))
+	}
+	if !equalStringMap(oldEntry.Headers, newEntry.Headers) {
+		details = append(details, "headers updated")
+	}
+	if len(details) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(details, ", ") + ")"
+}
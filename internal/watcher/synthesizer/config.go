@@ -4,12 +4,13 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher/diff"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 )
 
 // ConfigSynthesizer generates Auth entries from configuration API keys.
-// It handles Gemini, Claude, Codex, OpenAI-compat, and Vertex-compat providers.
+// It handles Gemini, Claude, Codex, OpenAI-compat, Claude-/Gemini-compat and Vertex-compat providers.
 type ConfigSynthesizer struct{}
 
 // NewConfigSynthesizer creates a new ConfigSynthesizer instance.
@@ -32,6 +33,9 @@ func (s *ConfigSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth,
 	out = append(out, s.synthesizeCodexKeys(ctx)...)
 	// OpenAI-compat
 	out = append(out, s.synthesizeOpenAICompat(ctx)...)
+	// Claude- and Gemini-compat
+	out = append(out, s.synthesizeProtocolCompat(ctx, "claude", ctx.Config.ClaudeCompatibility)...)
+	out = append(out, s.synthesizeProtocolCompat(ctx, "gemini", ctx.Config.GeminiCompatibility)...)
 	// Vertex-compat
 	out = append(out, s.synthesizeVertexCompat(ctx)...)
 
@@ -253,6 +257,64 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 	return out
 }
 
+// synthesizeProtocolCompat creates Auth entries for Claude- or Gemini-compatible providers.
+// kind names the vendor protocol and is recorded in the compat_kind attribute so the
+// service binds the matching executor.
+func (s *ConfigSynthesizer) synthesizeProtocolCompat(ctx *SynthesisContext, kind string, entries []config.ProtocolCompatibility) []*coreauth.Auth {
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(entries))
+	for i := range entries {
+		compat := &entries[i]
+		providerName := strings.ToLower(strings.TrimSpace(compat.Name))
+		if providerName == "" {
+			continue
+		}
+		prefix := strings.TrimSpace(compat.Prefix)
+		base := strings.TrimSpace(compat.BaseURL)
+		idKind := fmt.Sprintf("%s-compatibility:%s", kind, providerName)
+		newAuth := func(key, proxyURL string) *coreauth.Auth {
+			id, token := idGen.Next(idKind, key, base, proxyURL)
+			attrs := map[string]string{
+				"source":       fmt.Sprintf("config:%s-compatibility:%s[%s]", kind, providerName, token),
+				"base_url":     base,
+				"compat_name":  compat.Name,
+				"compat_kind":  kind,
+				"provider_key": providerName,
+			}
+			if key != "" {
+				attrs["api_key"] = key
+			}
+			if hash := diff.ComputeProtocolCompatModelsHash(compat.Models); hash != "" {
+				attrs["models_hash"] = hash
+			}
+			addConfigHeadersToAttrs(compat.Headers, attrs)
+			return &coreauth.Auth{
+				ID:         id,
+				Provider:   providerName,
+				Label:      compat.Name,
+				Prefix:     prefix,
+				Status:     coreauth.StatusActive,
+				ProxyURL:   proxyURL,
+				Attributes: attrs,
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			}
+		}
+		if len(compat.APIKeyEntries) == 0 {
+			// Gateways that authenticate through headers alone still get one credential.
+			out = append(out, newAuth("", ""))
+			continue
+		}
+		for j := range compat.APIKeyEntries {
+			entry := &compat.APIKeyEntries[j]
+			out = append(out, newAuth(strings.TrimSpace(entry.APIKey), strings.TrimSpace(entry.ProxyURL)))
+		}
+	}
+	return out
+}
+
 // synthesizeVertexCompat creates Auth entries for Vertex-compatible providers.
 func (s *ConfigSynthesizer) synthesizeVertexCompat(ctx *SynthesisContext) []*coreauth.Auth {
 	cfg := ctx.Config
@@ -365,6 +365,53 @@ func TestConfigSynthesizer_OpenAICompat(t *testing.T) {
 	}
 }
 
+func TestConfigSynthesizer_ProtocolCompat(t *testing.T) {
+	synth := NewConfigSynthesizer()
+	ctx := &SynthesisContext{
+		Config: &config.Config{
+			ClaudeCompatibility: []config.ProtocolCompatibility{
+				{
+					Name:    "Gateway",
+					BaseURL: "https://gateway.example.com",
+					APIKeyEntries: []config.OpenAICompatibilityAPIKey{
+						{APIKey: "key-1", ProxyURL: "http://proxy.local"},
+						{APIKey: "key-2"},
+					},
+					Models:  []config.ProtocolCompatibilityModel{{Name: "claude-sonnet-4-5", Alias: "sonnet"}},
+					Headers: map[string]string{"X-Team": "a"},
+				},
+			},
+			GeminiCompatibility: []config.ProtocolCompatibility{
+				{Name: "gem-proxy", BaseURL: "https://gem.example.com"},
+			},
+		},
+		Now:         time.Now(),
+		IDGenerator: NewStableIDGenerator(),
+	}
+
+	auths, err := synth.Synthesize(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(auths) != 3 {
+		t.Fatalf("expected 3 auths, got %d", len(auths))
+	}
+	first := auths[0]
+	if first.Provider != "gateway" || first.ProxyURL != "http://proxy.local" {
+		t.Fatalf("unexpected claude-compat auth: provider=%s proxy=%s", first.Provider, first.ProxyURL)
+	}
+	if first.Attributes["compat_kind"] != "claude" || first.Attributes["compat_name"] != "Gateway" || first.Attributes["api_key"] != "key-1" {
+		t.Fatalf("unexpected claude-compat attributes: %v", first.Attributes)
+	}
+	if first.Attributes["header:X-Team"] != "a" || first.Attributes["models_hash"] == "" {
+		t.Fatalf("expected headers and models hash, got %v", first.Attributes)
+	}
+	gem := auths[2]
+	if gem.Provider != "gem-proxy" || gem.Attributes["compat_kind"] != "gemini" || gem.Attributes["api_key"] != "" {
+		t.Fatalf("unexpected gemini-compat auth: provider=%s attrs=%v", gem.Provider, gem.Attributes)
+	}
+}
+
 func TestConfigSynthesizer_VertexCompat(t *testing.T) {
 	synth := NewConfigSynthesizer()
 	ctx := &SynthesisContext{
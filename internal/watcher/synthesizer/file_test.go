@@ -610,3 +610,32 @@ func TestBuildGeminiVirtualID(t *testing.T) {
 		})
 	}
 }
+
+func TestFileSynthesizer_Synthesize_UndecryptableFile(t *testing.T) {
+	tempDir := t.TempDir()
+	// An envelope sealed with a key this process does not have.
+	sealed := `{"cliproxy_encrypted":"v1","key_id":"gone","wrapped_key":"","nonce":"","ciphertext":""}`
+	if err := os.WriteFile(filepath.Join(tempDir, "sealed.json"), []byte(sealed), 0o600); err != nil {
+		t.Fatalf("failed to write auth file: %v", err)
+	}
+
+	synth := NewFileSynthesizer()
+	auths, err := synth.Synthesize(&SynthesisContext{
+		Config:      &config.Config{},
+		AuthDir:     tempDir,
+		Now:         time.Now(),
+		IDGenerator: NewStableIDGenerator(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(auths) != 1 {
+		t.Fatalf("expected the unreadable file to be reported, got %d auths", len(auths))
+	}
+	if auths[0].ID != "sealed.json" || auths[0].Status != coreauth.StatusError || auths[0].Metadata != nil {
+		t.Fatalf("unexpected auth for unreadable file: %+v", auths[0])
+	}
+	if !strings.Contains(auths[0].StatusMessage, "no encryption key") {
+		t.Errorf("status message = %q", auths[0].StatusMessage)
+	}
+}
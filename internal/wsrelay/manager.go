@@ -169,6 +169,16 @@ func (m *Manager) Send(ctx context.Context, provider string, msg Message) (<-cha
 	return s.request(ctx, msg)
 }
 
+// SessionCount returns the number of live websocket sessions.
+func (m *Manager) SessionCount() int {
+	if m == nil {
+		return 0
+	}
+	m.sessMutex.RLock()
+	defer m.sessMutex.RUnlock()
+	return len(m.sessions)
+}
+
 func (m *Manager) session(provider string) *session {
 	key := strings.ToLower(strings.TrimSpace(provider))
 	m.sessMutex.RLock()
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/tidwall/gjson"
+)
+
+// enforceAPIKeyPolicy applies the model, provider, thinking and streaming restrictions of the
+// calling client key and returns the providers the request may be routed to.
+func (h *BaseAPIHandler) enforceAPIKeyPolicy(ctx context.Context, handlerType, requestedModel, normalizedModel string, providers []string, metadata map[string]any, rawJSON []byte, stream bool) ([]string, *interfaces.ErrorMessage) {
+	if h.Cfg == nil {
+		return providers, nil
+	}
+	policy := h.Cfg.APIKeyPolicy(ClientAPIKeyFromContext(ctx))
+	if policy == nil {
+		return providers, nil
+	}
+	if stream && policy.DisableStreaming {
+		return nil, policyForbidden(handlerType, "streaming is not permitted for this API key")
+	}
+	if !policy.AllowsModel(requestedModel, normalizedModel) {
+		return nil, policyForbidden(handlerType, fmt.Sprintf("model %s is not permitted for this API key", requestedModel))
+	}
+	allowed := policy.FilterProviders(requestedModel, providers)
+	if len(allowed) == 0 {
+		return nil, policyForbidden(handlerType, fmt.Sprintf("no permitted provider serves model %s for this API key", requestedModel))
+	}
+	if policy.MaxThinkingBudget > 0 {
+		if budget, ok := requestedThinkingBudget(normalizedModel, metadata, rawJSON); ok {
+			if budget < 0 {
+				return nil, policyForbidden(handlerType, fmt.Sprintf("dynamic thinking budgets are not permitted for this API key (max %d)", policy.MaxThinkingBudget))
+			}
+			if budget > policy.MaxThinkingBudget {
+				return nil, policyForbidden(handlerType, fmt.Sprintf("thinking budget %d exceeds the limit of %d for this API key", budget, policy.MaxThinkingBudget))
+			}
+		}
+	}
+	return allowed, nil
+}
+
+// AllowsModelForRequest reports whether the calling client key may use model.
+// Model listings use it to hide models the key cannot call.
+func (h *BaseAPIHandler) AllowsModelForRequest(c *gin.Context, model string) bool {
+	if h.Cfg == nil || c == nil {
+		return true
+	}
+	policy := h.Cfg.APIKeyPolicy(clientAPIKeyFromGin(c))
+	if policy == nil {
+		return true
+	}
+	model = strings.TrimPrefix(model, "models/")
+	if !policy.AllowsModel(model) {
+		return false
+	}
+	if len(policy.AllowedProviders) == 0 {
+		return true
+	}
+	return len(policy.FilterProviders(model, util.GetProviderName(model))) > 0
+}
+
+// FilterModelsForRequest drops listing entries the calling client key may not use.
+// Entries are matched by their "id" or, for Gemini listings, "name" field.
+func (h *BaseAPIHandler) FilterModelsForRequest(c *gin.Context, models []map[string]any) []map[string]any {
+	out := make([]map[string]any, 0, len(models))
+	for _, model := range models {
+		id, _ := model["id"].(string)
+		if id == "" {
+			id, _ = model["name"].(string)
+		}
+		if id == "" || h.AllowsModelForRequest(c, id) {
+			out = append(out, model)
+		}
+	}
+	return out
+}
+
+func policyForbidden(handlerType, message string) *interfaces.ErrorMessage {
+	body := BuildProtocolErrorBody(handlerType, http.StatusForbidden, message)
+	return &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: errors.New(string(body))}
+}
+
+// requestedThinkingBudget returns the largest thinking budget requested through the model
+// suffix or the request body. Effort levels are converted to budgets; -1 means dynamic.
+func requestedThinkingBudget(model string, metadata map[string]any, rawJSON []byte) (int, bool) {
+	budget, found := 0, false
+	consider := func(value int) {
+		if !found || value < 0 || (budget >= 0 && value > budget) {
+			budget = value
+		}
+		found = true
+	}
+	considerEffort := func(effort string) {
+		if value, ok := util.ThinkingEffortToBudget(model, effort); ok {
+			consider(value)
+		}
+	}
+
+	if b, _, effort, matched := util.ThinkingFromMetadata(metadata); matched {
+		if b != nil {
+			consider(*b)
+		} else if effort != nil {
+			considerEffort(*effort)
+		}
+	}
+	if len(rawJSON) == 0 {
+		return budget, found
+	}
+	for _, path := range []string{
+		"thinking.budget_tokens",
+		"generationConfig.thinkingConfig.thinkingBudget",
+		"request.generationConfig.thinkingConfig.thinkingBudget",
+	} {
+		if r := gjson.GetBytes(rawJSON, path); r.Exists() && r.Type == gjson.Number {
+			consider(int(r.Int()))
+		}
+	}
+	for _, path := range []string{
+		"generationConfig.thinkingConfig.thinkingLevel",
+		"request.generationConfig.thinkingConfig.thinkingLevel",
+	} {
+		if r := gjson.GetBytes(rawJSON, path); r.Exists() {
+			if value, ok := util.ThinkingLevelToBudget(r.String()); ok {
+				consider(value)
+			}
+		}
+	}
+	for _, path := range []string{"reasoning_effort", "reasoning.effort"} {
+		if r := gjson.GetBytes(rawJSON, path); r.Exists() {
+			considerEffort(r.String())
+		}
+	}
+	return budget, found
+}
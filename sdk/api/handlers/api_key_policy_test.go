@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func policyTestContext(apiKey string) context.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("apiKey", apiKey)
+	return context.WithValue(context.Background(), "gin", c)
+}
+
+func TestEnforceAPIKeyPolicy_RejectsInCallerFormat(t *testing.T) {
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		APIKeyPolicies: []sdkconfig.APIKeyPolicy{{
+			APIKey:            "team-a",
+			AllowedModels:     []string{"claude-*"},
+			AllowedProviders:  []string{"claude"},
+			MaxThinkingBudget: 4096,
+			DisableStreaming:  true,
+		}},
+	}, nil)
+	ctx := policyTestContext("team-a")
+
+	providers, errMsg := h.enforceAPIKeyPolicy(ctx, "claude", "claude-sonnet-4", "claude-sonnet-4", []string{"claude", "kiro"}, nil, []byte(`{"thinking":{"type":"enabled","budget_tokens":2048}}`), false)
+	if errMsg != nil {
+		t.Fatalf("enforceAPIKeyPolicy() unexpected error = %v", errMsg.Error)
+	}
+	if len(providers) != 1 || providers[0] != "claude" {
+		t.Fatalf("providers = %v, want [claude]", providers)
+	}
+
+	cases := []struct {
+		name        string
+		handlerType string
+		model       string
+		body        string
+		stream      bool
+		errorPath   string
+	}{
+		{"model", "openai", "gpt-5", `{}`, false, "error.message"},
+		{"thinking", "claude", "claude-sonnet-4", `{"thinking":{"type":"enabled","budget_tokens":8192}}`, false, "error.message"},
+		{"stream", "gemini", "claude-sonnet-4", `{}`, true, "error.status"},
+	}
+	for _, tc := range cases {
+		_, errMsg = h.enforceAPIKeyPolicy(ctx, tc.handlerType, tc.model, tc.model, []string{"claude"}, nil, []byte(tc.body), tc.stream)
+		if errMsg == nil {
+			t.Fatalf("%s: enforceAPIKeyPolicy() error = nil, want 403", tc.name)
+		}
+		if errMsg.StatusCode != http.StatusForbidden {
+			t.Fatalf("%s: status = %d, want %d", tc.name, errMsg.StatusCode, http.StatusForbidden)
+		}
+		if !gjson.Get(errMsg.Error.Error(), tc.errorPath).Exists() {
+			t.Fatalf("%s: body %s missing %s", tc.name, errMsg.Error.Error(), tc.errorPath)
+		}
+	}
+}
+
+func TestFilterModelsForRequest_HidesDisallowedModels(t *testing.T) {
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		APIKeyPolicies: []sdkconfig.APIKeyPolicy{{APIKey: "team-a", AllowedModels: []string{"gemini-*"}}},
+	}, nil)
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("apiKey", "team-a")
+
+	models := []map[string]any{{"id": "gpt-5"}, {"name": "models/gemini-2.5-pro"}}
+	got := h.FilterModelsForRequest(c, models)
+	if len(got) != 1 || got[0]["name"] != "models/gemini-2.5-pro" {
+		t.Fatalf("FilterModelsForRequest() = %v, want only gemini-2.5-pro", got)
+	}
+
+	c.Set("apiKey", "unrestricted")
+	if got = h.FilterModelsForRequest(c, models); len(got) != 2 {
+		t.Fatalf("FilterModelsForRequest() unrestricted = %v, want all models", got)
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/responsestore"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// StartBatches applies the batch configuration and starts the batch engine with this handler
+// executing the queued requests. Unfinished jobs resume on the first call.
+func (h *BaseAPIHandler) StartBatches() {
+	engine := batch.Default()
+	if h.Cfg != nil {
+		engine.Apply(h.Cfg.Batch)
+	}
+	engine.Start(h.executeBatchRequest)
+}
+
+// BatchOwner returns the owner that batch jobs and files of the calling client key are stored
+// under: a hash of the key, so the key itself never reaches the batch directory.
+func BatchOwner(c *gin.Context) string {
+	return responsestore.Owner(ClientAPIKey(c))
+}
+
+// CanOwnBatches reports whether batches created by the calling client can run under its key.
+// Jobs keep only the owner hash, so the key must be listed in api-keys, api-key-policies or
+// cooldown-queue.api-keys; keys accepted by other access providers cannot be recovered.
+func (h *BaseAPIHandler) CanOwnBatches(c *gin.Context) bool {
+	apiKey := ClientAPIKey(c)
+	if apiKey == "" {
+		return true
+	}
+	resolved, ok := h.batchClientAPIKey(responsestore.Owner(apiKey))
+	return ok && resolved == apiKey
+}
+
+// executeBatchRequest runs one queued batch request through the regular pipeline under the
+// API key that created the batch. Errors are returned as bodies in the request's format.
+func (h *BaseAPIHandler) executeBatchRequest(ctx context.Context, owner string, req batch.Request) batch.Outcome {
+	apiKey, ok := h.batchClientAPIKey(owner)
+	if !ok {
+		status := http.StatusUnauthorized
+		return batch.Outcome{StatusCode: status, Body: BuildProtocolErrorBody(req.HandlerType, status, "the API key that created this batch is no longer configured")}
+	}
+	ctx = withClientAPIKey(ctx, apiKey)
+	var (
+		resp   []byte
+		errMsg *interfaces.ErrorMessage
+	)
+	switch req.HandlerType {
+	case constant.OpenAIEmbeddings:
+		resp, errMsg = h.ExecuteEmbedWithAuthManager(ctx, constant.OpenAI, sdktranslator.FormatOpenAIEmbeddings, req.Model, req.Body)
+	default:
+		resp, errMsg = h.ExecuteWithAuthManager(ctx, req.HandlerType, req.Model, req.Body, "")
+	}
+	if errMsg == nil {
+		return batch.Outcome{StatusCode: http.StatusOK, Body: resp}
+	}
+
+	status := http.StatusInternalServerError
+	if errMsg.StatusCode > 0 {
+		status = errMsg.StatusCode
+	}
+	errText := ""
+	if errMsg.Error != nil {
+		errText = errMsg.Error.Error()
+	}
+	out := batch.Outcome{StatusCode: status, Body: BuildProtocolErrorBody(req.HandlerType, status, errText)}
+	if errMsg.Addon != nil {
+		if seconds, err := strconv.Atoi(strings.TrimSpace(errMsg.Addon.Get("Retry-After"))); err == nil && seconds > 0 {
+			out.RetryAfter = time.Duration(seconds) * time.Second
+		}
+	}
+	return out
+}
+
+// batchClientAPIKey looks up the configured client API key whose hash is owner, so the key's
+// policy, limits and queueing apply to the batch. ok is false when none of them matches,
+// meaning the key was removed since the batch was created.
+func (h *BaseAPIHandler) batchClientAPIKey(owner string) (apiKey string, ok bool) {
+	if owner == "" || h.Cfg == nil {
+		return "", true
+	}
+	candidates := append([]string(nil), h.Cfg.APIKeys...)
+	for i := range h.Cfg.APIKeyPolicies {
+		candidates = append(candidates, h.Cfg.APIKeyPolicies[i].APIKey)
+	}
+	candidates = append(candidates, h.Cfg.CooldownQueue.APIKeys...)
+	for _, key := range candidates {
+		if key != "" && responsestore.Owner(key) == owner {
+			return key, true
+		}
+	}
+	return "", false
+}
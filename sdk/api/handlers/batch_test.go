@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/ratelimit"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/responsestore"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// usageReportingExecutor publishes a usage record for every call the way the runtime
+// executors do, attributing it to the client API key of the request context.
+type usageReportingExecutor struct{}
+
+func (usageReportingExecutor) Identifier() string { return "batch-usage" }
+
+func (usageReportingExecutor) Execute(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	coreusage.PublishRecord(ctx, coreusage.Record{
+		Provider:    auth.Provider,
+		Model:       req.Model,
+		APIKey:      ClientAPIKeyFromContext(ctx),
+		AuthID:      auth.ID,
+		RequestedAt: time.Now(),
+		Detail:      coreusage.Detail{InputTokens: 4, OutputTokens: 10, TotalTokens: 14},
+	})
+	return coreexecutor.Response{Payload: []byte(`{"ok":true}`)}, nil
+}
+
+func (usageReportingExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (usageReportingExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (usageReportingExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func TestBatchClientAPIKey_ResolvesOwnerHash(t *testing.T) {
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		APIKeys:        []string{"key-a"},
+		APIKeyPolicies: []sdkconfig.APIKeyPolicy{{APIKey: "key-b"}},
+	}, nil)
+
+	for _, key := range []string{"key-a", "key-b"} {
+		if got, ok := h.batchClientAPIKey(responsestore.Owner(key)); !ok || got != key {
+			t.Fatalf("batchClientAPIKey(owner of %s) = %q, %v", key, got, ok)
+		}
+	}
+	if got, ok := h.batchClientAPIKey(""); !ok || got != "" {
+		t.Fatalf("batchClientAPIKey(\"\") = %q, %v", got, ok)
+	}
+	if _, ok := h.batchClientAPIKey(responsestore.Owner("removed-key")); ok {
+		t.Fatalf("batchClientAPIKey accepted the owner of a key that is no longer configured")
+	}
+}
+
+func TestExecuteBatchRequest_RejectsRemovedKey(t *testing.T) {
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{APIKeys: []string{"key-a"}}, nil)
+
+	out := h.executeBatchRequest(context.Background(), responsestore.Owner("removed-key"), batch.Request{CustomID: "r1", HandlerType: "claude", Model: "m"})
+	if out.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", out.StatusCode, http.StatusUnauthorized)
+	}
+	if gjson.GetBytes(out.Body, "type").String() != "error" {
+		t.Fatalf("body = %s, want a Claude error object", out.Body)
+	}
+}
+
+func TestExecuteBatchRequest_ChargesOwnerTokenBudget(t *testing.T) {
+	const apiKey = "batch-budget-key"
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(usageReportingExecutor{})
+	auth := &coreauth.Auth{ID: "batch-usage-auth", Provider: "batch-usage", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register() error = %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "batch-usage-model"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+		ratelimit.Default().Reset(apiKey)
+	})
+
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		APIKeyPolicies: []sdkconfig.APIKeyPolicy{{APIKey: apiKey, OutputTokensPerDay: 10}},
+	}, manager)
+	req := batch.Request{CustomID: "r1", HandlerType: "openai", Model: "batch-usage-model", Body: []byte(`{"model":"batch-usage-model"}`)}
+
+	if out := h.executeBatchRequest(context.Background(), responsestore.Owner(apiKey), req); out.StatusCode != http.StatusOK {
+		t.Fatalf("first request status = %d, body = %s", out.StatusCode, out.Body)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for outputTokensToday(apiKey) < 10 {
+		if time.Now().After(deadline) {
+			t.Fatalf("usage of the batch request was not charged to %s", apiKey)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if out := h.executeBatchRequest(context.Background(), responsestore.Owner(apiKey), req); out.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("request over budget status = %d, want %d", out.StatusCode, http.StatusTooManyRequests)
+	}
+}
+
+func outputTokensToday(apiKey string) int64 {
+	for _, usage := range ratelimit.Default().Snapshot() {
+		if usage.APIKey == apiKey {
+			return usage.OutputTokensDay
+		}
+	}
+	return 0
+}
+
+func TestCanOwnBatches_RequiresConfiguredKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{APIKeys: []string{"key-a"}}, nil)
+	for key, want := range map[string]bool{"": true, "key-a": true, "provider-key": false} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		if key != "" {
+			c.Set("apiKey", key)
+		}
+		if got := h.CanOwnBatches(c); got != want {
+			t.Fatalf("CanOwnBatches(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
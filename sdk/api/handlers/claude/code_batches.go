@@ -0,0 +1,229 @@
+package claude
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultBatchListLimit = 20
+	maxBatchListLimit     = 1000
+)
+
+var batchCustomID = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// CreateMessageBatch handles POST /v1/messages/batches. Each request is queued and executed
+// as an individual non-streaming Messages call.
+func (h *ClaudeCodeAPIHandler) CreateMessageBatch(c *gin.Context) {
+	if !h.CanOwnBatches(c) {
+		writeBatchError(c, http.StatusForbidden, "batches require a client API key listed in api-keys")
+		return
+	}
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeBatchError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	items := gjson.GetBytes(rawJSON, "requests")
+	if !items.IsArray() {
+		writeBatchError(c, http.StatusBadRequest, "requests: field required")
+		return
+	}
+	var requests []batch.Request
+	for i, item := range items.Array() {
+		customID := item.Get("custom_id").String()
+		if !batchCustomID.MatchString(customID) {
+			writeBatchError(c, http.StatusBadRequest, fmt.Sprintf("requests.%d.custom_id: must match %s", i, batchCustomID.String()))
+			return
+		}
+		params := item.Get("params")
+		model := params.Get("model").String()
+		if !params.IsObject() || model == "" {
+			writeBatchError(c, http.StatusBadRequest, fmt.Sprintf("requests.%d.params.model: field required", i))
+			return
+		}
+		body, _ := sjson.Delete(params.Raw, "stream")
+		requests = append(requests, batch.Request{CustomID: customID, HandlerType: h.HandlerType(), Model: model, Body: []byte(body)})
+	}
+
+	job, err := batch.Default().Create(batch.KindClaude, handlers.BatchOwner(c), requests, batch.Options{})
+	if err != nil {
+		writeBatchEngineError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, messageBatchObject(c, job))
+}
+
+// GetMessageBatch handles GET /v1/messages/batches/{id}.
+func (h *ClaudeCodeAPIHandler) GetMessageBatch(c *gin.Context) {
+	job, err := batch.Default().Get(batch.KindClaude, c.Param("id"), handlers.BatchOwner(c))
+	if err != nil {
+		writeBatchEngineError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, messageBatchObject(c, job))
+}
+
+// ListMessageBatches handles GET /v1/messages/batches, newest first, paginated with
+// limit, after_id and before_id.
+func (h *ClaudeCodeAPIHandler) ListMessageBatches(c *gin.Context) {
+	limit := defaultBatchListLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxBatchListLimit {
+			writeBatchError(c, http.StatusBadRequest, fmt.Sprintf("limit: must be between 1 and %d", maxBatchListLimit))
+			return
+		}
+		limit = parsed
+	}
+	jobs := batch.Default().List(batch.KindClaude, handlers.BatchOwner(c))
+	if afterID := c.Query("after_id"); afterID != "" {
+		for i, job := range jobs {
+			if job.ID == afterID {
+				jobs = jobs[i+1:]
+				break
+			}
+		}
+	} else if beforeID := c.Query("before_id"); beforeID != "" {
+		for i, job := range jobs {
+			if job.ID == beforeID {
+				jobs = jobs[max(0, i-limit):i]
+				break
+			}
+		}
+	}
+	hasMore := len(jobs) > limit
+	if hasMore {
+		jobs = jobs[:limit]
+	}
+	data := make([]gin.H, 0, len(jobs))
+	for _, job := range jobs {
+		data = append(data, messageBatchObject(c, job))
+	}
+	var firstID, lastID any
+	if len(jobs) > 0 {
+		firstID, lastID = jobs[0].ID, jobs[len(jobs)-1].ID
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data, "has_more": hasMore, "first_id": firstID, "last_id": lastID})
+}
+
+// CancelMessageBatch handles POST /v1/messages/batches/{id}/cancel.
+func (h *ClaudeCodeAPIHandler) CancelMessageBatch(c *gin.Context) {
+	job, err := batch.Default().Cancel(batch.KindClaude, c.Param("id"), handlers.BatchOwner(c))
+	if err != nil {
+		writeBatchEngineError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, messageBatchObject(c, job))
+}
+
+// DeleteMessageBatch handles DELETE /v1/messages/batches/{id}. Only ended batches can be
+// deleted.
+func (h *ClaudeCodeAPIHandler) DeleteMessageBatch(c *gin.Context) {
+	id := c.Param("id")
+	if err := batch.Default().Delete(batch.KindClaude, id, handlers.BatchOwner(c)); err != nil {
+		writeBatchEngineError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "type": "message_batch_deleted"})
+}
+
+// MessageBatchResults handles GET /v1/messages/batches/{id}/results and streams the results
+// of an ended batch as JSON Lines.
+func (h *ClaudeCodeAPIHandler) MessageBatchResults(c *gin.Context) {
+	engine := batch.Default()
+	id, owner := c.Param("id"), handlers.BatchOwner(c)
+	if _, err := engine.Get(batch.KindClaude, id, owner); err != nil {
+		writeBatchEngineError(c, err)
+		return
+	}
+	headerWritten := false
+	err := engine.Results(batch.KindClaude, id, owner, func(r batch.Result) error {
+		if !headerWritten {
+			c.Header("Content-Type", "application/x-jsonl")
+			c.Status(http.StatusOK)
+			headerWritten = true
+		}
+		_, errWrite := c.Writer.Write(append(batch.ClaudeResultLine(r), '\n'))
+		return errWrite
+	})
+	if err != nil && !headerWritten {
+		writeBatchEngineError(c, err)
+		return
+	}
+	if !headerWritten {
+		c.Data(http.StatusOK, "application/x-jsonl", nil)
+	}
+}
+
+// messageBatchObject renders a job in the Message Batches API shape.
+func messageBatchObject(c *gin.Context, job *batch.Job) gin.H {
+	status := "in_progress"
+	switch job.Status {
+	case batch.StatusCanceling:
+		status = "canceling"
+	case batch.StatusEnded:
+		status = "ended"
+	}
+	var resultsURL any
+	if status == "ended" {
+		scheme := "http"
+		if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		resultsURL = fmt.Sprintf("%s://%s/v1/messages/batches/%s/results", scheme, c.Request.Host, job.ID)
+	}
+	return gin.H{
+		"id":                job.ID,
+		"type":              "message_batch",
+		"processing_status": status,
+		"request_counts": gin.H{
+			"processing": job.Counts.Processing,
+			"succeeded":  job.Counts.Succeeded,
+			"errored":    job.Counts.Errored,
+			"canceled":   job.Counts.Canceled,
+			"expired":    job.Counts.Expired,
+		},
+		"created_at":          job.CreatedAt.Format(time.RFC3339),
+		"expires_at":          job.ExpiresAt.Format(time.RFC3339),
+		"ended_at":            formatBatchTime(job.EndedAt),
+		"cancel_initiated_at": formatBatchTime(job.CancelRequestedAt),
+		"archived_at":         nil,
+		"results_url":         resultsURL,
+	}
+}
+
+func formatBatchTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.Format(time.RFC3339)
+}
+
+func writeBatchEngineError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, batch.ErrNotFound):
+		writeBatchError(c, http.StatusNotFound, "batch not found")
+	case errors.Is(err, batch.ErrDisabled):
+		writeBatchError(c, http.StatusServiceUnavailable, err.Error())
+	case errors.Is(err, batch.ErrNotEnded), errors.Is(err, batch.ErrInvalid):
+		writeBatchError(c, http.StatusBadRequest, err.Error())
+	default:
+		writeBatchError(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func writeBatchError(c *gin.Context, status int, message string) {
+	c.Data(status, "application/json", handlers.BuildProtocolErrorBody(Claude, status, message))
+}
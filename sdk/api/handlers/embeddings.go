@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// ExecuteEmbedWithAuthManager executes an embeddings request via the core auth manager.
+// sourceFormat is the embeddings wire format of rawJSON, and the response is returned in the
+// same format. Models registered without embedding support are rejected before routing.
+func (h *BaseAPIHandler) ExecuteEmbedWithAuthManager(ctx context.Context, handlerType string, sourceFormat sdktranslator.Format, modelName string, rawJSON []byte) ([]byte, *interfaces.ErrorMessage) {
+	release, errMsg := h.acquireAPIKeyLimit(ctx, handlerType, false)
+	defer release()
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	if info := registry.GetGlobalRegistry().GetModelInfo(normalizedModel); info != nil && !info.SupportsEmbeddings() {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("model %s does not support embeddings", modelName)}
+	}
+	providers, errMsg = h.enforceAPIKeyPolicy(ctx, handlerType, modelName, normalizedModel, providers, metadata, rawJSON, false)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	reqMeta := requestExecutionMetadata(ctx)
+	req := coreexecutor.Request{
+		Model:   normalizedModel,
+		Payload: cloneBytes(rawJSON),
+	}
+	if cloned := cloneMetadata(metadata); cloned != nil {
+		req.Metadata = cloned
+	}
+	opts := coreexecutor.Options{
+		Stream:          false,
+		OriginalRequest: cloneBytes(rawJSON),
+		SourceFormat:    sourceFormat,
+	}
+	opts.Metadata = mergeMetadata(cloneMetadata(metadata), reqMeta)
+	resp, err := h.AuthManager.ExecuteEmbed(ctx, providers, req, opts)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
+			if code := se.StatusCode(); code > 0 {
+				status = code
+			}
+		}
+		var addon http.Header
+		if he, ok := err.(interface{ Headers() http.Header }); ok && he != nil {
+			if hdr := he.Headers(); hdr != nil {
+				addon = hdr.Clone()
+			}
+		}
+		return nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
+	}
+	return cloneBytes(resp.Payload), nil
+}
@@ -17,6 +17,9 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 )
 
 // GeminiAPIHandler contains the handlers for Gemini API endpoints.
@@ -48,7 +51,7 @@ func (h *GeminiAPIHandler) Models() []map[string]any {
 // GeminiModels handles the Gemini models listing endpoint.
 // It returns a JSON response containing available Gemini models and their specifications.
 func (h *GeminiAPIHandler) GeminiModels(c *gin.Context) {
-	rawModels := h.Models()
+	rawModels := h.FilterModelsForRequest(c, h.Models())
 	normalizedModels := make([]map[string]any, 0, len(rawModels))
 	defaultMethods := []string{"generateContent"}
 	for _, model := range rawModels {
@@ -211,6 +214,10 @@ func (h *GeminiAPIHandler) GeminiHandler(c *gin.Context) {
 		h.handleStreamGenerateContent(c, action[0], rawJSON)
 	case "countTokens":
 		h.handleCountTokens(c, action[0], rawJSON)
+	case "embedContent":
+		h.handleEmbedContent(c, action[0], rawJSON)
+	case "batchEmbedContents":
+		h.handleBatchEmbedContents(c, action[0], rawJSON)
 	}
 }
 
@@ -346,6 +353,53 @@ func (h *GeminiAPIHandler) handleGenerateContent(c *gin.Context, modelName strin
 	cliCancel()
 }
 
+// handleEmbedContent handles single embedding requests for Gemini models.
+// The request is executed as a one-entry batch and the first embedding is returned.
+//
+// Parameters:
+//   - c: The Gin context for the request
+//   - modelName: The name of the embedding model
+//   - rawJSON: The raw JSON request body containing the content to embed
+func (h *GeminiAPIHandler) handleEmbedContent(c *gin.Context, modelName string, rawJSON []byte) {
+	entry := rawJSON
+	if !gjson.GetBytes(entry, "model").Exists() {
+		entry, _ = sjson.SetBytes(entry, "model", "models/"+modelName)
+	}
+	batch, _ := sjson.SetRawBytes([]byte(`{"requests":[]}`), "requests.-1", entry)
+	resp, ok := h.executeEmbed(c, modelName, batch)
+	if !ok {
+		return
+	}
+	out, _ := sjson.SetRawBytes([]byte(`{}`), "embedding", []byte(gjson.GetBytes(resp, "embeddings.0").Raw))
+	_, _ = c.Writer.Write(out)
+}
+
+// handleBatchEmbedContents handles batch embedding requests for Gemini models.
+//
+// Parameters:
+//   - c: The Gin context for the request
+//   - modelName: The name of the embedding model
+//   - rawJSON: The raw JSON request body containing the requests to embed
+func (h *GeminiAPIHandler) handleBatchEmbedContents(c *gin.Context, modelName string, rawJSON []byte) {
+	if resp, ok := h.executeEmbed(c, modelName, rawJSON); ok {
+		_, _ = c.Writer.Write(resp)
+	}
+}
+
+// executeEmbed runs a batchEmbedContents request and writes the error response on failure.
+func (h *GeminiAPIHandler) executeEmbed(c *gin.Context, modelName string, batch []byte) ([]byte, bool) {
+	c.Header("Content-Type", "application/json")
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, errMsg := h.ExecuteEmbedWithAuthManager(cliCtx, h.HandlerType(), sdktranslator.FormatGeminiEmbeddings, modelName, batch)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return nil, false
+	}
+	cliCancel()
+	return resp, true
+}
+
 func (h *GeminiAPIHandler) forwardGeminiStream(c *gin.Context, flusher http.Flusher, alt string, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
 	var keepAliveInterval *time.Duration
 	if alt != "" {
@@ -15,6 +15,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
@@ -45,6 +46,13 @@ type ErrorDetail struct {
 
 const idempotencyKeyMetadataKey = "idempotency_key"
 
+// ServedModelHeader reports the model that actually produced the response, which differs
+// from the requested model when a fallback chain was used.
+const ServedModelHeader = "X-CLIProxy-Model"
+
+// CooldownQueueHeader opts a request into the cooldown queue when set to "wait".
+const CooldownQueueHeader = "X-CLIProxy-Queue"
+
 const (
 	defaultStreamingKeepAliveSeconds = 0
 	defaultStreamingBootstrapRetries = 0
@@ -128,16 +136,61 @@ func StreamingBootstrapRetries(cfg *config.SDKConfig) int {
 func requestExecutionMetadata(ctx context.Context) map[string]any {
 	// Idempotency-Key is an optional client-supplied header used to correlate retries.
 	// It is forwarded as execution metadata; when absent we generate a UUID.
+	// X-Session-ID lets clients pin a conversation to one credential under sticky routing.
 	key := ""
+	sessionID := ""
 	if ctx != nil {
 		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
 			key = strings.TrimSpace(ginCtx.GetHeader("Idempotency-Key"))
+			sessionID = strings.TrimSpace(ginCtx.GetHeader("X-Session-ID"))
 		}
 	}
 	if key == "" {
 		key = uuid.NewString()
 	}
-	return map[string]any{idempotencyKeyMetadataKey: key}
+	meta := map[string]any{idempotencyKeyMetadataKey: key}
+	if sessionID != "" {
+		meta[coreexecutor.SessionIDMetadataKey] = sessionID
+	}
+	if ctx != nil {
+		if raw, ok := ctx.Value(completionsRequestContextKey{}).([]byte); ok && len(raw) > 0 {
+			meta[coreexecutor.CompletionsRequestMetadataKey] = raw
+		}
+	}
+	return meta
+}
+
+type completionsRequestContextKey struct{}
+
+// WithCompletionsRequest attaches the original body of a /v1/completions request to ctx. The
+// body is forwarded as execution metadata so upstreams speaking the completions API natively
+// receive it untranslated.
+func WithCompletionsRequest(ctx context.Context, rawJSON []byte) context.Context {
+	return context.WithValue(ctx, completionsRequestContextKey{}, rawJSON)
+}
+
+// markCooldownQueue flags meta so the auth manager queues the request while every credential
+// for its model cools down, when the queue is enabled and the client opted in.
+func (h *BaseAPIHandler) markCooldownQueue(ctx context.Context, meta map[string]any) {
+	if h.Cfg == nil || !h.Cfg.CooldownQueue.Enable || meta == nil {
+		return
+	}
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+		if strings.EqualFold(strings.TrimSpace(ginCtx.GetHeader(CooldownQueueHeader)), "wait") {
+			meta[coreexecutor.CooldownQueueMetadataKey] = true
+			return
+		}
+	}
+	apiKey := ClientAPIKeyFromContext(ctx)
+	if apiKey == "" {
+		return
+	}
+	for _, key := range h.Cfg.CooldownQueue.APIKeys {
+		if key == apiKey {
+			meta[coreexecutor.CooldownQueueMetadataKey] = true
+			return
+		}
+	}
 }
 
 func mergeMetadata(base, overlay map[string]any) map[string]any {
@@ -240,6 +293,9 @@ func (h *BaseAPIHandler) GetContextWithCancel(handler interfaces.APIHandler, c *
 			parentCtx = logging.WithRequestID(parentCtx, requestID)
 		}
 	}
+	if requestCtx != nil {
+		parentCtx = tracing.InheritSpan(parentCtx, requestCtx)
+	}
 	newCtx, cancel := context.WithCancel(parentCtx)
 	if requestCtx != nil && requestCtx != parentCtx {
 		go func() {
@@ -318,11 +374,26 @@ func appendAPIResponse(c *gin.Context, data []byte) {
 // ExecuteWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
+	release, errMsg := h.acquireAPIKeyLimit(ctx, handlerType, false)
+	defer release()
+	if errMsg != nil {
+		return nil, errMsg
+	}
 	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		return nil, errMsg
 	}
+	providers, errMsg = h.enforceAPIKeyPolicy(ctx, handlerType, modelName, normalizedModel, providers, metadata, rawJSON, false)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	cached := h.lookupResponseCache(ctx, handlerType, modelName, alt, rawJSON, false)
+	if cached.hit() {
+		return cloneBytes(cached.entry.Body), nil
+	}
+	ctx = h.withModelRouteHooks(ctx)
 	reqMeta := requestExecutionMetadata(ctx)
+	h.markCooldownQueue(ctx, reqMeta)
 	req := coreexecutor.Request{
 		Model:   normalizedModel,
 		Payload: cloneBytes(rawJSON),
@@ -353,6 +424,7 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		}
 		return nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 	}
+	cached.storeBody(handlerType, normalizedModel, resp.Payload)
 	return cloneBytes(resp.Payload), nil
 }
 
@@ -363,6 +435,10 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 	if errMsg != nil {
 		return nil, errMsg
 	}
+	providers, errMsg = h.enforceAPIKeyPolicy(ctx, handlerType, modelName, normalizedModel, providers, metadata, nil, false)
+	if errMsg != nil {
+		return nil, errMsg
+	}
 	reqMeta := requestExecutionMetadata(ctx)
 	req := coreexecutor.Request{
 		Model:   normalizedModel,
@@ -400,14 +476,38 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 // ExecuteStreamWithAuthManager executes a streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+	release, errMsg := h.acquireAPIKeyLimit(ctx, handlerType, true)
+	if errMsg != nil {
+		release()
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, errChan
+	}
 	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
+		release()
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, errChan
+	}
+	providers, errMsg = h.enforceAPIKeyPolicy(ctx, handlerType, modelName, normalizedModel, providers, metadata, rawJSON, true)
+	if errMsg != nil {
+		release()
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		errChan <- errMsg
 		close(errChan)
 		return nil, errChan
 	}
+	cached := h.lookupResponseCache(ctx, handlerType, modelName, alt, rawJSON, true)
+	if cached.hit() {
+		return replayCachedStream(ctx, cached.entry.StreamChunks(handlerType), release)
+	}
+	recorder := cached.recorder(handlerType, normalizedModel)
+	ctx = h.withModelRouteHooks(ctx)
 	reqMeta := requestExecutionMetadata(ctx)
+	h.markCooldownQueue(ctx, reqMeta)
 	req := coreexecutor.Request{
 		Model:   normalizedModel,
 		Payload: cloneBytes(rawJSON),
@@ -424,6 +524,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	opts.Metadata = mergeMetadata(cloneMetadata(metadata), reqMeta)
 	chunks, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
 	if err != nil {
+		release()
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
@@ -444,6 +545,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	dataChan := make(chan []byte)
 	errChan := make(chan *interfaces.ErrorMessage, 1)
 	go func() {
+		defer release()
 		defer close(dataChan)
 		defer close(errChan)
 		sentPayload := false
@@ -479,6 +581,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					chunk, ok = <-chunks
 				}
 				if !ok {
+					recorder.commit()
 					return
 				}
 				if chunk.Err != nil {
@@ -514,6 +617,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 				}
 				if len(chunk.Payload) > 0 {
 					sentPayload = true
+					recorder.add(chunk.Payload)
 					dataChan <- cloneBytes(chunk.Payload)
 				}
 			}
@@ -522,6 +626,32 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	return dataChan, errChan
 }
 
+// withModelRouteHooks restricts model fallbacks to what the client API key may use and reports
+// the served model through the ServedModelHeader response header.
+func (h *BaseAPIHandler) withModelRouteHooks(ctx context.Context) context.Context {
+	if ctx == nil {
+		return ctx
+	}
+	ginCtx, _ := ctx.Value("gin").(*gin.Context)
+	var policy *config.APIKeyPolicy
+	if h.Cfg != nil {
+		policy = h.Cfg.APIKeyPolicy(clientAPIKeyFromGin(ginCtx))
+	}
+	return coreauth.WithModelRouteHooks(ctx, coreauth.ModelRouteHooks{
+		FilterProviders: func(model string, providers []string) []string {
+			if !policy.AllowsModel(model) {
+				return nil
+			}
+			return policy.FilterProviders(model, providers)
+		},
+		Served: func(model string) {
+			if ginCtx != nil && !ginCtx.Writer.Written() {
+				ginCtx.Header(ServedModelHeader, model)
+			}
+		},
+	})
+}
+
 func statusFromError(err error) int {
 	if err == nil {
 		return 0
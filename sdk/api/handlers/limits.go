@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/ratelimit"
+)
+
+// acquireAPIKeyLimit applies the api-key-policies limits of the calling client key.
+// The returned release func must be called once the request finishes; it is never nil.
+func (h *BaseAPIHandler) acquireAPIKeyLimit(ctx context.Context, handlerType string, stream bool) (func(), *interfaces.ErrorMessage) {
+	apiKey := ClientAPIKeyFromContext(ctx)
+	if apiKey == "" || h.Cfg == nil {
+		return func() {}, nil
+	}
+	release, limitErr := ratelimit.Default().Acquire(apiKey, h.Cfg.APIKeyPolicy(apiKey), stream)
+	if limitErr == nil {
+		return release, nil
+	}
+	addon := http.Header{}
+	if limitErr.RetryAfter > 0 {
+		addon.Set("Retry-After", strconv.Itoa(int(math.Ceil(limitErr.RetryAfter.Seconds()))))
+	}
+	body := BuildProtocolErrorBody(handlerType, http.StatusTooManyRequests, limitErr.Message)
+	return release, &interfaces.ErrorMessage{
+		StatusCode: http.StatusTooManyRequests,
+		Error:      errors.New(string(body)),
+		Addon:      addon,
+	}
+}
+
+type clientAPIKeyContextKey struct{}
+
+// withClientAPIKey attaches a client API key to ctx for requests executed outside of an HTTP
+// request, so the key's policy still applies.
+func withClientAPIKey(ctx context.Context, apiKey string) context.Context {
+	return context.WithValue(ctx, clientAPIKeyContextKey{}, apiKey)
+}
+
+// ClientAPIKeyFromContext returns the client API key a request runs under: the key attached
+// for work executed outside of an HTTP request, such as batches, or else the key recorded by
+// the access middleware.
+func ClientAPIKeyFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if apiKey, ok := ctx.Value(clientAPIKeyContextKey{}).(string); ok {
+		return apiKey
+	}
+	ginCtx, _ := ctx.Value("gin").(*gin.Context)
+	return clientAPIKeyFromGin(ginCtx)
+}
+
+// ClientAPIKey returns the client API key the access middleware attached to the request.
+func ClientAPIKey(c *gin.Context) string {
+	return clientAPIKeyFromGin(c)
+}
+
+func clientAPIKeyFromGin(c *gin.Context) string {
+	if c == nil {
+		return ""
+	}
+	v, exists := c.Get("apiKey")
+	if !exists {
+		return ""
+	}
+	switch value := v.(type) {
+	case string:
+		return value
+	case fmt.Stringer:
+		return value.String()
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+// BuildProtocolErrorBody builds an error body in the native shape of the calling API:
+// Claude and Gemini clients receive their provider specific error objects while every
+// other handler type falls back to the OpenAI-compatible shape.
+func BuildProtocolErrorBody(handlerType string, status int, message string) []byte {
+	if strings.TrimSpace(message) == "" {
+		message = http.StatusText(status)
+	}
+	var payload any
+	switch handlerType {
+	case constant.Claude:
+		payload = map[string]any{
+			"type": "error",
+			"error": map[string]any{
+				"type":    claudeErrorType(status),
+				"message": message,
+			},
+		}
+	case constant.Gemini, constant.GeminiCLI:
+		payload = map[string]any{
+			"error": map[string]any{
+				"code":    status,
+				"message": message,
+				"status":  geminiErrorStatus(status),
+			},
+		}
+	default:
+		return BuildErrorResponseBody(status, message)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return BuildErrorResponseBody(status, message)
+	}
+	return data
+}
+
+func claudeErrorType(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "invalid_request_error"
+	case http.StatusUnauthorized:
+		return "authentication_error"
+	case http.StatusForbidden:
+		return "permission_error"
+	case http.StatusNotFound:
+		return "not_found_error"
+	case http.StatusTooManyRequests:
+		return "rate_limit_error"
+	default:
+		return "api_error"
+	}
+}
+
+func geminiErrorStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "INVALID_ARGUMENT"
+	case http.StatusUnauthorized:
+		return "UNAUTHENTICATED"
+	case http.StatusForbidden:
+		return "PERMISSION_DENIED"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusTooManyRequests:
+		return "RESOURCE_EXHAUSTED"
+	default:
+		return "INTERNAL"
+	}
+}
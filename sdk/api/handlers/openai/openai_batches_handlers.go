@@ -0,0 +1,366 @@
+package openai
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// maxBatchFileBytes matches the upload limit of the OpenAI Batch API.
+	maxBatchFileBytes = 200 << 20
+
+	defaultBatchListLimit = 20
+	maxBatchListLimit     = 100
+)
+
+// batchEndpoints maps the endpoints accepted by POST /v1/batches to the handler type used to
+// execute their requests.
+var batchEndpoints = map[string]string{
+	"/v1/chat/completions": constant.OpenAI,
+	"/v1/responses":        constant.OpenaiResponse,
+	"/v1/embeddings":       constant.OpenAIEmbeddings,
+}
+
+// UploadFile handles POST /v1/files. Only files with the "batch" purpose are accepted.
+func (h *OpenAIAPIHandler) UploadFile(c *gin.Context) {
+	purpose := c.PostForm("purpose")
+	if purpose != batch.PurposeBatch {
+		writeOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("purpose %q is not supported; use %q", purpose, batch.PurposeBatch))
+		return
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		writeOpenAIError(c, http.StatusBadRequest, "file: field required")
+		return
+	}
+	if header.Size > maxBatchFileBytes {
+		writeOpenAIError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("file exceeds %d bytes", maxBatchFileBytes))
+		return
+	}
+	src, err := header.Open()
+	if err != nil {
+		writeOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("read file: %v", err))
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(src, maxBatchFileBytes+1))
+	_ = src.Close()
+	if err != nil {
+		writeOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("read file: %v", err))
+		return
+	}
+	file, err := batch.Default().CreateFile(handlers.BatchOwner(c), header.Filename, purpose, data)
+	if err != nil {
+		writeBatchEngineError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, fileObject(file))
+}
+
+// ListFiles handles GET /v1/files, optionally filtered by purpose.
+func (h *OpenAIAPIHandler) ListFiles(c *gin.Context) {
+	files := batch.Default().Files(handlers.BatchOwner(c), c.Query("purpose"))
+	data := make([]gin.H, 0, len(files))
+	for _, file := range files {
+		data = append(data, fileObject(file))
+	}
+	c.JSON(http.StatusOK, gin.H{"object": "list", "data": data, "has_more": false})
+}
+
+// GetFile handles GET /v1/files/{id}.
+func (h *OpenAIAPIHandler) GetFile(c *gin.Context) {
+	file, err := batch.Default().File(c.Param("id"), handlers.BatchOwner(c))
+	if err != nil {
+		writeBatchEngineError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, fileObject(file))
+}
+
+// GetFileContent handles GET /v1/files/{id}/content.
+func (h *OpenAIAPIHandler) GetFileContent(c *gin.Context) {
+	data, err := batch.Default().FileContent(c.Param("id"), handlers.BatchOwner(c))
+	if err != nil {
+		writeBatchEngineError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, "application/octet-stream", data)
+}
+
+// DeleteFile handles DELETE /v1/files/{id}.
+func (h *OpenAIAPIHandler) DeleteFile(c *gin.Context) {
+	id := c.Param("id")
+	if err := batch.Default().DeleteFile(id, handlers.BatchOwner(c)); err != nil {
+		writeBatchEngineError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "object": "file", "deleted": true})
+}
+
+// CreateBatch handles POST /v1/batches. The requests of the input file are queued and
+// executed individually; results are written to an output and an error file.
+func (h *OpenAIAPIHandler) CreateBatch(c *gin.Context) {
+	if !h.CanOwnBatches(c) {
+		writeOpenAIError(c, http.StatusForbidden, "batches require a client API key listed in api-keys")
+		return
+	}
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	endpoint := gjson.GetBytes(rawJSON, "endpoint").String()
+	handlerType, ok := batchEndpoints[endpoint]
+	if !ok {
+		writeOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("endpoint %q is not supported", endpoint))
+		return
+	}
+	window := gjson.GetBytes(rawJSON, "completion_window").String()
+	if window != "24h" {
+		writeOpenAIError(c, http.StatusBadRequest, "completion_window must be \"24h\"")
+		return
+	}
+	engine := batch.Default()
+	owner := handlers.BatchOwner(c)
+	inputFileID := gjson.GetBytes(rawJSON, "input_file_id").String()
+	file, err := engine.File(inputFileID, owner)
+	if err == nil && file.Purpose != batch.PurposeBatch {
+		err = fmt.Errorf("%w: input file %s does not have purpose %q", batch.ErrInvalid, inputFileID, batch.PurposeBatch)
+	}
+	var content []byte
+	if err == nil {
+		content, err = engine.FileContent(inputFileID, owner)
+	}
+	if err != nil {
+		writeBatchEngineError(c, err)
+		return
+	}
+	requests, err := parseBatchInput(content, endpoint, handlerType)
+	if err != nil {
+		writeOpenAIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var metadata []byte
+	if value := gjson.GetBytes(rawJSON, "metadata"); value.IsObject() {
+		metadata = []byte(value.Raw)
+	}
+	job, err := engine.Create(batch.KindOpenAI, owner, requests, batch.Options{
+		Endpoint:         endpoint,
+		InputFileID:      inputFileID,
+		CompletionWindow: window,
+		Metadata:         metadata,
+	})
+	if err != nil {
+		writeBatchEngineError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, batchObject(job))
+}
+
+// GetBatch handles GET /v1/batches/{id}.
+func (h *OpenAIAPIHandler) GetBatch(c *gin.Context) {
+	job, err := batch.Default().Get(batch.KindOpenAI, c.Param("id"), handlers.BatchOwner(c))
+	if err != nil {
+		writeBatchEngineError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, batchObject(job))
+}
+
+// ListBatches handles GET /v1/batches, newest first, paginated with limit and after.
+func (h *OpenAIAPIHandler) ListBatches(c *gin.Context) {
+	limit := defaultBatchListLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxBatchListLimit {
+			writeOpenAIError(c, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxBatchListLimit))
+			return
+		}
+		limit = parsed
+	}
+	jobs := batch.Default().List(batch.KindOpenAI, handlers.BatchOwner(c))
+	if after := c.Query("after"); after != "" {
+		for i, job := range jobs {
+			if job.ID == after {
+				jobs = jobs[i+1:]
+				break
+			}
+		}
+	}
+	hasMore := len(jobs) > limit
+	if hasMore {
+		jobs = jobs[:limit]
+	}
+	data := make([]gin.H, 0, len(jobs))
+	for _, job := range jobs {
+		data = append(data, batchObject(job))
+	}
+	var firstID, lastID any
+	if len(jobs) > 0 {
+		firstID, lastID = jobs[0].ID, jobs[len(jobs)-1].ID
+	}
+	c.JSON(http.StatusOK, gin.H{"object": "list", "data": data, "first_id": firstID, "last_id": lastID, "has_more": hasMore})
+}
+
+// CancelBatch handles POST /v1/batches/{id}/cancel.
+func (h *OpenAIAPIHandler) CancelBatch(c *gin.Context) {
+	job, err := batch.Default().Cancel(batch.KindOpenAI, c.Param("id"), handlers.BatchOwner(c))
+	if err != nil {
+		writeBatchEngineError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, batchObject(job))
+}
+
+// parseBatchInput reads the JSON Lines input file of a batch. Every line must target the
+// batch endpoint with a POST request and a unique custom_id.
+func parseBatchInput(content []byte, endpoint, handlerType string) ([]batch.Request, error) {
+	var requests []batch.Request
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !gjson.Valid(line) {
+			return nil, fmt.Errorf("line %d: invalid JSON", i+1)
+		}
+		item := gjson.Parse(line)
+		customID := item.Get("custom_id").String()
+		if customID == "" {
+			return nil, fmt.Errorf("line %d: custom_id is required", i+1)
+		}
+		if method := item.Get("method").String(); !strings.EqualFold(method, http.MethodPost) {
+			return nil, fmt.Errorf("line %d: method must be POST", i+1)
+		}
+		if url := item.Get("url").String(); url != endpoint {
+			return nil, fmt.Errorf("line %d: url %q does not match the batch endpoint %s", i+1, url, endpoint)
+		}
+		body := item.Get("body")
+		model := body.Get("model").String()
+		if !body.IsObject() || model == "" {
+			return nil, fmt.Errorf("line %d: body.model is required", i+1)
+		}
+		payload, _ := sjson.Delete(body.Raw, "stream")
+		payload, _ = sjson.Delete(payload, "stream_options")
+		requests = append(requests, batch.Request{CustomID: customID, HandlerType: handlerType, Model: model, Body: []byte(payload)})
+	}
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("input file contains no requests")
+	}
+	return requests, nil
+}
+
+// batchObject renders a job in the OpenAI Batch API shape.
+func batchObject(job *batch.Job) gin.H {
+	status := "in_progress"
+	var completedAt, cancelledAt, expiredAt, cancellingAt any
+	if job.CancelRequestedAt != nil {
+		cancellingAt = job.CancelRequestedAt.Unix()
+	}
+	switch job.Status {
+	case batch.StatusCanceling:
+		status = "cancelling"
+	case batch.StatusEnded:
+		ended := job.EndedAt.Unix()
+		switch {
+		case job.CancelRequestedAt != nil:
+			status, cancelledAt = "cancelled", ended
+		case job.Expired():
+			status, expiredAt = "expired", ended
+		default:
+			status, completedAt = "completed", ended
+		}
+	}
+	var inProgressAt any
+	if job.StartedAt != nil {
+		inProgressAt = job.StartedAt.Unix()
+	}
+	var metadata any
+	if len(job.Metadata) > 0 {
+		metadata = job.Metadata
+	}
+	return gin.H{
+		"id":                job.ID,
+		"object":            "batch",
+		"endpoint":          job.Endpoint,
+		"errors":            nil,
+		"input_file_id":     job.InputFileID,
+		"completion_window": job.CompletionWindow,
+		"status":            status,
+		"output_file_id":    nullableString(job.OutputFileID),
+		"error_file_id":     nullableString(job.ErrorFileID),
+		"created_at":        job.CreatedAt.Unix(),
+		"in_progress_at":    inProgressAt,
+		"expires_at":        job.ExpiresAt.Unix(),
+		"finalizing_at":     nil,
+		"completed_at":      completedAt,
+		"failed_at":         nil,
+		"expired_at":        expiredAt,
+		"cancelling_at":     cancellingAt,
+		"cancelled_at":      cancelledAt,
+		"request_counts": gin.H{
+			"total":     job.Total,
+			"completed": job.Counts.Succeeded,
+			"failed":    job.Counts.Errored + job.Counts.Canceled + job.Counts.Expired,
+		},
+		"metadata": metadata,
+	}
+}
+
+// fileObject renders a stored file in the OpenAI Files API shape.
+func fileObject(file *batch.File) gin.H {
+	return gin.H{
+		"id":         file.ID,
+		"object":     "file",
+		"bytes":      file.Bytes,
+		"created_at": file.CreatedAt,
+		"expires_at": nil,
+		"filename":   file.Filename,
+		"purpose":    file.Purpose,
+		"status":     "processed",
+	}
+}
+
+func nullableString(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+func writeBatchEngineError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, batch.ErrNotFound):
+		writeOpenAIError(c, http.StatusNotFound, "No such object")
+	case errors.Is(err, batch.ErrDisabled):
+		writeOpenAIError(c, http.StatusServiceUnavailable, err.Error())
+	case errors.Is(err, batch.ErrInvalid), errors.Is(err, batch.ErrNotEnded):
+		writeOpenAIError(c, http.StatusBadRequest, err.Error())
+	default:
+		writeOpenAIError(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func writeOpenAIError(c *gin.Context, status int, message string) {
+	errType := "invalid_request_error"
+	if status >= http.StatusInternalServerError {
+		errType = "server_error"
+	}
+	c.JSON(status, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    errType,
+		},
+	})
+}
@@ -0,0 +1,356 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// maxImagesPerRequest mirrors the OpenAI limit on n for image requests.
+	maxImagesPerRequest = 10
+	// maxImageUploadBytes bounds the multipart body accepted by /v1/images/edits.
+	maxImageUploadBytes = 50 << 20
+)
+
+// geminiAspectRatios lists the aspect ratios accepted by Gemini image models.
+var geminiAspectRatios = []string{"1:1", "2:3", "3:2", "3:4", "4:3", "4:5", "5:4", "9:16", "16:9", "21:9"}
+
+// imageRequest is an OpenAI image generation or edit request normalised from JSON or
+// multipart form input.
+type imageRequest struct {
+	Model          string
+	Prompt         string
+	N              int
+	Size           string
+	ResponseFormat string
+	// Images holds the input images of an edit request as data URLs.
+	Images []string
+	// Mask is the optional edit mask as a data URL.
+	Mask string
+}
+
+// ImageGenerations handles the /v1/images/generations endpoint.
+// The request is bridged to an image-capable Gemini model through a chat completion with
+// image output, so it is served by the Gemini, Vertex and AI Studio executors with the usual
+// credential rotation.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) ImageGenerations(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeImageRequestError(c, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	req := imageRequestFromJSON(rawJSON)
+	h.handleImageRequest(c, req)
+}
+
+// ImageEdits handles the /v1/images/edits endpoint. Input images are accepted as multipart
+// uploads (image or image[] fields) or, for JSON bodies, as data URLs in image.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) ImageEdits(c *gin.Context) {
+	var req imageRequest
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		parsed, err := imageRequestFromMultipart(c)
+		if err != nil {
+			writeImageRequestError(c, fmt.Sprintf("Invalid request: %v", err))
+			return
+		}
+		req = parsed
+	} else {
+		rawJSON, err := c.GetRawData()
+		if err != nil {
+			writeImageRequestError(c, fmt.Sprintf("Invalid request: %v", err))
+			return
+		}
+		req = imageRequestFromJSON(rawJSON)
+	}
+	if len(req.Images) == 0 {
+		writeImageRequestError(c, "Invalid request: image is required")
+		return
+	}
+	h.handleImageRequest(c, req)
+}
+
+// handleImageRequest runs one upstream generation per requested image and writes the
+// combined OpenAI images response.
+func (h *OpenAIAPIHandler) handleImageRequest(c *gin.Context, req imageRequest) {
+	if req.Model == "" || strings.TrimSpace(req.Prompt) == "" {
+		writeImageRequestError(c, "Invalid request: model and prompt are required")
+		return
+	}
+	if req.N < 1 {
+		req.N = 1
+	}
+	if req.N > maxImagesPerRequest {
+		writeImageRequestError(c, fmt.Sprintf("Invalid request: n must be at most %d", maxImagesPerRequest))
+		return
+	}
+	chatJSON := convertImageRequestToChatCompletions(req)
+
+	c.Header("Content-Type", "application/json")
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	responses := make([][]byte, req.N)
+	errs := make([]*interfaces.ErrorMessage, req.N)
+	var wg sync.WaitGroup
+	for i := 0; i < req.N; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i], errs[i] = h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), req.Model, chatJSON, "")
+		}(i)
+	}
+	wg.Wait()
+
+	// Partial results are returned as long as at least one image was generated.
+	out, errMsg := convertChatCompletionsToImagesResponse(responses, errs, req.ResponseFormat)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	_, _ = c.Writer.Write(out)
+	cliCancel()
+}
+
+func imageRequestFromJSON(rawJSON []byte) imageRequest {
+	root := gjson.ParseBytes(rawJSON)
+	req := imageRequest{
+		Model:          root.Get("model").String(),
+		Prompt:         root.Get("prompt").String(),
+		N:              int(root.Get("n").Int()),
+		Size:           root.Get("size").String(),
+		ResponseFormat: root.Get("response_format").String(),
+		Mask:           root.Get("mask").String(),
+	}
+	image := root.Get("image")
+	if image.IsArray() {
+		for _, item := range image.Array() {
+			req.Images = append(req.Images, item.String())
+		}
+	} else if image.String() != "" {
+		req.Images = append(req.Images, image.String())
+	}
+	return req
+}
+
+func imageRequestFromMultipart(c *gin.Context) (imageRequest, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImageUploadBytes)
+	form, err := c.MultipartForm()
+	if err != nil {
+		return imageRequest{}, err
+	}
+	value := func(key string) string {
+		if values := form.Value[key]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	n, _ := strconv.Atoi(value("n"))
+	req := imageRequest{
+		Model:          value("model"),
+		Prompt:         value("prompt"),
+		N:              n,
+		Size:           value("size"),
+		ResponseFormat: value("response_format"),
+	}
+	for _, key := range []string{"image", "image[]"} {
+		for _, file := range form.File[key] {
+			dataURL, errRead := fileDataURL(file)
+			if errRead != nil {
+				return imageRequest{}, errRead
+			}
+			req.Images = append(req.Images, dataURL)
+		}
+	}
+	if masks := form.File["mask"]; len(masks) > 0 {
+		if req.Mask, err = fileDataURL(masks[0]); err != nil {
+			return imageRequest{}, err
+		}
+	}
+	return req, nil
+}
+
+// fileDataURL reads an uploaded image into a base64 data URL.
+func fileDataURL(header *multipart.FileHeader) (string, error) {
+	file, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = file.Close() }()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	mimeType := header.Header.Get("Content-Type")
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		mimeType = http.DetectContentType(data)
+	}
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// convertImageRequestToChatCompletions builds a chat completion that asks for image output,
+// mapping size onto the closest Gemini aspect ratio and, for large sizes, an image size tier.
+// Gemini has no inpainting masks, so a mask is sent as an extra image with an instruction.
+//
+// Parameters:
+//   - req: The normalised image request
+//
+// Returns:
+//   - []byte: The chat completions request
+func convertImageRequestToChatCompletions(req imageRequest) []byte {
+	out := `{"model":"","modalities":["image"],"messages":[{"role":"user","content":[]}]}`
+	out, _ = sjson.Set(out, "model", req.Model)
+	for _, image := range req.Images {
+		part := `{"type":"image_url","image_url":{"url":""}}`
+		part, _ = sjson.Set(part, "image_url.url", image)
+		out, _ = sjson.SetRaw(out, "messages.0.content.-1", part)
+	}
+	prompt := req.Prompt
+	if req.Mask != "" {
+		part := `{"type":"image_url","image_url":{"url":""}}`
+		part, _ = sjson.Set(part, "image_url.url", req.Mask)
+		out, _ = sjson.SetRaw(out, "messages.0.content.-1", part)
+		prompt += "\n\nThe last image is a mask: only change the areas where the mask is transparent and keep everything else unchanged."
+	}
+	textPart, _ := sjson.Set(`{"type":"text","text":""}`, "text", prompt)
+	out, _ = sjson.SetRaw(out, "messages.0.content.-1", textPart)
+
+	if width, height, ok := parseImageSize(req.Size); ok {
+		out, _ = sjson.Set(out, "image_config.aspect_ratio", closestAspectRatio(width, height))
+		switch longest := max(width, height); {
+		case longest >= 4096:
+			out, _ = sjson.Set(out, "image_config.image_size", "4K")
+		case longest >= 2048:
+			out, _ = sjson.Set(out, "image_config.image_size", "2K")
+		}
+	}
+	return []byte(out)
+}
+
+// parseImageSize parses an OpenAI size such as "1024x1536". "auto" and empty sizes are not parsed.
+func parseImageSize(size string) (width, height int, ok bool) {
+	w, hgt, found := strings.Cut(strings.ToLower(strings.TrimSpace(size)), "x")
+	if !found {
+		return 0, 0, false
+	}
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(hgt)
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return 0, 0, false
+	}
+	return width, height, true
+}
+
+// closestAspectRatio picks the supported Gemini aspect ratio nearest to width:height.
+func closestAspectRatio(width, height int) string {
+	target := math.Log(float64(width) / float64(height))
+	best, bestDistance := "1:1", math.Inf(1)
+	for _, ratio := range geminiAspectRatios {
+		w, hgt, _ := strings.Cut(ratio, ":")
+		rw, _ := strconv.ParseFloat(w, 64)
+		rh, _ := strconv.ParseFloat(hgt, 64)
+		if distance := math.Abs(math.Log(rw/rh) - target); distance < bestDistance {
+			best, bestDistance = ratio, distance
+		}
+	}
+	return best
+}
+
+// convertChatCompletionsToImagesResponse collects the images of the chat completion responses
+// into an OpenAI images response. Images are returned as b64_json unless response_format is
+// "url", in which case a data URL is returned since the proxy does not host files.
+//
+// Parameters:
+//   - responses: The chat completion responses, one per requested image
+//   - errs: The error of each upstream call, nil on success
+//   - responseFormat: The requested response_format
+//
+// Returns:
+//   - []byte: The images response
+//   - *interfaces.ErrorMessage: The first error when no image was produced
+func convertChatCompletionsToImagesResponse(responses [][]byte, errs []*interfaces.ErrorMessage, responseFormat string) ([]byte, *interfaces.ErrorMessage) {
+	out := `{"created":0,"data":[],"usage":{"input_tokens":0,"output_tokens":0,"total_tokens":0}}`
+	out, _ = sjson.Set(out, "created", time.Now().Unix())
+	var inputTokens, outputTokens int64
+	var firstErr *interfaces.ErrorMessage
+	var refusal string
+	for i, resp := range responses {
+		if errs[i] != nil {
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+			continue
+		}
+		root := gjson.ParseBytes(resp)
+		inputTokens += root.Get("usage.prompt_tokens").Int()
+		outputTokens += root.Get("usage.completion_tokens").Int()
+		root.Get("choices").ForEach(func(_, choice gjson.Result) bool {
+			text := choice.Get("message.content").String()
+			images := choice.Get("message.images").Array()
+			if len(images) == 0 && text != "" {
+				refusal = text
+			}
+			for _, image := range images {
+				dataURL := image.Get("image_url.url").String()
+				_, data, found := strings.Cut(dataURL, ";base64,")
+				if !found {
+					continue
+				}
+				entry := `{}`
+				if responseFormat == "url" {
+					entry, _ = sjson.Set(entry, "url", dataURL)
+				} else {
+					entry, _ = sjson.Set(entry, "b64_json", data)
+				}
+				if text != "" {
+					entry, _ = sjson.Set(entry, "revised_prompt", text)
+				}
+				out, _ = sjson.SetRaw(out, "data.-1", entry)
+			}
+			return true
+		})
+	}
+	if gjson.Get(out, "data.#").Int() == 0 {
+		if firstErr != nil {
+			return nil, firstErr
+		}
+		message := "upstream returned no image"
+		if refusal != "" {
+			message += ": " + refusal
+		}
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: errors.New(message)}
+	}
+	out, _ = sjson.Set(out, "usage.input_tokens", inputTokens)
+	out, _ = sjson.Set(out, "usage.output_tokens", outputTokens)
+	out, _ = sjson.Set(out, "usage.total_tokens", inputTokens+outputTokens)
+	return []byte(out), nil
+}
+
+func writeImageRequestError(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+		},
+	})
+}
@@ -0,0 +1,72 @@
+package openai
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertImageRequestToChatCompletions(t *testing.T) {
+	out := convertImageRequestToChatCompletions(imageRequest{
+		Model:  "gemini-3-pro-image-preview",
+		Prompt: "a lighthouse",
+		Size:   "1792x1024",
+		Images: []string{"data:image/png;base64,AAAA"},
+	})
+
+	if got := gjson.GetBytes(out, "modalities").Raw; got != `["image"]` {
+		t.Fatalf("modalities = %s", got)
+	}
+	if got := gjson.GetBytes(out, "image_config.aspect_ratio").String(); got != "16:9" {
+		t.Fatalf("aspect_ratio = %q, want 16:9", got)
+	}
+	if gjson.GetBytes(out, "image_config.image_size").Exists() {
+		t.Fatalf("1792px should keep the default image size")
+	}
+	content := gjson.GetBytes(out, "messages.0.content").Array()
+	if len(content) != 2 || content[0].Get("type").String() != "image_url" || content[1].Get("text").String() != "a lighthouse" {
+		t.Fatalf("content = %s", gjson.GetBytes(out, "messages.0.content").Raw)
+	}
+
+	large := convertImageRequestToChatCompletions(imageRequest{Model: "m", Prompt: "p", Size: "2048x2048"})
+	if gjson.GetBytes(large, "image_config.aspect_ratio").String() != "1:1" || gjson.GetBytes(large, "image_config.image_size").String() != "2K" {
+		t.Fatalf("image_config = %s", gjson.GetBytes(large, "image_config").Raw)
+	}
+	if auto := convertImageRequestToChatCompletions(imageRequest{Model: "m", Prompt: "p", Size: "auto"}); gjson.GetBytes(auto, "image_config").Exists() {
+		t.Fatalf("auto size should not set image_config")
+	}
+}
+
+func TestConvertChatCompletionsToImagesResponse(t *testing.T) {
+	completion := []byte(`{"choices":[{"message":{"content":"","images":[{"type":"image_url","image_url":{"url":"data:image/png;base64,QUJD"}}]}}],"usage":{"prompt_tokens":5,"completion_tokens":1290}}`)
+	failure := &interfaces.ErrorMessage{StatusCode: http.StatusTooManyRequests}
+
+	out, errMsg := convertChatCompletionsToImagesResponse([][]byte{completion, nil}, []*interfaces.ErrorMessage{nil, failure}, "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if got := gjson.GetBytes(out, "data.#").Int(); got != 1 {
+		t.Fatalf("data = %s, want the successful image only", gjson.GetBytes(out, "data").Raw)
+	}
+	if got := gjson.GetBytes(out, "data.0.b64_json").String(); got != "QUJD" {
+		t.Fatalf("b64_json = %q", got)
+	}
+	if got := gjson.GetBytes(out, "usage.total_tokens").Int(); got != 1295 {
+		t.Fatalf("total_tokens = %d", got)
+	}
+
+	out, _ = convertChatCompletionsToImagesResponse([][]byte{completion}, []*interfaces.ErrorMessage{nil}, "url")
+	if got := gjson.GetBytes(out, "data.0.url").String(); got != "data:image/png;base64,QUJD" {
+		t.Fatalf("url = %q", got)
+	}
+
+	refusal := []byte(`{"choices":[{"message":{"content":"I can't draw that."}}]}`)
+	if _, errMsg = convertChatCompletionsToImagesResponse([][]byte{refusal}, []*interfaces.ErrorMessage{nil}, ""); errMsg == nil || errMsg.StatusCode != http.StatusBadGateway {
+		t.Fatalf("text-only response should fail with 502, got %+v", errMsg)
+	}
+	if _, errMsg = convertChatCompletionsToImagesResponse([][]byte{nil}, []*interfaces.ErrorMessage{failure}, ""); errMsg != failure {
+		t.Fatalf("all-failed request should return the upstream error")
+	}
+}
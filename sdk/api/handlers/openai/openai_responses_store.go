@@ -0,0 +1,166 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/responsestore"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	defaultInputItemsLimit = 20
+	maxInputItemsLimit     = 100
+)
+
+// responseStore returns the process-wide response store configured from the current config.
+func (h *OpenAIResponsesAPIHandler) responseStore() *responsestore.Store {
+	store := responsestore.Default()
+	if h.Cfg != nil {
+		store.Apply(h.Cfg.ResponseStore)
+	}
+	return store
+}
+
+// storedResponse loads the response named by the :id path parameter for the calling client
+// and writes a 404 error when it is unavailable.
+func (h *OpenAIResponsesAPIHandler) storedResponse(c *gin.Context) (*responsestore.Entry, bool) {
+	id := c.Param("id")
+	entry, ok := h.responseStore().Get(id, responsestore.Owner(handlers.ClientAPIKey(c)))
+	if !ok {
+		c.JSON(http.StatusNotFound, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Response with id '%s' not found.", id),
+				Type:    "invalid_request_error",
+			},
+		})
+		return nil, false
+	}
+	return entry, true
+}
+
+// GetResponse handles GET /v1/responses/{id} and returns a stored response.
+func (h *OpenAIResponsesAPIHandler) GetResponse(c *gin.Context) {
+	entry, ok := h.storedResponse(c)
+	if !ok {
+		return
+	}
+	c.Data(http.StatusOK, "application/json", entry.Response)
+}
+
+// DeleteResponse handles DELETE /v1/responses/{id}. Responses that continued the deleted
+// one can no longer be expanded past it.
+func (h *OpenAIResponsesAPIHandler) DeleteResponse(c *gin.Context) {
+	entry, ok := h.storedResponse(c)
+	if !ok {
+		return
+	}
+	h.responseStore().Delete(entry.ID, entry.Owner)
+	c.JSON(http.StatusOK, gin.H{"id": entry.ID, "object": "response.deleted", "deleted": true})
+}
+
+// ResponseInputItems handles GET /v1/responses/{id}/input_items and lists the input items
+// submitted with a stored response. It supports the limit, order and after query parameters.
+func (h *OpenAIResponsesAPIHandler) ResponseInputItems(c *gin.Context) {
+	entry, ok := h.storedResponse(c)
+	if !ok {
+		return
+	}
+	limit := defaultInputItemsLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxInputItemsLimit {
+			c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+				Error: handlers.ErrorDetail{
+					Message: fmt.Sprintf("Invalid limit: must be between 1 and %d", maxInputItemsLimit),
+					Type:    "invalid_request_error",
+				},
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	items := gjson.ParseBytes(entry.Input).Array()
+	if c.DefaultQuery("order", "desc") != "asc" {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	if after := c.Query("after"); after != "" {
+		for i, item := range items {
+			if item.Get("id").String() == after {
+				items = items[i+1:]
+				break
+			}
+		}
+	}
+	hasMore := len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+
+	var data bytes.Buffer
+	data.WriteByte('[')
+	for i, item := range items {
+		if i > 0 {
+			data.WriteByte(',')
+		}
+		data.WriteString(item.Raw)
+	}
+	data.WriteByte(']')
+	var firstID, lastID any
+	if len(items) > 0 {
+		firstID = items[0].Get("id").String()
+		lastID = items[len(items)-1].Get("id").String()
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"object":   "list",
+		"data":     json.RawMessage(data.Bytes()),
+		"first_id": firstID,
+		"last_id":  lastID,
+		"has_more": hasMore,
+	})
+}
+
+// writePreviousResponseError reports a previous_response_id that could not be resolved.
+func writePreviousResponseError(c *gin.Context, previousID string, err error) {
+	status, code := http.StatusBadRequest, ""
+	message := err.Error()
+	if errors.Is(err, responsestore.ErrNotFound) {
+		status, code = http.StatusNotFound, "previous_response_not_found"
+		message = fmt.Sprintf("Previous response with id '%s' not found.", previousID)
+	}
+	c.JSON(status, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+			Code:    code,
+		},
+	})
+}
+
+// completedResponse returns the response object carried by a response.completed stream
+// event, or nil when chunk holds another event.
+func completedResponse(chunk []byte) []byte {
+	for _, line := range bytes.Split(chunk, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		data := bytes.TrimSpace(line[len("data:"):])
+		if gjson.GetBytes(data, "type").String() != "response.completed" {
+			continue
+		}
+		if resp := gjson.GetBytes(data, "response"); resp.IsObject() {
+			return []byte(resp.Raw)
+		}
+	}
+	return nil
+}
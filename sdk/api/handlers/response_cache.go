@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/responsecache"
+	"golang.org/x/net/context"
+)
+
+// maxRecordedStreamBytes bounds how much of a streaming response is buffered for the cache.
+// Longer streams are forwarded normally but not stored.
+const maxRecordedStreamBytes = 8 << 20
+
+// cacheLookup is the outcome of consulting the response cache for one request.
+type cacheLookup struct {
+	cache *responsecache.Cache
+	key   string
+	// entry is set on a cache hit.
+	entry *responsecache.Entry
+	// store reports whether a fresh response should be written back.
+	store bool
+}
+
+// lookupResponseCache consults the response cache for a request and reports the outcome to
+// the client through the X-CLIProxy-Cache response header. It returns nil when the request
+// does not take part in caching.
+func (h *BaseAPIHandler) lookupResponseCache(ctx context.Context, handlerType, modelName, alt string, rawJSON []byte, stream bool) *cacheLookup {
+	if h.Cfg == nil {
+		return nil
+	}
+	cache := responsecache.Default()
+	cache.Apply(h.Cfg.ResponseCache)
+	if !cache.Enabled() {
+		return nil
+	}
+
+	var ginCtx *gin.Context
+	if ctx != nil {
+		ginCtx, _ = ctx.Value("gin").(*gin.Context)
+	}
+	directive := ""
+	if ginCtx != nil && ginCtx.Request != nil {
+		directive = responsecache.ParseDirective(ginCtx.GetHeader(responsecache.Header))
+	}
+	apiKey := clientAPIKeyFromGin(ginCtx)
+	policy := h.Cfg.APIKeyPolicy(apiKey)
+	if directive == responsecache.DirectiveBypass || (policy != nil && policy.DisableResponseCache) || !cache.Cacheable(rawJSON) {
+		setCacheStatus(ginCtx, responsecache.StatusBypass)
+		return nil
+	}
+	key, ok := cache.Key(handlerType, modelName, alt, apiKey, rawJSON)
+	if !ok {
+		setCacheStatus(ginCtx, responsecache.StatusBypass)
+		return nil
+	}
+
+	lookup := &cacheLookup{cache: cache, key: key, store: directive != responsecache.DirectiveNoStore}
+	if directive != responsecache.DirectiveRefresh {
+		if entry, hit := cache.Lookup(key, stream); hit {
+			lookup.entry = entry
+			lookup.store = false
+			setCacheStatus(ginCtx, responsecache.StatusHit)
+			return lookup
+		}
+	}
+	setCacheStatus(ginCtx, responsecache.StatusMiss)
+	return lookup
+}
+
+func setCacheStatus(c *gin.Context, status string) {
+	if c != nil {
+		c.Header(responsecache.Header, status)
+	}
+}
+
+// hit reports whether the lookup found a usable entry.
+func (l *cacheLookup) hit() bool { return l != nil && l.entry != nil }
+
+// storeBody writes a complete non-streaming response back to the cache.
+func (l *cacheLookup) storeBody(handlerType, model string, body []byte) {
+	if l == nil || !l.store || len(body) == 0 {
+		return
+	}
+	l.cache.Put(l.key, &responsecache.Entry{Body: cloneBytes(body), HandlerType: handlerType, Model: model})
+}
+
+// streamRecorder buffers the chunks of a streaming response so a completed stream can be
+// stored. It is nil-safe so callers need not check whether caching applies.
+type streamRecorder struct {
+	lookup      *cacheLookup
+	handlerType string
+	model       string
+	chunks      [][]byte
+	size        int
+	overflow    bool
+}
+
+func (l *cacheLookup) recorder(handlerType, model string) *streamRecorder {
+	if l == nil || !l.store {
+		return nil
+	}
+	return &streamRecorder{lookup: l, handlerType: handlerType, model: model}
+}
+
+func (r *streamRecorder) add(chunk []byte) {
+	if r == nil || r.overflow {
+		return
+	}
+	r.size += len(chunk)
+	if r.size > maxRecordedStreamBytes {
+		r.overflow = true
+		r.chunks = nil
+		return
+	}
+	r.chunks = append(r.chunks, cloneBytes(chunk))
+}
+
+// commit stores the recorded stream once it completed without error.
+func (r *streamRecorder) commit() {
+	if r == nil || r.overflow || len(r.chunks) == 0 {
+		return
+	}
+	r.lookup.cache.Put(r.lookup.key, &responsecache.Entry{Chunks: r.chunks, HandlerType: r.handlerType, Model: r.model})
+}
+
+// replayCachedStream delivers cached chunks through the same channels a live stream uses.
+func replayCachedStream(ctx context.Context, chunks [][]byte, release func()) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	dataChan := make(chan []byte)
+	errChan := make(chan *interfaces.ErrorMessage, 1)
+	go func() {
+		defer release()
+		defer close(dataChan)
+		defer close(errChan)
+		for _, chunk := range chunks {
+			select {
+			case <-ctx.Done():
+				return
+			case dataChan <- cloneBytes(chunk):
+			}
+		}
+	}()
+	return dataChan, errChan
+}
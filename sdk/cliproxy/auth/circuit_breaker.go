@@ -0,0 +1,283 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultCircuitFailureThreshold = 5
+	defaultCircuitOpenDuration     = 30 * time.Second
+	defaultCircuitHalfOpenProbes   = 1
+)
+
+// CircuitState is the state of an upstream circuit.
+type CircuitState string
+
+const (
+	// CircuitClosed lets every request through.
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen rejects every request until the open period ends.
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a limited number of probe requests through.
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// CircuitStatus is a snapshot of one upstream circuit.
+type CircuitStatus struct {
+	Provider            string       `json:"provider"`
+	BaseURL             string       `json:"base_url,omitempty"`
+	State               CircuitState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	OpenedAt            time.Time    `json:"opened_at,omitempty"`
+	RetryAt             time.Time    `json:"retry_at,omitempty"`
+	ProbesInFlight      int          `json:"probes_in_flight,omitempty"`
+	LastError           string       `json:"last_error,omitempty"`
+}
+
+type circuitKey struct {
+	provider string
+	baseURL  string
+}
+
+// circuitKeyFor returns the upstream an auth sends its requests to.
+func circuitKeyFor(auth *Auth) circuitKey {
+	if auth == nil {
+		return circuitKey{}
+	}
+	key := circuitKey{provider: strings.ToLower(strings.TrimSpace(auth.Provider))}
+	if auth.Attributes != nil {
+		key.baseURL = strings.TrimRight(strings.TrimSpace(auth.Attributes["base_url"]), "/")
+	}
+	return key
+}
+
+type circuit struct {
+	state     CircuitState
+	failures  int
+	openedAt  time.Time
+	retryAt   time.Time
+	lastError string
+	// probes holds the expiry of each probe admitted while half-open. Probes whose result is
+	// never reported (for example a cancelled hedge) free their slot once they expire.
+	probes []time.Time
+}
+
+// circuitBreakers tracks one circuit per upstream. The zero value is disabled.
+type circuitBreakers struct {
+	mu        sync.Mutex
+	enabled   bool
+	threshold int
+	open      time.Duration
+	maxProbes int
+	circuits  map[circuitKey]*circuit
+}
+
+// SetCircuitBreakerConfig updates the circuit breaker settings. Disabling the breaker closes
+// every circuit.
+func (m *Manager) SetCircuitBreakerConfig(cfg internalconfig.CircuitBreakerConfig) {
+	if m == nil {
+		return
+	}
+	b := &m.circuits
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.enabled = cfg.Enable
+	b.threshold = cfg.FailureThreshold
+	if b.threshold <= 0 {
+		b.threshold = defaultCircuitFailureThreshold
+	}
+	b.open = time.Duration(cfg.OpenSeconds) * time.Second
+	if b.open <= 0 {
+		b.open = defaultCircuitOpenDuration
+	}
+	b.maxProbes = cfg.HalfOpenProbes
+	if b.maxProbes <= 0 {
+		b.maxProbes = defaultCircuitHalfOpenProbes
+	}
+	if !b.enabled {
+		b.circuits = nil
+	}
+}
+
+// CircuitBreakers returns the state of every tracked upstream circuit.
+func (m *Manager) CircuitBreakers() []CircuitStatus {
+	if m == nil {
+		return nil
+	}
+	b := &m.circuits
+	now := time.Now()
+	b.mu.Lock()
+	out := make([]CircuitStatus, 0, len(b.circuits))
+	for key, c := range b.circuits {
+		b.advance(c, now)
+		out = append(out, CircuitStatus{
+			Provider:            key.provider,
+			BaseURL:             key.baseURL,
+			State:               c.state,
+			ConsecutiveFailures: c.failures,
+			OpenedAt:            c.openedAt,
+			RetryAt:             c.retryAt,
+			ProbesInFlight:      len(c.probes),
+			LastError:           c.lastError,
+		})
+	}
+	b.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		return out[i].BaseURL < out[j].BaseURL
+	})
+	return out
+}
+
+// ResetCircuitBreakers closes the circuits matching provider and baseURL; empty values match
+// any upstream. It returns the number of circuits reset.
+func (m *Manager) ResetCircuitBreakers(provider, baseURL string) int {
+	if m == nil {
+		return 0
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	b := &m.circuits
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	reset := 0
+	for key := range b.circuits {
+		if (provider == "" || key.provider == provider) && (baseURL == "" || key.baseURL == baseURL) {
+			delete(b.circuits, key)
+			reset++
+		}
+	}
+	return reset
+}
+
+// advance moves an open circuit whose open period has ended to half-open and drops expired
+// probes. Callers hold b.mu.
+func (b *circuitBreakers) advance(c *circuit, now time.Time) {
+	if c.state == CircuitOpen && !now.Before(c.retryAt) {
+		c.state = CircuitHalfOpen
+		c.probes = nil
+	}
+	if c.state != CircuitHalfOpen || len(c.probes) == 0 {
+		return
+	}
+	live := c.probes[:0]
+	for _, expiry := range c.probes {
+		if now.Before(expiry) {
+			live = append(live, expiry)
+		}
+	}
+	c.probes = live
+}
+
+// admits reports whether a request may be sent to key without reserving a probe slot.
+func (b *circuitBreakers) admits(key circuitKey, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuits[key]
+	if !b.enabled || c == nil {
+		return true
+	}
+	b.advance(c, now)
+	switch c.state {
+	case CircuitOpen:
+		return false
+	case CircuitHalfOpen:
+		return len(c.probes) < b.maxProbes
+	default:
+		return true
+	}
+}
+
+// acquire admits a request to key, reserving a probe slot when the circuit is half-open.
+func (b *circuitBreakers) acquire(key circuitKey, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuits[key]
+	if !b.enabled || c == nil {
+		return true
+	}
+	b.advance(c, now)
+	switch c.state {
+	case CircuitOpen:
+		return false
+	case CircuitHalfOpen:
+		if len(c.probes) >= b.maxProbes {
+			return false
+		}
+		c.probes = append(c.probes, now.Add(b.open))
+		return true
+	default:
+		return true
+	}
+}
+
+// observe feeds an attempt result into the circuit for key. Any upstream response other than
+// a server error or timeout proves the upstream reachable and closes the circuit.
+func (b *circuitBreakers) observe(ctx context.Context, key circuitKey, result Result, now time.Time) {
+	failure := !result.Success && isUpstreamFailure(result.Error)
+	if failure && ctx != nil && ctx.Err() != nil {
+		// The caller went away; the attempt says nothing about the upstream.
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.enabled {
+		return
+	}
+	c := b.circuits[key]
+	if !failure {
+		if c != nil {
+			if c.state != CircuitClosed {
+				log.Infof("circuit breaker: %s closed", key)
+			}
+			delete(b.circuits, key)
+		}
+		return
+	}
+	if c == nil {
+		if b.circuits == nil {
+			b.circuits = make(map[circuitKey]*circuit)
+		}
+		c = &circuit{state: CircuitClosed}
+		b.circuits[key] = c
+	}
+	b.advance(c, now)
+	c.failures++
+	if result.Error != nil {
+		c.lastError = result.Error.Message
+	}
+	if c.state == CircuitHalfOpen || (c.state == CircuitClosed && c.failures >= b.threshold) {
+		c.state = CircuitOpen
+		c.openedAt = now
+		c.retryAt = now.Add(b.open)
+		c.probes = nil
+		log.Warnf("circuit breaker: %s opened after %d consecutive failures, retry at %s", key, c.failures, c.retryAt.Format(time.RFC3339))
+	}
+}
+
+func (k circuitKey) String() string {
+	if k.baseURL == "" {
+		return k.provider
+	}
+	return k.provider + " " + k.baseURL
+}
+
+// isUpstreamFailure reports whether err points at the upstream rather than the credential or
+// the request: transport errors without a status, timeouts and server errors.
+func isUpstreamFailure(err *Error) bool {
+	if err == nil {
+		return true
+	}
+	status := statusCodeFromResult(err)
+	return status == 0 || status == http.StatusRequestTimeout || status >= http.StatusInternalServerError
+}
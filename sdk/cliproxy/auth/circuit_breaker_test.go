@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestCircuitBreakerOpensAndShortCircuitsSelection(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&fallbackTestExecutor{provider: "test"})
+	for _, auth := range []*Auth{
+		{ID: "cb-a", Provider: "test", Attributes: map[string]string{"base_url": "https://down.example/v1/"}},
+		{ID: "cb-b", Provider: "test", Attributes: map[string]string{"base_url": "https://down.example/v1"}},
+		{ID: "cb-c", Provider: "test", Attributes: map[string]string{"base_url": "https://up.example/v1"}},
+	} {
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+	manager.SetCircuitBreakerConfig(internalconfig.CircuitBreakerConfig{Enable: true, FailureThreshold: 2, OpenSeconds: 60})
+
+	fail := Result{Provider: "test", Error: &Error{Message: "bad gateway", HTTPStatus: http.StatusBadGateway}}
+	fail.AuthID = "cb-a"
+	manager.MarkResult(context.Background(), fail)
+	fail.AuthID = "cb-b"
+	manager.MarkResult(context.Background(), fail)
+
+	circuits := manager.CircuitBreakers()
+	if len(circuits) != 1 || circuits[0].State != CircuitOpen || circuits[0].BaseURL != "https://down.example/v1" {
+		t.Fatalf("circuits = %+v, want one open circuit for the failing base URL", circuits)
+	}
+	for i := 0; i < 3; i++ {
+		auth, _, err := manager.pickNext(context.Background(), "test", "", cliproxyexecutor.Options{}, nil)
+		if err != nil {
+			t.Fatalf("pickNext() error = %v", err)
+		}
+		if auth.ID != "cb-c" {
+			t.Fatalf("pickNext() = %s, want only the healthy upstream", auth.ID)
+		}
+	}
+	_, _, err := manager.pickNext(context.Background(), "test", "", cliproxyexecutor.Options{}, map[string]struct{}{"cb-c": {}})
+	var authErr *Error
+	if !errors.As(err, &authErr) || authErr.Code != "circuit_open" {
+		t.Fatalf("pickNext() error = %v, want circuit_open", err)
+	}
+
+	if reset := manager.ResetCircuitBreakers("test", "https://down.example/v1"); reset != 1 {
+		t.Fatalf("ResetCircuitBreakers() = %d, want 1", reset)
+	}
+	if len(manager.CircuitBreakers()) != 0 {
+		t.Fatalf("circuit should be gone after reset")
+	}
+}
+
+func TestCircuitBreakerHalfOpenProbe(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	manager.SetCircuitBreakerConfig(internalconfig.CircuitBreakerConfig{Enable: true, FailureThreshold: 1, OpenSeconds: 10, HalfOpenProbes: 1})
+	b := &manager.circuits
+
+	key := circuitKey{provider: "codex"}
+	now := time.Unix(1_700_000_000, 0)
+	failure := Result{Error: &Error{Message: "timeout"}}
+	b.observe(context.Background(), key, failure, now)
+	if b.admits(key, now.Add(5*time.Second)) {
+		t.Fatalf("open circuit must reject requests")
+	}
+
+	probeAt := now.Add(11 * time.Second)
+	if !b.acquire(key, probeAt) {
+		t.Fatalf("half-open circuit should admit a probe")
+	}
+	if b.acquire(key, probeAt) {
+		t.Fatalf("half-open circuit must admit only one probe")
+	}
+	b.observe(context.Background(), key, failure, probeAt)
+	if b.admits(key, probeAt.Add(time.Second)) {
+		t.Fatalf("failed probe should reopen the circuit")
+	}
+
+	probeAt = probeAt.Add(11 * time.Second)
+	if !b.acquire(key, probeAt) {
+		t.Fatalf("half-open circuit should admit a probe after reopening")
+	}
+	b.observe(context.Background(), key, Result{Success: true}, probeAt)
+	if !b.admits(key, probeAt) || len(b.circuits) != 0 {
+		t.Fatalf("successful probe should close the circuit")
+	}
+
+	// Client errors prove the upstream reachable and do not count as failures.
+	b.observe(context.Background(), key, Result{Error: &Error{HTTPStatus: http.StatusTooManyRequests}}, probeAt)
+	if len(b.circuits) != 0 {
+		t.Fatalf("429 must not be tracked as an upstream failure")
+	}
+}
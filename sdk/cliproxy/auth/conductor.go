@@ -14,9 +14,13 @@ import (
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ProviderExecutor defines the contract required by Manager to execute provider calls.
@@ -67,6 +71,10 @@ type Result struct {
 	RetryAfter *time.Duration
 	// Error describes the failure when Success is false.
 	Error *Error
+	// Latency is the wall time spent on the attempt, including the full stream when streaming.
+	Latency time.Duration
+	// FirstByteLatency is the time until the first stream chunk; zero for non-streaming attempts.
+	FirstByteLatency time.Duration
 }
 
 // Selector chooses an auth candidate for execution.
@@ -96,6 +104,22 @@ func (NoopHook) OnAuthUpdated(context.Context, *Auth) {}
 // OnResult implements Hook.
 func (NoopHook) OnResult(context.Context, Result) {}
 
+// ExecutionHook intercepts individual provider attempts performed by Manager.
+type ExecutionHook interface {
+	// BeforeAttempt runs after an auth has been selected and before the executor is invoked.
+	// Implementations may mutate req and opts and return a derived context; the returned
+	// observer, when non-nil, receives the outcome of the attempt.
+	BeforeAttempt(ctx context.Context, auth *Auth, req *cliproxyexecutor.Request, opts *cliproxyexecutor.Options) (context.Context, ExecutionObserver)
+}
+
+// ExecutionObserver receives the outcome of a single provider attempt.
+type ExecutionObserver interface {
+	// AfterAttempt fires once the attempt completes. For streams it fires after the last chunk.
+	AfterAttempt(ctx context.Context, resp cliproxyexecutor.Response, err error)
+	// OnStreamChunk fires for every chunk emitted by a streaming attempt.
+	OnStreamChunk(ctx context.Context, chunk cliproxyexecutor.StreamChunk)
+}
+
 // Manager orchestrates auth lifecycle, selection, execution, and persistence.
 type Manager struct {
 	store     Store
@@ -110,13 +134,39 @@ type Manager struct {
 	// Retry controls request retry behavior.
 	requestRetry     atomic.Int32
 	maxRetryInterval atomic.Int64
+	// retries counts retry rounds scheduled after a failed attempt.
+	retries atomic.Uint64
 
 	// modelNameMappings stores global model name alias mappings (alias -> upstream name) keyed by channel.
 	modelNameMappings atomic.Value
 
+	// modelFallbacks stores the cross-model fallback chains (*modelFallbackTable).
+	modelFallbacks atomic.Value
+
+	// hedging stores the hedged request settings (*internalconfig.HedgingConfig).
+	hedging atomic.Value
+
+	// circuits tracks the per-upstream circuit breaker state.
+	circuits circuitBreakers
+
+	// cooldownQueue parks opted-in requests while every credential for their model cools down.
+	cooldownQueue cooldownQueue
+
+	// runtimeEvents fans auth state changes and attempt results out to subscribers.
+	runtimeEvents runtimeEventBus
+
+	// controls holds operator drains and per-auth in-flight request counts.
+	controls authControls
+
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
 
+	// Optional per-attempt execution hook injected by host.
+	executionHook ExecutionHook
+
+	// Optional translator pipeline handed to executors through the request context.
+	translatorPipeline *sdktranslator.Pipeline
+
 	// Auto refresh state
 	refreshCancel context.CancelFunc
 }
@@ -151,6 +201,26 @@ func (m *Manager) SetSelector(selector Selector) {
 	m.mu.Unlock()
 }
 
+// Selector returns the active credential selector.
+func (m *Manager) Selector() Selector {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.selector
+}
+
+// SelectorScores returns the health scores tracked by the active selector.
+// The boolean is false when the selector does not report scores.
+func (m *Manager) SelectorScores() ([]AuthScore, bool) {
+	reporter, ok := m.Selector().(ScoreReporter)
+	if !ok || reporter == nil {
+		return nil, false
+	}
+	return reporter.Scores(), true
+}
+
 // SetStore swaps the underlying persistence store.
 func (m *Manager) SetStore(store Store) {
 	m.mu.Lock()
@@ -165,6 +235,26 @@ func (m *Manager) SetRoundTripperProvider(p RoundTripperProvider) {
 	m.mu.Unlock()
 }
 
+// SetExecutionHook registers a hook invoked around every provider attempt.
+func (m *Manager) SetExecutionHook(hook ExecutionHook) {
+	m.mu.Lock()
+	m.executionHook = hook
+	m.mu.Unlock()
+}
+
+// SetTranslatorPipeline sets the translator pipeline executors use for request and response translation.
+func (m *Manager) SetTranslatorPipeline(pipeline *sdktranslator.Pipeline) {
+	m.mu.Lock()
+	m.translatorPipeline = pipeline
+	m.mu.Unlock()
+}
+
+func (m *Manager) translatorPipelineRef() *sdktranslator.Pipeline {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.translatorPipeline
+}
+
 // SetRetryConfig updates retry attempts and cooldown wait interval.
 func (m *Manager) SetRetryConfig(retry int, maxRetryInterval time.Duration) {
 	if m == nil {
@@ -215,6 +305,7 @@ func (m *Manager) Register(ctx context.Context, auth *Auth) (*Auth, error) {
 	m.mu.Unlock()
 	_ = m.persist(ctx, auth)
 	m.hook.OnAuthRegistered(ctx, auth.Clone())
+	m.runtimeEvents.publish(RuntimeEvent{Type: RuntimeEventAuthRegistered, AuthID: auth.ID, Time: time.Now()})
 	return auth.Clone(), nil
 }
 
@@ -225,9 +316,14 @@ func (m *Manager) Update(ctx context.Context, auth *Auth) (*Auth, error) {
 	}
 
 	m.mu.Lock()
-	if existing, ok := m.auths[auth.ID]; ok && existing != nil && !auth.indexAssigned && auth.Index == "" {
-		auth.Index = existing.Index
-		auth.indexAssigned = existing.indexAssigned
+	if existing, ok := m.auths[auth.ID]; ok && existing != nil {
+		if !auth.indexAssigned && auth.Index == "" {
+			auth.Index = existing.Index
+			auth.indexAssigned = existing.indexAssigned
+		}
+		if auth.LastSuccessAt.IsZero() {
+			auth.LastSuccessAt = existing.LastSuccessAt
+		}
 	}
 	auth.EnsureIndex()
 
@@ -255,6 +351,7 @@ func (m *Manager) Update(ctx context.Context, auth *Auth) (*Auth, error) {
 	m.mu.Unlock()
 	_ = m.persist(ctx, auth)
 	m.hook.OnAuthUpdated(ctx, auth.Clone())
+	m.runtimeEvents.publish(RuntimeEvent{Type: RuntimeEventAuthUpdated, AuthID: auth.ID, Time: time.Now()})
 	return auth.Clone(), nil
 }
 
@@ -282,7 +379,12 @@ func (m *Manager) Load(ctx context.Context) error {
 
 // Execute performs a non-streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
+// When every credential for the model is cooling down, configured fallback models are tried in order.
 func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return executeWithFallback(ctx, m, providers, req, opts, m.executeModel)
+}
+
+func (m *Manager) executeModel(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
@@ -297,13 +399,24 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 
 	var lastErr error
 	for attempt := 0; attempt < attempts; attempt++ {
-		resp, errExec := m.executeProvidersOnce(ctx, rotated, func(execCtx context.Context, provider string) (cliproxyexecutor.Response, error) {
-			return m.executeWithProvider(execCtx, provider, req, opts)
-		})
+		var (
+			resp    cliproxyexecutor.Response
+			errExec error
+		)
+		if delay, hedged := m.hedgeDelay(req.Model); hedged {
+			resp, errExec = m.executeHedged(ctx, rotated, delay, req, opts)
+		} else {
+			resp, errExec = m.executeProvidersOnce(ctx, rotated, func(execCtx context.Context, provider string) (cliproxyexecutor.Response, error) {
+				return m.executeWithProvider(execCtx, provider, req, opts)
+			})
+		}
 		if errExec == nil {
 			return resp, nil
 		}
 		lastErr = errExec
+		if m.shouldFallback(errExec, rotated, req.Model) {
+			break
+		}
 		wait, shouldRetry := m.shouldRetryAfterError(errExec, attempt, attempts, rotated, req.Model, maxWait)
 		if !shouldRetry {
 			break
@@ -321,6 +434,19 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 // ExecuteCount performs a non-streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
 func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return m.executeUnary(ctx, providers, req, opts, countTokensCall)
+}
+
+// unaryCall performs one non-streaming, non-generating upstream call with a picked auth.
+type unaryCall func(ctx context.Context, executor ProviderExecutor, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error)
+
+func countTokensCall(ctx context.Context, executor ProviderExecutor, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return executor.CountTokens(ctx, auth, req, opts)
+}
+
+// executeUnary runs call across providers with the same retry, cooldown and auth rotation
+// rules as Execute, without model fallback or hedging.
+func (m *Manager) executeUnary(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, call unaryCall) (cliproxyexecutor.Response, error) {
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
@@ -336,7 +462,7 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 	var lastErr error
 	for attempt := 0; attempt < attempts; attempt++ {
 		resp, errExec := m.executeProvidersOnce(ctx, rotated, func(execCtx context.Context, provider string) (cliproxyexecutor.Response, error) {
-			return m.executeCountWithProvider(execCtx, provider, req, opts)
+			return m.executeUnaryWithProvider(execCtx, provider, req, opts, call)
 		})
 		if errExec == nil {
 			return resp, nil
@@ -358,7 +484,12 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 
 // ExecuteStream performs a streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
+// When every credential for the model is cooling down, configured fallback models are tried in order.
 func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return executeWithFallback(ctx, m, providers, req, opts, m.executeStreamModel)
+}
+
+func (m *Manager) executeStreamModel(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return nil, &Error{Code: "provider_not_found", Message: "no provider supplied"}
@@ -373,13 +504,24 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cli
 
 	var lastErr error
 	for attempt := 0; attempt < attempts; attempt++ {
-		chunks, errStream := m.executeStreamProvidersOnce(ctx, rotated, func(execCtx context.Context, provider string) (<-chan cliproxyexecutor.StreamChunk, error) {
-			return m.executeStreamWithProvider(execCtx, provider, req, opts)
-		})
+		var (
+			chunks    <-chan cliproxyexecutor.StreamChunk
+			errStream error
+		)
+		if delay, hedged := m.hedgeDelay(req.Model); hedged {
+			chunks, errStream = m.executeStreamHedged(ctx, rotated, delay, req, opts)
+		} else {
+			chunks, errStream = m.executeStreamProvidersOnce(ctx, rotated, func(execCtx context.Context, provider string) (<-chan cliproxyexecutor.StreamChunk, error) {
+				return m.executeStreamWithProvider(execCtx, provider, req, opts)
+			})
+		}
 		if errStream == nil {
 			return chunks, nil
 		}
 		lastErr = errStream
+		if m.shouldFallback(errStream, rotated, req.Model) {
+			break
+		}
 		wait, shouldRetry := m.shouldRetryAfterError(errStream, attempt, attempts, rotated, req.Model, maxWait)
 		if !shouldRetry {
 			break
@@ -400,6 +542,7 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 	}
 	routeModel := req.Model
 	tried := make(map[string]struct{})
+	hedge := hedgeGroupFrom(ctx)
 	var lastErr error
 	maxAttempts := 100
 	attemptCount := 0
@@ -421,13 +564,17 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 			}
 			return cliproxyexecutor.Response{}, &Error{Code: "max_attempts_exceeded", Message: "exceeded maximum retry attempts"}
 		}
-		auth, executor, errPick := m.pickNext(ctx, provider, routeModel, opts, tried)
+		attemptCtx, span := startAttemptSpan(ctx, provider, routeModel, attemptCount)
+		auth, executor, errPick := m.pickNext(attemptCtx, provider, routeModel, opts, hedge.exclude(tried))
 		if errPick != nil {
+			tracing.RecordError(span, errPick)
+			span.End()
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
 			}
 			return cliproxyexecutor.Response{}, errPick
 		}
+		span.SetAttributes(tracing.AttrAuthIndex.String(auth.EnsureIndex()))
 
 		accountType, accountInfo := auth.AccountInfo()
 		proxyInfo := auth.ProxyInfo()
@@ -447,16 +594,28 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 		}
 
 		tried[auth.ID] = struct{}{}
-		execCtx := ctx
+		hedge.claim(auth.ID)
+		execCtx := attemptCtx
 		if rt := m.roundTripperFor(auth); rt != nil {
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		if pipeline := m.translatorPipelineRef(); pipeline != nil {
+			execCtx = sdktranslator.WithPipeline(execCtx, pipeline)
+		}
 		execReq := req
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
 		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
-		resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
-		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
+		execOpts := opts
+		execCtx, observer := m.beginAttempt(execCtx, auth, &execReq, &execOpts)
+		attemptStart := time.Now()
+		done := m.controls.begin(auth.ID)
+		resp, errExec := executor.Execute(execCtx, auth, execReq, execOpts)
+		done()
+		if observer != nil {
+			observer.AfterAttempt(execCtx, resp, errExec)
+		}
+		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil, Latency: time.Since(attemptStart)}
 		if errExec != nil {
 			result.Error = &Error{Message: errExec.Error()}
 			var se cliproxyexecutor.StatusError
@@ -466,16 +625,24 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 			if ra := retryAfterFromError(errExec); ra != nil {
 				result.RetryAfter = ra
 			}
+			if IsHedgeCancelled(execCtx) {
+				span.SetAttributes(attribute.Bool("cliproxy.hedge.cancelled", true))
+				span.End()
+				return cliproxyexecutor.Response{}, errExec
+			}
 			m.MarkResult(execCtx, result)
+			tracing.RecordError(span, errExec)
+			span.End()
 			lastErr = errExec
 			continue
 		}
 		m.MarkResult(execCtx, result)
+		span.End()
 		return resp, nil
 	}
 }
 
-func (m *Manager) executeCountWithProvider(ctx context.Context, provider string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+func (m *Manager) executeUnaryWithProvider(ctx context.Context, provider string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, call unaryCall) (cliproxyexecutor.Response, error) {
 	if provider == "" {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "provider identifier is empty"}
 	}
@@ -502,13 +669,17 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 			}
 			return cliproxyexecutor.Response{}, &Error{Code: "max_attempts_exceeded", Message: "exceeded maximum retry attempts"}
 		}
-		auth, executor, errPick := m.pickNext(ctx, provider, routeModel, opts, tried)
+		attemptCtx, span := startAttemptSpan(ctx, provider, routeModel, attemptCount)
+		auth, executor, errPick := m.pickNext(attemptCtx, provider, routeModel, opts, tried)
 		if errPick != nil {
+			tracing.RecordError(span, errPick)
+			span.End()
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
 			}
 			return cliproxyexecutor.Response{}, errPick
 		}
+		span.SetAttributes(tracing.AttrAuthIndex.String(auth.EnsureIndex()))
 
 		accountType, accountInfo := auth.AccountInfo()
 		proxyInfo := auth.ProxyInfo()
@@ -528,16 +699,27 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 		}
 
 		tried[auth.ID] = struct{}{}
-		execCtx := ctx
+		execCtx := attemptCtx
 		if rt := m.roundTripperFor(auth); rt != nil {
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		if pipeline := m.translatorPipelineRef(); pipeline != nil {
+			execCtx = sdktranslator.WithPipeline(execCtx, pipeline)
+		}
 		execReq := req
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
 		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
-		resp, errExec := executor.CountTokens(execCtx, auth, execReq, opts)
-		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
+		execOpts := opts
+		execCtx, observer := m.beginAttempt(execCtx, auth, &execReq, &execOpts)
+		attemptStart := time.Now()
+		done := m.controls.begin(auth.ID)
+		resp, errExec := call(execCtx, executor, auth, execReq, execOpts)
+		done()
+		if observer != nil {
+			observer.AfterAttempt(execCtx, resp, errExec)
+		}
+		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil, Latency: time.Since(attemptStart)}
 		if errExec != nil {
 			result.Error = &Error{Message: errExec.Error()}
 			var se cliproxyexecutor.StatusError
@@ -548,10 +730,13 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 				result.RetryAfter = ra
 			}
 			m.MarkResult(execCtx, result)
+			tracing.RecordError(span, errExec)
+			span.End()
 			lastErr = errExec
 			continue
 		}
 		m.MarkResult(execCtx, result)
+		span.End()
 		return resp, nil
 	}
 }
@@ -562,6 +747,7 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 	}
 	routeModel := req.Model
 	tried := make(map[string]struct{})
+	hedge := hedgeGroupFrom(ctx)
 	var lastErr error
 	maxAttempts := 100
 	attemptCount := 0
@@ -583,13 +769,18 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 			}
 			return nil, &Error{Code: "max_attempts_exceeded", Message: "exceeded maximum retry attempts"}
 		}
-		auth, executor, errPick := m.pickNext(ctx, provider, routeModel, opts, tried)
+		attemptCtx, span := startAttemptSpan(ctx, provider, routeModel, attemptCount)
+		span.SetAttributes(tracing.AttrStream.Bool(true))
+		auth, executor, errPick := m.pickNext(attemptCtx, provider, routeModel, opts, hedge.exclude(tried))
 		if errPick != nil {
+			tracing.RecordError(span, errPick)
+			span.End()
 			if lastErr != nil {
 				return nil, lastErr
 			}
 			return nil, errPick
 		}
+		span.SetAttributes(tracing.AttrAuthIndex.String(auth.EnsureIndex()))
 
 		accountType, accountInfo := auth.AccountInfo()
 		proxyInfo := auth.ProxyInfo()
@@ -609,47 +800,89 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 		}
 
 		tried[auth.ID] = struct{}{}
-		execCtx := ctx
+		hedge.claim(auth.ID)
+		execCtx := attemptCtx
 		if rt := m.roundTripperFor(auth); rt != nil {
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		if pipeline := m.translatorPipelineRef(); pipeline != nil {
+			execCtx = sdktranslator.WithPipeline(execCtx, pipeline)
+		}
 		execReq := req
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
 		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
-		chunks, errStream := executor.ExecuteStream(execCtx, auth, execReq, opts)
+		execOpts := opts
+		execCtx, observer := m.beginAttempt(execCtx, auth, &execReq, &execOpts)
+		attemptStart := time.Now()
+		done := m.controls.begin(auth.ID)
+		chunks, errStream := executor.ExecuteStream(execCtx, auth, execReq, execOpts)
 		if errStream != nil {
+			done()
+			if observer != nil {
+				observer.AfterAttempt(execCtx, cliproxyexecutor.Response{}, errStream)
+			}
 			rerr := &Error{Message: errStream.Error()}
 			var se cliproxyexecutor.StatusError
 			if errors.As(errStream, &se) && se != nil {
 				rerr.HTTPStatus = se.StatusCode()
 			}
-			result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: rerr}
+			if IsHedgeCancelled(execCtx) {
+				span.SetAttributes(attribute.Bool("cliproxy.hedge.cancelled", true))
+				span.End()
+				return nil, errStream
+			}
+			result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: rerr, Latency: time.Since(attemptStart)}
 			result.RetryAfter = retryAfterFromError(errStream)
 			m.MarkResult(execCtx, result)
+			tracing.RecordError(span, errStream)
+			span.End()
 			lastErr = errStream
 			continue
 		}
 		out := make(chan cliproxyexecutor.StreamChunk)
-		go func(streamCtx context.Context, streamAuth *Auth, streamProvider string, streamChunks <-chan cliproxyexecutor.StreamChunk) {
+		go func(streamCtx context.Context, streamAuth *Auth, streamProvider string, streamChunks <-chan cliproxyexecutor.StreamChunk, streamObserver ExecutionObserver, streamSpan trace.Span) {
 			defer close(out)
+			defer streamSpan.End()
+			defer done()
 			var failed bool
+			var streamErr error
+			var firstByte time.Duration
+			var chunkCount int
 			for chunk := range streamChunks {
-				if chunk.Err != nil && !failed {
+				chunkCount++
+				if firstByte == 0 {
+					firstByte = time.Since(attemptStart)
+				}
+				if streamObserver != nil {
+					streamObserver.OnStreamChunk(streamCtx, chunk)
+				}
+				if chunk.Err != nil && !failed && !IsHedgeCancelled(streamCtx) {
 					failed = true
+					streamErr = chunk.Err
 					rerr := &Error{Message: chunk.Err.Error()}
 					var se cliproxyexecutor.StatusError
 					if errors.As(chunk.Err, &se) && se != nil {
 						rerr.HTTPStatus = se.StatusCode()
 					}
-					m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: false, Error: rerr})
+					m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: false, Error: rerr, Latency: time.Since(attemptStart), FirstByteLatency: firstByte})
 				}
 				out <- chunk
 			}
-			if !failed {
-				m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: true})
+			cancelled := IsHedgeCancelled(streamCtx)
+			if !failed && !cancelled {
+				m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: true, Latency: time.Since(attemptStart), FirstByteLatency: firstByte})
 			}
-		}(execCtx, auth.Clone(), provider, chunks)
+			if streamObserver != nil {
+				streamObserver.AfterAttempt(streamCtx, cliproxyexecutor.Response{}, streamErr)
+			}
+			streamSpan.AddEvent("stream.completed", trace.WithAttributes(
+				attribute.Int("cliproxy.stream.chunks", chunkCount),
+				attribute.Int64("cliproxy.stream.first_byte_ms", firstByte.Milliseconds()),
+				attribute.Bool("cliproxy.hedge.cancelled", cancelled),
+			))
+			tracing.RecordError(streamSpan, streamErr)
+		}(execCtx, auth.Clone(), provider, chunks, observer, span)
 		return out, nil
 	}
 }
@@ -816,13 +1049,35 @@ func (m *Manager) shouldRetryAfterError(err error, attempt, maxAttempts int, pro
 	if !found || wait > maxWait {
 		return 0, false
 	}
+	m.retries.Add(1)
 	return wait, true
 }
 
+// startAttemptSpan opens the span covering one credential pick and the executor call made with it.
+func startAttemptSpan(ctx context.Context, provider, model string, attempt int) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "cliproxy.attempt", trace.WithAttributes(
+		tracing.AttrProvider.String(provider),
+		tracing.AttrModel.String(model),
+		tracing.AttrAttempt.Int(attempt),
+	))
+}
+
+// RetryCount returns the number of retry rounds scheduled since the manager was created.
+func (m *Manager) RetryCount() uint64 {
+	if m == nil {
+		return 0
+	}
+	return m.retries.Load()
+}
+
 func waitForCooldown(ctx context.Context, wait time.Duration) error {
 	if wait <= 0 {
 		return nil
 	}
+	_, span := tracing.Tracer().Start(ctx, "cliproxy.cooldown_wait", trace.WithAttributes(
+		attribute.Int64("cliproxy.cooldown_wait_ms", wait.Milliseconds()),
+	))
+	defer span.End()
 	timer := time.NewTimer(wait)
 	defer timer.Stop()
 	select {
@@ -875,6 +1130,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 		return
 	}
 
+	var upstream circuitKey
 	shouldResumeModel := false
 	shouldSuspendModel := false
 	suspendReason := ""
@@ -884,8 +1140,15 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	m.mu.Lock()
 	if auth, ok := m.auths[result.AuthID]; ok && auth != nil {
 		now := time.Now()
-
+		upstream = circuitKeyFor(auth)
 		if result.Success {
+			auth.LastSuccessAt = now
+		}
+
+		switch {
+		case result.Model != "" && manualCooldownActive(auth.ModelStates[result.Model], now):
+			// An operator cooldown outranks the outcome of requests that were already in flight.
+		case result.Success:
 			if result.Model != "" {
 				state := ensureModelState(auth, result.Model)
 				resetModelState(state, now)
@@ -901,7 +1164,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 			} else {
 				clearAuthStateOnSuccess(auth, now)
 			}
-		} else {
+		default:
 			if result.Model != "" {
 				state := ensureModelState(auth, result.Model)
 				state.Unavailable = true
@@ -972,6 +1235,9 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	}
 	m.mu.Unlock()
 
+	if upstream.provider != "" {
+		m.circuits.observe(ctx, upstream, result, time.Now())
+	}
 	if clearModelQuota && result.Model != "" {
 		registry.GetGlobalRegistry().ClearModelQuotaExceeded(result.AuthID, result.Model)
 	}
@@ -984,7 +1250,16 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 		registry.GetGlobalRegistry().SuspendClientModel(result.AuthID, result.Model, suspendReason)
 	}
 
+	m.mu.RLock()
+	selector := m.selector
+	m.mu.RUnlock()
+	if observer, ok := selector.(ResultObserver); ok && observer != nil {
+		observer.ObserveResult(result)
+	}
+
 	m.hook.OnResult(ctx, result)
+	resultCopy := result
+	m.runtimeEvents.publish(RuntimeEvent{Type: RuntimeEventResult, AuthID: result.AuthID, Time: time.Now(), Result: &resultCopy})
 }
 
 func ensureModelState(auth *Auth, model string) *ModelState {
@@ -1012,6 +1287,7 @@ func resetModelState(state *ModelState, now time.Time) {
 	state.NextRetryAfter = time.Time{}
 	state.LastError = nil
 	state.Quota = QuotaState{}
+	state.Manual = false
 	state.UpdatedAt = now
 }
 
@@ -1268,6 +1544,8 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 	candidates := make([]*Auth, 0, len(m.auths))
 	modelKey := strings.TrimSpace(model)
 	registryRef := registry.GetGlobalRegistry()
+	now := time.Now()
+	circuitOpen := false
 	for _, candidate := range m.auths {
 		if candidate.Provider != provider || candidate.Disabled {
 			continue
@@ -1278,10 +1556,20 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 		if modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(candidate.ID, modelKey) {
 			continue
 		}
+		if m.controls.isDrained(candidate.ID) {
+			continue
+		}
+		if !m.circuits.admits(circuitKeyFor(candidate), now) {
+			circuitOpen = true
+			continue
+		}
 		candidates = append(candidates, candidate)
 	}
 	if len(candidates) == 0 {
 		m.mu.RUnlock()
+		if circuitOpen {
+			return nil, nil, &Error{Code: "circuit_open", Message: "upstream circuit breaker is open", Retryable: true, HTTPStatus: http.StatusServiceUnavailable}
+		}
 		return nil, nil, &Error{Code: "auth_not_found", Message: "no auth available"}
 	}
 	selected, errPick := m.selector.Pick(ctx, provider, model, opts, candidates)
@@ -1295,6 +1583,15 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 	}
 	authCopy := selected.Clone()
 	m.mu.RUnlock()
+	if !m.circuits.acquire(circuitKeyFor(authCopy), now) {
+		// Another request took the last half-open probe slot in the meantime.
+		skip := make(map[string]struct{}, len(tried)+1)
+		for id := range tried {
+			skip[id] = struct{}{}
+		}
+		skip[authCopy.ID] = struct{}{}
+		return m.pickNext(ctx, provider, model, opts, skip)
+	}
 	if !selected.indexAssigned {
 		m.mu.Lock()
 		if current := m.auths[authCopy.ID]; current != nil && !current.indexAssigned {
@@ -1674,6 +1971,21 @@ func (m *Manager) roundTripperFor(auth *Auth) http.RoundTripper {
 	return p.RoundTripperFor(auth)
 }
 
+// beginAttempt invokes the registered execution hook for a single provider attempt.
+func (m *Manager) beginAttempt(ctx context.Context, auth *Auth, req *cliproxyexecutor.Request, opts *cliproxyexecutor.Options) (context.Context, ExecutionObserver) {
+	m.mu.RLock()
+	hook := m.executionHook
+	m.mu.RUnlock()
+	if hook == nil {
+		return ctx, nil
+	}
+	hookCtx, observer := hook.BeforeAttempt(ctx, auth, req, opts)
+	if hookCtx == nil {
+		hookCtx = ctx
+	}
+	return hookCtx, observer
+}
+
 // RoundTripperProvider defines a minimal provider of per-auth HTTP transports.
 type RoundTripperProvider interface {
 	RoundTripperFor(auth *Auth) http.RoundTripper
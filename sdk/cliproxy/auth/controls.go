@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+)
+
+// manualCooldownReason suspends manually cooled models with the reason the registry treats as a
+// cooldown, so the models stay listed while the credential waits out its deadline.
+const manualCooldownReason = "quota"
+
+// authControls holds operator controls that live only in memory: drained auths and the number
+// of upstream requests each auth has in flight.
+type authControls struct {
+	mu       sync.Mutex
+	drained  map[string]time.Time
+	inflight map[string]int
+}
+
+// begin counts an upstream request for authID until the returned func is called.
+func (c *authControls) begin(authID string) func() {
+	c.mu.Lock()
+	if c.inflight == nil {
+		c.inflight = make(map[string]int)
+	}
+	c.inflight[authID]++
+	c.mu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			if c.inflight[authID] <= 1 {
+				delete(c.inflight, authID)
+			} else {
+				c.inflight[authID]--
+			}
+			c.mu.Unlock()
+		})
+	}
+}
+
+func (c *authControls) isDrained(authID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.drained[authID]
+	return ok
+}
+
+// DrainState describes whether an auth is drained and how many requests it still serves.
+type DrainState struct {
+	Draining bool      `json:"draining"`
+	Since    time.Time `json:"since,omitempty"`
+	InFlight int       `json:"in_flight"`
+}
+
+// DrainState reports the drain status and in-flight request count of authID.
+func (m *Manager) DrainState(authID string) DrainState {
+	m.controls.mu.Lock()
+	defer m.controls.mu.Unlock()
+	since, draining := m.controls.drained[authID]
+	return DrainState{Draining: draining, Since: since, InFlight: m.controls.inflight[authID]}
+}
+
+// DrainAuth stops routing new requests to authID. Requests already in flight, including open
+// streams, run to completion. The drain is not persisted and ends with ResumeAuth or a restart.
+func (m *Manager) DrainAuth(authID string) (DrainState, error) {
+	if _, ok := m.GetByID(authID); !ok {
+		return DrainState{}, errAuthControlNotFound(authID)
+	}
+	m.controls.mu.Lock()
+	if m.controls.drained == nil {
+		m.controls.drained = make(map[string]time.Time)
+	}
+	if _, already := m.controls.drained[authID]; !already {
+		m.controls.drained[authID] = time.Now()
+	}
+	m.controls.mu.Unlock()
+	m.runtimeEvents.publish(RuntimeEvent{Type: RuntimeEventAuthUpdated, AuthID: authID, Time: time.Now()})
+	return m.DrainState(authID), nil
+}
+
+// ResumeAuth lets a drained auth receive new requests again.
+func (m *Manager) ResumeAuth(authID string) (DrainState, error) {
+	if _, ok := m.GetByID(authID); !ok {
+		return DrainState{}, errAuthControlNotFound(authID)
+	}
+	m.controls.mu.Lock()
+	delete(m.controls.drained, authID)
+	m.controls.mu.Unlock()
+	m.runtimeEvents.publish(RuntimeEvent{Type: RuntimeEventAuthUpdated, AuthID: authID, Time: time.Now()})
+	return m.DrainState(authID), nil
+}
+
+// CooldownAuth puts authID into a manual cooldown for model, or for every model it serves when
+// model is empty, until duration elapses. Attempt results do not shorten or clear a manual
+// cooldown; ClearCooldown does. It returns the models affected.
+func (m *Manager) CooldownAuth(ctx context.Context, authID, model string, duration time.Duration) ([]string, error) {
+	if duration <= 0 {
+		return nil, &Error{Code: "invalid_duration", Message: "cooldown duration must be positive", HTTPStatus: http.StatusBadRequest}
+	}
+	now := time.Now()
+	until := now.Add(duration)
+	models, err := m.updateModelStates(authID, model, func(state *ModelState) bool {
+		state.Unavailable = true
+		state.Status = StatusError
+		state.StatusMessage = "manual cooldown"
+		state.NextRetryAfter = until
+		state.Manual = true
+		state.UpdatedAt = now
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	reg := registry.GetGlobalRegistry()
+	for _, name := range models {
+		reg.SuspendClientModel(authID, name, manualCooldownReason)
+	}
+	m.notifyControl(ctx, authID)
+	return models, nil
+}
+
+// ClearCooldown ends the cooldown of authID for model, or for every model when model is
+// empty, whether it was set manually or by a failed attempt. It returns the models cleared.
+func (m *Manager) ClearCooldown(ctx context.Context, authID, model string) ([]string, error) {
+	now := time.Now()
+	models, err := m.updateModelStates(authID, model, func(state *ModelState) bool {
+		if !state.Unavailable && state.NextRetryAfter.IsZero() && !state.Quota.Exceeded {
+			return false
+		}
+		backoff := state.Quota.BackoffLevel
+		resetModelState(state, now)
+		// Keep the backoff level so the next quota error still escalates; ResetBackoff clears it.
+		state.Quota.BackoffLevel = backoff
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	reg := registry.GetGlobalRegistry()
+	for _, name := range models {
+		reg.ClearModelQuotaExceeded(authID, name)
+		reg.ResumeClientModel(authID, name)
+	}
+	m.notifyControl(ctx, authID)
+	return models, nil
+}
+
+// ResetBackoff resets the progressive quota backoff of authID for model, or for every model
+// when model is empty, so the next quota error starts again at the shortest cooldown.
+func (m *Manager) ResetBackoff(ctx context.Context, authID, model string) ([]string, error) {
+	models, err := m.updateModelStates(authID, model, func(state *ModelState) bool {
+		if state.Quota.BackoffLevel == 0 {
+			return false
+		}
+		state.Quota.BackoffLevel = 0
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.notifyControl(ctx, authID)
+	return models, nil
+}
+
+// updateModelStates applies fn to the state of model under authID, or to every model the auth
+// serves or has state for when model is empty, and returns the models fn changed.
+func (m *Manager) updateModelStates(authID, model string, fn func(*ModelState) bool) ([]string, error) {
+	model = strings.TrimSpace(model)
+	targets := []string{model}
+	if model == "" {
+		targets = nil
+		for _, info := range registry.GetGlobalRegistry().GetModelsForClient(authID) {
+			if info != nil && info.ID != "" {
+				targets = append(targets, info.ID)
+			}
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	auth, ok := m.auths[authID]
+	if !ok || auth == nil {
+		return nil, errAuthControlNotFound(authID)
+	}
+	if model == "" {
+		for name := range auth.ModelStates {
+			targets = append(targets, name)
+		}
+	}
+	seen := make(map[string]struct{}, len(targets))
+	changed := make([]string, 0, len(targets))
+	for _, name := range targets {
+		if _, dup := seen[name]; dup {
+			continue
+		}
+		seen[name] = struct{}{}
+		if fn(ensureModelState(auth, name)) {
+			changed = append(changed, name)
+		}
+	}
+	now := time.Now()
+	updateAggregatedAvailability(auth, now)
+	if !auth.Unavailable && auth.Status == StatusError && !hasModelError(auth, now) {
+		auth.Status = StatusActive
+		auth.StatusMessage = ""
+		auth.LastError = nil
+	} else if auth.Unavailable {
+		auth.Status = StatusError
+	}
+	auth.UpdatedAt = now
+	sort.Strings(changed)
+	return changed, nil
+}
+
+func (m *Manager) notifyControl(ctx context.Context, authID string) {
+	auth, ok := m.GetByID(authID)
+	if !ok {
+		return
+	}
+	m.hook.OnAuthUpdated(ctx, auth)
+	m.runtimeEvents.publish(RuntimeEvent{Type: RuntimeEventAuthUpdated, AuthID: authID, Time: time.Now()})
+}
+
+// manualCooldownActive reports whether state carries a manual cooldown that has not expired.
+func manualCooldownActive(state *ModelState, now time.Time) bool {
+	return state != nil && state.Manual && state.NextRetryAfter.After(now)
+}
+
+func errAuthControlNotFound(authID string) *Error {
+	return &Error{Code: "auth_not_found", Message: "auth not found: " + authID, HTTPStatus: http.StatusNotFound}
+}
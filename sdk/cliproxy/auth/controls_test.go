@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func newControlsTestManager(t *testing.T) *Manager {
+	t.Helper()
+	reg := registry.GetGlobalRegistry()
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&fallbackTestExecutor{provider: "ctlprov"})
+	for _, id := range []string{"ctl-a", "ctl-b"} {
+		reg.RegisterClient(id, "ctlprov", []*registry.ModelInfo{{ID: "ctl-model"}, {ID: "ctl-other"}})
+		if _, err := manager.Register(context.Background(), &Auth{ID: id, Provider: "ctlprov"}); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		reg.UnregisterClient("ctl-a")
+		reg.UnregisterClient("ctl-b")
+	})
+	return manager
+}
+
+func TestManualCooldownSurvivesResultsUntilCleared(t *testing.T) {
+	manager := newControlsTestManager(t)
+	ctx := context.Background()
+
+	models, err := manager.CooldownAuth(ctx, "ctl-a", "", time.Hour)
+	if err != nil {
+		t.Fatalf("CooldownAuth() error = %v", err)
+	}
+	if len(models) != 2 {
+		t.Fatalf("cooled models = %v, want every served model", models)
+	}
+	// A request that was already in flight succeeds; the manual cooldown must hold.
+	manager.MarkResult(ctx, Result{AuthID: "ctl-a", Provider: "ctlprov", Model: "ctl-model", Success: true})
+	for i := 0; i < 4; i++ {
+		auth, _, errPick := manager.pickNext(ctx, "ctlprov", "ctl-model", cliproxyexecutor.Options{}, nil)
+		if errPick != nil {
+			t.Fatalf("pickNext() error = %v", errPick)
+		}
+		if auth.ID != "ctl-b" {
+			t.Fatalf("pickNext() = %s while ctl-a is cooling down", auth.ID)
+		}
+	}
+
+	manager.MarkResult(ctx, Result{AuthID: "ctl-b", Provider: "ctlprov", Model: "ctl-model", Error: &Error{HTTPStatus: http.StatusTooManyRequests}})
+	auth, _ := manager.GetByID("ctl-b")
+	if auth.ModelStates["ctl-model"].Quota.BackoffLevel == 0 {
+		t.Fatalf("429 should raise the backoff level")
+	}
+	if _, err = manager.ResetBackoff(ctx, "ctl-b", "ctl-model"); err != nil {
+		t.Fatalf("ResetBackoff() error = %v", err)
+	}
+	auth, _ = manager.GetByID("ctl-b")
+	if level := auth.ModelStates["ctl-model"].Quota.BackoffLevel; level != 0 {
+		t.Fatalf("backoff level = %d after reset", level)
+	}
+
+	if _, err = manager.ClearCooldown(ctx, "ctl-a", "ctl-model"); err != nil {
+		t.Fatalf("ClearCooldown() error = %v", err)
+	}
+	auth, _ = manager.GetByID("ctl-a")
+	if state := auth.ModelStates["ctl-model"]; state.Unavailable || state.Manual {
+		t.Fatalf("cooldown not cleared: %+v", state)
+	}
+	if state := auth.ModelStates["ctl-other"]; !state.Manual {
+		t.Fatalf("clearing one model must keep the others cooling down")
+	}
+
+	var authErr *Error
+	if _, err = manager.CooldownAuth(ctx, "missing", "", time.Minute); !errors.As(err, &authErr) || authErr.HTTPStatus != http.StatusNotFound {
+		t.Fatalf("CooldownAuth(missing) error = %v", err)
+	}
+}
+
+func TestDrainStopsNewRequestsButKeepsInFlight(t *testing.T) {
+	manager := newControlsTestManager(t)
+	ctx := context.Background()
+
+	done := manager.controls.begin("ctl-a")
+	state, err := manager.DrainAuth("ctl-a")
+	if err != nil {
+		t.Fatalf("DrainAuth() error = %v", err)
+	}
+	if !state.Draining || state.InFlight != 1 {
+		t.Fatalf("drain state = %+v", state)
+	}
+	for i := 0; i < 4; i++ {
+		auth, _, errPick := manager.pickNext(ctx, "ctlprov", "ctl-model", cliproxyexecutor.Options{}, nil)
+		if errPick != nil {
+			t.Fatalf("pickNext() error = %v", errPick)
+		}
+		if auth.ID == "ctl-a" {
+			t.Fatalf("drained auth was selected")
+		}
+	}
+	done()
+	if got := manager.DrainState("ctl-a").InFlight; got != 0 {
+		t.Fatalf("in-flight = %d after request finished", got)
+	}
+	if state, _ = manager.ResumeAuth("ctl-a"); state.Draining {
+		t.Fatalf("auth still draining after resume")
+	}
+}
+
+func TestManualCooldownKeepsModelListed(t *testing.T) {
+	reg := registry.GetGlobalRegistry()
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&fallbackTestExecutor{provider: "ctlprov"})
+	reg.RegisterClient("ctl-solo", "ctlprov", []*registry.ModelInfo{{ID: "ctl-solo-model"}})
+	t.Cleanup(func() { reg.UnregisterClient("ctl-solo") })
+	ctx := context.Background()
+	if _, err := manager.Register(ctx, &Auth{ID: "ctl-solo", Provider: "ctlprov"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	listed := func() bool {
+		for _, model := range reg.GetAvailableModels("openai") {
+			if model["id"] == "ctl-solo-model" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if _, err := manager.CooldownAuth(ctx, "ctl-solo", "", 50*time.Millisecond); err != nil {
+		t.Fatalf("CooldownAuth() error = %v", err)
+	}
+	if !listed() {
+		t.Fatalf("model hidden while its only credential is manually cooling down")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if !listed() {
+		t.Fatalf("model hidden after the manual cooldown ended")
+	}
+	if auth, _, err := manager.pickNext(ctx, "ctlprov", "ctl-solo-model", cliproxyexecutor.Options{}, nil); err != nil || auth.ID != "ctl-solo" {
+		t.Fatalf("pickNext() after the cooldown = %v, %v", auth, err)
+	}
+}
@@ -0,0 +1,358 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+const (
+	defaultCooldownQueueLength  = 100
+	defaultCooldownQueueMaxWait = 5 * time.Minute
+	// cooldownQueueRetryFloor spaces retries when the credentials report no cooldown end.
+	cooldownQueueRetryFloor = 500 * time.Millisecond
+)
+
+var errCooldownQueueTimeout = errors.New("cooldown queue wait exceeded")
+
+// cooldownQueue parks opted-in requests in a FIFO line per model while every credential for
+// the model cools down. The front of a line, one ticket per credential that is available or
+// recovers first, retries; a ticket leaves the line while its attempt is in flight and rejoins
+// in its original position when the credentials are still cooling.
+type cooldownQueue struct {
+	mu        sync.Mutex
+	enabled   bool
+	maxLength int
+	maxWait   time.Duration
+	lines     map[string]*cooldownLine
+}
+
+type cooldownLine struct {
+	tickets []*cooldownTicket
+	// changed is closed and replaced whenever tickets leave or rejoin the line.
+	changed chan struct{}
+	stats   CooldownQueueModelStats
+	waitSum time.Duration
+}
+
+type cooldownTicket struct {
+	model    string
+	enqueued time.Time
+}
+
+// CooldownQueueModelStats describes the cooldown queue of one model.
+type CooldownQueueModelStats struct {
+	Model        string `json:"model"`
+	Depth        int    `json:"depth"`
+	OldestWaitMS int64  `json:"oldest_wait_ms"`
+	Enqueued     uint64 `json:"enqueued"`
+	Served       uint64 `json:"served"`
+	TimedOut     uint64 `json:"timed_out"`
+	Failed       uint64 `json:"failed"`
+	Rejected     uint64 `json:"rejected"`
+	AvgWaitMS    int64  `json:"avg_wait_ms"`
+	MaxWaitMS    int64  `json:"max_wait_ms"`
+}
+
+// SetCooldownQueueConfig updates the cooldown queue settings. Requests already waiting keep
+// the deadline they were admitted with.
+func (m *Manager) SetCooldownQueueConfig(cfg internalconfig.CooldownQueueConfig) {
+	if m == nil {
+		return
+	}
+	q := &m.cooldownQueue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.enabled = cfg.Enable
+	q.maxLength = cfg.MaxQueueLength
+	if q.maxLength <= 0 {
+		q.maxLength = defaultCooldownQueueLength
+	}
+	q.maxWait = time.Duration(cfg.MaxWaitSeconds) * time.Second
+	if q.maxWait <= 0 {
+		q.maxWait = defaultCooldownQueueMaxWait
+	}
+}
+
+// CooldownQueueStats returns the depth and wait times of every model that has used the
+// cooldown queue, sorted by model.
+func (m *Manager) CooldownQueueStats() []CooldownQueueModelStats {
+	if m == nil {
+		return nil
+	}
+	q := &m.cooldownQueue
+	now := time.Now()
+	q.mu.Lock()
+	out := make([]CooldownQueueModelStats, 0, len(q.lines))
+	for _, line := range q.lines {
+		stats := line.stats
+		stats.Depth = len(line.tickets)
+		for _, t := range line.tickets {
+			if wait := now.Sub(t.enqueued).Milliseconds(); wait > stats.OldestWaitMS {
+				stats.OldestWaitMS = wait
+			}
+		}
+		if stats.Served > 0 {
+			stats.AvgWaitMS = (line.waitSum / time.Duration(stats.Served)).Milliseconds()
+		}
+		out = append(out, stats)
+	}
+	q.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Model < out[j].Model })
+	return out
+}
+
+// shouldQueue reports whether a failed request may wait in the cooldown queue: the queue must
+// be enabled, the client must have opted in and the failure must be a credential cooldown.
+func (m *Manager) shouldQueue(err error, opts cliproxyexecutor.Options, providers []string, model string) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if queued, _ := opts.Metadata[cliproxyexecutor.CooldownQueueMetadataKey].(bool); !queued {
+		return false
+	}
+	m.cooldownQueue.mu.Lock()
+	enabled := m.cooldownQueue.enabled
+	m.cooldownQueue.mu.Unlock()
+	if !enabled || statusCodeFromError(err) != http.StatusTooManyRequests {
+		return false
+	}
+	_, found := m.closestCooldownWait(providers, model)
+	return found
+}
+
+// executeQueued waits in the cooldown queue of req.Model and retries run once the request
+// reaches the front of the line and the nearest cooldown has passed. lastErr is returned when
+// the queue is full or the request waits longer than the configured maximum.
+func executeQueued[T any](ctx context.Context, m *Manager, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options,
+	run func(context.Context, []string, cliproxyexecutor.Request, cliproxyexecutor.Options) (T, error), lastResult T, lastErr error) (T, error) {
+	q := &m.cooldownQueue
+	ticket, deadline, ok := q.enter(req.Model)
+	if !ok {
+		logEntryWithRequestID(ctx).Debugf("cooldown queue for %s is full", req.Model)
+		return lastResult, lastErr
+	}
+	logEntryWithRequestID(ctx).Debugf("model %s is cooling down, request queued", req.Model)
+	slots := func() int {
+		n, _ := m.cooldownQueueSlots(providers, req.Model)
+		return n
+	}
+	for attempt := 0; ; attempt++ {
+		if errWait := q.awaitTurn(ctx, ticket, deadline, slots); errWait != nil {
+			q.leave(ticket, false, errors.Is(errWait, errCooldownQueueTimeout))
+			return lastResult, lastErr
+		}
+		wait, _ := m.closestCooldownWait(providers, req.Model)
+		if _, available := m.cooldownQueueSlots(providers, req.Model); available {
+			// closestCooldownWait only looks at credentials that are still cooling down.
+			wait = 0
+		}
+		if attempt > 0 && wait < cooldownQueueRetryFloor {
+			wait = cooldownQueueRetryFloor
+		}
+		if remaining := time.Until(deadline); wait > remaining {
+			wait = remaining
+		}
+		if errWait := waitForCooldown(ctx, wait); errWait != nil {
+			q.leave(ticket, false, false)
+			return lastResult, lastErr
+		}
+		q.dispatch(ticket)
+		result, err := run(ctx, providers, req, opts)
+		if err == nil {
+			q.finish(ticket, true, false)
+			return result, nil
+		}
+		lastResult, lastErr = result, err
+		if !m.shouldQueue(err, opts, m.normalizeProviders(providers), req.Model) {
+			q.finish(ticket, false, false)
+			return lastResult, lastErr
+		}
+		if !time.Now().Before(deadline) {
+			q.finish(ticket, false, true)
+			return lastResult, lastErr
+		}
+		q.rejoin(ticket)
+	}
+}
+
+// enter appends a ticket for model to its line, or reports false when the line is full.
+func (q *cooldownQueue) enter(model string) (*cooldownTicket, time.Time, bool) {
+	now := time.Now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	line := q.lineLocked(model)
+	if len(line.tickets) >= q.maxLength {
+		line.stats.Rejected++
+		return nil, time.Time{}, false
+	}
+	ticket := &cooldownTicket{model: model, enqueued: now}
+	line.tickets = append(line.tickets, ticket)
+	line.stats.Enqueued++
+	return ticket, now.Add(q.maxWait), true
+}
+
+func (q *cooldownQueue) lineLocked(model string) *cooldownLine {
+	key := strings.ToLower(strings.TrimSpace(model))
+	if q.lines == nil {
+		q.lines = make(map[string]*cooldownLine)
+	}
+	line, ok := q.lines[key]
+	if !ok {
+		line = &cooldownLine{changed: make(chan struct{}), stats: CooldownQueueModelStats{Model: key}}
+		q.lines[key] = line
+	}
+	return line
+}
+
+// awaitTurn blocks until ticket is among the first slots() tickets of its line. slots is
+// re-evaluated whenever the line changes and every cooldownQueueRetryFloor, since credentials
+// recover without touching the line.
+func (q *cooldownQueue) awaitTurn(ctx context.Context, ticket *cooldownTicket, deadline time.Time, slots func() int) error {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	for {
+		front := slots()
+		q.mu.Lock()
+		line := q.lineLocked(ticket.model)
+		for i := 0; i < front && i < len(line.tickets); i++ {
+			if line.tickets[i] == ticket {
+				q.mu.Unlock()
+				return nil
+			}
+		}
+		changed := line.changed
+		q.mu.Unlock()
+		recheck := time.NewTimer(cooldownQueueRetryFloor)
+		select {
+		case <-changed:
+		case <-recheck.C:
+		case <-ctx.Done():
+			recheck.Stop()
+			return ctx.Err()
+		case <-timer.C:
+			recheck.Stop()
+			return errCooldownQueueTimeout
+		}
+		recheck.Stop()
+	}
+}
+
+// cooldownQueueSlots returns how many queued requests for model may try a credential at once:
+// the credentials available now or, when every credential cools down, those recovering
+// together with the nearest one. It is at least one so the head always waits for a cooldown.
+// available reports whether any credential can serve the model right now.
+func (m *Manager) cooldownQueueSlots(providers []string, model string) (slots int, available bool) {
+	providerSet := make(map[string]struct{}, len(providers))
+	for _, provider := range providers {
+		if key := strings.TrimSpace(strings.ToLower(provider)); key != "" {
+			providerSet[key] = struct{}{}
+		}
+	}
+	now := time.Now()
+	ready := 0
+	var recovering []time.Time
+	m.mu.RLock()
+	for _, auth := range m.auths {
+		if auth == nil {
+			continue
+		}
+		if _, ok := providerSet[strings.TrimSpace(strings.ToLower(auth.Provider))]; !ok {
+			continue
+		}
+		blocked, reason, next := isAuthBlockedForModel(auth, model, now)
+		switch {
+		case !blocked:
+			ready++
+		case reason == blockReasonCooldown && !next.IsZero():
+			recovering = append(recovering, next)
+		}
+	}
+	m.mu.RUnlock()
+	if ready > 0 {
+		return ready, true
+	}
+	if len(recovering) == 0 {
+		return 1, false
+	}
+	sort.Slice(recovering, func(i, j int) bool { return recovering[i].Before(recovering[j]) })
+	for _, next := range recovering {
+		if next.Sub(recovering[0]) > cooldownQueueRetryFloor {
+			break
+		}
+		slots++
+	}
+	return slots, false
+}
+
+// dispatch takes ticket out of its line while its attempt is in flight so the next request
+// can move to the front.
+func (q *cooldownQueue) dispatch(ticket *cooldownTicket) {
+	q.mu.Lock()
+	q.removeLocked(ticket)
+	q.mu.Unlock()
+}
+
+// rejoin puts ticket back into its line at the position of its original arrival.
+func (q *cooldownQueue) rejoin(ticket *cooldownTicket) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	line := q.lineLocked(ticket.model)
+	i := sort.Search(len(line.tickets), func(i int) bool { return line.tickets[i].enqueued.After(ticket.enqueued) })
+	line.tickets = append(line.tickets, nil)
+	copy(line.tickets[i+1:], line.tickets[i:])
+	line.tickets[i] = ticket
+	line.notifyLocked()
+}
+
+// leave removes a waiting ticket and records its outcome.
+func (q *cooldownQueue) leave(ticket *cooldownTicket, served, timedOut bool) {
+	q.mu.Lock()
+	q.removeLocked(ticket)
+	q.mu.Unlock()
+	q.finish(ticket, served, timedOut)
+}
+
+// finish records the outcome of a ticket that is no longer in its line.
+func (q *cooldownQueue) finish(ticket *cooldownTicket, served, timedOut bool) {
+	wait := time.Since(ticket.enqueued)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	line := q.lineLocked(ticket.model)
+	switch {
+	case served:
+		line.stats.Served++
+		line.waitSum += wait
+		if ms := wait.Milliseconds(); ms > line.stats.MaxWaitMS {
+			line.stats.MaxWaitMS = ms
+		}
+	case timedOut:
+		line.stats.TimedOut++
+	default:
+		line.stats.Failed++
+	}
+}
+
+func (q *cooldownQueue) removeLocked(ticket *cooldownTicket) {
+	line := q.lineLocked(ticket.model)
+	for i, t := range line.tickets {
+		if t != ticket {
+			continue
+		}
+		line.tickets = append(line.tickets[:i], line.tickets[i+1:]...)
+		line.notifyLocked()
+		return
+	}
+}
+
+func (l *cooldownLine) notifyLocked() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
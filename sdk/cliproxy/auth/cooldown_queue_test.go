@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type cooldownQueueTestExecutor struct {
+	provider string
+	// failures is how many calls fail with a quota error before calls succeed; negative
+	// fails every call.
+	failures   int32
+	retryAfter time.Duration
+	calls      atomic.Int32
+}
+
+func (e *cooldownQueueTestExecutor) Identifier() string { return e.provider }
+
+func (e *cooldownQueueTestExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if call := e.calls.Add(1); e.failures < 0 || call <= e.failures {
+		return cliproxyexecutor.Response{}, &quotaError{retryAfter: e.retryAfter}
+	}
+	return cliproxyexecutor.Response{Payload: []byte("ok")}, nil
+}
+
+func (e *cooldownQueueTestExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *cooldownQueueTestExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	return auth, nil
+}
+
+func (e *cooldownQueueTestExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, errors.New("not implemented")
+}
+
+func newCooldownQueueManager(t *testing.T, executor *cooldownQueueTestExecutor, model string, cfg internalconfig.CooldownQueueConfig) *Manager {
+	t.Helper()
+	authID := executor.provider + "-auth"
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(authID, executor.provider, []*registry.ModelInfo{{ID: model}})
+	t.Cleanup(func() { reg.UnregisterClient(authID) })
+
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	if _, err := manager.Register(context.Background(), &Auth{ID: authID, Provider: executor.provider}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	manager.SetCooldownQueueConfig(cfg)
+	return manager
+}
+
+func queuedOptions() cliproxyexecutor.Options {
+	return cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.CooldownQueueMetadataKey: true}}
+}
+
+func TestManagerExecute_QueuedRequestWaitsForCooldown(t *testing.T) {
+	executor := &cooldownQueueTestExecutor{provider: "cqwait", failures: 1, retryAfter: 150 * time.Millisecond}
+	manager := newCooldownQueueManager(t, executor, "cq-model", internalconfig.CooldownQueueConfig{Enable: true})
+	req := cliproxyexecutor.Request{Model: "cq-model"}
+
+	resp, err := manager.Execute(context.Background(), []string{"cqwait"}, req, queuedOptions())
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if string(resp.Payload) != "ok" || executor.calls.Load() != 2 {
+		t.Fatalf("payload = %q after %d calls", resp.Payload, executor.calls.Load())
+	}
+	stats := manager.CooldownQueueStats()
+	if len(stats) != 1 || stats[0].Enqueued != 1 || stats[0].Served != 1 || stats[0].Depth != 0 || stats[0].MaxWaitMS < 100 {
+		t.Fatalf("stats = %+v", stats)
+	}
+
+	// Requests that did not opt in keep failing fast.
+	executor.calls.Store(0)
+	start := time.Now()
+	if _, err = manager.Execute(context.Background(), []string{"cqwait"}, req, cliproxyexecutor.Options{}); err == nil {
+		t.Fatalf("expected cooldown error without opting into the queue")
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("request without opt-in waited %v", elapsed)
+	}
+}
+
+func TestManagerExecute_CooldownQueueRejectsWhenFullAndTimesOut(t *testing.T) {
+	executor := &cooldownQueueTestExecutor{provider: "cqfull", failures: -1, retryAfter: 10 * time.Minute}
+	manager := newCooldownQueueManager(t, executor, "cq-full-model", internalconfig.CooldownQueueConfig{Enable: true, MaxQueueLength: 1, MaxWaitSeconds: 1})
+	req := cliproxyexecutor.Request{Model: "cq-full-model"}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := manager.Execute(context.Background(), []string{"cqfull"}, req, queuedOptions())
+		done <- err
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if stats := manager.CooldownQueueStats(); len(stats) == 1 && stats[0].Depth == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("first request never queued")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	start := time.Now()
+	if _, err := manager.Execute(context.Background(), []string{"cqfull"}, req, queuedOptions()); statusCodeFromError(err) != 429 {
+		t.Fatalf("Execute() on a full queue error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("rejected request waited %v", elapsed)
+	}
+	if err := <-done; statusCodeFromError(err) != 429 {
+		t.Fatalf("queued request error = %v, want the cooldown error after timing out", err)
+	}
+	stats := manager.CooldownQueueStats()
+	if stats[0].Rejected != 1 || stats[0].TimedOut != 1 || stats[0].Served != 0 || stats[0].Depth != 0 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
+
+// pairedQueueExecutor succeeds only when two calls are in flight together, proving that
+// queued requests were dispatched concurrently rather than one after the other.
+type pairedQueueExecutor struct {
+	arrived atomic.Int32
+	once    sync.Once
+	both    chan struct{}
+}
+
+func (e *pairedQueueExecutor) Identifier() string { return "cqpair" }
+
+func (e *pairedQueueExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if e.arrived.Add(1) == 2 {
+		e.once.Do(func() { close(e.both) })
+	}
+	select {
+	case <-e.both:
+		return cliproxyexecutor.Response{Payload: []byte("ok")}, nil
+	case <-time.After(time.Second):
+		return cliproxyexecutor.Response{}, errors.New("queued requests were not dispatched together")
+	}
+}
+
+func (e *pairedQueueExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *pairedQueueExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	return auth, nil
+}
+
+func (e *pairedQueueExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, errors.New("not implemented")
+}
+
+func TestManagerExecute_CooldownQueueDispatchesOnePerRecoveredCredential(t *testing.T) {
+	const model = "cq-pair-model"
+	executor := &pairedQueueExecutor{both: make(chan struct{})}
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	manager.SetCooldownQueueConfig(internalconfig.CooldownQueueConfig{Enable: true})
+	reg := registry.GetGlobalRegistry()
+	recoverAt := time.Now().Add(200 * time.Millisecond)
+	for _, id := range []string{"cq-pair-a", "cq-pair-b"} {
+		reg.RegisterClient(id, "cqpair", []*registry.ModelInfo{{ID: model}})
+		t.Cleanup(func() { reg.UnregisterClient(id) })
+		auth := &Auth{ID: id, Provider: "cqpair", ModelStates: map[string]*ModelState{model: {
+			Status:         StatusError,
+			Unavailable:    true,
+			NextRetryAfter: recoverAt,
+			Quota:          QuotaState{Exceeded: true, NextRecoverAt: recoverAt},
+		}}}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+	if slots, available := manager.cooldownQueueSlots([]string{"cqpair"}, model); slots != 2 || available {
+		t.Fatalf("cooldownQueueSlots() = %d, %v; want 2 credentials recovering together", slots, available)
+	}
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := manager.Execute(context.Background(), []string{"cqpair"}, cliproxyexecutor.Request{Model: model}, queuedOptions())
+			errs <- err
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("queued Execute() error = %v", err)
+		}
+	}
+	stats := manager.CooldownQueueStats()
+	if len(stats) != 1 || stats[0].Enqueued != 2 || stats[0].Served != 2 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// EmbeddingExecutor is implemented by provider executors that can compute embeddings.
+type EmbeddingExecutor interface {
+	// Embed returns the embeddings for the request payload, translated back to opts.SourceFormat.
+	Embed(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error)
+}
+
+// ExecuteEmbed performs an embeddings request. Providers whose executor does not implement
+// EmbeddingExecutor are skipped; auth selection, retries and cooldowns follow ExecuteCount.
+func (m *Manager) ExecuteEmbed(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	supported := make([]string, 0, len(providers))
+	for _, provider := range m.normalizeProviders(providers) {
+		if _, ok := m.executorFor(provider).(EmbeddingExecutor); ok {
+			supported = append(supported, provider)
+		}
+	}
+	if len(supported) == 0 {
+		return cliproxyexecutor.Response{}, &Error{Code: "embeddings_unsupported", Message: "no provider for model " + req.Model + " supports embeddings", HTTPStatus: http.StatusBadRequest}
+	}
+	return m.executeUnary(ctx, supported, req, opts, embedCall)
+}
+
+func embedCall(ctx context.Context, executor ProviderExecutor, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	embedder, ok := executor.(EmbeddingExecutor)
+	if !ok {
+		return cliproxyexecutor.Response{}, &Error{Code: "embeddings_unsupported", Message: "provider " + executor.Identifier() + " does not support embeddings", HTTPStatus: http.StatusBadRequest}
+	}
+	return embedder.Embed(ctx, auth, req, opts)
+}
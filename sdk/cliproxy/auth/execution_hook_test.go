@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type hookTestExecutor struct {
+	mu      sync.Mutex
+	clients []*http.Client
+	models  []string
+	chunks  [][]byte
+}
+
+func (e *hookTestExecutor) Identifier() string { return "hooktest" }
+
+func (e *hookTestExecutor) record(ctx context.Context, req cliproxyexecutor.Request) {
+	e.mu.Lock()
+	e.clients = append(e.clients, cliproxyexecutor.HTTPClientFromContext(ctx))
+	e.models = append(e.models, req.Model)
+	e.mu.Unlock()
+}
+
+func (e *hookTestExecutor) Execute(ctx context.Context, _ *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.record(ctx, req)
+	return cliproxyexecutor.Response{Payload: []byte("ok")}, nil
+}
+
+func (e *hookTestExecutor) ExecuteStream(ctx context.Context, _ *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	e.record(ctx, req)
+	out := make(chan cliproxyexecutor.StreamChunk, len(e.chunks))
+	for _, chunk := range e.chunks {
+		out <- cliproxyexecutor.StreamChunk{Payload: chunk}
+	}
+	close(out)
+	return out, nil
+}
+
+func (e *hookTestExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) { return auth, nil }
+
+func (e *hookTestExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, errors.New("not implemented")
+}
+
+type recordingExecutionHook struct {
+	client  *http.Client
+	mu      sync.Mutex
+	authIDs []string
+	after   []string
+	chunks  int
+}
+
+func (h *recordingExecutionHook) BeforeAttempt(ctx context.Context, auth *Auth, req *cliproxyexecutor.Request, _ *cliproxyexecutor.Options) (context.Context, ExecutionObserver) {
+	h.mu.Lock()
+	h.authIDs = append(h.authIDs, auth.ID)
+	h.mu.Unlock()
+	req.Model = "rewritten"
+	return cliproxyexecutor.WithHTTPClient(ctx, h.client), h
+}
+
+func (h *recordingExecutionHook) AfterAttempt(_ context.Context, resp cliproxyexecutor.Response, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err != nil {
+		h.after = append(h.after, err.Error())
+		return
+	}
+	h.after = append(h.after, string(resp.Payload))
+}
+
+func (h *recordingExecutionHook) OnStreamChunk(context.Context, cliproxyexecutor.StreamChunk) {
+	h.mu.Lock()
+	h.chunks++
+	h.mu.Unlock()
+}
+
+func newHookTestManager(t *testing.T, exec *hookTestExecutor, hook ExecutionHook) *Manager {
+	t.Helper()
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(exec)
+	manager.SetExecutionHook(hook)
+	if _, err := manager.Register(context.Background(), &Auth{ID: "auth-1", Provider: "hooktest"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	return manager
+}
+
+func TestManagerExecute_InvokesExecutionHook(t *testing.T) {
+	exec := &hookTestExecutor{}
+	hook := &recordingExecutionHook{client: &http.Client{}}
+	manager := newHookTestManager(t, exec, hook)
+
+	resp, err := manager.Execute(context.Background(), []string{"hooktest"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if string(resp.Payload) != "ok" {
+		t.Fatalf("Execute() payload = %q, want %q", resp.Payload, "ok")
+	}
+	if len(hook.authIDs) != 1 || hook.authIDs[0] != "auth-1" {
+		t.Fatalf("hook auth IDs = %v, want [auth-1]", hook.authIDs)
+	}
+	if len(hook.after) != 1 || hook.after[0] != "ok" {
+		t.Fatalf("hook after = %v, want [ok]", hook.after)
+	}
+	if len(exec.models) != 1 || exec.models[0] != "rewritten" {
+		t.Fatalf("executor models = %v, want [rewritten]", exec.models)
+	}
+	if len(exec.clients) != 1 || exec.clients[0] != hook.client {
+		t.Fatalf("executor did not receive hook HTTP client")
+	}
+}
+
+func TestManagerExecuteStream_InvokesExecutionHookPerChunk(t *testing.T) {
+	exec := &hookTestExecutor{chunks: [][]byte{[]byte("a"), []byte("b"), []byte("c")}}
+	hook := &recordingExecutionHook{client: &http.Client{}}
+	manager := newHookTestManager(t, exec, hook)
+
+	chunks, err := manager.ExecuteStream(context.Background(), []string{"hooktest"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	count := 0
+	for range chunks {
+		count++
+	}
+	if count != 3 {
+		t.Fatalf("received %d chunks, want 3", count)
+	}
+
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	if hook.chunks != 3 {
+		t.Fatalf("hook observed %d chunks, want 3", hook.chunks)
+	}
+	if len(hook.after) != 1 {
+		t.Fatalf("hook after called %d times, want 1", len(hook.after))
+	}
+}
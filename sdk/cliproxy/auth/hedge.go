@@ -0,0 +1,272 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+const defaultHedgeDelay = 2 * time.Second
+
+// ErrHedgeCancelled is the cancellation cause of the losing attempt of a hedged request.
+var ErrHedgeCancelled = errors.New("hedged request lost to a faster attempt")
+
+// IsHedgeCancelled reports whether ctx was cancelled because a hedged sibling attempt won.
+func IsHedgeCancelled(ctx context.Context) bool {
+	return ctx != nil && errors.Is(context.Cause(ctx), ErrHedgeCancelled)
+}
+
+// SetHedgingConfig updates the hedged request settings.
+func (m *Manager) SetHedgingConfig(cfg internalconfig.HedgingConfig) {
+	if m == nil {
+		return
+	}
+	cfg.Models = append([]string(nil), cfg.Models...)
+	m.hedging.Store(&cfg)
+}
+
+// hedgeDelay returns the delay before a hedge is sent for model, or false when the model is
+// not hedged.
+func (m *Manager) hedgeDelay(model string) (time.Duration, bool) {
+	cfg, _ := m.hedging.Load().(*internalconfig.HedgingConfig)
+	if cfg == nil || !cfg.AppliesTo(model) {
+		return 0, false
+	}
+	if cfg.DelayMS <= 0 {
+		return defaultHedgeDelay, true
+	}
+	return time.Duration(cfg.DelayMS) * time.Millisecond, true
+}
+
+// hedgeGroup tracks the credentials in use by the attempts of one hedged request so the
+// hedge never reuses the credential the primary attempt is waiting on.
+type hedgeGroup struct {
+	mu    sync.Mutex
+	inUse map[string]struct{}
+}
+
+type hedgeGroupKey struct{}
+
+func withHedgeGroup(ctx context.Context, group *hedgeGroup) context.Context {
+	return context.WithValue(ctx, hedgeGroupKey{}, group)
+}
+
+func hedgeGroupFrom(ctx context.Context) *hedgeGroup {
+	if ctx == nil {
+		return nil
+	}
+	group, _ := ctx.Value(hedgeGroupKey{}).(*hedgeGroup)
+	return group
+}
+
+func (g *hedgeGroup) claim(authID string) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	if g.inUse == nil {
+		g.inUse = make(map[string]struct{})
+	}
+	g.inUse[authID] = struct{}{}
+	g.mu.Unlock()
+}
+
+// exclude adds the credentials claimed by sibling attempts to tried.
+func (g *hedgeGroup) exclude(tried map[string]struct{}) map[string]struct{} {
+	if g == nil {
+		return tried
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.inUse) == 0 {
+		return tried
+	}
+	out := make(map[string]struct{}, len(tried)+len(g.inUse))
+	for id := range tried {
+		out[id] = struct{}{}
+	}
+	for id := range g.inUse {
+		out[id] = struct{}{}
+	}
+	return out
+}
+
+// hedgeProviders returns the provider order used by the hedge: the next provider of the
+// rotation goes first so a second provider is preferred when one exists.
+func hedgeProviders(providers []string) []string {
+	if len(providers) < 2 {
+		return providers
+	}
+	out := make([]string, 0, len(providers))
+	out = append(out, providers[1:]...)
+	return append(out, providers[0])
+}
+
+type hedgeOutcome[T any] struct {
+	index  int
+	value  T
+	err    error
+	cancel context.CancelCauseFunc
+}
+
+// runHedged starts attempt for the primary provider order and, when it has not finished
+// within delay, a second attempt with hedgeProviders. The first successful attempt wins and
+// the other is cancelled with ErrHedgeCancelled; discard releases the loser's result. The
+// returned release func cancels the winner's context once its result is no longer used.
+func runHedged[T any](ctx context.Context, providers []string, delay time.Duration,
+	attempt func(context.Context, []string) (T, error), discard func(T)) (T, context.CancelFunc, error) {
+	group := &hedgeGroup{}
+	results := make(chan hedgeOutcome[T], 2)
+	cancels := make([]context.CancelCauseFunc, 0, 2)
+	launch := func(index int, order []string) {
+		attemptCtx, cancel := context.WithCancelCause(withHedgeGroup(ctx, group))
+		cancels = append(cancels, cancel)
+		go func() {
+			value, err := attempt(attemptCtx, order)
+			results <- hedgeOutcome[T]{index: index, value: value, err: err, cancel: cancel}
+		}()
+	}
+	// settle drains the remaining attempts in the background, discarding late winners.
+	settle := func(pending int) {
+		if pending == 0 {
+			return
+		}
+		go func() {
+			for i := 0; i < pending; i++ {
+				outcome := <-results
+				if outcome.err == nil && discard != nil {
+					discard(outcome.value)
+				}
+				outcome.cancel(ErrHedgeCancelled)
+			}
+		}()
+	}
+
+	launch(0, providers)
+	pending := 1
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	var firstErr error
+	for {
+		select {
+		case <-ctx.Done():
+			for _, cancel := range cancels {
+				cancel(ctx.Err())
+			}
+			settle(pending)
+			var zero T
+			return zero, nil, ctx.Err()
+		case <-timer.C:
+			if len(cancels) == 1 && pending == 1 {
+				logEntryWithRequestID(ctx).Debugf("no first byte after %s, sending hedged request", delay)
+				launch(1, hedgeProviders(providers))
+				pending++
+			}
+		case outcome := <-results:
+			pending--
+			if outcome.err != nil {
+				if firstErr == nil || outcome.index == 0 {
+					firstErr = outcome.err
+				}
+				outcome.cancel(nil)
+				if pending == 0 {
+					var zero T
+					return zero, nil, firstErr
+				}
+				continue
+			}
+			for i, cancel := range cancels {
+				if i != outcome.index {
+					cancel(ErrHedgeCancelled)
+				}
+			}
+			settle(pending)
+			return outcome.value, func() { outcome.cancel(nil) }, nil
+		}
+	}
+}
+
+// executeHedged runs a non-streaming request with hedging; the first complete response wins.
+func (m *Manager) executeHedged(ctx context.Context, providers []string, delay time.Duration, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	resp, release, err := runHedged(ctx, providers, delay, func(attemptCtx context.Context, order []string) (cliproxyexecutor.Response, error) {
+		return m.executeProvidersOnce(attemptCtx, order, func(execCtx context.Context, provider string) (cliproxyexecutor.Response, error) {
+			return m.executeWithProvider(execCtx, provider, req, opts)
+		})
+	}, nil)
+	if release != nil {
+		release()
+	}
+	return resp, err
+}
+
+// firstByteStream is a stream whose first chunk has already been received.
+type firstByteStream struct {
+	first    cliproxyexecutor.StreamChunk
+	hasFirst bool
+	chunks   <-chan cliproxyexecutor.StreamChunk
+}
+
+// executeStreamHedged runs a streaming request with hedging; the attempt that delivers the
+// first chunk wins and is forwarded to the caller.
+func (m *Manager) executeStreamHedged(ctx context.Context, providers []string, delay time.Duration, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	winner, release, err := runHedged(ctx, providers, delay, func(attemptCtx context.Context, order []string) (firstByteStream, error) {
+		chunks, errStream := m.executeStreamProvidersOnce(attemptCtx, order, func(execCtx context.Context, provider string) (<-chan cliproxyexecutor.StreamChunk, error) {
+			return m.executeStreamWithProvider(execCtx, provider, req, opts)
+		})
+		if errStream != nil {
+			return firstByteStream{}, errStream
+		}
+		first, ok := <-chunks
+		if !ok {
+			return firstByteStream{chunks: chunks}, nil
+		}
+		if first.Err != nil {
+			drainStream(chunks)
+			return firstByteStream{}, first.Err
+		}
+		return firstByteStream{first: first, hasFirst: true, chunks: chunks}, nil
+	}, func(loser firstByteStream) { drainStream(loser.chunks) })
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer release()
+		defer close(out)
+		if !winner.hasFirst {
+			return
+		}
+		pending := []cliproxyexecutor.StreamChunk{winner.first}
+		for {
+			if len(pending) == 0 {
+				chunk, ok := <-winner.chunks
+				if !ok {
+					return
+				}
+				pending = append(pending, chunk)
+			}
+			select {
+			case <-ctx.Done():
+				drainStream(winner.chunks)
+				return
+			case out <- pending[0]:
+				pending = pending[1:]
+			}
+		}
+	}()
+	return out, nil
+}
+
+func drainStream(chunks <-chan cliproxyexecutor.StreamChunk) {
+	if chunks == nil {
+		return
+	}
+	go func() {
+		for range chunks {
+		}
+	}()
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// hedgeTestExecutor stalls requests on slowID until they are cancelled and answers every
+// other credential immediately.
+type hedgeTestExecutor struct {
+	slowID    string
+	cancelled chan bool
+}
+
+func (e *hedgeTestExecutor) Identifier() string { return "hedgeprov" }
+
+func (e *hedgeTestExecutor) Execute(ctx context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if auth.ID == e.slowID {
+		<-ctx.Done()
+		e.cancelled <- IsHedgeCancelled(ctx)
+		return cliproxyexecutor.Response{}, ctx.Err()
+	}
+	return cliproxyexecutor.Response{Payload: []byte(auth.ID)}, nil
+}
+
+func (e *hedgeTestExecutor) ExecuteStream(ctx context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	out := make(chan cliproxyexecutor.StreamChunk, 1)
+	go func() {
+		defer close(out)
+		if auth.ID == e.slowID {
+			<-ctx.Done()
+			e.cancelled <- IsHedgeCancelled(ctx)
+			out <- cliproxyexecutor.StreamChunk{Err: ctx.Err()}
+			return
+		}
+		out <- cliproxyexecutor.StreamChunk{Payload: []byte(auth.ID)}
+	}()
+	return out, nil
+}
+
+func (e *hedgeTestExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	return auth, nil
+}
+
+func (e *hedgeTestExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, errors.New("not implemented")
+}
+
+func newHedgeTestManager(t *testing.T) (*Manager, *hedgeTestExecutor) {
+	t.Helper()
+	executor := &hedgeTestExecutor{slowID: "hedge-a-slow", cancelled: make(chan bool, 4)}
+	reg := registry.GetGlobalRegistry()
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	for _, id := range []string{"hedge-a-slow", "hedge-b-fast"} {
+		reg.RegisterClient(id, "hedgeprov", []*registry.ModelInfo{{ID: "hedge-model"}})
+		if _, err := manager.Register(context.Background(), &Auth{ID: id, Provider: "hedgeprov"}); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		reg.UnregisterClient("hedge-a-slow")
+		reg.UnregisterClient("hedge-b-fast")
+	})
+	manager.SetHedgingConfig(internalconfig.HedgingConfig{Enable: true, DelayMS: 20, Models: []string{"hedge-*"}})
+	return manager, executor
+}
+
+func assertHedgeLoserCancelled(t *testing.T, manager *Manager, executor *hedgeTestExecutor) {
+	t.Helper()
+	select {
+	case hedged := <-executor.cancelled:
+		if !hedged {
+			t.Fatalf("loser context was not cancelled with ErrHedgeCancelled")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("slow attempt was never cancelled")
+	}
+	// MarkResult for the loser would run right after the executor returns.
+	time.Sleep(20 * time.Millisecond)
+	slow, _ := manager.GetByID("hedge-a-slow")
+	if slow == nil || slow.LastError != nil || slow.Unavailable {
+		t.Fatalf("cancelled hedge attempt must not be recorded as a failure: %+v", slow)
+	}
+}
+
+func TestManagerExecute_HedgeWinsAndCancelsLoser(t *testing.T) {
+	manager, executor := newHedgeTestManager(t)
+	resp, err := manager.Execute(context.Background(), []string{"hedgeprov"}, cliproxyexecutor.Request{Model: "hedge-model"}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if string(resp.Payload) != "hedge-b-fast" {
+		t.Fatalf("payload = %q, want response from hedged credential", resp.Payload)
+	}
+	assertHedgeLoserCancelled(t, manager, executor)
+}
+
+func TestManagerExecuteStream_HedgeWinsAndCancelsLoser(t *testing.T) {
+	manager, executor := newHedgeTestManager(t)
+	chunks, err := manager.ExecuteStream(context.Background(), []string{"hedgeprov"}, cliproxyexecutor.Request{Model: "hedge-model"}, cliproxyexecutor.Options{Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	var got []string
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error = %v", chunk.Err)
+		}
+		got = append(got, string(chunk.Payload))
+	}
+	if len(got) != 1 || got[0] != "hedge-b-fast" {
+		t.Fatalf("chunks = %v, want the hedged credential's stream", got)
+	}
+	assertHedgeLoserCancelled(t, manager, executor)
+}
+
+func TestHedgingConfigAppliesTo(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	if _, ok := manager.hedgeDelay("any"); ok {
+		t.Fatalf("hedging must be off by default")
+	}
+	manager.SetHedgingConfig(internalconfig.HedgingConfig{Enable: true, Models: []string{"gpt-*"}})
+	if delay, ok := manager.hedgeDelay("gpt-5"); !ok || delay != defaultHedgeDelay {
+		t.Fatalf("hedgeDelay(gpt-5) = %v, %v", delay, ok)
+	}
+	if _, ok := manager.hedgeDelay("claude-x"); ok {
+		t.Fatalf("unmatched model must not be hedged")
+	}
+}
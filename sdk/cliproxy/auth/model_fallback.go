@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+type modelFallbackTable struct {
+	// chains maps a lower-cased model name to its ordered fallback models.
+	chains map[string][]string
+	// threshold is the cooldown wait beyond which fallbacks are tried instead of waiting.
+	// Zero means the manager's max retry interval.
+	threshold time.Duration
+}
+
+// SetModelFallbacks updates the cross-model fallback chains tried when every credential for
+// a requested model is cooling down for longer than the configured threshold.
+func (m *Manager) SetModelFallbacks(cfg internalconfig.QuotaExceeded) {
+	if m == nil {
+		return
+	}
+	table := &modelFallbackTable{}
+	if cfg.FallbackAfterSeconds > 0 {
+		table.threshold = time.Duration(cfg.FallbackAfterSeconds) * time.Second
+	}
+	for _, entry := range cfg.ModelFallbacks {
+		model := strings.ToLower(strings.TrimSpace(entry.Model))
+		if model == "" {
+			continue
+		}
+		chain := make([]string, 0, len(entry.Fallbacks))
+		for _, fallback := range entry.Fallbacks {
+			fallback = strings.TrimSpace(fallback)
+			if fallback == "" || strings.EqualFold(fallback, model) {
+				continue
+			}
+			chain = append(chain, fallback)
+		}
+		if len(chain) == 0 {
+			continue
+		}
+		if table.chains == nil {
+			table.chains = make(map[string][]string)
+		}
+		table.chains[model] = chain
+	}
+	m.modelFallbacks.Store(table)
+}
+
+func (m *Manager) fallbackChain(model string) ([]string, time.Duration) {
+	table, _ := m.modelFallbacks.Load().(*modelFallbackTable)
+	if table == nil || len(table.chains) == 0 {
+		return nil, 0
+	}
+	threshold := table.threshold
+	if threshold <= 0 {
+		// Cooldowns within the retry interval are waited out, so only fall back beyond it.
+		_, threshold = m.retrySettings()
+	}
+	return table.chains[strings.ToLower(strings.TrimSpace(model))], threshold
+}
+
+// shouldFallback reports whether a failed request for model should move on to its fallback
+// chain: the failure must not be caused by the request itself, and the nearest credential
+// cooldown must be further away than the fallback threshold.
+func (m *Manager) shouldFallback(err error, providers []string, model string) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	chain, threshold := m.fallbackChain(model)
+	if len(chain) == 0 {
+		return false
+	}
+	switch status := statusCodeFromError(err); status {
+	case http.StatusUnauthorized, http.StatusPaymentRequired, http.StatusForbidden,
+		http.StatusRequestTimeout, http.StatusTooManyRequests:
+	default:
+		if status >= http.StatusBadRequest && status < http.StatusInternalServerError {
+			return false
+		}
+	}
+	wait, found := m.closestCooldownWait(providers, model)
+	return found && wait > threshold
+}
+
+// ModelRouteHooks lets the caller constrain and observe model fallback for one request.
+type ModelRouteHooks struct {
+	// FilterProviders returns the providers a fallback model may be routed to. Returning
+	// none skips the model. When nil every provider serving the model is allowed.
+	FilterProviders func(model string, providers []string) []string
+	// Served receives the model that produced the response, before any payload is returned.
+	Served func(model string)
+}
+
+type modelRouteHooksKey struct{}
+
+// WithModelRouteHooks attaches hooks consulted while routing the request carried by ctx.
+func WithModelRouteHooks(ctx context.Context, hooks ModelRouteHooks) context.Context {
+	return context.WithValue(ctx, modelRouteHooksKey{}, hooks)
+}
+
+func modelRouteHooksFrom(ctx context.Context) ModelRouteHooks {
+	if ctx == nil {
+		return ModelRouteHooks{}
+	}
+	hooks, _ := ctx.Value(modelRouteHooksKey{}).(ModelRouteHooks)
+	return hooks
+}
+
+// executeWithFallback runs run for the requested model and, when that model is exhausted,
+// for each model of its fallback chain in order. Fallback models may belong to another
+// provider family; executors translate the original request from opts.SourceFormat again.
+// Requests that opted into the cooldown queue wait there when every route is cooling down.
+func executeWithFallback[T any](ctx context.Context, m *Manager, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options,
+	run func(context.Context, []string, cliproxyexecutor.Request, cliproxyexecutor.Options) (T, error)) (T, error) {
+	hooks := modelRouteHooksFrom(ctx)
+	result, err := run(ctx, providers, req, opts)
+	if err == nil {
+		if hooks.Served != nil {
+			hooks.Served(req.Model)
+		}
+		return result, nil
+	}
+	normalized := m.normalizeProviders(providers)
+	if !m.shouldFallback(err, normalized, req.Model) {
+		if m.shouldQueue(err, opts, normalized, req.Model) {
+			return executeQueued(ctx, m, providers, req, opts, run, result, err)
+		}
+		return result, err
+	}
+	chain, _ := m.fallbackChain(req.Model)
+	entry := logEntryWithRequestID(ctx)
+	for _, model := range chain {
+		if ctx.Err() != nil {
+			return result, err
+		}
+		fallbackReq, fallbackOpts := fallbackRequest(req, opts, model)
+		fallbackProviders := util.GetProviderName(fallbackReq.Model)
+		if hooks.FilterProviders != nil {
+			fallbackProviders = hooks.FilterProviders(model, fallbackProviders)
+		}
+		if len(fallbackProviders) == 0 {
+			continue
+		}
+		entry.Infof("model %s is cooling down, falling back to %s", req.Model, model)
+		fallbackResult, errFallback := run(ctx, fallbackProviders, fallbackReq, fallbackOpts)
+		if errFallback == nil {
+			if hooks.Served != nil {
+				hooks.Served(model)
+			}
+			return fallbackResult, nil
+		}
+		entry.Debugf("fallback model %s failed: %v", model, errFallback)
+	}
+	if m.shouldQueue(err, opts, normalized, req.Model) {
+		return executeQueued(ctx, m, providers, req, opts, run, result, err)
+	}
+	return result, err
+}
+
+// modelDerivedMetadataKeys lists the request metadata derived from the requested model name:
+// the original-model keys executors resolve upstream models from, and the thinking settings
+// parsed from a model suffix. They are recomputed for every fallback model.
+var modelDerivedMetadataKeys = []string{
+	util.ModelMappingOriginalModelMetadataKey,
+	util.ThinkingOriginalModelMetadataKey,
+	util.GeminiOriginalModelMetadataKey,
+	util.ThinkingBudgetMetadataKey,
+	util.ThinkingIncludeThoughtsMetadataKey,
+	util.ReasoningEffortMetadataKey,
+	util.GeminiThinkingBudgetMetadataKey,
+	util.GeminiIncludeThoughtsMetadataKey,
+}
+
+// fallbackRequest retargets a request at model, rewriting the model field of payloads that
+// carry one so translators and upstreams see the fallback model. Metadata derived from the
+// primary model name is dropped and rebuilt from the fallback model, whose thinking suffix
+// (e.g. "gemini-2.5-pro(8192)") is honoured like on an inbound request.
+func fallbackRequest(req cliproxyexecutor.Request, opts cliproxyexecutor.Options, model string) (cliproxyexecutor.Request, cliproxyexecutor.Options) {
+	baseModel, thinking := util.NormalizeThinkingModel(model)
+	req.Model = baseModel
+	req.Payload = rewritePayloadModel(req.Payload, baseModel)
+	opts.OriginalRequest = rewritePayloadModel(opts.OriginalRequest, baseModel)
+	metadata := make(map[string]any, len(req.Metadata)+len(thinking))
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+	for _, key := range modelDerivedMetadataKeys {
+		delete(metadata, key)
+	}
+	for k, v := range thinking {
+		metadata[k] = v
+	}
+	if len(metadata) == 0 {
+		metadata = nil
+	}
+	req.Metadata = metadata
+	return req, opts
+}
+
+func rewritePayloadModel(payload []byte, model string) []byte {
+	if len(payload) == 0 || gjson.GetBytes(payload, "model").Type != gjson.String {
+		return payload
+	}
+	out, err := sjson.SetBytes(payload, "model", model)
+	if err != nil {
+		return payload
+	}
+	return out
+}
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+type quotaError struct{ retryAfter time.Duration }
+
+func (e *quotaError) Error() string              { return "quota exhausted" }
+func (e *quotaError) StatusCode() int            { return http.StatusTooManyRequests }
+func (e *quotaError) RetryAfter() *time.Duration { return &e.retryAfter }
+
+type fallbackTestExecutor struct {
+	provider string
+	fail     bool
+
+	mu       sync.Mutex
+	models   []string
+	payloads [][]byte
+	metadata []map[string]any
+}
+
+func (e *fallbackTestExecutor) Identifier() string { return e.provider }
+
+func (e *fallbackTestExecutor) Execute(_ context.Context, _ *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.mu.Lock()
+	e.models = append(e.models, req.Model)
+	e.payloads = append(e.payloads, req.Payload)
+	e.metadata = append(e.metadata, req.Metadata)
+	e.mu.Unlock()
+	if e.fail {
+		return cliproxyexecutor.Response{}, &quotaError{retryAfter: 10 * time.Minute}
+	}
+	return cliproxyexecutor.Response{Payload: []byte(e.provider)}, nil
+}
+
+func (e *fallbackTestExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *fallbackTestExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	return auth, nil
+}
+
+func (e *fallbackTestExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, errors.New("not implemented")
+}
+
+func TestManagerExecute_FallsBackToNextModelWhenExhausted(t *testing.T) {
+	primary := &fallbackTestExecutor{provider: "fbprimary", fail: true}
+	secondary := &fallbackTestExecutor{provider: "fbsecondary"}
+
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("fb-primary-auth", "fbprimary", []*registry.ModelInfo{{ID: "fb-model-a"}})
+	reg.RegisterClient("fb-secondary-auth", "fbsecondary", []*registry.ModelInfo{{ID: "fb-model-b"}})
+	t.Cleanup(func() {
+		reg.UnregisterClient("fb-primary-auth")
+		reg.UnregisterClient("fb-secondary-auth")
+	})
+
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(primary)
+	manager.RegisterExecutor(secondary)
+	for _, auth := range []*Auth{
+		{ID: "fb-primary-auth", Provider: "fbprimary"},
+		{ID: "fb-secondary-auth", Provider: "fbsecondary"},
+	} {
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+	manager.SetModelFallbacks(internalconfig.QuotaExceeded{
+		FallbackAfterSeconds: 60,
+		ModelFallbacks:       []internalconfig.ModelFallback{{Model: "fb-model-a", Fallbacks: []string{"fb-model-b"}}},
+	})
+
+	var served string
+	ctx := WithModelRouteHooks(context.Background(), ModelRouteHooks{Served: func(model string) { served = model }})
+	req := cliproxyexecutor.Request{Model: "fb-model-a", Payload: []byte(`{"model":"fb-model-a","messages":[]}`)}
+	resp, err := manager.Execute(ctx, []string{"fbprimary"}, req, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if string(resp.Payload) != "fbsecondary" {
+		t.Fatalf("payload = %q, want response from fallback provider", resp.Payload)
+	}
+	if served != "fb-model-b" {
+		t.Fatalf("served model = %q, want fb-model-b", served)
+	}
+	if len(secondary.models) != 1 || secondary.models[0] != "fb-model-b" {
+		t.Fatalf("fallback executor models = %v", secondary.models)
+	}
+	if got := gjson.GetBytes(secondary.payloads[0], "model").String(); got != "fb-model-b" {
+		t.Fatalf("fallback payload model = %q", got)
+	}
+
+	// Without a chain the cooldown error is returned as-is.
+	manager.SetModelFallbacks(internalconfig.QuotaExceeded{})
+	if _, err = manager.Execute(context.Background(), []string{"fbprimary"}, req, cliproxyexecutor.Options{}); err == nil {
+		t.Fatalf("expected cooldown error without fallback chain")
+	}
+}
+
+func TestManagerExecute_FallbackRebuildsModelMetadata(t *testing.T) {
+	primary := &fallbackTestExecutor{provider: "fbtprimary", fail: true}
+	secondary := &fallbackTestExecutor{provider: "fbtsecondary"}
+
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("fbt-primary-auth", "fbtprimary", []*registry.ModelInfo{{ID: "fbt-model-a"}})
+	reg.RegisterClient("fbt-secondary-auth", "fbtsecondary", []*registry.ModelInfo{{ID: "fbt-model-b"}})
+	t.Cleanup(func() {
+		reg.UnregisterClient("fbt-primary-auth")
+		reg.UnregisterClient("fbt-secondary-auth")
+	})
+
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(primary)
+	manager.RegisterExecutor(secondary)
+	for _, auth := range []*Auth{
+		{ID: "fbt-primary-auth", Provider: "fbtprimary"},
+		{ID: "fbt-secondary-auth", Provider: "fbtsecondary"},
+	} {
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+	manager.SetModelFallbacks(internalconfig.QuotaExceeded{
+		FallbackAfterSeconds: 60,
+		ModelFallbacks:       []internalconfig.ModelFallback{{Model: "fbt-model-a", Fallbacks: []string{"fbt-model-b(1024)"}}},
+	})
+
+	model, metadata := util.NormalizeThinkingModel("fbt-model-a(high)")
+	metadata[util.GeminiOriginalModelMetadataKey] = "fbt-model-a(high)"
+	req := cliproxyexecutor.Request{Model: model, Payload: []byte(`{"model":"fbt-model-a"}`), Metadata: metadata}
+	if _, err := manager.Execute(context.Background(), []string{"fbtprimary"}, req, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if len(secondary.models) != 1 || secondary.models[0] != "fbt-model-b" {
+		t.Fatalf("fallback executor models = %v", secondary.models)
+	}
+	seen := secondary.metadata[0]
+	if got := util.ResolveOriginalModel(secondary.models[0], seen); got != "fbt-model-b" {
+		t.Fatalf("fallback original model = %q, want fbt-model-b", got)
+	}
+	if _, ok := seen[util.ReasoningEffortMetadataKey]; ok {
+		t.Fatalf("primary reasoning effort leaked into fallback metadata: %v", seen)
+	}
+	if budget, ok := seen[util.ThinkingBudgetMetadataKey].(int); !ok || budget != 1024 {
+		t.Fatalf("fallback thinking budget = %v, want 1024 from the fallback suffix", seen[util.ThinkingBudgetMetadataKey])
+	}
+	if got := gjson.GetBytes(secondary.payloads[0], "model").String(); got != "fbt-model-b" {
+		t.Fatalf("fallback payload model = %q", got)
+	}
+	if len(primary.metadata) == 0 || primary.metadata[0][util.ReasoningEffortMetadataKey] != "high" {
+		t.Fatalf("primary metadata was modified: %v", primary.metadata)
+	}
+}
+
+func TestFallbackChain_ThresholdDefaultsToMaxRetryInterval(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	manager.SetRetryConfig(1, 30*time.Second)
+	chains := []internalconfig.ModelFallback{{Model: "fb-model-a", Fallbacks: []string{"fb-model-b"}}}
+
+	manager.SetModelFallbacks(internalconfig.QuotaExceeded{ModelFallbacks: chains})
+	if _, threshold := manager.fallbackChain("fb-model-a"); threshold != 30*time.Second {
+		t.Fatalf("threshold = %v, want the max retry interval", threshold)
+	}
+	manager.SetModelFallbacks(internalconfig.QuotaExceeded{FallbackAfterSeconds: 5, ModelFallbacks: chains})
+	if _, threshold := manager.fallbackChain("fb-model-a"); threshold != 5*time.Second {
+		t.Fatalf("threshold = %v, want fallback-after-seconds", threshold)
+	}
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// RuntimeEventType identifies the kind of a RuntimeEvent.
+type RuntimeEventType string
+
+const (
+	// RuntimeEventAuthRegistered is published after Register.
+	RuntimeEventAuthRegistered RuntimeEventType = "auth_registered"
+	// RuntimeEventAuthUpdated is published after Update.
+	RuntimeEventAuthUpdated RuntimeEventType = "auth_updated"
+	// RuntimeEventResult is published after MarkResult recorded an attempt outcome.
+	RuntimeEventResult RuntimeEventType = "result"
+)
+
+// RuntimeEvent reports a change to the runtime state of one auth. It mirrors the Hook
+// callbacks for consumers that subscribe at runtime instead of being wired in at construction.
+type RuntimeEvent struct {
+	Type   RuntimeEventType
+	AuthID string
+	Time   time.Time
+	// Result is set for RuntimeEventResult.
+	Result *Result
+}
+
+// runtimeEventBus delivers events to subscribers without ever blocking the publisher; a
+// subscriber that falls behind loses events rather than stalling request handling.
+type runtimeEventBus struct {
+	mu   sync.Mutex
+	next int
+	subs map[int]chan RuntimeEvent
+}
+
+// SubscribeRuntimeEvents registers a subscriber for runtime events. The returned cancel func
+// unsubscribes and closes the channel.
+func (m *Manager) SubscribeRuntimeEvents(buffer int) (<-chan RuntimeEvent, func()) {
+	if buffer <= 0 {
+		buffer = 64
+	}
+	ch := make(chan RuntimeEvent, buffer)
+	bus := &m.runtimeEvents
+	bus.mu.Lock()
+	if bus.subs == nil {
+		bus.subs = make(map[int]chan RuntimeEvent)
+	}
+	id := bus.next
+	bus.next++
+	bus.subs[id] = ch
+	bus.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			bus.mu.Lock()
+			delete(bus.subs, id)
+			bus.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (b *runtimeEventBus) publish(event RuntimeEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, ch := range b.subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRuntimeEventsFollowRegisterAndResult(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	events, cancel := manager.SubscribeRuntimeEvents(8)
+
+	if _, err := manager.Register(context.Background(), &Auth{ID: "rt-a", Provider: "test"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	manager.MarkResult(context.Background(), Result{AuthID: "rt-a", Provider: "test", Model: "m", Success: true})
+
+	first := <-events
+	if first.Type != RuntimeEventAuthRegistered || first.AuthID != "rt-a" {
+		t.Fatalf("first event = %+v", first)
+	}
+	second := <-events
+	if second.Type != RuntimeEventResult || second.Result == nil || !second.Result.Success {
+		t.Fatalf("second event = %+v", second)
+	}
+	auth, _ := manager.GetByID("rt-a")
+	if auth.LastSuccessAt.IsZero() || time.Since(auth.LastSuccessAt) > time.Minute {
+		t.Fatalf("LastSuccessAt = %v, want set by the successful result", auth.LastSuccessAt)
+	}
+
+	// Update with a fresh auth keeps the success timestamp.
+	if _, err := manager.Update(context.Background(), &Auth{ID: "rt-a", Provider: "test"}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if updated, _ := manager.GetByID("rt-a"); !updated.LastSuccessAt.Equal(auth.LastSuccessAt) {
+		t.Fatalf("Update() dropped LastSuccessAt")
+	}
+
+	cancel()
+	for range events {
+		// Drain the buffered update event; the loop ends once cancel closed the channel.
+	}
+	// Publishing after unsubscribe must not panic.
+	manager.MarkResult(context.Background(), Result{AuthID: "rt-a", Provider: "test", Model: "m", Success: true})
+}
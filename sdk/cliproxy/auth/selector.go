@@ -112,69 +112,17 @@ func (e *modelCooldownError) Headers() http.Header {
 func (s *RoundRobinSelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
 	_ = ctx
 	_ = opts
-	if len(auths) == 0 {
-		return nil, &Error{Code: "auth_not_found", Message: "no auth candidates"}
-	}
-	if s.cursors == nil {
-		s.cursors = make(map[string]int)
-	}
-
-	now := time.Now()
-	cooldownCount := 0
-	var earliest time.Time
-
-	// Group auths by priority and filter out blocked ones
-	priorityGroups := make(map[int][]*Auth)
-	for i := 0; i < len(auths); i++ {
-		candidate := auths[i]
-		blocked, reason, next := isAuthBlockedForModel(candidate, model, now)
-		log.Debugf("[Selector] Auth %s (provider=%s, disabled=%v, status=%s): blocked=%v, reason=%d, next=%v",
-			candidate.ID, candidate.Provider, candidate.Disabled, candidate.Status, blocked, reason, next)
-		if !blocked {
-			priority := candidate.Priority
-			priorityGroups[priority] = append(priorityGroups[priority], candidate)
-			continue
-		}
-		if reason == blockReasonCooldown {
-			cooldownCount++
-			if !next.IsZero() && (earliest.IsZero() || next.Before(earliest)) {
-				earliest = next
-			}
-		}
-	}
-
-	log.Debugf("[Selector] Provider=%s, Model=%s, Total auths=%d, Priority groups=%d, Cooldown count=%d",
-		provider, model, len(auths), len(priorityGroups), cooldownCount)
-
-	if len(priorityGroups) == 0 {
-		if cooldownCount == len(auths) && !earliest.IsZero() {
-			resetIn := earliest.Sub(now)
-			if resetIn < 0 {
-				resetIn = 0
-			}
-			return nil, newModelCooldownError(model, provider, resetIn)
-		}
-		return nil, &Error{Code: "auth_unavailable", Message: "no auth available"}
-	}
-
-	// Find the highest priority level with available auths
-	priorities := make([]int, 0, len(priorityGroups))
-	for priority := range priorityGroups {
-		priorities = append(priorities, priority)
-	}
-	sort.Sort(sort.Reverse(sort.IntSlice(priorities)))
-
-	highestPriority := priorities[0]
-	available := priorityGroups[highestPriority]
-
-	// Make round-robin deterministic even if caller's candidate order is unstable.
-	if len(available) > 1 {
-		sort.Slice(available, func(i, j int) bool { return available[i].ID < available[j].ID })
+	highestPriority, available, err := availableAuthsByPriority(provider, model, auths, time.Now())
+	if err != nil {
+		return nil, err
 	}
 
 	// Use separate cursor for each priority level to maintain independent round-robin
 	key := fmt.Sprintf("%s:%s:p%d", provider, model, highestPriority)
 	s.mu.Lock()
+	if s.cursors == nil {
+		s.cursors = make(map[string]int)
+	}
 	index := s.cursors[key]
 
 	if index >= 2_147_483_640 {
@@ -193,11 +141,22 @@ func (s *RoundRobinSelector) Pick(ctx context.Context, provider, model string, o
 func (s *FillFirstSelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
 	_ = ctx
 	_ = opts
-	if len(auths) == 0 {
-		return nil, &Error{Code: "auth_not_found", Message: "no auth candidates"}
+	_, available, err := availableAuthsByPriority(provider, model, auths, time.Now())
+	if err != nil {
+		return nil, err
 	}
 
-	now := time.Now()
+	// Always return the first available auth
+	return available[0], nil
+}
+
+// availableAuthsByPriority filters out blocked auths and returns the highest priority level
+// that still has candidates together with those candidates sorted by ID. When nothing is
+// available it returns a cooldown error if every auth is cooling down.
+func availableAuthsByPriority(provider, model string, auths []*Auth, now time.Time) (int, []*Auth, error) {
+	if len(auths) == 0 {
+		return 0, nil, &Error{Code: "auth_not_found", Message: "no auth candidates"}
+	}
 	cooldownCount := 0
 	var earliest time.Time
 
@@ -206,6 +165,8 @@ func (s *FillFirstSelector) Pick(ctx context.Context, provider, model string, op
 	for i := 0; i < len(auths); i++ {
 		candidate := auths[i]
 		blocked, reason, next := isAuthBlockedForModel(candidate, model, now)
+		log.Debugf("[Selector] Auth %s (provider=%s, disabled=%v, status=%s): blocked=%v, reason=%d, next=%v",
+			candidate.ID, candidate.Provider, candidate.Disabled, candidate.Status, blocked, reason, next)
 		if !blocked {
 			priority := candidate.Priority
 			priorityGroups[priority] = append(priorityGroups[priority], candidate)
@@ -219,15 +180,18 @@ func (s *FillFirstSelector) Pick(ctx context.Context, provider, model string, op
 		}
 	}
 
+	log.Debugf("[Selector] Provider=%s, Model=%s, Total auths=%d, Priority groups=%d, Cooldown count=%d",
+		provider, model, len(auths), len(priorityGroups), cooldownCount)
+
 	if len(priorityGroups) == 0 {
 		if cooldownCount == len(auths) && !earliest.IsZero() {
 			resetIn := earliest.Sub(now)
 			if resetIn < 0 {
 				resetIn = 0
 			}
-			return nil, newModelCooldownError(model, provider, resetIn)
+			return 0, nil, newModelCooldownError(model, provider, resetIn)
 		}
-		return nil, &Error{Code: "auth_unavailable", Message: "no auth available"}
+		return 0, nil, &Error{Code: "auth_unavailable", Message: "no auth available"}
 	}
 
 	// Find the highest priority level with available auths
@@ -240,13 +204,11 @@ func (s *FillFirstSelector) Pick(ctx context.Context, provider, model string, op
 	highestPriority := priorities[0]
 	available := priorityGroups[highestPriority]
 
-	// Sort by ID for deterministic selection
+	// Sort by ID so selection is deterministic even if the caller's candidate order is unstable.
 	if len(available) > 1 {
 		sort.Slice(available, func(i, j int) bool { return available[i].ID < available[j].ID })
 	}
-
-	// Always return the first available auth
-	return available[0], nil
+	return highestPriority, available, nil
 }
 
 func isAuthBlockedForModel(auth *Auth, model string, now time.Time) (bool, blockReason, time.Time) {
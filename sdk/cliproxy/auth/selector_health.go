@@ -0,0 +1,311 @@
+package auth
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+const (
+	// HealthModeLeastLatency always prefers the credential with the best score.
+	HealthModeLeastLatency = "least-latency"
+	// HealthModeWeighted picks credentials randomly, weighted by the inverse of their score.
+	HealthModeWeighted = "weighted"
+
+	defaultHealthEWMAAlpha        = 0.2
+	defaultHealthExplorationRatio = 0.05
+	// minHealthSuccessRate bounds the failure penalty so a failing auth keeps a finite score.
+	minHealthSuccessRate = 0.05
+)
+
+// ResultObserver is implemented by selectors that learn from execution outcomes.
+// Manager forwards every recorded Result to the active selector when supported.
+type ResultObserver interface {
+	ObserveResult(result Result)
+}
+
+// ScoreReporter is implemented by selectors that expose their internal scoring.
+type ScoreReporter interface {
+	Scores() []AuthScore
+}
+
+// AuthScore reports the health statistics tracked for one auth and model.
+type AuthScore struct {
+	AuthID   string `json:"auth_id"`
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	// LatencyMs is the EWMA of total request latency in milliseconds.
+	LatencyMs float64 `json:"latency_ms"`
+	// FirstByteMs is the EWMA of time-to-first-token in milliseconds for streaming requests.
+	FirstByteMs float64 `json:"first_byte_ms"`
+	// FailureRate is the EWMA of failed attempts in the range [0, 1].
+	FailureRate float64 `json:"failure_rate"`
+	// Samples counts the results observed for this auth and model.
+	Samples int64 `json:"samples"`
+	// Score is the value used for ranking; lower is healthier.
+	Score     float64   `json:"score"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// HealthSelectorOptions configures HealthSelector.
+type HealthSelectorOptions struct {
+	// Mode is either HealthModeLeastLatency or HealthModeWeighted.
+	Mode string
+	// ExplorationRatio is the probability of picking a random available auth so that
+	// slow or recovering credentials keep being measured. Negative values disable exploration.
+	ExplorationRatio float64
+	// Alpha is the EWMA smoothing factor in (0, 1]; higher values react faster.
+	Alpha float64
+}
+
+type healthKey struct {
+	authID string
+	model  string
+}
+
+type healthStats struct {
+	provider    string
+	latency     float64
+	firstByte   float64
+	failureRate float64
+	samples     int64
+	updatedAt   time.Time
+}
+
+// HealthSelector prefers credentials with low latency, low time-to-first-token and
+// low recent failure rate, as observed through Manager.MarkResult.
+// Priority groups are honoured the same way as RoundRobinSelector.
+type HealthSelector struct {
+	mu      sync.Mutex
+	opts    HealthSelectorOptions
+	stats   map[healthKey]*healthStats
+	randSrc *rand.Rand
+}
+
+// NewHealthSelector constructs a health-aware selector with the supplied options.
+func NewHealthSelector(opts HealthSelectorOptions) *HealthSelector {
+	s := &HealthSelector{
+		stats:   make(map[healthKey]*healthStats),
+		randSrc: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	s.SetOptions(opts)
+	return s
+}
+
+// SetOptions updates the selector options in place, keeping collected statistics.
+func (s *HealthSelector) SetOptions(opts HealthSelectorOptions) {
+	if opts.Mode != HealthModeWeighted {
+		opts.Mode = HealthModeLeastLatency
+	}
+	if opts.Alpha <= 0 || opts.Alpha > 1 {
+		opts.Alpha = defaultHealthEWMAAlpha
+	}
+	if opts.ExplorationRatio == 0 {
+		opts.ExplorationRatio = defaultHealthExplorationRatio
+	}
+	if opts.ExplorationRatio < 0 {
+		opts.ExplorationRatio = 0
+	}
+	if opts.ExplorationRatio > 1 {
+		opts.ExplorationRatio = 1
+	}
+	s.mu.Lock()
+	s.opts = opts
+	s.mu.Unlock()
+}
+
+// Options returns the effective selector options.
+func (s *HealthSelector) Options() HealthSelectorOptions {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.opts
+}
+
+// ObserveResult implements ResultObserver.
+func (s *HealthSelector) ObserveResult(result Result) {
+	if result.AuthID == "" {
+		return
+	}
+	key := healthKey{authID: result.AuthID, model: result.Model}
+	failure := 0.0
+	if !result.Success {
+		failure = 1.0
+	}
+	latency := float64(result.Latency) / float64(time.Millisecond)
+	firstByte := float64(result.FirstByteLatency) / float64(time.Millisecond)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	alpha := s.opts.Alpha
+	stats, ok := s.stats[key]
+	if !ok {
+		stats = &healthStats{provider: result.Provider, failureRate: failure}
+		s.stats[key] = stats
+	} else {
+		stats.failureRate = ewma(stats.failureRate, failure, alpha)
+	}
+	// Failed attempts often return early and would otherwise make an auth look fast.
+	if result.Success && latency > 0 {
+		if stats.latency == 0 {
+			stats.latency = latency
+		} else {
+			stats.latency = ewma(stats.latency, latency, alpha)
+		}
+	}
+	if result.Success && firstByte > 0 {
+		if stats.firstByte == 0 {
+			stats.firstByte = firstByte
+		} else {
+			stats.firstByte = ewma(stats.firstByte, firstByte, alpha)
+		}
+	}
+	stats.samples++
+	stats.updatedAt = time.Now()
+}
+
+// Scores implements ScoreReporter.
+func (s *HealthSelector) Scores() []AuthScore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	worstByModel := make(map[string]float64)
+	for key, stats := range s.stats {
+		worstByModel[key.model] = math.Max(worstByModel[key.model], measuredLatency(stats, false))
+	}
+	out := make([]AuthScore, 0, len(s.stats))
+	for key, stats := range s.stats {
+		out = append(out, AuthScore{
+			AuthID:      key.authID,
+			Provider:    stats.provider,
+			Model:       key.model,
+			LatencyMs:   stats.latency,
+			FirstByteMs: stats.firstByte,
+			FailureRate: stats.failureRate,
+			Samples:     stats.samples,
+			Score:       healthScore(stats, false, worstByModel[key.model]),
+			UpdatedAt:   stats.updatedAt,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Model != out[j].Model {
+			return out[i].Model < out[j].Model
+		}
+		if out[i].Score != out[j].Score {
+			return out[i].Score < out[j].Score
+		}
+		return out[i].AuthID < out[j].AuthID
+	})
+	return out
+}
+
+// Pick selects the healthiest available auth within the highest priority group.
+// Auths without recorded statistics score best so they are measured first.
+func (s *HealthSelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+	_ = ctx
+	_, available, err := availableAuthsByPriority(provider, model, auths, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(available) == 1 {
+		return available[0], nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.opts.ExplorationRatio > 0 && s.randSrc.Float64() < s.opts.ExplorationRatio {
+		return available[s.randSrc.Intn(len(available))], nil
+	}
+
+	candidates := make([]*healthStats, len(available))
+	worst := 0.0
+	for i, candidate := range available {
+		candidates[i] = s.stats[healthKey{authID: candidate.ID, model: model}]
+		worst = math.Max(worst, measuredLatency(candidates[i], opts.Stream))
+	}
+	scores := make([]float64, len(available))
+	for i, stats := range candidates {
+		scores[i] = healthScore(stats, opts.Stream, worst)
+	}
+
+	if s.opts.Mode == HealthModeWeighted {
+		return available[s.weightedIndex(scores)], nil
+	}
+	best := 0
+	for i := 1; i < len(scores); i++ {
+		if scores[i] < scores[best] {
+			best = i
+		}
+	}
+	return available[best], nil
+}
+
+// weightedIndex draws an index with probability proportional to the inverse score.
+// Unmeasured auths (score 0) share the weight of the best measured auth.
+func (s *HealthSelector) weightedIndex(scores []float64) int {
+	minPositive := math.Inf(1)
+	for _, score := range scores {
+		if score > 0 && score < minPositive {
+			minPositive = score
+		}
+	}
+	if math.IsInf(minPositive, 1) {
+		return s.randSrc.Intn(len(scores))
+	}
+	weights := make([]float64, len(scores))
+	total := 0.0
+	for i, score := range scores {
+		if score <= 0 {
+			score = minPositive
+		}
+		weights[i] = 1 / score
+		total += weights[i]
+	}
+	target := s.randSrc.Float64() * total
+	for i, weight := range weights {
+		target -= weight
+		if target < 0 {
+			return i
+		}
+	}
+	return len(weights) - 1
+}
+
+// healthScore combines latency and failure rate; lower is better and zero means unmeasured.
+// Latency is only measured on success, so an auth that has only failed is scored with
+// fallbackLatency, the slowest latency measured among its peers, instead of looking fast.
+func healthScore(stats *healthStats, stream bool, fallbackLatency float64) float64 {
+	if stats == nil || stats.samples == 0 {
+		return 0
+	}
+	latency := measuredLatency(stats, stream)
+	if latency <= 0 {
+		latency = fallbackLatency
+	}
+	if latency <= 0 {
+		latency = 1
+	}
+	successRate := 1 - stats.failureRate
+	if successRate < minHealthSuccessRate {
+		successRate = minHealthSuccessRate
+	}
+	return latency / successRate
+}
+
+// measuredLatency returns the latency used for ranking, or zero when none was measured.
+func measuredLatency(stats *healthStats, stream bool) float64 {
+	if stats == nil {
+		return 0
+	}
+	if stream && stats.firstByte > 0 {
+		return stats.firstByte
+	}
+	return stats.latency
+}
+
+func ewma(previous, sample, alpha float64) float64 {
+	return alpha*sample + (1-alpha)*previous
+}
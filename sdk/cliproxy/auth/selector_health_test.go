@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestHealthSelectorPick_PrefersLowLatency(t *testing.T) {
+	t.Parallel()
+
+	selector := NewHealthSelector(HealthSelectorOptions{Mode: HealthModeLeastLatency, ExplorationRatio: -1})
+	auths := []*Auth{{ID: "slow"}, {ID: "fast"}}
+	selector.ObserveResult(Result{AuthID: "slow", Model: "m", Success: true, Latency: 900 * time.Millisecond})
+	selector.ObserveResult(Result{AuthID: "fast", Model: "m", Success: true, Latency: 100 * time.Millisecond})
+
+	for i := 0; i < 5; i++ {
+		got, err := selector.Pick(context.Background(), "gemini", "m", cliproxyexecutor.Options{}, auths)
+		if err != nil {
+			t.Fatalf("Pick() #%d error = %v", i, err)
+		}
+		if got.ID != "fast" {
+			t.Fatalf("Pick() #%d auth.ID = %q, want %q", i, got.ID, "fast")
+		}
+	}
+}
+
+func TestHealthSelectorPick_PenalizesFailures(t *testing.T) {
+	t.Parallel()
+
+	selector := NewHealthSelector(HealthSelectorOptions{Mode: HealthModeLeastLatency, ExplorationRatio: -1, Alpha: 1})
+	auths := []*Auth{{ID: "a"}, {ID: "b"}}
+	selector.ObserveResult(Result{AuthID: "a", Model: "m", Success: true, Latency: 100 * time.Millisecond})
+	selector.ObserveResult(Result{AuthID: "a", Model: "m", Success: false, Latency: 10 * time.Millisecond})
+	selector.ObserveResult(Result{AuthID: "b", Model: "m", Success: true, Latency: 300 * time.Millisecond})
+
+	got, err := selector.Pick(context.Background(), "gemini", "m", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "b" {
+		t.Fatalf("Pick() auth.ID = %q, want %q", got.ID, "b")
+	}
+}
+
+func TestHealthSelectorPick_PenalizesAuthsThatOnlyFail(t *testing.T) {
+	t.Parallel()
+
+	selector := NewHealthSelector(HealthSelectorOptions{Mode: HealthModeLeastLatency, ExplorationRatio: -1, Alpha: 1})
+	auths := []*Auth{{ID: "a"}, {ID: "b"}}
+	selector.ObserveResult(Result{AuthID: "a", Model: "m", Success: false, Latency: 5 * time.Millisecond})
+	selector.ObserveResult(Result{AuthID: "b", Model: "m", Success: true, Latency: 300 * time.Millisecond})
+
+	got, err := selector.Pick(context.Background(), "gemini", "m", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "b" {
+		t.Fatalf("Pick() auth.ID = %q, want %q", got.ID, "b")
+	}
+}
+
+func TestHealthSelectorPick_StreamUsesFirstByteLatency(t *testing.T) {
+	t.Parallel()
+
+	selector := NewHealthSelector(HealthSelectorOptions{Mode: HealthModeLeastLatency, ExplorationRatio: -1})
+	auths := []*Auth{{ID: "a"}, {ID: "b"}}
+	selector.ObserveResult(Result{AuthID: "a", Model: "m", Success: true, Latency: 2 * time.Second, FirstByteLatency: 50 * time.Millisecond})
+	selector.ObserveResult(Result{AuthID: "b", Model: "m", Success: true, Latency: time.Second, FirstByteLatency: 400 * time.Millisecond})
+
+	got, err := selector.Pick(context.Background(), "gemini", "m", cliproxyexecutor.Options{Stream: true}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "a" {
+		t.Fatalf("Pick() stream auth.ID = %q, want %q", got.ID, "a")
+	}
+	got, err = selector.Pick(context.Background(), "gemini", "m", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "b" {
+		t.Fatalf("Pick() non-stream auth.ID = %q, want %q", got.ID, "b")
+	}
+}
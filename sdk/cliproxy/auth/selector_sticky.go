@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+// DefaultStickySessionTTL is how long a session stays pinned to a credential without traffic.
+const DefaultStickySessionTTL = time.Hour
+
+// stickyHashedMessages bounds how many leading conversation messages are hashed when no explicit
+// session key exists. Only the opening message is stable between the first turn and later turns
+// of a conversation; leading system and developer messages are hashed but not counted.
+const stickyHashedMessages = 1
+
+type stickyPin struct {
+	authID    string
+	expiresAt time.Time
+}
+
+// StickySelector pins a conversation session to the credential that served it first,
+// preserving upstream prompt caches and thinking-signature continuity across turns.
+// New sessions, and sessions whose pinned credential is unavailable, are delegated to
+// the fallback selector and re-pinned to its choice.
+type StickySelector struct {
+	mu        sync.Mutex
+	fallback  Selector
+	ttl       time.Duration
+	pins      map[string]stickyPin
+	lastSweep time.Time
+}
+
+// NewStickySelector constructs a session-sticky selector. A nil fallback defaults to
+// RoundRobinSelector and a non-positive ttl defaults to DefaultStickySessionTTL.
+func NewStickySelector(fallback Selector, ttl time.Duration) *StickySelector {
+	s := &StickySelector{pins: make(map[string]stickyPin)}
+	s.SetFallback(fallback)
+	s.SetTTL(ttl)
+	return s
+}
+
+// SetTTL updates the idle time after which a session pin expires.
+func (s *StickySelector) SetTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultStickySessionTTL
+	}
+	s.mu.Lock()
+	s.ttl = ttl
+	s.mu.Unlock()
+}
+
+// SetFallback replaces the selector used for unpinned sessions, keeping existing pins.
+func (s *StickySelector) SetFallback(fallback Selector) {
+	if fallback == nil {
+		fallback = &RoundRobinSelector{}
+	}
+	s.mu.Lock()
+	s.fallback = fallback
+	s.mu.Unlock()
+}
+
+// Fallback returns the selector used for unpinned sessions.
+func (s *StickySelector) Fallback() Selector {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fallback
+}
+
+// Pick returns the pinned auth for the request session when it is still available,
+// otherwise the fallback choice, which then becomes the new pin.
+func (s *StickySelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+	fallback := s.Fallback()
+	session := StickySessionKey(opts)
+	if session == "" {
+		return fallback.Pick(ctx, provider, model, opts, auths)
+	}
+	key := provider + "|" + model + "|" + session
+	now := time.Now()
+
+	s.mu.Lock()
+	pin, ok := s.pins[key]
+	ttl := s.ttl
+	s.mu.Unlock()
+	if ok && now.Before(pin.expiresAt) {
+		for _, candidate := range auths {
+			if candidate == nil || candidate.ID != pin.authID {
+				continue
+			}
+			if blocked, _, _ := isAuthBlockedForModel(candidate, model, now); !blocked {
+				s.pin(key, candidate.ID, now.Add(ttl))
+				return candidate, nil
+			}
+			break
+		}
+	}
+
+	selected, err := fallback.Pick(ctx, provider, model, opts, auths)
+	if err != nil {
+		return nil, err
+	}
+	if selected != nil {
+		s.pin(key, selected.ID, now.Add(ttl))
+	}
+	return selected, nil
+}
+
+func (s *StickySelector) pin(key, authID string, expiresAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pins[key] = stickyPin{authID: authID, expiresAt: expiresAt}
+	now := time.Now()
+	if now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+	s.lastSweep = now
+	for k, p := range s.pins {
+		if now.After(p.expiresAt) {
+			delete(s.pins, k)
+		}
+	}
+}
+
+// ObserveResult forwards execution outcomes to the fallback selector when it learns from them.
+func (s *StickySelector) ObserveResult(result Result) {
+	if observer, ok := s.Fallback().(ResultObserver); ok && observer != nil {
+		observer.ObserveResult(result)
+	}
+}
+
+// Scores exposes the fallback selector scores when it reports any.
+func (s *StickySelector) Scores() []AuthScore {
+	if reporter, ok := s.Fallback().(ScoreReporter); ok && reporter != nil {
+		return reporter.Scores()
+	}
+	return nil
+}
+
+// StickySessionKey derives the session identity of a request. It prefers an explicit
+// session identifier from the request metadata, then Claude metadata.user_id, then
+// OpenAI prompt_cache_key, and finally a hash of the system prompt and opening message.
+// An empty string means the request carries no usable session identity.
+func StickySessionKey(opts cliproxyexecutor.Options) string {
+	if opts.Metadata != nil {
+		if v, ok := opts.Metadata[cliproxyexecutor.SessionIDMetadataKey].(string); ok && strings.TrimSpace(v) != "" {
+			return "sid:" + strings.TrimSpace(v)
+		}
+	}
+	payload := opts.OriginalRequest
+	if len(payload) == 0 || !gjson.ValidBytes(payload) {
+		return ""
+	}
+	if v := strings.TrimSpace(gjson.GetBytes(payload, "metadata.user_id").String()); v != "" {
+		return "uid:" + v
+	}
+	if v := strings.TrimSpace(gjson.GetBytes(payload, "prompt_cache_key").String()); v != "" {
+		return "pck:" + v
+	}
+
+	var messages gjson.Result
+	for _, path := range []string{"messages", "input", "contents"} {
+		if r := gjson.GetBytes(payload, path); r.IsArray() {
+			messages = r
+			break
+		}
+	}
+	if !messages.Exists() {
+		return ""
+	}
+	hasher := sha256.New()
+	for _, path := range []string{"system", "instructions", "systemInstruction", "system_instruction"} {
+		if r := gjson.GetBytes(payload, path); r.Exists() {
+			hasher.Write([]byte(r.Raw))
+		}
+	}
+	count := 0
+	messages.ForEach(func(_, value gjson.Result) bool {
+		hasher.Write([]byte(value.Raw))
+		if role := value.Get("role").String(); role == "system" || role == "developer" {
+			return true
+		}
+		count++
+		return count < stickyHashedMessages
+	})
+	if count == 0 {
+		return ""
+	}
+	return "msg:" + hex.EncodeToString(hasher.Sum(nil))[:32]
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestStickySelectorPick_PinsSession(t *testing.T) {
+	t.Parallel()
+
+	selector := NewStickySelector(&RoundRobinSelector{}, time.Minute)
+	auths := []*Auth{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.SessionIDMetadataKey: "s1"}}
+
+	first, err := selector.Pick(context.Background(), "claude", "m", opts, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		got, errPick := selector.Pick(context.Background(), "claude", "m", opts, auths)
+		if errPick != nil {
+			t.Fatalf("Pick() #%d error = %v", i, errPick)
+		}
+		if got.ID != first.ID {
+			t.Fatalf("Pick() #%d auth.ID = %q, want pinned %q", i, got.ID, first.ID)
+		}
+	}
+}
+
+func TestStickySelectorPick_FallsBackWhenPinnedCoolsDown(t *testing.T) {
+	t.Parallel()
+
+	selector := NewStickySelector(&FillFirstSelector{}, time.Minute)
+	auths := []*Auth{{ID: "a"}, {ID: "b"}}
+	opts := cliproxyexecutor.Options{OriginalRequest: []byte(`{"metadata":{"user_id":"user-1"}}`)}
+
+	got, err := selector.Pick(context.Background(), "claude", "m", opts, auths)
+	if err != nil || got.ID != "a" {
+		t.Fatalf("Pick() = %v, %v; want a", got, err)
+	}
+
+	auths[0].ModelStates = map[string]*ModelState{
+		"m": {Unavailable: true, NextRetryAfter: time.Now().Add(time.Minute)},
+	}
+	got, err = selector.Pick(context.Background(), "claude", "m", opts, auths)
+	if err != nil || got.ID != "b" {
+		t.Fatalf("Pick() after cooldown = %v, %v; want b", got, err)
+	}
+
+	auths[0].ModelStates = nil
+	got, err = selector.Pick(context.Background(), "claude", "m", opts, auths)
+	if err != nil || got.ID != "b" {
+		t.Fatalf("Pick() after recovery = %v, %v; want re-pinned b", got, err)
+	}
+}
+
+func TestStickySessionKey_HashesOpeningMessage(t *testing.T) {
+	t.Parallel()
+
+	first := cliproxyexecutor.Options{OriginalRequest: []byte(`{"system":"sys","messages":[{"role":"user","content":"hi"}]}`)}
+	later := cliproxyexecutor.Options{OriginalRequest: []byte(`{"system":"sys","messages":[{"role":"user","content":"hi"},{"role":"assistant","content":"hello"},{"role":"user","content":"more"}]}`)}
+	other := cliproxyexecutor.Options{OriginalRequest: []byte(`{"system":"sys","messages":[{"role":"user","content":"bye"}]}`)}
+
+	key := StickySessionKey(first)
+	if key == "" {
+		t.Fatalf("StickySessionKey() = empty, want hash")
+	}
+	if got := StickySessionKey(later); got != key {
+		t.Fatalf("StickySessionKey() later turn = %q, want %q", got, key)
+	}
+	if got := StickySessionKey(other); got == key {
+		t.Fatalf("StickySessionKey() different conversation = %q, want distinct key", got)
+	}
+}
+
+func TestStickySessionKey_SkipsOpenAISystemMessages(t *testing.T) {
+	t.Parallel()
+
+	first := cliproxyexecutor.Options{OriginalRequest: []byte(`{"messages":[{"role":"system","content":"sys"},{"role":"developer","content":"dev"},{"role":"user","content":"hi"}]}`)}
+	later := cliproxyexecutor.Options{OriginalRequest: []byte(`{"messages":[{"role":"system","content":"sys"},{"role":"developer","content":"dev"},{"role":"user","content":"hi"},{"role":"assistant","content":"hello"},{"role":"user","content":"more"}]}`)}
+	other := cliproxyexecutor.Options{OriginalRequest: []byte(`{"messages":[{"role":"system","content":"sys"},{"role":"developer","content":"dev"},{"role":"user","content":"bye"}]}`)}
+
+	key := StickySessionKey(first)
+	if key == "" {
+		t.Fatalf("StickySessionKey() = empty, want hash")
+	}
+	if got := StickySessionKey(later); got != key {
+		t.Fatalf("StickySessionKey() later turn = %q, want %q", got, key)
+	}
+	if got := StickySessionKey(other); got == key {
+		t.Fatalf("StickySessionKey() conversation sharing the system prompt = %q, want distinct key", got)
+	}
+}
@@ -5,13 +5,16 @@ package cliproxy
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/pipeline"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/translator/builtin"
 )
 
 // Builder constructs a Service instance with customizable providers.
@@ -47,6 +50,12 @@ type Builder struct {
 
 	// serverOptions contains additional server configuration options.
 	serverOptions []api.ServerOption
+
+	// pipelineHooks are invoked around every provider attempt.
+	pipelineHooks []pipeline.Hook
+
+	// translator is the pipeline used for all request/response translation.
+	translator *sdktranslator.Pipeline
 }
 
 // Hooks allows callers to plug into service lifecycle stages.
@@ -143,6 +152,21 @@ func (b *Builder) WithServerOptions(opts ...api.ServerOption) *Builder {
 	return b
 }
 
+// WithPipelineHooks registers hooks invoked around every provider attempt made by the core manager.
+// Hooks observe the selected auth, the translated request, the response and every stream chunk,
+// and may replace the outbound HTTP client per request.
+func (b *Builder) WithPipelineHooks(hooks ...pipeline.Hook) *Builder {
+	b.pipelineHooks = append(b.pipelineHooks, hooks...)
+	return b
+}
+
+// WithTranslatorPipeline overrides the translator pipeline used for all provider traffic.
+// Request and response middleware registered on it applies uniformly to every endpoint.
+func (b *Builder) WithTranslatorPipeline(p *sdktranslator.Pipeline) *Builder {
+	b.translator = p
+	return b
+}
+
 // WithLocalManagementPassword configures a password that is only accepted from localhost management requests.
 func (b *Builder) WithLocalManagementPassword(password string) *Builder {
 	if password == "" {
@@ -199,23 +223,26 @@ func (b *Builder) Build() (*Service, error) {
 			dirSetter.SetBaseDir(b.cfg.AuthDir)
 		}
 
-		strategy := ""
-		if b.cfg != nil {
-			strategy = strings.ToLower(strings.TrimSpace(b.cfg.Routing.Strategy))
-		}
-		var selector coreauth.Selector
-		switch strategy {
-		case "fill-first", "fillfirst", "ff":
-			selector = &coreauth.FillFirstSelector{}
-		default:
-			selector = &coreauth.RoundRobinSelector{}
-		}
+		selector := newRoutingSelector(b.cfg)
 
-		coreManager = coreauth.NewManager(tokenStore, selector, nil)
+		coreManager = coreauth.NewManager(tokenStore, selector, metrics.Default())
 	}
 	// Attach a default RoundTripper provider so providers can opt-in per-auth transports.
 	coreManager.SetRoundTripperProvider(newDefaultRoundTripperProvider())
 	coreManager.SetOAuthModelMappings(b.cfg.OAuthModelMappings)
+	coreManager.SetModelFallbacks(b.cfg.QuotaExceeded)
+	coreManager.SetHedgingConfig(b.cfg.Hedging)
+	coreManager.SetCircuitBreakerConfig(b.cfg.CircuitBreaker)
+	coreManager.SetCooldownQueueConfig(b.cfg.CooldownQueue)
+
+	translator := b.translator
+	if translator == nil {
+		translator = builtin.Pipeline()
+	}
+	coreManager.SetTranslatorPipeline(translator)
+	if chain := newPipelineHookChain(b.pipelineHooks, translator); chain != nil {
+		coreManager.SetExecutionHook(chain)
+	}
 
 	service := &Service{
 		cfg:            b.cfg,
@@ -227,6 +254,7 @@ func (b *Builder) Build() (*Service, error) {
 		authManager:    authManager,
 		accessManager:  accessManager,
 		coreManager:    coreManager,
+		translator:     translator,
 		serverOptions:  append([]api.ServerOption(nil), b.serverOptions...),
 	}
 	return service, nil
@@ -0,0 +1,30 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+)
+
+// httpClientContextKey is an unexported context key type to avoid collisions.
+type httpClientContextKey struct{}
+
+// WithHTTPClient returns a derived context that carries a per-request HTTP client override.
+// Provider executors prefer this client over their default proxy-aware transport.
+func WithHTTPClient(ctx context.Context, client *http.Client) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if client == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, httpClientContextKey{}, client)
+}
+
+// HTTPClientFromContext returns the HTTP client override stored in ctx, if any.
+func HTTPClientFromContext(ctx context.Context) *http.Client {
+	if ctx == nil {
+		return nil
+	}
+	client, _ := ctx.Value(httpClientContextKey{}).(*http.Client)
+	return client
+}
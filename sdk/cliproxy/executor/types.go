@@ -19,6 +19,19 @@ type Request struct {
 	Metadata map[string]any
 }
 
+// SessionIDMetadataKey is the Options.Metadata key carrying a client supplied session identifier
+// (for example the X-Session-ID header) used by session-sticky credential routing.
+const SessionIDMetadataKey = "session_id"
+
+// CooldownQueueMetadataKey is the Options.Metadata key marking a request that may wait in the
+// cooldown queue instead of failing when every credential for its model is cooling down.
+const CooldownQueueMetadataKey = "cooldown_queue"
+
+// CompletionsRequestMetadataKey is the Options.Metadata key carrying the original body of a
+// legacy /v1/completions request, which is otherwise executed as a chat completion, so
+// executors for upstreams that serve completions natively can forward it unchanged.
+const CompletionsRequestMetadataKey = "completions_request"
+
 // Options controls execution behavior for both streaming and non-streaming calls.
 type Options struct {
 	// Stream toggles streaming mode.
@@ -11,7 +11,9 @@ import (
 
 // Context encapsulates execution state shared across middleware, translators, and executors.
 type Context struct {
-	// Request encapsulates the provider facing request payload.
+	// Request is the request handed to the executor. Its payload is still in the client's
+	// source format (Options.SourceFormat); the executor translates it into the provider
+	// schema after the hooks ran, so changes made here are translated as well.
 	Request cliproxyexecutor.Request
 	// Options carries execution flags (streaming, headers, etc.).
 	Options cliproxyexecutor.Options
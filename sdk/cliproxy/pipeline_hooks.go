@@ -0,0 +1,68 @@
+package cliproxy
+
+import (
+	"context"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/pipeline"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// pipelineHookChain adapts pipeline hooks to the core manager execution hook contract.
+type pipelineHookChain struct {
+	hooks      []pipeline.Hook
+	translator *sdktranslator.Pipeline
+}
+
+func newPipelineHookChain(hooks []pipeline.Hook, translator *sdktranslator.Pipeline) *pipelineHookChain {
+	filtered := make([]pipeline.Hook, 0, len(hooks))
+	for _, hook := range hooks {
+		if hook != nil {
+			filtered = append(filtered, hook)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil
+	}
+	return &pipelineHookChain{hooks: filtered, translator: translator}
+}
+
+// BeforeAttempt implements coreauth.ExecutionHook.
+func (c *pipelineHookChain) BeforeAttempt(ctx context.Context, auth *coreauth.Auth, req *cliproxyexecutor.Request, opts *cliproxyexecutor.Options) (context.Context, coreauth.ExecutionObserver) {
+	execCtx := &pipeline.Context{
+		Request:    *req,
+		Options:    *opts,
+		Auth:       auth,
+		Translator: c.translator,
+	}
+	for _, hook := range c.hooks {
+		hook.BeforeExecute(ctx, execCtx)
+	}
+	*req = execCtx.Request
+	*opts = execCtx.Options
+	if execCtx.HTTPClient != nil {
+		ctx = cliproxyexecutor.WithHTTPClient(ctx, execCtx.HTTPClient)
+	}
+	return ctx, &pipelineHookObserver{hooks: c.hooks, execCtx: execCtx}
+}
+
+// pipelineHookObserver forwards attempt outcomes to the registered pipeline hooks.
+type pipelineHookObserver struct {
+	hooks   []pipeline.Hook
+	execCtx *pipeline.Context
+}
+
+// AfterAttempt implements coreauth.ExecutionObserver.
+func (o *pipelineHookObserver) AfterAttempt(ctx context.Context, resp cliproxyexecutor.Response, err error) {
+	for _, hook := range o.hooks {
+		hook.AfterExecute(ctx, o.execCtx, resp, err)
+	}
+}
+
+// OnStreamChunk implements coreauth.ExecutionObserver.
+func (o *pipelineHookObserver) OnStreamChunk(ctx context.Context, chunk cliproxyexecutor.StreamChunk) {
+	for _, hook := range o.hooks {
+		hook.OnStreamChunk(ctx, o.execCtx, chunk)
+	}
+}
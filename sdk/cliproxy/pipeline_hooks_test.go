@@ -0,0 +1,38 @@
+package cliproxy
+
+import (
+	"context"
+	"testing"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/pipeline"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+func TestPipelineHookChain_BeforeAttemptSeesSourceFormat(t *testing.T) {
+	var seen pipeline.Context
+	chain := newPipelineHookChain([]pipeline.Hook{&pipeline.HookFunc{
+		Before: func(_ context.Context, execCtx *pipeline.Context) {
+			seen = *execCtx
+			execCtx.Request.Payload, _ = sjson.SetBytes(execCtx.Request.Payload, "user", "hook")
+		},
+	}}, sdktranslator.NewPipeline(nil))
+
+	auth := &coreauth.Auth{ID: "a", Provider: "claude"}
+	req := cliproxyexecutor.Request{Model: "m", Payload: []byte(`{"model":"m","messages":[{"role":"user","content":"hi"}]}`)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAI}
+	chain.BeforeAttempt(context.Background(), auth, &req, &opts)
+
+	if seen.Options.SourceFormat != sdktranslator.FormatOpenAI || seen.Auth != auth {
+		t.Fatalf("hook context = %+v", seen)
+	}
+	if gjson.GetBytes(seen.Request.Payload, "messages.0.content").String() != "hi" {
+		t.Fatalf("hook payload = %s, want the OpenAI source payload", seen.Request.Payload)
+	}
+	if gjson.GetBytes(req.Payload, "user").String() != "hook" {
+		t.Fatalf("request payload = %s, want the hook's change applied", req.Payload)
+	}
+}
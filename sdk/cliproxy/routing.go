@@ -0,0 +1,143 @@
+package cliproxy
+
+import (
+	"strings"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+const (
+	routingStrategyRoundRobin   = "round-robin"
+	routingStrategyFillFirst    = "fill-first"
+	routingStrategyLeastLatency = coreauth.HealthModeLeastLatency
+	routingStrategyWeighted     = coreauth.HealthModeWeighted
+	routingStrategySticky       = "sticky"
+)
+
+// normalizeRoutingStrategy maps configured strategy aliases to their canonical name.
+func normalizeRoutingStrategy(strategy string) string {
+	switch strings.ToLower(strings.TrimSpace(strategy)) {
+	case "fill-first", "fillfirst", "ff":
+		return routingStrategyFillFirst
+	case "least-latency", "leastlatency", "latency":
+		return routingStrategyLeastLatency
+	case "weighted", "weighted-latency":
+		return routingStrategyWeighted
+	case "sticky", "session-sticky", "session":
+		return routingStrategySticky
+	default:
+		return routingStrategyRoundRobin
+	}
+}
+
+// routingStrategyLabel describes the effective strategy, including the sticky fallback.
+func routingStrategyLabel(routing config.RoutingConfig) string {
+	strategy := normalizeRoutingStrategy(routing.Strategy)
+	if strategy == routingStrategySticky {
+		return strategy + "/" + stickyFallbackStrategy(routing)
+	}
+	return strategy
+}
+
+func stickyFallbackStrategy(routing config.RoutingConfig) string {
+	fallback := normalizeRoutingStrategy(routing.StickyFallback)
+	if fallback == routingStrategySticky {
+		return routingStrategyRoundRobin
+	}
+	return fallback
+}
+
+// newRoutingSelector constructs the credential selector configured under routing.
+func newRoutingSelector(cfg *config.Config) coreauth.Selector {
+	var routing config.RoutingConfig
+	if cfg != nil {
+		routing = cfg.Routing
+	}
+	strategy := normalizeRoutingStrategy(routing.Strategy)
+	if strategy == routingStrategySticky {
+		fallback := newBaseSelector(stickyFallbackStrategy(routing), routing)
+		return coreauth.NewStickySelector(fallback, stickySessionTTL(routing))
+	}
+	return newBaseSelector(strategy, routing)
+}
+
+func newBaseSelector(strategy string, routing config.RoutingConfig) coreauth.Selector {
+	switch strategy {
+	case routingStrategyFillFirst:
+		return &coreauth.FillFirstSelector{}
+	case routingStrategyLeastLatency, routingStrategyWeighted:
+		return coreauth.NewHealthSelector(healthSelectorOptions(strategy, routing))
+	default:
+		return &coreauth.RoundRobinSelector{}
+	}
+}
+
+func healthSelectorOptions(strategy string, routing config.RoutingConfig) coreauth.HealthSelectorOptions {
+	return coreauth.HealthSelectorOptions{
+		Mode:             strategy,
+		ExplorationRatio: routing.ExplorationRatio,
+		Alpha:            routing.EWMAAlpha,
+	}
+}
+
+func stickySessionTTL(routing config.RoutingConfig) time.Duration {
+	if routing.SessionTTL <= 0 {
+		return coreauth.DefaultStickySessionTTL
+	}
+	return time.Duration(routing.SessionTTL) * time.Second
+}
+
+// applyRoutingConfig updates the manager selector after a configuration reload.
+// Health selectors keep their statistics and sticky selectors keep their session pins
+// when only their tuning changes.
+func applyRoutingConfig(manager *coreauth.Manager, previous, next *config.Config) (string, bool) {
+	if manager == nil || next == nil {
+		return "", false
+	}
+	previousLabel := routingStrategyRoundRobin
+	if previous != nil {
+		previousLabel = routingStrategyLabel(previous.Routing)
+	}
+	nextLabel := routingStrategyLabel(next.Routing)
+	changed := previousLabel != nextLabel
+
+	current := manager.Selector()
+	strategy := normalizeRoutingStrategy(next.Routing.Strategy)
+	if sticky, ok := current.(*coreauth.StickySelector); ok && sticky != nil && strategy == routingStrategySticky {
+		sticky.SetTTL(stickySessionTTL(next.Routing))
+		fallbackStrategy := stickyFallbackStrategy(next.Routing)
+		if !tuneSelector(sticky.Fallback(), fallbackStrategy, next.Routing) {
+			sticky.SetFallback(newBaseSelector(fallbackStrategy, next.Routing))
+		}
+		return nextLabel, changed
+	}
+	if strategy != routingStrategySticky && tuneSelector(current, strategy, next.Routing) {
+		return nextLabel, changed
+	}
+	if !changed {
+		return nextLabel, false
+	}
+	manager.SetSelector(newRoutingSelector(next))
+	return nextLabel, true
+}
+
+// tuneSelector applies routing options to an existing selector in place and reports
+// whether it already implements the requested strategy.
+func tuneSelector(selector coreauth.Selector, strategy string, routing config.RoutingConfig) bool {
+	switch s := selector.(type) {
+	case *coreauth.HealthSelector:
+		if strategy != routingStrategyLeastLatency && strategy != routingStrategyWeighted {
+			return false
+		}
+		s.SetOptions(healthSelectorOptions(strategy, routing))
+		return true
+	case *coreauth.FillFirstSelector:
+		return strategy == routingStrategyFillFirst
+	case *coreauth.RoundRobinSelector:
+		return strategy == routingStrategyRoundRobin
+	default:
+		return false
+	}
+}
@@ -13,9 +13,11 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
-	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
+	internalusage "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/wsrelay"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
@@ -23,6 +25,7 @@ import (
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -81,11 +84,17 @@ type Service struct {
 	// coreManager handles core authentication and execution.
 	coreManager *coreauth.Manager
 
+	// translator is the pipeline used for all request/response translation.
+	translator *sdktranslator.Pipeline
+
 	// shutdownOnce ensures shutdown is called only once.
 	shutdownOnce sync.Once
 
 	// wsGateway manages websocket Gemini providers.
 	wsGateway *wsrelay.Manager
+
+	// shutdownTracing flushes and stops the trace exporter installed at startup.
+	shutdownTracing func(context.Context) error
 }
 
 // RegisterUsagePlugin registers a usage plugin on the global usage manager.
@@ -97,6 +106,12 @@ func (s *Service) RegisterUsagePlugin(plugin usage.Plugin) {
 	usage.RegisterPlugin(plugin)
 }
 
+// TranslatorPipeline returns the translator pipeline shared by every endpoint served by the service.
+// Middleware registered through UseRequest/UseResponse takes effect for subsequent requests.
+func (s *Service) TranslatorPipeline() *sdktranslator.Pipeline {
+	return s.translator
+}
+
 // newDefaultAuthManager creates a default authentication manager with all supported providers.
 func newDefaultAuthManager() *sdkAuth.Manager {
 	return sdkAuth.NewManager(
@@ -214,6 +229,7 @@ func (s *Service) ensureWebsocketGateway() {
 		LogWarnf:       log.Warnf,
 	}
 	s.wsGateway = wsrelay.NewManager(opts)
+	metrics.Default().SetRelaySessions(s.wsGateway.SessionCount)
 }
 
 func (s *Service) wsOnConnected(channelID string) {
@@ -323,6 +339,10 @@ func openAICompatInfoFromAuth(a *coreauth.Auth) (providerKey string, compatName
 		return "", "", false
 	}
 	if len(a.Attributes) > 0 {
+		if strings.TrimSpace(a.Attributes["compat_kind"]) != "" {
+			// claude-compatibility and gemini-compatibility auths use their own executors.
+			return "", "", false
+		}
 		providerKey = strings.TrimSpace(a.Attributes["provider_key"])
 		compatName = strings.TrimSpace(a.Attributes["compat_name"])
 		if compatName != "" {
@@ -338,6 +358,27 @@ func openAICompatInfoFromAuth(a *coreauth.Auth) (providerKey string, compatName
 	return "", "", false
 }
 
+// protocolCompatInfoFromAuth reports whether a was synthesized from a claude-compatibility
+// or gemini-compatibility entry and returns its provider key, compat name and kind.
+func protocolCompatInfoFromAuth(a *coreauth.Auth) (providerKey string, compatName string, kind string, ok bool) {
+	if a == nil || len(a.Attributes) == 0 {
+		return "", "", "", false
+	}
+	kind = strings.ToLower(strings.TrimSpace(a.Attributes["compat_kind"]))
+	if kind == "" {
+		return "", "", "", false
+	}
+	compatName = strings.TrimSpace(a.Attributes["compat_name"])
+	providerKey = strings.TrimSpace(a.Attributes["provider_key"])
+	if providerKey == "" {
+		providerKey = compatName
+	}
+	if providerKey == "" {
+		providerKey = strings.TrimSpace(a.Provider)
+	}
+	return strings.ToLower(providerKey), compatName, kind, true
+}
+
 func (s *Service) ensureExecutorsForAuth(a *coreauth.Auth) {
 	if s == nil || a == nil {
 		return
@@ -348,6 +389,15 @@ func (s *Service) ensureExecutorsForAuth(a *coreauth.Auth) {
 	if a.Disabled {
 		return
 	}
+	if providerKey, _, kind, isProtocolCompat := protocolCompatInfoFromAuth(a); isProtocolCompat {
+		switch kind {
+		case "claude":
+			s.coreManager.RegisterExecutor(executor.NewClaudeCompatExecutor(providerKey, s.cfg))
+		case "gemini":
+			s.coreManager.RegisterExecutor(executor.NewGeminiCompatExecutor(providerKey, s.cfg))
+		}
+		return
+	}
 	if compatProviderKey, _, isCompat := openAICompatInfoFromAuth(a); isCompat {
 		if compatProviderKey == "" {
 			compatProviderKey = strings.ToLower(strings.TrimSpace(a.Provider))
@@ -420,6 +470,17 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 
 	usage.StartDefault(ctx)
+	shutdownTracing, errTracing := tracing.Setup(ctx, s.cfg)
+	if errTracing != nil {
+		log.Errorf("failed to start tracing: %v", errTracing)
+	}
+	s.shutdownTracing = shutdownTracing
+	if s.coreManager != nil {
+		metrics.Default().SetAuthSource(s.coreManager)
+	}
+	if err := internalusage.StartPersistence(ctx, s.cfg); err != nil {
+		log.Errorf("failed to start usage persistence: %v", err)
+	}
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer shutdownCancel()
@@ -509,46 +570,23 @@ func (s *Service) Run(ctx context.Context) error {
 
 	var watcherWrapper *WatcherWrapper
 	reloadCallback := func(newCfg *config.Config) {
-		previousStrategy := ""
 		s.cfgMu.RLock()
-		if s.cfg != nil {
-			previousStrategy = strings.ToLower(strings.TrimSpace(s.cfg.Routing.Strategy))
-		}
+		previousCfg := s.cfg
 		s.cfgMu.RUnlock()
 
 		if newCfg == nil {
-			s.cfgMu.RLock()
-			newCfg = s.cfg
-			s.cfgMu.RUnlock()
+			newCfg = previousCfg
 		}
 		if newCfg == nil {
 			return
 		}
 
-		nextStrategy := strings.ToLower(strings.TrimSpace(newCfg.Routing.Strategy))
-		normalizeStrategy := func(strategy string) string {
-			switch strategy {
-			case "fill-first", "fillfirst", "ff":
-				return "fill-first"
-			default:
-				return "round-robin"
-			}
-		}
-		previousStrategy = normalizeStrategy(previousStrategy)
-		nextStrategy = normalizeStrategy(nextStrategy)
-		if s.coreManager != nil && previousStrategy != nextStrategy {
-			var selector coreauth.Selector
-			switch nextStrategy {
-			case "fill-first":
-				selector = &coreauth.FillFirstSelector{}
-			default:
-				selector = &coreauth.RoundRobinSelector{}
-			}
-			s.coreManager.SetSelector(selector)
-			log.Infof("routing strategy updated to %s", nextStrategy)
+		if strategy, changed := applyRoutingConfig(s.coreManager, previousCfg, newCfg); changed {
+			log.Infof("routing strategy updated to %s", strategy)
 		}
 
 		s.applyRetryConfig(newCfg)
+		internalusage.UpdatePersistenceConfig(newCfg)
 		if s.server != nil {
 			s.server.UpdateClients(newCfg)
 		}
@@ -557,6 +595,10 @@ func (s *Service) Run(ctx context.Context) error {
 		s.cfgMu.Unlock()
 		if s.coreManager != nil {
 			s.coreManager.SetOAuthModelMappings(newCfg.OAuthModelMappings)
+			s.coreManager.SetModelFallbacks(newCfg.QuotaExceeded)
+			s.coreManager.SetHedgingConfig(newCfg.Hedging)
+			s.coreManager.SetCircuitBreakerConfig(newCfg.CircuitBreaker)
+			s.coreManager.SetCooldownQueueConfig(newCfg.CooldownQueue)
 		}
 		s.rebindExecutors()
 	}
@@ -655,6 +697,12 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		}
 
 		usage.StopDefault()
+		internalusage.StopPersistence()
+		if s.shutdownTracing != nil {
+			if err := s.shutdownTracing(ctx); err != nil {
+				log.Errorf("failed to flush traces: %v", err)
+			}
+		}
 	})
 	return shutdownErr
 }
@@ -702,6 +750,10 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 			}
 		}
 	}
+	if providerKey, compatName, kind, ok := protocolCompatInfoFromAuth(a); ok {
+		s.registerProtocolCompatModels(a, providerKey, compatName, kind)
+		return
+	}
 	provider := strings.ToLower(strings.TrimSpace(a.Provider))
 	compatProviderKey, compatDisplayName, compatDetected := openAICompatInfoFromAuth(a)
 	if compatDetected {
@@ -824,14 +876,18 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 						if modelID == "" {
 							modelID = m.Name
 						}
-						ms = append(ms, &ModelInfo{
+						info := &ModelInfo{
 							ID:          modelID,
 							Object:      "model",
 							Created:     time.Now().Unix(),
 							OwnedBy:     compat.Name,
 							Type:        "openai-compatibility",
 							DisplayName: modelID,
-						})
+						}
+						if m.Embedding {
+							info.SupportedGenerationMethods = append([]string(nil), registry.EmbeddingGenerationMethods...)
+						}
+						ms = append(ms, info)
 					}
 					// Register and return
 					if len(ms) > 0 {
@@ -1165,8 +1221,11 @@ func buildConfigModels[T modelEntry](models []T, ownedBy, modelType string) []*M
 			DisplayName: display,
 		}
 		if name != "" {
-			if upstream := registry.LookupStaticModelInfo(name); upstream != nil && upstream.Thinking != nil {
+			if upstream := registry.LookupStaticModelInfo(name); upstream != nil {
 				info.Thinking = upstream.Thinking
+				if upstream.SupportsEmbeddings() {
+					info.SupportedGenerationMethods = append([]string(nil), upstream.SupportedGenerationMethods...)
+				}
 			}
 		}
 		out = append(out, info)
@@ -1181,6 +1240,32 @@ func buildVertexCompatConfigModels(entry *config.VertexCompatKey) []*ModelInfo {
 	return buildConfigModels(entry.Models, "google", "vertex")
 }
 
+// registerProtocolCompatModels registers the models configured for a claude-compatibility or
+// gemini-compatibility provider, or clears the registration when the entry is gone or empty.
+func (s *Service) registerProtocolCompatModels(a *coreauth.Auth, providerKey, compatName, kind string) {
+	if s.cfg == nil {
+		GlobalModelRegistry().UnregisterClient(a.ID)
+		return
+	}
+	entries := s.cfg.ClaudeCompatibility
+	if kind == "gemini" {
+		entries = s.cfg.GeminiCompatibility
+	}
+	for i := range entries {
+		entry := &entries[i]
+		if !strings.EqualFold(entry.Name, compatName) {
+			continue
+		}
+		ms := buildConfigModels(entry.Models, entry.Name, kind)
+		if len(ms) == 0 {
+			break
+		}
+		GlobalModelRegistry().RegisterClient(a.ID, providerKey, applyModelPrefixes(ms, a.Prefix, s.cfg.ForceModelPrefix))
+		return
+	}
+	GlobalModelRegistry().UnregisterClient(a.ID)
+}
+
 func buildGeminiConfigModels(entry *config.GeminiKey) []*ModelInfo {
 	if entry == nil {
 		return nil
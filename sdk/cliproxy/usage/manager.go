@@ -18,7 +18,10 @@ type Record struct {
 	Source      string
 	RequestedAt time.Time
 	Failed      bool
-	Detail      Detail
+	// Cancelled marks an attempt abandoned because a hedged sibling attempt won. Cancelled
+	// records are never Failed.
+	Cancelled bool
+	Detail    Detail
 }
 
 // Detail holds the token usage breakdown.
@@ -121,6 +124,16 @@ func (m *Manager) Publish(ctx context.Context, record Record) {
 	m.cond.Signal()
 }
 
+// QueueDepth returns the number of records waiting to be delivered to plugins.
+func (m *Manager) QueueDepth() int {
+	if m == nil {
+		return 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.queue)
+}
+
 func (m *Manager) run(ctx context.Context) {
 	for {
 		m.mu.Lock()
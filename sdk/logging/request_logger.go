@@ -16,3 +16,14 @@ type FileRequestLogger = internallogging.FileRequestLogger
 func NewFileRequestLogger(enabled bool, logsDir string, configDir string) *FileRequestLogger {
 	return internallogging.NewFileRequestLogger(enabled, logsDir, configDir)
 }
+
+// JSONLRequestLogger implements RequestLogger by appending structured records to JSON Lines files.
+type JSONLRequestLogger = internallogging.JSONLRequestLogger
+
+// RequestRecord is one line of a JSON Lines request log.
+type RequestRecord = internallogging.RequestRecord
+
+// NewJSONLRequestLogger creates a new JSON Lines request logger.
+func NewJSONLRequestLogger(enabled bool, logsDir string, configDir string) *JSONLRequestLogger {
+	return internallogging.NewJSONLRequestLogger(enabled, logsDir, configDir)
+}
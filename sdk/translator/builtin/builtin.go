@@ -12,7 +12,9 @@ func Registry() *sdktranslator.Registry {
 	return sdktranslator.Default()
 }
 
-// Pipeline returns a pipeline that already contains the built-in translators.
+// Pipeline returns the shared pipeline that already contains the built-in translators.
+// Middleware registered on it applies to every request served by a Service that uses
+// the default translator pipeline.
 func Pipeline() *sdktranslator.Pipeline {
-	return sdktranslator.NewPipeline(sdktranslator.Default())
+	return sdktranslator.DefaultPipeline()
 }
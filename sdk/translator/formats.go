@@ -9,4 +9,7 @@ const (
 	FormatGeminiCLI      Format = "gemini-cli"
 	FormatCodex          Format = "codex"
 	FormatAntigravity    Format = "antigravity"
+
+	FormatOpenAIEmbeddings Format = "openai-embeddings"
+	FormatGeminiEmbeddings Format = "gemini-embeddings"
 )
@@ -1,6 +1,9 @@
 package translator
 
-import "context"
+import (
+	"context"
+	"sync"
+)
 
 // RequestEnvelope represents a request in the translation pipeline.
 type RequestEnvelope struct {
@@ -33,6 +36,7 @@ type ResponseHandler func(ctx context.Context, resp ResponseEnvelope) (ResponseE
 
 // Pipeline orchestrates request/response transformation with middleware support.
 type Pipeline struct {
+	mu                 sync.RWMutex
 	registry           *Registry
 	requestMiddleware  []RequestMiddleware
 	responseMiddleware []ResponseMiddleware
@@ -46,17 +50,28 @@ func NewPipeline(registry *Registry) *Pipeline {
 	return &Pipeline{registry: registry}
 }
 
+// Registry returns the registry backing the pipeline.
+func (p *Pipeline) Registry() *Registry {
+	return p.registry
+}
+
 // UseRequest adds request middleware executed in registration order.
+// It is safe to call while translations are in flight.
 func (p *Pipeline) UseRequest(mw RequestMiddleware) {
 	if mw != nil {
+		p.mu.Lock()
 		p.requestMiddleware = append(p.requestMiddleware, mw)
+		p.mu.Unlock()
 	}
 }
 
 // UseResponse adds response middleware executed in registration order.
+// It is safe to call while translations are in flight.
 func (p *Pipeline) UseResponse(mw ResponseMiddleware) {
 	if mw != nil {
+		p.mu.Lock()
 		p.responseMiddleware = append(p.responseMiddleware, mw)
+		p.mu.Unlock()
 	}
 }
 
@@ -69,9 +84,13 @@ func (p *Pipeline) TranslateRequest(ctx context.Context, from, to Format, req Re
 		return input, nil
 	}
 
+	p.mu.RLock()
+	middleware := p.requestMiddleware
+	p.mu.RUnlock()
+
 	handler := terminal
-	for i := len(p.requestMiddleware) - 1; i >= 0; i-- {
-		mw := p.requestMiddleware[i]
+	for i := len(middleware) - 1; i >= 0; i-- {
+		mw := middleware[i]
 		next := handler
 		handler = func(ctx context.Context, r RequestEnvelope) (RequestEnvelope, error) {
 			return mw(ctx, r, next)
@@ -93,9 +112,13 @@ func (p *Pipeline) TranslateResponse(ctx context.Context, from, to Format, resp
 		return input, nil
 	}
 
+	p.mu.RLock()
+	middleware := p.responseMiddleware
+	p.mu.RUnlock()
+
 	handler := terminal
-	for i := len(p.responseMiddleware) - 1; i >= 0; i-- {
-		mw := p.responseMiddleware[i]
+	for i := len(middleware) - 1; i >= 0; i-- {
+		mw := middleware[i]
 		next := handler
 		handler = func(ctx context.Context, r ResponseEnvelope) (ResponseEnvelope, error) {
 			return mw(ctx, r, next)
@@ -104,3 +127,40 @@ func (p *Pipeline) TranslateResponse(ctx context.Context, from, to Format, resp
 
 	return handler(ctx, resp)
 }
+
+// TranslateTokenCount converts token counts using the pipeline registry.
+// Token counts bypass middleware because they carry no user content.
+func (p *Pipeline) TranslateTokenCount(ctx context.Context, from, to Format, count int64, rawJSON []byte) string {
+	return p.registry.TranslateTokenCount(ctx, from, to, count, rawJSON)
+}
+
+var defaultPipeline = NewPipeline(defaultRegistry)
+
+// DefaultPipeline exposes the package-level pipeline bound to the default registry.
+func DefaultPipeline() *Pipeline {
+	return defaultPipeline
+}
+
+// pipelineContextKey is an unexported context key type to avoid collisions.
+type pipelineContextKey struct{}
+
+// WithPipeline returns a derived context that carries the pipeline used for translation.
+func WithPipeline(ctx context.Context, p *Pipeline) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if p == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, pipelineContextKey{}, p)
+}
+
+// PipelineFromContext returns the pipeline stored in ctx, falling back to DefaultPipeline.
+func PipelineFromContext(ctx context.Context) *Pipeline {
+	if ctx != nil {
+		if p, ok := ctx.Value(pipelineContextKey{}).(*Pipeline); ok && p != nil {
+			return p
+		}
+	}
+	return defaultPipeline
+}
@@ -0,0 +1,47 @@
+package translator
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestPipelineFromContext_FallsBackToDefault(t *testing.T) {
+	if got := PipelineFromContext(context.Background()); got != DefaultPipeline() {
+		t.Fatalf("PipelineFromContext() = %p, want default pipeline %p", got, DefaultPipeline())
+	}
+
+	custom := NewPipeline(NewRegistry())
+	ctx := WithPipeline(context.Background(), custom)
+	if got := PipelineFromContext(ctx); got != custom {
+		t.Fatalf("PipelineFromContext() = %p, want custom pipeline %p", got, custom)
+	}
+}
+
+func TestPipelineTranslateRequest_AppliesMiddlewareInOrder(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("src", "dst", func(model string, rawJSON []byte, stream bool) []byte {
+		return append(bytes.Clone(rawJSON), []byte("|translated")...)
+	}, ResponseTransform{})
+
+	pipeline := NewPipeline(registry)
+	pipeline.UseRequest(func(ctx context.Context, req RequestEnvelope, next RequestHandler) (RequestEnvelope, error) {
+		req.Body = append(req.Body, []byte("|first")...)
+		return next(ctx, req)
+	})
+	pipeline.UseRequest(func(ctx context.Context, req RequestEnvelope, next RequestHandler) (RequestEnvelope, error) {
+		req.Body = append(req.Body, []byte("|second")...)
+		return next(ctx, req)
+	})
+
+	out, err := pipeline.TranslateRequest(context.Background(), "src", "dst", RequestEnvelope{Format: "src", Body: []byte("body")})
+	if err != nil {
+		t.Fatalf("TranslateRequest() error = %v", err)
+	}
+	if want := "body|first|second|translated"; string(out.Body) != want {
+		t.Fatalf("TranslateRequest() body = %q, want %q", out.Body, want)
+	}
+	if out.Format != "dst" {
+		t.Fatalf("TranslateRequest() format = %q, want %q", out.Format, "dst")
+	}
+}